@@ -0,0 +1,148 @@
+// Package apperrors defines the typed errors that repositories and services
+// return. Every error has a Kind that decides the HTTP status, a stable
+// machine-readable Code for clients and a Message that is safe to show them.
+// The underlying cause of internal errors is kept for logging only.
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind classifies an error
+type Kind int
+
+const (
+	// KindInternal is an unexpected failure whose details must not reach clients
+	KindInternal Kind = iota
+	// KindNotFound means the requested resource doesn't exist
+	KindNotFound
+	// KindForbidden means the user isn't allowed to do this
+	KindForbidden
+	// KindConflict means the request clashes with the current state
+	KindConflict
+	// KindValidation means the request itself is invalid
+	KindValidation
+	// KindUnauthorized means the user isn't (or can't be) authenticated
+	KindUnauthorized
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindForbidden:
+		return "forbidden"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation"
+	case KindUnauthorized:
+		return "unauthorized"
+	default:
+		return "internal"
+	}
+}
+
+// FieldError describes why a single request field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error is an error with a kind and a stable code
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	// Fields lists the invalid request fields of a validation error
+	Fields []FieldError
+	// Err is the underlying cause, never shown to clients
+	Err error
+}
+
+// Error returns the message, followed by the cause if there is one
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error with the same kind and code, so a
+// sentinel still matches after Wrap gave it a cause
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Kind == t.Kind && e.Code == t.Code
+}
+
+// Wrap returns a copy of e with the given cause attached
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.Err = cause
+	return &wrapped
+}
+
+// New creates an error of the given kind
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// NotFound creates a KindNotFound error
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+// Forbidden creates a KindForbidden error
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// Conflict creates a KindConflict error
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// Validation creates a KindValidation error
+func Validation(code, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+// InvalidFields creates a KindValidation error listing the invalid request fields
+func InvalidFields(fields []FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: "validation_failed", Message: "request validation failed", Fields: fields}
+}
+
+// Unauthorized creates a KindUnauthorized error
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+// Internal wraps an unexpected failure. Clients only ever see a generic message.
+// A cause that already is an *Error is returned unchanged so its kind isn't lost.
+func Internal(cause error) *Error {
+	var e *Error
+	if errors.As(cause, &e) {
+		return e
+	}
+	return &Error{Kind: KindInternal, Code: "internal_error", Message: "internal server error", Err: cause}
+}
+
+// KindOf returns the kind of err. Errors that aren't an *Error are internal.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
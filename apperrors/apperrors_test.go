@@ -0,0 +1,40 @@
+package apperrors
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSentinelMatchesAfterWrap(t *testing.T) {
+	sentinel := NotFound("event_not_found", "event not found")
+
+	wrapped := fmt.Errorf("loading event: %w", sentinel.Wrap(sql.ErrNoRows))
+	if !errors.Is(wrapped, sentinel) {
+		t.Errorf("expected wrapped error to match the sentinel")
+	}
+	if !errors.Is(wrapped, sql.ErrNoRows) {
+		t.Errorf("expected wrapped error to keep its cause")
+	}
+	if errors.Is(wrapped, NotFound("user_not_found", "user not found")) {
+		t.Errorf("expected errors with other codes not to match")
+	}
+	if KindOf(wrapped) != KindNotFound {
+		t.Errorf("expected kind %v, got %v", KindNotFound, KindOf(wrapped))
+	}
+}
+
+func TestInternalHidesCause(t *testing.T) {
+	err := Internal(errors.New("pq: relation \"events\" does not exist"))
+
+	if err.Message != "internal server error" {
+		t.Errorf("unexpected client message %q", err.Message)
+	}
+	if got := Internal(Conflict("event_already_open", "event is already open")); got.Kind != KindConflict {
+		t.Errorf("expected typed causes to keep their kind, got %v", got.Kind)
+	}
+	if KindOf(errors.New("plain")) != KindInternal {
+		t.Errorf("expected plain errors to be internal")
+	}
+}
@@ -0,0 +1,97 @@
+// Command migrate applies, rolls back and inspects database schema migrations.
+//
+// Usage:
+//
+//	migrate up               apply all pending migrations
+//	migrate down [steps]     roll back the last applied migrations (default 1)
+//	migrate goto <version>   migrate up or down to the given version (0 rolls back everything)
+//	migrate status           print every migration and whether it is applied
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/event-system/database"
+	"github.com/event-system/migrations"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	// Load environment variables
+	err := godotenv.Load(".env")
+	if err != nil {
+		log.Println("Warning: .env file not found")
+	}
+
+	// Initialize database connection
+	db, err := database.Connect()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil {
+				log.Fatalf("Invalid number of steps %q", os.Args[2])
+			}
+		}
+		err = migrator.Down(steps)
+	case "goto":
+		if len(os.Args) < 3 {
+			usage()
+		}
+		version, convErr := strconv.Atoi(os.Args[2])
+		if convErr != nil {
+			log.Fatalf("Invalid version %q", os.Args[2])
+		}
+		err = migrator.To(version)
+	case "status":
+		err = printStatus(migrator)
+	default:
+		usage()
+	}
+
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+}
+
+func printStatus(migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if status.Applied {
+			fmt.Printf("%04d_%-40s applied at %s\n", status.Version, status.Name, status.AppliedAt.Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Printf("%04d_%-40s pending\n", status.Version, status.Name)
+		}
+	}
+
+	return nil
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up | down [steps] | goto <version> | status")
+	os.Exit(2)
+}
@@ -1,20 +1,69 @@
 package config
 
 import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/event-system/apperrors"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 )
 
+// وضعیت HTTP متناظر با هر نوع خطای دامنه
+var kindStatus = map[apperrors.Kind]int{
+	apperrors.KindNotFound:     fiber.StatusNotFound,
+	apperrors.KindForbidden:    fiber.StatusForbidden,
+	apperrors.KindConflict:     fiber.StatusConflict,
+	apperrors.KindValidation:   fiber.StatusUnprocessableEntity,
+	apperrors.KindUnauthorized: fiber.StatusUnauthorized,
+	apperrors.KindInternal:     fiber.StatusInternalServerError,
+}
+
 // یه هندلر خطای سفارشی برای Fiber
+// خطاهای دامنه به وضعیت HTTP و کد ثابت خودشون تبدیل میشن و جزئیات خطاهای داخلی هیچوقت به کاربر نمیرسه
 func ErrorHandler(c *fiber.Ctx, err error) error {
-	code := fiber.StatusInternalServerError
+	status := fiber.StatusInternalServerError
+	code := "internal_error"
+	message := "internal server error"
+	var fields []apperrors.FieldError
+
+	var appErr *apperrors.Error
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &appErr):
+		status = kindStatus[appErr.Kind]
+		code = appErr.Code
+		message = appErr.Message
+		fields = appErr.Fields
+	case errors.As(err, &fiberErr):
+		status = fiberErr.Code
+		code = statusCode(status)
+		message = fiberErr.Message
+	}
 
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
+	// خطاهای داخلی فقط لاگ میشن و کاربر یه پیام کلی میبینه
+	if status >= fiber.StatusInternalServerError {
+		log.Printf("Error handling %s %s: %v", c.Method(), c.Path(), err)
+		code = "internal_error"
+		message = "internal server error"
 	}
 
-	c.Status(code)
-	return c.JSON(fiber.Map{
+	body := fiber.Map{
 		"error":   true,
-		"message": err.Error(),
-	})
+		"code":    code,
+		"message": message,
+	}
+	// خطاهای اعتبارسنجی لیست فیلدهای نامعتبر رو هم دارن
+	if len(fields) > 0 {
+		body["fields"] = fields
+	}
+
+	c.Status(status)
+	return c.JSON(body)
+}
+
+// برای خطاهای خود Fiber (مثل 404 مسیر یا 400 بدنه درخواست) یه کد ثابت از روی وضعیت HTTP میسازه
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(utils.StatusMessage(status)), " ", "_")
 }
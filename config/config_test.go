@@ -0,0 +1,58 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/event-system/apperrors"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{"not found", apperrors.NotFound("event_not_found", "event not found"), 404, "event_not_found", "event not found"},
+		{"forbidden", apperrors.Forbidden("not_event_organizer", "nope"), 403, "not_event_organizer", "nope"},
+		{"conflict", apperrors.Conflict("already_participant", "again"), 409, "already_participant", "again"},
+		{"validation", apperrors.Validation("invalid_cursor", "invalid cursor"), 422, "invalid_cursor", "invalid cursor"},
+		{"internal", apperrors.Internal(errors.New("pq: connection refused")), 500, "internal_error", "internal server error"},
+		{"plain error", errors.New("sql: no rows"), 500, "internal_error", "internal server error"},
+		{"fiber error", fiber.NewError(fiber.StatusBadRequest, "Invalid event ID"), 400, "bad_request", "Invalid event ID"},
+		{"fiber server error", fiber.NewError(fiber.StatusInternalServerError, "pq: syntax error"), 500, "internal_error", "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			app.Get("/", func(c *fiber.Ctx) error { return tt.err })
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+
+			var body struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Code != tt.code || body.Message != tt.message {
+				t.Errorf("expected %q/%q, got %q/%q", tt.code, tt.message, body.Code, body.Message)
+			}
+		})
+	}
+}
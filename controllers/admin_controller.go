@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/event-system/models"
+	"github.com/event-system/services"
+	"github.com/event-system/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminController handles HTTP requests of the admin API
+type AdminController struct {
+	AdminService *services.AdminService
+}
+
+// NewAdminController creates a new admin controller instance
+func NewAdminController(adminService *services.AdminService) *AdminController {
+	return &AdminController{AdminService: adminService}
+}
+
+// ListUsers handles listing all users
+// @Summary List users
+// @Description List all users with their roles and suspension state
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of users to skip"
+// @Success 200 {object} models.AdminUserListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/users [get]
+func (c *AdminController) ListUsers(ctx *fiber.Ctx) error {
+	limit, err := parseLimit(ctx)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	offset := 0
+	if value := ctx.Query("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "offset must be a non-negative integer")
+		}
+	}
+
+	// Get users
+	users, err := c.AdminService.ListUsers(limit, offset)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(users)
+}
+
+// SuspendUser handles suspending a user
+// @Summary Suspend a user
+// @Description Suspend a user and revoke all of their tokens
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.AdminUserResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/users/{id}/suspend [post]
+func (c *AdminController) SuspendUser(ctx *fiber.Ctx) error {
+	// Get admin ID from context
+	adminID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get user ID from path
+	userID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	// Suspend user
+	user, err := c.AdminService.SuspendUser(adminID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(user)
+}
+
+// UnsuspendUser handles lifting the suspension of a user
+// @Summary Unsuspend a user
+// @Description Lift the suspension of a user so they can log in again
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.AdminUserResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/users/{id}/unsuspend [post]
+func (c *AdminController) UnsuspendUser(ctx *fiber.Ctx) error {
+	// Get user ID from path
+	userID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	// Unsuspend user
+	user, err := c.AdminService.UnsuspendUser(userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(user)
+}
+
+// SetUserRole handles changing the role of a user
+// @Summary Change a user's role
+// @Description Change the role of a user to user, organizer or admin
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param role body models.UpdateRoleRequest true "New role"
+// @Success 200 {object} models.AdminUserResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /admin/users/{id}/role [put]
+func (c *AdminController) SetUserRole(ctx *fiber.Ctx) error {
+	// Get admin ID from context
+	adminID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get user ID from path
+	userID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	// Parse request body
+	req := new(models.UpdateRoleRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Change role
+	user, err := c.AdminService.SetUserRole(adminID, userID, req.Role)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(user)
+}
+
+// UpdateEvent handles updating any event
+// @Summary Update any event
+// @Description Update an event regardless of who organizes it. The capacity can't drop below the seats that are taken or held by promo codes.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param event body models.EventRequest true "Event update data"
+// @Success 200 {object} models.EventResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /admin/events/{id} [put]
+func (c *AdminController) UpdateEvent(ctx *fiber.Ctx) error {
+	// Get event ID from path
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body. An event that already started keeps its start time,
+	// so the service only rejects past start times that were changed.
+	req := new(models.EventRequest)
+	ctx.SetUserContext(validation.AllowPast(ctx.UserContext()))
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Update event
+	event, err := c.AdminService.UpdateEvent(id, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(event)
+}
+
+// CloseEvent handles closing any event
+// @Summary Close any event
+// @Description Close registration for an event regardless of who organizes it
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {object} models.EventResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /admin/events/{id}/close [post]
+func (c *AdminController) CloseEvent(ctx *fiber.Ctx) error {
+	// Get event ID from path
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Close event
+	event, err := c.AdminService.CloseEvent(id)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(event)
+}
+
+// DeleteEvent handles deleting any event
+// @Summary Delete any event
+// @Description Delete an event and all of its participants regardless of who organizes it. An event with paid orders or unfinished refunds can't be deleted; cancel it and let the refunds finish first.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /admin/events/{id} [delete]
+func (c *AdminController) DeleteEvent(ctx *fiber.Ctx) error {
+	// Get event ID from path
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Delete event
+	err = c.AdminService.DeleteEvent(id)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(fiber.Map{
+		"message": "Event deleted successfully",
+	})
+}
@@ -25,23 +25,20 @@ func NewAuthController(authService *services.AuthService) *AuthController {
 // @Param user body models.RegisterRequest true "User registration data"
 // @Success 201 {object} models.TokenResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /auth/register [post]
 func (c *AuthController) Register(ctx *fiber.Ctx) error {
 	// Parse request body
 	req := new(models.RegisterRequest)
-	if err := ctx.BodyParser(req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
-	}
-
-	// Validate request
-	if req.Username == "" || req.Email == "" || req.Password == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "Username, email, and password are required")
+	if err := parseBody(ctx, req); err != nil {
+		return err
 	}
 
 	// Register user
 	response, err := c.AuthService.Register(*req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return err
 	}
 
 	// Return response
@@ -59,23 +56,20 @@ func (c *AuthController) Register(ctx *fiber.Ctx) error {
 // @Success 200 {object} models.TokenResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /auth/login [post]
 func (c *AuthController) Login(ctx *fiber.Ctx) error {
 	// Parse request body
 	req := new(models.LoginRequest)
-	if err := ctx.BodyParser(req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
-	}
-
-	// Validate request
-	if req.Email == "" || req.Password == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "Email and password are required")
+	if err := parseBody(ctx, req); err != nil {
+		return err
 	}
 
 	// Login user
 	response, err := c.AuthService.Login(*req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		return err
 	}
 
 	// Return response
@@ -91,6 +85,7 @@ func (c *AuthController) Login(ctx *fiber.Ctx) error {
 // @Security BearerAuth
 // @Success 200 {object} models.UserResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /auth/profile [get]
 // @Summary Get user profile
 func (c *AuthController) GetProfile(ctx *fiber.Ctx) error {
@@ -103,9 +98,78 @@ func (c *AuthController) GetProfile(ctx *fiber.Ctx) error {
 	// Get user profile
 	user, err := c.AuthService.GetUserByID(userID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	// Return response
 	return ctx.JSON(user)
 }
+
+// Refresh handles exchanging a refresh token for a new token pair
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and refresh token. Every refresh token can be used once; reusing one revokes the whole session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /auth/refresh [post]
+func (c *AuthController) Refresh(ctx *fiber.Ctx) error {
+	// Parse request body
+	req := new(models.RefreshRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Rotate tokens
+	response, err := c.AuthService.Refresh(*req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(response)
+}
+
+// Logout handles logging out
+// @Summary Logout
+// @Description Revoke the current access token and, when given, every refresh token of the session
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param token body models.LogoutRequest false "Refresh token of the session"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/logout [post]
+func (c *AuthController) Logout(ctx *fiber.Ctx) error {
+	// Get token claims from context
+	claims, ok := ctx.Locals("claims").(*services.TokenClaims)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Parse request body; it is optional
+	req := new(models.LogoutRequest)
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	// Revoke tokens
+	err := c.AuthService.Logout(claims, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(fiber.Map{
+		"message": "Successfully logged out",
+	})
+}
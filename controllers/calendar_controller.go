@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/event-system/models"
+	"github.com/event-system/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// calendarContentType is the media type of iCalendar files
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// CalendarController handles iCalendar export related HTTP requests
+type CalendarController struct {
+	CalendarService *services.CalendarService
+}
+
+// NewCalendarController creates a new calendar controller instance
+func NewCalendarController(calendarService *services.CalendarService) *CalendarController {
+	return &CalendarController{CalendarService: calendarService}
+}
+
+// ExportEvent handles exporting an event as an iCalendar file
+// @Summary Export an event to a calendar
+// @Description Download an event as an iCalendar (.ics) file that can be imported into Google Calendar, Outlook or Apple Calendar. Times are in UTC and the UID stays the same on every export. Private events are only exported for those who may see them.
+// @Tags calendar
+// @Produce text/calendar
+// @Param id path int true "Event ID"
+// @Param invite query string false "Token of an invite link to the private event"
+// @Success 200 {string} string "iCalendar file"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}.ics [get]
+func (c *CalendarController) ExportEvent(ctx *fiber.Ctx) error {
+	// Get event ID from path
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Export event
+	data, err := c.CalendarService.EventCalendar(id, viewerFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	// Return calendar file
+	ctx.Set(fiber.HeaderContentType, calendarContentType)
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="event-%d.ics"`, id))
+	return ctx.Send(data)
+}
+
+// CreateFeed handles creating the calendar feed URL of the current user
+// @Summary Create a calendar feed URL
+// @Description Create a secret URL to subscribe to from a calendar application. The feed has every event the user organizes or takes part in. Calling this again replaces the URL and the old one stops working.
+// @Tags calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} models.CalendarFeedResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /calendar/feed [post]
+func (c *CalendarController) CreateFeed(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Create feed token
+	token, err := c.CalendarService.CreateFeedToken(userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(models.CalendarFeedResponse{
+		URL: ctx.BaseURL() + "/api/calendar/feed/" + token + ".ics",
+	})
+}
+
+// RevokeFeed handles revoking the calendar feed URL of the current user
+// @Summary Revoke the calendar feed URL
+// @Description Stop the calendar feed URL of the user from working
+// @Tags calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.MessageResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /calendar/feed [delete]
+func (c *CalendarController) RevokeFeed(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Revoke feed token
+	if err := c.CalendarService.RevokeFeedToken(userID); err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(fiber.Map{
+		"message": "Calendar feed revoked successfully",
+	})
+}
+
+// Feed handles serving a calendar feed
+// @Summary Get a calendar feed
+// @Description The calendar a feed URL points to, with every event its owner organizes or takes part in. The secret token in the path takes the place of the Authorization header, which calendar applications can't send.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "Feed token"
+// @Success 200 {string} string "iCalendar file"
+// @Failure 401 {object} models.ErrorResponse
+// @Router /calendar/feed/{token}.ics [get]
+func (c *CalendarController) Feed(ctx *fiber.Ctx) error {
+	// Build the feed of the token owner
+	data, err := c.CalendarService.FeedCalendar(ctx.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	// Return calendar file
+	ctx.Set(fiber.HeaderContentType, calendarContentType)
+	return ctx.Send(data)
+}
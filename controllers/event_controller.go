@@ -1,10 +1,17 @@
 package controllers
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/event-system/models"
 	"github.com/event-system/services"
+	"github.com/event-system/validation"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -20,7 +27,7 @@ func NewEventController(eventService *services.EventService) *EventController {
 
 // CreateEvent handles event creation
 // @Summary Create a new event
-// @Description Create a new event with name, description, location, start time, end time, and capacity
+// @Description Create a new event with name, description, location, start time, end time, and capacity. It is published right away unless draft is set.
 // @Tags events
 // @Accept json
 // @Produce json
@@ -29,6 +36,8 @@ func NewEventController(eventService *services.EventService) *EventController {
 // @Success 201 {object} models.EventResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /events [post]
 func (c *EventController) CreateEvent(ctx *fiber.Ctx) error {
 	// Get user ID from context
@@ -39,24 +48,14 @@ func (c *EventController) CreateEvent(ctx *fiber.Ctx) error {
 
 	// Parse request body
 	req := new(models.EventRequest)
-	if err := ctx.BodyParser(req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body"+err.Error())
-	}
-
-	// Validate request
-	if req.Name == "" || req.StartTime.IsZero() || req.EndTime.IsZero() || req.Capacity <= 0 {
-		return fiber.NewError(fiber.StatusBadRequest, "Name, start time, end time, and capacity are required")
-	}
-
-	// Check if end time is after start time
-	if !req.EndTime.After(req.StartTime) {
-		return fiber.NewError(fiber.StatusBadRequest, "End time must be after start time")
+	if err := parseBody(ctx, req); err != nil {
+		return err
 	}
 
 	// Create event
 	event, err := c.EventService.CreateEvent(*req, userID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	// Return response
@@ -64,13 +63,101 @@ func (c *EventController) CreateEvent(ctx *fiber.Ctx) error {
 	return ctx.JSON(event)
 }
 
+// ImportEvents handles creating events from an iCalendar file
+// @Summary Import events from an iCalendar file
+// @Description Create one event for every VEVENT of an .ics file, uploaded as the file form field or sent as a text/calendar body. SUMMARY, DESCRIPTION, LOCATION, DTSTART and DTEND (or DURATION) are used, with TZID time zones; the capacity comes from X-EVENT-CAPACITY or default_capacity. Every event is validated like a new event and they are created in one transaction: if any is invalid, nothing is created and the error lists the invalid fields as events[index].field. With dry_run the events are only checked and the problems of each are reported.
+// @Tags events
+// @Accept multipart/form-data
+// @Accept text/calendar
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file false "iCalendar file"
+// @Param dry_run query bool false "Only check the events"
+// @Param default_capacity query int false "Capacity of events without X-EVENT-CAPACITY"
+// @Param timezone query string false "Time zone of times without TZID and of all-day events (default UTC)"
+// @Success 200 {object} models.ImportResponse "Dry run"
+// @Success 201 {object} models.ImportResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/import [post]
+func (c *EventController) ImportEvents(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Parse options
+	options, err := parseImportOptions(ctx)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Read the calendar from the upload or from the body
+	var data []byte
+	if file, err := ctx.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid calendar file")
+		}
+		defer f.Close()
+		if data, err = io.ReadAll(f); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid calendar file")
+		}
+	} else if strings.HasPrefix(ctx.Get(fiber.HeaderContentType), "text/calendar") {
+		data = ctx.Body()
+	} else {
+		return fiber.NewError(fiber.StatusBadRequest, "Missing calendar file")
+	}
+
+	// Import events
+	response, err := c.EventService.ImportEvents(bytes.NewReader(data), options, userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	if !options.DryRun {
+		ctx.Status(fiber.StatusCreated)
+	}
+	return ctx.JSON(response)
+}
+
+// parseImportOptions reads the options of an import from the query string
+func parseImportOptions(ctx *fiber.Ctx) (models.ImportOptions, error) {
+	options := models.ImportOptions{
+		TimeZone: ctx.Query("timezone"),
+	}
+
+	if dryRun := ctx.Query("dry_run"); dryRun != "" {
+		value, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			return options, errors.New("dry_run must be true or false")
+		}
+		options.DryRun = value
+	}
+
+	if capacity := ctx.Query("default_capacity"); capacity != "" {
+		value, err := strconv.Atoi(capacity)
+		if err != nil || value < 1 || value > models.MaxEventCapacity {
+			return options, fmt.Errorf("default_capacity must be between 1 and %d", models.MaxEventCapacity)
+		}
+		options.DefaultCapacity = value
+	}
+
+	return options, nil
+}
+
 // GetEvent handles getting a single event by ID
 // @Summary Get an event
-// @Description Get an event by ID
+// @Description Get an event by ID. Drafts and events that aren't published yet are only shown to their organizers, staff and admins; private events also to invited users and holders of an invite link token.
 // @Tags events
 // @Accept json
 // @Produce json
 // @Param id path int true "Event ID"
+// @Param invite query string false "Token of an invite link to the private event"
 // @Success 200 {object} models.EventResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
@@ -83,9 +170,9 @@ func (c *EventController) GetEvent(ctx *fiber.Ctx) error {
 	}
 
 	// Get event
-	event, err := c.EventService.GetEventByID(id)
+	event, err := c.EventService.GetEventByID(id, viewerFromContext(ctx))
 	if err != nil {
-		return fiber.NewError(fiber.StatusNotFound, err.Error())
+		return err
 	}
 
 	// Return response
@@ -94,7 +181,7 @@ func (c *EventController) GetEvent(ctx *fiber.Ctx) error {
 
 // UpdateEvent handles updating an event
 // @Summary Update an event
-// @Description Update an event with name, description, location, start time, end time, and capacity
+// @Description Update an event with name, description, location, start time, end time, and capacity (owner and co-organizers only). The capacity can't drop below the seats that are taken or held by promo codes.
 // @Tags events
 // @Accept json
 // @Produce json
@@ -104,7 +191,10 @@ func (c *EventController) GetEvent(ctx *fiber.Ctx) error {
 // @Success 200 {object} models.EventResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
-// @Failure 500 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /events/{id} [put]
 func (c *EventController) UpdateEvent(ctx *fiber.Ctx) error {
 	// Get user ID from context
@@ -119,41 +209,38 @@ func (c *EventController) UpdateEvent(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
 	}
 
-	// Parse request body
+	// Parse request body. An event that already started keeps its start time,
+	// so the service only rejects past start times that were changed.
 	req := new(models.EventRequest)
-	if err := ctx.BodyParser(req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
-	}
-
-	// Validate request
-	if req.Name == "" || req.StartTime.IsZero() || req.EndTime.IsZero() || req.Capacity <= 0 {
-		return fiber.NewError(fiber.StatusBadRequest, "Name, start time, end time, and capacity are required")
-	}
-
-	// Check if end time is after start time
-	if !req.EndTime.After(req.StartTime) {
-		return fiber.NewError(fiber.StatusBadRequest, "End time must be after start time")
+	ctx.SetUserContext(validation.AllowPast(ctx.UserContext()))
+	if err := parseBody(ctx, req); err != nil {
+		return err
 	}
 
 	// Update event
 	event, err := c.EventService.UpdateEvent(id, *req, userID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	// Return response
 	return ctx.JSON(event)
 }
 
-// CloseEvent change event status to close
+// CloseEvent closes registration of an event
 // @Summary Close an event
-// @Description Close an event by ID
+// @Description Close registration of a published event, moving it to registration_closed (owner and co-organizers only)
 // @Tags events
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Event ID"
 // @Success 200 {object} models.EventResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Router /events/{id}/close [post]
 func (c *EventController) CloseEvent(ctx *fiber.Ctx) error {
 	// Get user ID from context
@@ -171,22 +258,27 @@ func (c *EventController) CloseEvent(ctx *fiber.Ctx) error {
 	// Update event
 	event, err := c.EventService.CloseEvent(userID, id)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	// Return response
 	return ctx.JSON(event)
 }
 
-// OpenEvent change event status to open
+// OpenEvent publishes an event or reopens its registration
 // @Summary Open an event
-// @Description Open an event by ID
+// @Description Publish a draft or reopen registration of an event that hasn't started, moving it to published (owner and co-organizers only)
 // @Tags events
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Event ID"
 // @Success 200 {object} models.EventResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Router /events/{id}/open [post]
 func (c *EventController) OpenEvent(ctx *fiber.Ctx) error {
 	// Get user ID from context
@@ -204,7 +296,90 @@ func (c *EventController) OpenEvent(ctx *fiber.Ctx) error {
 	// Update event
 	event, err := c.EventService.OpenEvent(userID, id)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(event)
+}
+
+// GetTransitions handles listing the statuses an event may move to
+// @Summary Get allowed status transitions
+// @Description Get the current status of an event and the statuses it may move to right now (owner and co-organizers only)
+// @Tags events
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {object} models.EventTransitionsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/transitions [get]
+func (c *EventController) GetTransitions(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get transitions
+	transitions, err := c.EventService.GetTransitions(userID, id)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(transitions)
+}
+
+// TransitionEvent handles moving an event to another status
+// @Summary Change the status of an event
+// @Description Move an event along its lifecycle: draft, published, registration_closed, ongoing, completed, or cancelled. Cancelling notifies every participant with the given reason, refunds paid orders, cancels unpaid ones and stops further joins. (owner and co-organizers only)
+// @Tags events
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param transition body models.EventTransitionRequest true "New status"
+// @Success 200 {object} models.EventResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/transitions [post]
+func (c *EventController) TransitionEvent(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.EventTransitionRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Change the status
+	event, err := c.EventService.TransitionEvent(userID, id, *req)
+	if err != nil {
+		return err
 	}
 
 	// Return response
@@ -213,7 +388,7 @@ func (c *EventController) OpenEvent(ctx *fiber.Ctx) error {
 
 // DeleteEvent handles deleting an event
 // @Summary Delete an event
-// @Description Delete an event by ID
+// @Description Delete an event by ID (owner only)
 // @Tags events
 // @Accept json
 // @Produce json
@@ -222,7 +397,9 @@ func (c *EventController) OpenEvent(ctx *fiber.Ctx) error {
 // @Success 200 {object} models.MessageResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
-// @Failure 500 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Router /events/{id} [delete]
 func (c *EventController) DeleteEvent(ctx *fiber.Ctx) error {
 	// Get user ID from context
@@ -240,7 +417,7 @@ func (c *EventController) DeleteEvent(ctx *fiber.Ctx) error {
 	// Delete event
 	err = c.EventService.DeleteEvent(id, userID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	// Return response
@@ -249,36 +426,114 @@ func (c *EventController) DeleteEvent(ctx *fiber.Ctx) error {
 	})
 }
 
-// GetAllPublicEvents handles getting all open events
-// @Summary Get all open events
-// @Description Get all open events
+// GetAllPublicEvents handles getting published events page by page
+// @Summary Get published events
+// @Description Get a page of published events, optionally filtered by date range, location and free seats. Drafts are never listed.
 // @Tags events
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.EventResponse
-// @Failure 500 {object} models.ErrorResponse
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from the next_cursor of the previous page"
+// @Param from query string false "Only events starting at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Only events starting at or before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param location query string false "Only events whose location contains this text"
+// @Param has_seats query bool false "Only events that still have free seats"
+// @Param sort query string false "Sort order: start_time (default) or -start_time"
+// @Success 200 {object} models.EventPageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /events/public [get]
 func (c *EventController) GetAllPublicEvents(ctx *fiber.Ctx) error {
+	// Parse paging and filters
+	query, err := parseEventListQuery(ctx)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
 	// Get events
-	events, err := c.EventService.GetAllPublicEvents()
+	events, err := c.EventService.GetAllPublicEvents(query)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	// Return response
 	return ctx.JSON(events)
 }
 
-// GetMyEvents handles getting all events created by the current user
+// SearchEvents handles full-text search over events
+// @Summary Search events
+// @Description Rank events by relevance of name, description and location to the search text. Every word matches as a prefix and matches are wrapped in <mark> tags in the highlights. The rest of the highlighted text is HTML-escaped.
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param q query string true "Search text"
+// @Param status query string false "Event status (default published); drafts can't be searched"
+// @Param from query string false "Only events starting at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Only events starting at or before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param location query string false "Only events whose location contains this text"
+// @Param has_seats query bool false "Only events that still have free seats"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param offset query int false "Number of results to skip"
+// @Success 200 {object} models.EventSearchResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/search [get]
+func (c *EventController) SearchEvents(ctx *fiber.Ctx) error {
+	// Parse search text, filters and paging
+	query := models.EventSearchQuery{
+		Text:   ctx.Query("q"),
+		Status: ctx.Query("status"),
+	}
+	if query.Text == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Search query is required")
+	}
+
+	filter, err := parseEventFilter(ctx)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	query.EventFilter = filter
+
+	query.Limit, err = parseLimit(ctx)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if offset := ctx.Query("offset"); offset != "" {
+		query.Offset, err = strconv.Atoi(offset)
+		if err != nil || query.Offset < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "offset must be a non-negative number")
+		}
+	}
+
+	// Search events
+	results, err := c.EventService.SearchEvents(query)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(results)
+}
+
+// GetMyEvents handles getting the events created by the current user page by page
 // @Summary Get my events
-// @Description Get all events created by the current user
+// @Description Get a page of events created by the current user
 // @Tags events
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {array} models.EventResponse
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from the next_cursor of the previous page"
+// @Param from query string false "Only events starting at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Only events starting at or before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param location query string false "Only events whose location contains this text"
+// @Param has_seats query bool false "Only events that still have free seats"
+// @Param sort query string false "Sort order: start_time (default) or -start_time"
+// @Success 200 {object} models.EventPageResponse
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
-// @Failure 500 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /events/my/ [get]
 func (c *EventController) GetMyEvents(ctx *fiber.Ctx) error {
 
@@ -287,10 +542,17 @@ func (c *EventController) GetMyEvents(ctx *fiber.Ctx) error {
 	if !ok {
 		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
 	}
+
+	// Parse paging and filters
+	query, err := parseEventListQuery(ctx)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
 	// Get events
-	events, err := c.EventService.GetEventsByOrganizer(userID)
+	events, err := c.EventService.GetEventsByOrganizer(userID, query)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	// Return response
@@ -299,7 +561,7 @@ func (c *EventController) GetMyEvents(ctx *fiber.Ctx) error {
 
 // GetEventWithParticipants handles getting an event with its participants
 // @Summary Get event with participants
-// @Description Get an event with its participants
+// @Description Get an event with its participants (owner and staff only)
 // @Tags events
 // @Accept json
 // @Produce json
@@ -308,7 +570,8 @@ func (c *EventController) GetMyEvents(ctx *fiber.Ctx) error {
 // @Success 200 {object} models.EventWithParticipantsResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
-// @Failure 500 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /events/{id}/participants [get]
 func (c *EventController) GetEventWithParticipants(ctx *fiber.Ctx) error {
 	// Get user ID from context
@@ -326,23 +589,31 @@ func (c *EventController) GetEventWithParticipants(ctx *fiber.Ctx) error {
 	// Get event with participants
 	event, err := c.EventService.GetEventWithParticipants(id, userID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	// Return response
 	return ctx.JSON(event)
 }
 
-// GetMyParticipatingEvents handles getting all events the current user is participating in
+// GetMyParticipatingEvents handles getting the events the current user is participating in page by page
 // @Summary Get my participating events
-// @Description Get all events the current user is participating in
+// @Description Get a page of events the current user is participating in
 // @Tags events
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {array} models.EventResponse
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from the next_cursor of the previous page"
+// @Param from query string false "Only events starting at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Only events starting at or before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param location query string false "Only events whose location contains this text"
+// @Param has_seats query bool false "Only events that still have free seats"
+// @Param sort query string false "Sort order: start_time (default) or -start_time"
+// @Success 200 {object} models.EventPageResponse
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
-// @Failure 500 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /events/participating [get]
 func (c *EventController) GetMyParticipatingEvents(ctx *fiber.Ctx) error {
 	// Get user ID from context
@@ -351,12 +622,109 @@ func (c *EventController) GetMyParticipatingEvents(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
 	}
 
+	// Parse paging and filters
+	query, err := parseEventListQuery(ctx)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
 	// Get events
-	events, err := c.EventService.GetEventsByParticipant(userID)
+	events, err := c.EventService.GetEventsByParticipant(userID, query)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	// Return response
 	return ctx.JSON(events)
 }
+
+// parseEventListQuery reads paging, filter and sort parameters from the query string
+func parseEventListQuery(ctx *fiber.Ctx) (models.EventListQuery, error) {
+	query := models.EventListQuery{
+		Cursor: ctx.Query("cursor"),
+		Sort:   ctx.Query("sort"),
+	}
+
+	filter, err := parseEventFilter(ctx)
+	if err != nil {
+		return query, err
+	}
+	query.EventFilter = filter
+
+	query.Limit, err = parseLimit(ctx)
+	if err != nil {
+		return query, err
+	}
+
+	if query.Sort != "" && query.Sort != models.SortStartTimeAsc && query.Sort != models.SortStartTimeDesc {
+		return query, fmt.Errorf("sort must be %s or %s", models.SortStartTimeAsc, models.SortStartTimeDesc)
+	}
+
+	return query, nil
+}
+
+// parseEventFilter reads the filters shared by event listing and search
+func parseEventFilter(ctx *fiber.Ctx) (models.EventFilter, error) {
+	filter := models.EventFilter{
+		Location: ctx.Query("location"),
+	}
+
+	if from := ctx.Query("from"); from != "" {
+		value, err := parseTimeParam(from, false)
+		if err != nil {
+			return filter, errors.New("invalid from date")
+		}
+		filter.From = &value
+	}
+
+	if to := ctx.Query("to"); to != "" {
+		value, err := parseTimeParam(to, true)
+		if err != nil {
+			return filter, errors.New("invalid to date")
+		}
+		filter.To = &value
+	}
+
+	if hasSeats := ctx.Query("has_seats"); hasSeats != "" {
+		value, err := strconv.ParseBool(hasSeats)
+		if err != nil {
+			return filter, errors.New("has_seats must be true or false")
+		}
+		filter.HasSeats = value
+	}
+
+	return filter, nil
+}
+
+// parseLimit reads the optional page size; zero means the default
+func parseLimit(ctx *fiber.Ctx) (int, error) {
+	limit := ctx.Query("limit")
+	if limit == "" {
+		return 0, nil
+	}
+
+	value, err := strconv.Atoi(limit)
+	if err != nil || value < 1 || value > models.MaxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", models.MaxPageSize)
+	}
+
+	return value, nil
+}
+
+// parseTimeParam accepts RFC 3339 timestamps or plain dates.
+// A plain date used as an upper bound covers that whole day.
+func parseTimeParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/event-system/models"
+	"github.com/event-system/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// InviteController handles HTTP requests related to invites to private events
+type InviteController struct {
+	InviteService *services.InviteService
+}
+
+// NewInviteController creates a new invite controller instance
+func NewInviteController(inviteService *services.InviteService) *InviteController {
+	return &InviteController{InviteService: inviteService}
+}
+
+// CreateInviteLink handles creating an invite link
+// @Summary Create an invite link
+// @Description Create an invite link to an event with an optional expiry and maximum number of uses (owner and co-organizers only). Anyone holding the token may see the event and join it with the token while the link is valid.
+// @Tags invites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param invite body models.InviteLinkRequest true "Expiry and maximum uses"
+// @Success 201 {object} models.InviteResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/invites/link [post]
+func (c *InviteController) CreateInviteLink(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.InviteLinkRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Create invite link
+	invite, err := c.InviteService.CreateInviteLink(eventID, userID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(invite)
+}
+
+// InviteUsers handles inviting users and email addresses
+// @Summary Invite users to an event
+// @Description Invite users by ID or email address to an event (owner and co-organizers only). An invite only lets its own user in and has no use limit, so an invited user who leaves can join again. Users and emails that were invited before are skipped, and invited users get a notification.
+// @Tags invites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param invite body models.InviteUsersRequest true "Users and emails to invite"
+// @Success 201 {array} models.InviteResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/invites [post]
+func (c *InviteController) InviteUsers(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.InviteUsersRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Invite users
+	invites, err := c.InviteService.InviteUsers(eventID, userID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(invites)
+}
+
+// ListInvites handles listing the invites of an event
+// @Summary Get event invites
+// @Description Get the invites of an event with how often each was used, including the tokens of invite links (owner and co-organizers only)
+// @Tags invites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {array} models.InviteResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/invites [get]
+func (c *InviteController) ListInvites(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get invites
+	invites, err := c.InviteService.ListInvites(eventID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(invites)
+}
+
+// RevokeInvite handles revoking an invite
+// @Summary Revoke an invite
+// @Description Revoke an invite so it can't be used anymore (owner and co-organizers only). Users who already joined with it stay.
+// @Tags invites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param inviteId path int true "Invite ID"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/invites/{inviteId} [delete]
+func (c *InviteController) RevokeInvite(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get invite ID from path
+	inviteID, err := strconv.Atoi(ctx.Params("inviteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid invite ID")
+	}
+
+	// Revoke invite
+	if err := c.InviteService.RevokeInvite(eventID, userID, inviteID); err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(fiber.Map{
+		"message": "Invite revoked successfully",
+	})
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"github.com/event-system/scheduler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// JobController handles HTTP requests about background jobs
+type JobController struct {
+	Scheduler *scheduler.Scheduler
+}
+
+// NewJobController creates a new job controller instance
+func NewJobController(jobs *scheduler.Scheduler) *JobController {
+	return &JobController{Scheduler: jobs}
+}
+
+// ListJobs handles listing the background jobs and how they did
+// @Summary List background jobs
+// @Description List the background jobs of this instance with their last run, failures and next run
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.JobStatus
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /admin/jobs [get]
+func (c *JobController) ListJobs(ctx *fiber.Ctx) error {
+	return ctx.JSON(c.Scheduler.Status())
+}
@@ -20,15 +20,19 @@ func NewParticipantController(participantService *services.ParticipantService) *
 
 // JoinEvent handles joining an event
 // @Summary Join an event
-// @Description Join an event as a participant
+// @Description Join an event as a participant, or its waitlist when the event is full. Events that require approval take the registration as pending until an organizer reviews it. Private events need an invite: either one for the user or their email, or the token of an invite link in the body. Events with ticket types need ticket_type_id of a public ticket type that is on sale; the user gets a seat while both the ticket type and the event have room and goes to the waitlist otherwise. A seat of a priced ticket type is reserved until reserved_until; the response carries the order with its checkout_url, and the seat is released if the payment doesn't finish in time. A promo_code of the event is redeemed with the registration: it can take a discount off the order, unlock a hidden ticket type or give one of the seats it reserves. Leaving gives the use of the code back but still counts toward its per-user limit.
 // @Tags participants
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Event ID"
-// @Success 200 {object} models.MessageResponse
+// @Param request body models.JoinEventRequest false "Invite link token for private events and the chosen ticket type"
+// @Success 200 {object} models.JoinEventResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Router /events/{id}/join [post]
 func (c *ParticipantController) JoinEvent(ctx *fiber.Ctx) error {
 	// Get user ID from context
@@ -43,21 +47,43 @@ func (c *ParticipantController) JoinEvent(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
 	}
 
+	// The body is optional
+	var req models.JoinEventRequest
+	if len(ctx.Body()) > 0 {
+		if err := parseBody(ctx, &req); err != nil {
+			return err
+		}
+	}
+
 	// Join event
-	err = c.ParticipantService.JoinEvent(userID, eventID)
+	participant, err := c.ParticipantService.JoinEvent(userID, eventID, req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return err
+	}
+
+	message := "Successfully joined event"
+	switch participant.Status {
+	case models.ParticipantStatusWaitlisted:
+		message = "Event is full, you have been added to the waitlist"
+	case models.ParticipantStatusPending:
+		message = "Your registration is waiting for approval"
+	case models.ParticipantStatusReserved:
+		message = "Your seat is reserved, pay for your ticket to keep it"
 	}
 
 	// Return response
-	return ctx.JSON(fiber.Map{
-		"message": "Successfully joined event",
+	return ctx.JSON(models.JoinEventResponse{
+		Message:          message,
+		Status:           participant.Status,
+		WaitlistPosition: participant.WaitlistPosition,
+		ReservedUntil:    participant.ReservedUntil,
+		Order:            participant.Order,
 	})
 }
 
 // LeaveEvent handles leaving an event
 // @Summary Leave an event
-// @Description Leave an event as a participant
+// @Description Leave an event as a participant. A paid ticket is refunded and an unpaid order is cancelled. The next user on the waitlist moves up into the seat and is notified, with the link to pay when the seat is priced.
 // @Tags participants
 // @Accept json
 // @Produce json
@@ -66,6 +92,7 @@ func (c *ParticipantController) JoinEvent(ctx *fiber.Ctx) error {
 // @Success 200 {object} models.MessageResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /events/{id}/leave [post]
 func (c *ParticipantController) LeaveEvent(ctx *fiber.Ctx) error {
 	// Get user ID from context
@@ -83,7 +110,7 @@ func (c *ParticipantController) LeaveEvent(ctx *fiber.Ctx) error {
 	// Leave event
 	err = c.ParticipantService.LeaveEvent(userID, eventID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return err
 	}
 
 	// Return response
@@ -94,7 +121,7 @@ func (c *ParticipantController) LeaveEvent(ctx *fiber.Ctx) error {
 
 // IsParticipant handles checking if a user is a participant of an event
 // @Summary Check if user is a participant
-// @Description Check if the current user is a participant of an event
+// @Description Check if the current user is a confirmed participant of an event. The status also reports a registration that is reserved until paid, waitlisted, pending approval or rejected, with the organizer's message.
 // @Tags participants
 // @Accept json
 // @Produce json
@@ -103,7 +130,6 @@ func (c *ParticipantController) LeaveEvent(ctx *fiber.Ctx) error {
 // @Success 200 {object} models.ParticipantStatusResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
-// @Failure 500 {object} models.ErrorResponse
 // @Router /events/{id}/is-participant [get]
 func (c *ParticipantController) IsParticipant(ctx *fiber.Ctx) error {
 	// Get user ID from context
@@ -119,27 +145,26 @@ func (c *ParticipantController) IsParticipant(ctx *fiber.Ctx) error {
 	}
 
 	// Check if user is a participant
-	isParticipant, err := c.ParticipantService.IsParticipant(userID, eventID)
+	status, err := c.ParticipantService.IsParticipant(userID, eventID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	// Return response
-	return ctx.JSON(models.ParticipantStatusResponse{
-		IsParticipant: isParticipant,
-	})
+	return ctx.JSON(status)
 }
 
 // GetParticipantCount handles getting the number of participants for an event
 // @Summary Get participant count
-// @Description Get the number of participants for an event
+// @Description Get the number of confirmed participants for an event. Events with ticket types also get the count of each public ticket type. Events the caller may not see get the same 404 as GET /events/{id}.
 // @Tags participants
 // @Accept json
 // @Produce json
 // @Param id path int true "Event ID"
+// @Param invite query string false "Token of an invite link to the private event"
 // @Success 200 {object} models.ParticipantCountResponse
 // @Failure 400 {object} models.ErrorResponse
-// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /events/{id}/participant-count [get]
 func (c *ParticipantController) GetParticipantCount(ctx *fiber.Ctx) error {
 	// Get event ID from path
@@ -149,13 +174,258 @@ func (c *ParticipantController) GetParticipantCount(ctx *fiber.Ctx) error {
 	}
 
 	// Get participant count
-	count, err := c.ParticipantService.GetParticipantCount(eventID)
+	count, err := c.ParticipantService.GetParticipantCount(eventID, viewerFromContext(ctx))
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	// Return response
-	return ctx.JSON(models.ParticipantCountResponse{
-		Count: count,
-	})
+	return ctx.JSON(count)
+}
+
+// GetWaitlistPosition handles getting the current user's place on the waitlist of an event
+// @Summary Get my waitlist position
+// @Description Get the current user's position on the waitlist of an event
+// @Tags participants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {object} models.WaitlistPositionResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/waitlist/position [get]
+func (c *ParticipantController) GetWaitlistPosition(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get waitlist position
+	position, err := c.ParticipantService.GetWaitlistPosition(userID, eventID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(position)
+}
+
+// GetWaitlist handles getting the waitlist of an event
+// @Summary Get event waitlist
+// @Description Get the waitlist of an event in queue order (owner and staff only)
+// @Tags participants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {array} models.WaitlistEntryResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/waitlist [get]
+func (c *ParticipantController) GetWaitlist(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get waitlist
+	waitlist, err := c.ParticipantService.GetWaitlist(eventID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(waitlist)
+}
+
+// ReorderWaitlist handles putting the waitlist of an event in a new order
+// @Summary Reorder event waitlist
+// @Description Reorder the waitlist of an event; user_ids must list every waitlisted user exactly once (owner and co-organizers only)
+// @Tags participants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param order body models.ReorderWaitlistRequest true "New waitlist order"
+// @Success 200 {array} models.WaitlistEntryResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/waitlist [put]
+func (c *ParticipantController) ReorderWaitlist(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.ReorderWaitlistRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Reorder waitlist
+	waitlist, err := c.ParticipantService.ReorderWaitlist(eventID, userID, req.UserIDs)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(waitlist)
+}
+
+// GetPendingRegistrations handles listing the applicants of an event that wait for approval
+// @Summary Get pending registrations
+// @Description Get the applicants of an event that wait for approval, oldest first (owner and staff only)
+// @Tags participants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {array} models.PendingRegistrationResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/registrations/pending [get]
+func (c *ParticipantController) GetPendingRegistrations(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get pending registrations
+	pending, err := c.ParticipantService.GetPendingRegistrations(eventID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(pending)
+}
+
+// ApproveRegistrations handles approving pending applicants of an event
+// @Summary Approve registrations
+// @Description Approve pending applicants of an event in bulk. Approved users are confirmed while seats are left and waitlisted otherwise, and are notified with the optional message (owner and co-organizers only)
+// @Tags participants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param review body models.ReviewRegistrationsRequest true "Applicants and an optional message"
+// @Success 200 {array} models.ParticipantResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/registrations/approve [post]
+func (c *ParticipantController) ApproveRegistrations(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.ReviewRegistrationsRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Approve registrations
+	reviewed, err := c.ParticipantService.ApproveRegistrations(eventID, userID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(reviewed)
+}
+
+// RejectRegistrations handles rejecting pending applicants of an event
+// @Summary Reject registrations
+// @Description Reject pending applicants of an event in bulk. Rejected users are notified with the optional message and cannot register again (owner and co-organizers only)
+// @Tags participants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param review body models.ReviewRegistrationsRequest true "Applicants and an optional message"
+// @Success 200 {array} models.ParticipantResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/registrations/reject [post]
+func (c *ParticipantController) RejectRegistrations(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.ReviewRegistrationsRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Reject registrations
+	reviewed, err := c.ParticipantService.RejectRegistrations(eventID, userID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(reviewed)
 }
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/event-system/models"
+	"github.com/event-system/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PaymentController handles HTTP requests related to orders and payments
+type PaymentController struct {
+	PaymentService *services.PaymentService
+	Fake           *services.FakePaymentProvider
+}
+
+// NewPaymentController creates a new payment controller instance. fake is the
+// gateway behind the fake checkout page; it may be nil when another provider is used.
+func NewPaymentController(paymentService *services.PaymentService, fake *services.FakePaymentProvider) *PaymentController {
+	return &PaymentController{PaymentService: paymentService, Fake: fake}
+}
+
+// Checkout handles starting the payment of a reserved seat
+// @Summary Pay for a reserved seat
+// @Description Start the payment of the pending order of the current user in an event, or get the one already started. Users get a pending order when they take a seat of a priced ticket type; the seat is released if the order isn't paid before expires_at.
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {object} models.OrderResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /events/{id}/checkout [post]
+func (c *PaymentController) Checkout(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Start payment
+	order, err := c.PaymentService.Checkout(userID, eventID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(order)
+}
+
+// GetOrders handles listing the orders of the current user in an event
+// @Summary List my orders
+// @Description List the orders of the current user in an event, newest first, with their payment and refund state
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {array} models.OrderResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /events/{id}/orders [get]
+func (c *PaymentController) GetOrders(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get orders
+	orders, err := c.PaymentService.GetOrders(userID, eventID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(orders)
+}
+
+// Webhook handles the webhooks of the payment provider
+// @Summary Payment webhook
+// @Description Called by the payment provider when a payment succeeds or is declined. The call must be signed by the provider. A succeeded payment confirms the reserved seat; if the seat was already released the payment is refunded.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.MessageResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /payments/webhook [post]
+func (c *PaymentController) Webhook(ctx *fiber.Ctx) error {
+	header := http.Header{}
+	ctx.Request().Header.VisitAll(func(key, value []byte) {
+		header.Add(string(key), string(value))
+	})
+
+	// Apply webhook
+	if err := c.PaymentService.HandleWebhook(ctx.Body(), header); err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(fiber.Map{
+		"message": "Webhook processed",
+	})
+}
+
+// SettleFakePayment handles the checkout page of the fake payment gateway
+// @Summary Settle a fake payment
+// @Description Choose the outcome of a payment of the fake gateway used in development; the route only exists with PAYMENT_PROVIDER=fake. The gateway sends its webhook right away, or after delay_seconds to simulate a gateway that calls back late.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param ref path string true "Payment reference from the checkout URL"
+// @Param payment body models.FakePaymentRequest true "Payment outcome"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /payments/fake/{ref} [post]
+func (c *PaymentController) SettleFakePayment(ctx *fiber.Ctx) error {
+	if c.Fake == nil {
+		return fiber.NewError(fiber.StatusNotFound, "Fake payment gateway is not enabled")
+	}
+
+	// Parse request body
+	req := new(models.FakePaymentRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Settle payment
+	delay := time.Duration(req.DelaySeconds) * time.Second
+	if err := c.Fake.Settle(ctx.Params("ref"), req.Outcome, delay); err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(fiber.Map{
+		"message": "Payment " + req.Outcome,
+	})
+}
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/event-system/models"
+	"github.com/event-system/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PromoCodeController handles HTTP requests related to the promo codes of events
+type PromoCodeController struct {
+	PromoCodeService *services.PromoCodeService
+}
+
+// NewPromoCodeController creates a new promo code controller instance
+func NewPromoCodeController(promoCodeService *services.PromoCodeService) *PromoCodeController {
+	return &PromoCodeController{PromoCodeService: promoCodeService}
+}
+
+// ListPromoCodes handles listing the promo codes of an event
+// @Summary List promo codes
+// @Description List the promo codes of an event with their uses and the seats taken from their blocks (owner, staff and admins only)
+// @Tags promo-codes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {array} models.PromoCodeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/promo-codes [get]
+func (c *PromoCodeController) ListPromoCodes(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get promo codes
+	codes, err := c.PromoCodeService.ListPromoCodes(eventID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(codes)
+}
+
+// CreatePromoCode handles adding a promo code to an event
+// @Summary Create a promo code
+// @Description Add a promo code to an event (owner and co-organizers only). A code can take a percentage or a fixed amount off the price of a ticket, be limited to one ticket type (unlocking it when it is hidden) and reserve a block of seats that only its holders can take; the block has to fit into the seats nobody took or holds yet. max_uses and per_user_limit of 0 mean no limit. Codes are case-insensitive.
+// @Tags promo-codes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param promoCode body models.PromoCodeRequest true "Promo code"
+// @Success 201 {object} models.PromoCodeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/promo-codes [post]
+func (c *PromoCodeController) CreatePromoCode(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.PromoCodeRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Create promo code
+	code, err := c.PromoCodeService.CreatePromoCode(eventID, userID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(code)
+}
+
+// UpdatePromoCode handles updating a promo code of an event
+// @Summary Update a promo code
+// @Description Update a promo code of an event (owner and co-organizers only). Lowering its limits doesn't undo past redemptions, and a larger block has to fit into the free seats; seats a smaller block or an earlier expiry frees go to waitlisted users.
+// @Tags promo-codes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param codeId path int true "Promo code ID"
+// @Param promoCode body models.PromoCodeRequest true "Promo code"
+// @Success 200 {object} models.PromoCodeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/promo-codes/{codeId} [put]
+func (c *PromoCodeController) UpdatePromoCode(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get promo code ID from path
+	codeID, err := strconv.Atoi(ctx.Params("codeId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid promo code ID")
+	}
+
+	// Parse request body
+	req := new(models.PromoCodeRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Update promo code
+	code, err := c.PromoCodeService.UpdatePromoCode(eventID, userID, codeID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(code)
+}
+
+// DeletePromoCode handles deleting a promo code of an event
+// @Summary Delete a promo code
+// @Description Delete a promo code of an event that was never redeemed (owner and co-organizers only). A redeemed code can be expired instead.
+// @Tags promo-codes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param codeId path int true "Promo code ID"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /events/{id}/promo-codes/{codeId} [delete]
+func (c *PromoCodeController) DeletePromoCode(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get promo code ID from path
+	codeID, err := strconv.Atoi(ctx.Params("codeId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid promo code ID")
+	}
+
+	// Delete promo code
+	if err := c.PromoCodeService.DeletePromoCode(eventID, userID, codeID); err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(fiber.Map{
+		"message": "Promo code deleted successfully",
+	})
+}
+
+// GetRedemptionReport handles reporting the redemptions of a promo code
+// @Summary Promo code redemptions
+// @Description Report every use of a promo code: who redeemed it, the status of the registration that holds it, the discount it gave and when a use was released (owner, staff and admins only)
+// @Tags promo-codes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param codeId path int true "Promo code ID"
+// @Success 200 {object} models.PromoCodeReportResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/promo-codes/{codeId}/redemptions [get]
+func (c *PromoCodeController) GetRedemptionReport(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get promo code ID from path
+	codeID, err := strconv.Atoi(ctx.Params("codeId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid promo code ID")
+	}
+
+	// Get report
+	report, err := c.PromoCodeService.GetRedemptionReport(eventID, userID, codeID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(report)
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"github.com/event-system/services"
+	"github.com/event-system/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseBody reads the JSON request body into out and validates it against its validate tags
+func parseBody(ctx *fiber.Ctx, out interface{}) error {
+	if err := ctx.BodyParser(out); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	return validation.StructCtx(ctx.UserContext(), out)
+}
+
+// viewerFromContext returns who is asking to see an event: the signed in user
+// stored by OptionalAuth, if any, and the invite token from the query string
+func viewerFromContext(ctx *fiber.Ctx) services.Viewer {
+	viewer := services.Viewer{InviteToken: ctx.Query("invite")}
+	if claims, ok := ctx.Locals("claims").(*services.TokenClaims); ok {
+		viewer.UserID = claims.UserID
+		viewer.Role = claims.Role
+	}
+	return viewer
+}
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/event-system/models"
+	"github.com/event-system/services"
+	"github.com/event-system/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SeriesController handles recurring event series related HTTP requests
+type SeriesController struct {
+	SeriesService *services.SeriesService
+}
+
+// NewSeriesController creates a new series controller instance
+func NewSeriesController(seriesService *services.SeriesService) *SeriesController {
+	return &SeriesController{SeriesService: seriesService}
+}
+
+// CreateSeries handles creating a recurring event series
+// @Summary Create a recurring event series
+// @Description Create a series from the first occurrence and an RFC 5545 RRULE (DAILY, WEEKLY or MONTHLY with INTERVAL, COUNT, UNTIL, BYDAY and BYMONTHDAY). Occurrences are created as events for the coming year, at most 100 at a time.
+// @Tags series
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param series body models.SeriesRequest true "Series creation data"
+// @Success 201 {object} models.SeriesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /series [post]
+func (c *SeriesController) CreateSeries(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Parse request body
+	req := new(models.SeriesRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Create series
+	series, err := c.SeriesService.CreateSeries(*req, userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(series)
+}
+
+// GetSeries handles getting a series with its occurrences
+// @Summary Get a recurring event series
+// @Description Get a series by ID together with all its occurrences
+// @Tags series
+// @Accept json
+// @Produce json
+// @Param id path int true "Series ID"
+// @Success 200 {object} models.SeriesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /series/{id} [get]
+func (c *SeriesController) GetSeries(ctx *fiber.Ctx) error {
+	// Get series ID from path
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid series ID")
+	}
+
+	// Get series
+	series, err := c.SeriesService.GetSeries(id)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(series)
+}
+
+// UpdateOccurrence handles editing an occurrence of a series
+// @Summary Edit an occurrence of a series
+// @Description Edit one occurrence (scope "this"), this and all following occurrences (scope "following") or the whole series (scope "all"). With following and all every occurrence moves by as much as this one did, and rrule and exdates may be changed as well. Occurrences that already started are left alone. Series organizer only.
+// @Tags series
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Param eventId path int true "Event ID of the occurrence"
+// @Param update body models.SeriesUpdateRequest true "Occurrence update data"
+// @Success 200 {object} models.SeriesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /series/{id}/occurrences/{eventId} [put]
+func (c *SeriesController) UpdateOccurrence(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get series and event ID from path
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid series ID")
+	}
+	eventID, err := strconv.Atoi(ctx.Params("eventId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body. Like UpdateEvent, an occurrence that already
+	// started may keep its start time.
+	req := new(models.SeriesUpdateRequest)
+	ctx.SetUserContext(validation.AllowPast(ctx.UserContext()))
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Update occurrence
+	series, err := c.SeriesService.UpdateOccurrence(id, eventID, userID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(series)
+}
+
+// DeleteSeries handles deleting a series
+// @Summary Delete a recurring event series
+// @Description Delete a series with all its occurrences, as long as nobody joined any of them (series organizer only)
+// @Tags series
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /series/{id} [delete]
+func (c *SeriesController) DeleteSeries(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get series ID from path
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid series ID")
+	}
+
+	// Delete series
+	err = c.SeriesService.DeleteSeries(id, userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(fiber.Map{
+		"message": "Series deleted successfully",
+	})
+}
+
+// JoinSeries handles joining a whole series
+// @Summary Join a recurring event series
+// @Description Join every upcoming occurrence of a series, and the ones added to it later. Each occurrence gives a seat or a waitlist spot on its own. The series counts as one event towards the active events limit.
+// @Tags series
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Success 200 {object} models.SeriesJoinResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /series/{id}/join [post]
+func (c *SeriesController) JoinSeries(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get series ID from path
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid series ID")
+	}
+
+	// Join series
+	response, err := c.SeriesService.JoinSeries(userID, id)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(response)
+}
+
+// LeaveSeries handles leaving a whole series
+// @Summary Leave a recurring event series
+// @Description Leave every upcoming occurrence that was joined through the series
+// @Tags series
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /series/{id}/leave [post]
+func (c *SeriesController) LeaveSeries(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get series ID from path
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid series ID")
+	}
+
+	// Leave series
+	err = c.SeriesService.LeaveSeries(userID, id)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(fiber.Map{
+		"message": "Successfully left series",
+	})
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/event-system/models"
+	"github.com/event-system/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// StaffController handles HTTP requests related to event staff
+type StaffController struct {
+	StaffService *services.StaffService
+}
+
+// NewStaffController creates a new staff controller instance
+func NewStaffController(staffService *services.StaffService) *StaffController {
+	return &StaffController{StaffService: staffService}
+}
+
+// GetStaff handles listing the staff of an event
+// @Summary Get event staff
+// @Description Get the co-organizers, check-in staff and viewers of an event (owner and staff only)
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {array} models.StaffResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/staff [get]
+func (c *StaffController) GetStaff(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get staff
+	staff, err := c.StaffService.GetStaff(eventID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(staff)
+}
+
+// AddStaff handles granting a user a staff role on an event
+// @Summary Add event staff
+// @Description Grant a user the co_organizer, checkin_staff or viewer role on an event, or change the role they have (owner only)
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param staff body models.AddStaffRequest true "User and staff role"
+// @Success 200 {array} models.StaffResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/staff [post]
+func (c *StaffController) AddStaff(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.AddStaffRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Add staff member
+	staff, err := c.StaffService.AddStaff(eventID, userID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(staff)
+}
+
+// RemoveStaff handles taking a staff role away
+// @Summary Remove event staff
+// @Description Remove a user from the staff of an event. The owner may remove anyone and staff may remove themselves.
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param userId path int true "User ID of the staff member"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/staff/{userId} [delete]
+func (c *StaffController) RemoveStaff(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get staff member ID from path
+	staffUserID, err := strconv.Atoi(ctx.Params("userId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	// Remove staff member
+	err = c.StaffService.RemoveStaff(eventID, userID, staffUserID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(fiber.Map{
+		"message": "Staff member removed successfully",
+	})
+}
+
+// TransferOwnership handles handing an event over to another organizer
+// @Summary Transfer event ownership
+// @Description Make another organizer the owner of an event. The previous owner stays on as co-organizer (owner only).
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param owner body models.TransferOwnershipRequest true "New owner"
+// @Success 200 {object} models.EventResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/transfer-ownership [post]
+func (c *StaffController) TransferOwnership(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.TransferOwnershipRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Transfer ownership
+	event, err := c.StaffService.TransferOwnership(eventID, userID, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(event)
+}
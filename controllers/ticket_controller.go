@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/event-system/models"
+	"github.com/event-system/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TicketController handles ticket and check-in related HTTP requests
+type TicketController struct {
+	TicketService *services.TicketService
+}
+
+// NewTicketController creates a new ticket controller instance
+func NewTicketController(ticketService *services.TicketService) *TicketController {
+	return &TicketController{TicketService: ticketService}
+}
+
+// GetTicket handles getting the ticket of the current user as a QR code
+// @Summary Get my ticket
+// @Description Get the ticket of the current user for an event as a PNG QR code. Only confirmed participants have a ticket; the code is signed and stays the same as long as the registration does.
+// @Tags tickets
+// @Produce png
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {file} file "QR code of the ticket"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /events/{id}/ticket [get]
+func (c *TicketController) GetTicket(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get ticket
+	png, err := c.TicketService.TicketQR(userID, eventID)
+	if err != nil {
+		return err
+	}
+
+	// Return QR code
+	ctx.Set(fiber.HeaderContentType, "image/png")
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="ticket-%d.png"`, eventID))
+	ctx.Set(fiber.HeaderCacheControl, "private, no-store")
+	return ctx.Send(png)
+}
+
+// CheckIn handles checking in the holder of a scanned ticket
+// @Summary Check in a participant
+// @Description Validate a scanned ticket code and record that its holder entered the event. Each ticket can be checked in once (owner, co-organizers and check-in staff).
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param ticket body models.CheckInRequest true "Scanned ticket code"
+// @Success 200 {object} models.CheckInResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/checkins [post]
+func (c *TicketController) CheckIn(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.CheckInRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Check in
+	checkIn, err := c.TicketService.CheckIn(eventID, userID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(checkIn)
+}
+
+// GetAttendance handles getting the attendance summary of an event
+// @Summary Get attendance summary
+// @Description Get how many confirmed participants have checked in so far (owner and staff only)
+// @Tags tickets
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {object} models.AttendanceResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/attendance [get]
+func (c *TicketController) GetAttendance(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get attendance
+	attendance, err := c.TicketService.GetAttendance(eventID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(attendance)
+}
+
+// GetCheckInManifest handles downloading the signed tickets of an event for checking in offline
+// @Summary Get check-in manifest
+// @Description Download the tickets of an event for checking in without a connection. The manifest is a JWT signed with EdDSA whose tickets claim lists the SHA-256 hash of every ticket code and the check-in time of tickets used so far; public_key verifies the signature (owner, co-organizers and check-in staff).
+// @Tags tickets
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Success 200 {object} models.CheckInManifestResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /events/{id}/checkins/manifest [get]
+func (c *TicketController) GetCheckInManifest(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get manifest
+	manifest, err := c.TicketService.GetCheckInManifest(eventID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(manifest)
+}
+
+// SyncCheckIns handles uploading check-ins made offline
+// @Summary Sync offline check-ins
+// @Description Upload a batch of check-ins made offline with the time each ticket was scanned. The earliest scan of a ticket wins whatever order devices sync in; later scans are reported as duplicates and an earlier scan replaces the recorded check-in (superseded_at). Invalid tickets and scan times in the future or more than 24 hours before the start of the event are reported per entry (owner, co-organizers and check-in staff).
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param checkins body models.CheckInSyncRequest true "Offline check-ins"
+// @Success 200 {object} models.CheckInSyncResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/checkins/sync [post]
+func (c *TicketController) SyncCheckIns(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.CheckInSyncRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Sync check-ins
+	synced, err := c.TicketService.SyncCheckIns(eventID, userID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(synced)
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/event-system/models"
+	"github.com/event-system/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TicketTypeController handles HTTP requests related to the ticket types of events
+type TicketTypeController struct {
+	TicketTypeService *services.TicketTypeService
+}
+
+// NewTicketTypeController creates a new ticket type controller instance
+func NewTicketTypeController(ticketTypeService *services.TicketTypeService) *TicketTypeController {
+	return &TicketTypeController{TicketTypeService: ticketTypeService}
+}
+
+// ListTicketTypes handles listing the ticket types of an event
+// @Summary List ticket types
+// @Description List the ticket types of an event with their quota, the tickets sold and whether they are on sale. Hidden ticket types are only shown to the owner, staff and admins.
+// @Tags ticket-types
+// @Produce json
+// @Param id path int true "Event ID"
+// @Param invite query string false "Token of an invite link to the private event"
+// @Success 200 {array} models.TicketTypeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /events/{id}/ticket-types [get]
+func (c *TicketTypeController) ListTicketTypes(ctx *fiber.Ctx) error {
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get ticket types
+	ticketTypes, err := c.TicketTypeService.ListTicketTypes(eventID, viewerFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(ticketTypes)
+}
+
+// CreateTicketType handles adding a ticket type to an event
+// @Summary Create a ticket type
+// @Description Add a ticket type with its own quota, optional sales window and visibility to an event (owner and co-organizers only). Once an event has ticket types, joining it requires choosing one; the capacity of the event stays the overall cap.
+// @Tags ticket-types
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param ticketType body models.TicketTypeRequest true "Ticket type"
+// @Success 201 {object} models.TicketTypeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/ticket-types [post]
+func (c *TicketTypeController) CreateTicketType(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Parse request body
+	req := new(models.TicketTypeRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Create ticket type
+	ticketType, err := c.TicketTypeService.CreateTicketType(eventID, userID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(ticketType)
+}
+
+// UpdateTicketType handles updating a ticket type of an event
+// @Summary Update a ticket type
+// @Description Update a ticket type of an event (owner and co-organizers only). The quota can't be lower than the tickets already sold; raising it gives the new tickets to waitlisted users of this ticket type.
+// @Tags ticket-types
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param ticketTypeId path int true "Ticket type ID"
+// @Param ticketType body models.TicketTypeRequest true "Ticket type"
+// @Success 200 {object} models.TicketTypeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /events/{id}/ticket-types/{ticketTypeId} [put]
+func (c *TicketTypeController) UpdateTicketType(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get ticket type ID from path
+	ticketTypeID, err := strconv.Atoi(ctx.Params("ticketTypeId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid ticket type ID")
+	}
+
+	// Parse request body
+	req := new(models.TicketTypeRequest)
+	if err := parseBody(ctx, req); err != nil {
+		return err
+	}
+
+	// Update ticket type
+	ticketType, err := c.TicketTypeService.UpdateTicketType(eventID, userID, ticketTypeID, *req)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(ticketType)
+}
+
+// DeleteTicketType handles deleting a ticket type of an event
+// @Summary Delete a ticket type
+// @Description Delete a ticket type of an event that nobody has registered with and no promo code is tied to (owner and co-organizers only)
+// @Tags ticket-types
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Event ID"
+// @Param ticketTypeId path int true "Ticket type ID"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /events/{id}/ticket-types/{ticketTypeId} [delete]
+func (c *TicketTypeController) DeleteTicketType(ctx *fiber.Ctx) error {
+	// Get user ID from context
+	userID, ok := ctx.Locals("userID").(int)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Get event ID from path
+	eventID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid event ID")
+	}
+
+	// Get ticket type ID from path
+	ticketTypeID, err := strconv.Atoi(ctx.Params("ticketTypeId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid ticket type ID")
+	}
+
+	// Delete ticket type
+	if err := c.TicketTypeService.DeleteTicketType(eventID, userID, ticketTypeID); err != nil {
+		return err
+	}
+
+	// Return response
+	return ctx.JSON(fiber.Map{
+		"message": "Ticket type deleted successfully",
+	})
+}
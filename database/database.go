@@ -2,20 +2,65 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
-// Connect establishes a connection to the PostgreSQL database
+// Supported values of DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Connect opens the database selected by DB_DRIVER (postgres by default)
 func Connect() (*sql.DB, error) {
+	driver := os.Getenv("DB_DRIVER")
+	if driver == "" {
+		driver = DriverPostgres
+	}
+
+	var db *sql.DB
+	var err error
+	switch driver {
+	case DriverPostgres:
+		db, err = connectPostgres()
+	case DriverSQLite:
+		path := os.Getenv("DB_PATH")
+		if path == "" {
+			path = "event_system.db"
+		}
+		db, err = OpenSQLite(path)
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER %q, expected %q or %q", driver, DriverPostgres, DriverSQLite)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Check connection
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Printf("Successfully connected to %s database", driver)
+	return db, nil
+}
+
+// connectPostgres opens the PostgreSQL database described by the DB_* variables
+func connectPostgres() (*sql.DB, error) {
 	host := os.Getenv("DB_HOST")
 	port := os.Getenv("DB_PORT")
 	user := os.Getenv("DB_USER")
 	password := os.Getenv("DB_PASSWORD")
 	dbname := os.Getenv("DB_NAME")
+	sslmode := os.Getenv("DB_SSLMODE")
 
 	// Set default values if environment variables are not set
 	if host == "" {
@@ -30,87 +75,32 @@ func Connect() (*sql.DB, error) {
 	if dbname == "" {
 		dbname = "event_system"
 	}
-
-	// Create connection string
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
-
-	// Open connection
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		return nil, err
+	if sslmode == "" {
+		sslmode = "disable"
 	}
 
-	// Check connection
-	err = db.Ping()
-	if err != nil {
-		return nil, err
-	}
+	// Create connection string
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		host, port, user, password, dbname, sslmode)
 
-	log.Println("Successfully connected to database")
-	return db, nil
+	return sql.Open(DriverPostgres, connStr)
 }
 
-// CreateTables creates all necessary tables if they don't exist
-func CreateTables(db *sql.DB) error {
-	// Create users table
-	usersTable := `
-	CREATE TABLE IF NOT EXISTS users (
-		id SERIAL PRIMARY KEY,
-		username VARCHAR(50) UNIQUE NOT NULL,
-		email VARCHAR(100) UNIQUE NOT NULL,
-		password VARCHAR(255) NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-
-	// Create events table
-	eventsTable := `
-	CREATE TABLE IF NOT EXISTS events (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(100) NOT NULL,
-		description TEXT,
-		location VARCHAR(255),
-		start_time TIMESTAMP NOT NULL,
-		end_time TIMESTAMP NOT NULL,
-		capacity INTEGER NOT NULL,
-		organizer_id INTEGER NOT NULL REFERENCES users(id),
-		status VARCHAR(20) DEFAULT 'open',
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		CONSTRAINT check_capacity CHECK (capacity > 0),
-		CONSTRAINT check_time CHECK (end_time > start_time)
-	);
-	`
-
-	// Create participants table (for many-to-many relationship between users and events)
-	participantsTable := `
-	CREATE TABLE IF NOT EXISTS participants (
-		id SERIAL PRIMARY KEY,
-		user_id INTEGER NOT NULL REFERENCES users(id),
-		event_id INTEGER NOT NULL REFERENCES events(id),
-		joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		CONSTRAINT unique_participant UNIQUE (user_id, event_id)
-	);
-	`
-
-	// Execute SQL statements
-	_, err := db.Exec(usersTable)
-	if err != nil {
-		return err
-	}
-
-	_, err = db.Exec(eventsTable)
-	if err != nil {
-		return err
+// Dialect reports which SQL dialect a database opened by this package speaks
+func Dialect(db *sql.DB) string {
+	if _, ok := db.Driver().(sqliteDriver); ok {
+		return DriverSQLite
 	}
+	return DriverPostgres
+}
 
-	_, err = db.Exec(participantsTable)
-	if err != nil {
-		return err
+// IsUniqueViolation reports whether err comes from a violated unique constraint
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
 	}
-
-	log.Println("Database tables created successfully")
-	return nil
-}
\ No newline at end of file
+	// Matching the message keeps this working in builds without cgo,
+	// where go-sqlite3 has no error type
+	return strings.HasPrefix(err.Error(), "UNIQUE constraint failed")
+}
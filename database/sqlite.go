@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// The repositories are written in PostgreSQL flavoured SQL. The sqlite driver
+// wraps go-sqlite3 and translates the few differences on the fly, so the same
+// queries run against a single-file database:
+//
+//   - $1, $2, ... placeholders become ?1, ?2, ..., which SQLite numbers the same way
+//   - FOR UPDATE is dropped; transactions start with BEGIN IMMEDIATE instead,
+//     which locks the whole database for writing until they finish
+//   - times are stored in UTC, so comparing their text sorts them correctly
+//
+// Features SQLite doesn't have at all, like full text search with tsvector,
+// need their own queries; use Dialect to tell the databases apart.
+func init() {
+	sql.Register(DriverSQLite, sqliteDriver{})
+}
+
+var (
+	placeholderPattern = regexp.MustCompile(`\$(\d+)`)
+	forUpdatePattern   = regexp.MustCompile(`(?i)\s+FOR\s+UPDATE\b`)
+)
+
+// OpenSQLite opens (and creates if needed) the SQLite database at path
+func OpenSQLite(path string) (*sql.DB, error) {
+	dsn := path + "?_txlock=immediate&_busy_timeout=10000&_foreign_keys=on&_journal_mode=WAL"
+	return sql.Open(DriverSQLite, dsn)
+}
+
+// rebind rewrites a PostgreSQL query into one SQLite understands
+func rebind(query string) string {
+	query = forUpdatePattern.ReplaceAllString(query, "")
+	return placeholderPattern.ReplaceAllString(query, "?$1")
+}
+
+type sqliteDriver struct{}
+
+func (sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := (&sqlite3.SQLiteDriver{}).Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{conn}, nil
+}
+
+// sqliteConn rebinds every query before handing it to go-sqlite3
+type sqliteConn struct {
+	driver.Conn
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(rebind(query))
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, rebind(query))
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, rebind(query), args)
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, rebind(query), args)
+}
+
+func (c *sqliteConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *sqliteConn) Ping(ctx context.Context) error {
+	return c.Conn.(driver.Pinger).Ping(ctx)
+}
+
+// CheckNamedValue converts arguments like database/sql would and moves times to UTC
+func (c *sqliteConn) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC()
+	}
+	nv.Value = value
+	return nil
+}
@@ -0,0 +1,18 @@
+package database
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := map[string]string{
+		`SELECT id FROM events WHERE id = $1 AND organizer_id = $2`:    `SELECT id FROM events WHERE id = ?1 AND organizer_id = ?2`,
+		`SELECT status FROM events WHERE id = $1 FOR UPDATE`:           `SELECT status FROM events WHERE id = ?1`,
+		"SELECT id FROM users WHERE id = $12\n\tfor update\n":          "SELECT id FROM users WHERE id = ?12\n",
+		`SELECT access_token_id, $2 FROM refresh_tokens WHERE id = $1`: `SELECT access_token_id, ?2 FROM refresh_tokens WHERE id = ?1`,
+	}
+
+	for query, want := range tests {
+		if got := rebind(query); got != want {
+			t.Errorf("rebind(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package ical reads and writes RFC 5545 iCalendar data, so events can be
+// exchanged with calendar applications. Times are always written in UTC,
+// which every client converts to the zone of its user.
+package ical
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultProdID identifies this application as the producer of a calendar
+const DefaultProdID = "-//event-system//Event System//EN"
+
+// Event statuses
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+)
+
+// maxLineOctets is the longest content line allowed before it must be folded
+const maxLineOctets = 75
+
+const (
+	utcFormat   = "20060102T150405Z"
+	localFormat = "20060102T150405"
+	dateFormat  = "20060102"
+)
+
+// Event is one VEVENT
+type Event struct {
+	UID          string // must stay the same for the event across exports
+	Summary      string
+	Description  string
+	Location     string
+	Start        time.Time
+	End          time.Time // exclusive, also for all-day events
+	AllDay       bool      // Start and End are dates at midnight
+	RRule        string    // recurrence rule without the RRULE: prefix, if the event repeats
+	Status       string    // one of the statuses above, or empty to leave it out
+	Created      time.Time
+	LastModified time.Time
+	Extra        map[string]string // X- properties by upper case name, as unescaped text
+}
+
+// Calendar is a VCALENDAR holding events
+type Calendar struct {
+	ProdID string    // DefaultProdID when empty
+	Name   string    // shown by most clients as the name of a subscribed calendar
+	Stamp  time.Time // DTSTAMP of every event; the current time when zero
+	Events []Event
+}
+
+// Bytes encodes the calendar with CRLF line endings and folded long lines
+func (c *Calendar) Bytes() []byte {
+	w := &writer{}
+
+	prodID := c.ProdID
+	if prodID == "" {
+		prodID = DefaultProdID
+	}
+	stamp := c.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", prodID)
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		w.line("X-WR-CALNAME", EscapeText(c.Name))
+	}
+
+	for _, event := range c.Events {
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", event.UID)
+		w.line("DTSTAMP", FormatTime(stamp))
+		if event.AllDay {
+			w.line("DTSTART;VALUE=DATE", event.Start.Format(dateFormat))
+			w.line("DTEND;VALUE=DATE", event.End.Format(dateFormat))
+		} else {
+			w.line("DTSTART", FormatTime(event.Start))
+			w.line("DTEND", FormatTime(event.End))
+		}
+		if event.RRule != "" {
+			w.line("RRULE", event.RRule)
+		}
+		w.line("SUMMARY", EscapeText(event.Summary))
+		if event.Description != "" {
+			w.line("DESCRIPTION", EscapeText(event.Description))
+		}
+		if event.Location != "" {
+			w.line("LOCATION", EscapeText(event.Location))
+		}
+		if event.Status != "" {
+			w.line("STATUS", event.Status)
+		}
+		if !event.Created.IsZero() {
+			w.line("CREATED", FormatTime(event.Created))
+		}
+		if !event.LastModified.IsZero() {
+			w.line("LAST-MODIFIED", FormatTime(event.LastModified))
+		}
+		names := make([]string, 0, len(event.Extra))
+		for name := range event.Extra {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			w.line(name, EscapeText(event.Extra[name]))
+		}
+		w.line("END", "VEVENT")
+	}
+
+	w.line("END", "VCALENDAR")
+	return w.buf.Bytes()
+}
+
+// FormatTime formats t as a UTC DATE-TIME value
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(utcFormat)
+}
+
+// EscapeText escapes a TEXT value: backslashes, semicolons, commas and line breaks
+func EscapeText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(text)
+}
+
+type writer struct {
+	buf bytes.Buffer
+}
+
+// line writes one content line, folding it after every 75 octets without
+// splitting a UTF-8 sequence
+func (w *writer) line(name, value string) {
+	line := name + ":" + value
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.buf.WriteString(line[:cut])
+		w.buf.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length
+		limit = maxLineOctets - 1
+	}
+	w.buf.WriteString(line)
+	w.buf.WriteString("\r\n")
+}
@@ -0,0 +1,85 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestCalendarBytes(t *testing.T) {
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	calendar := &Calendar{
+		Name:  "My events",
+		Stamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Events: []Event{{
+			UID:         "event-7@example.com",
+			Summary:     "Go Meetup; part 1, 2",
+			Description: "First line\nSecond line",
+			Location:    `C:\Hall`,
+			Start:       time.Date(2026, 3, 1, 18, 30, 0, 0, tehran),
+			End:         time.Date(2026, 3, 1, 20, 30, 0, 0, tehran),
+			Status:      StatusConfirmed,
+		}},
+	}
+
+	got := string(calendar.Bytes())
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + DefaultProdID,
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:My events",
+		"BEGIN:VEVENT",
+		"UID:event-7@example.com",
+		"DTSTAMP:20260102T030405Z",
+		"DTSTART:20260301T150000Z",
+		"DTEND:20260301T170000Z",
+		`SUMMARY:Go Meetup\; part 1\, 2`,
+		`DESCRIPTION:First line\nSecond line`,
+		`LOCATION:C:\\Hall`,
+		"STATUS:CONFIRMED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	if got != want {
+		t.Errorf("unexpected calendar:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLongLinesAreFolded(t *testing.T) {
+	w := &writer{}
+	summary := strings.Repeat("رویداد ", 30)
+	w.line("SUMMARY", summary)
+
+	lines := strings.Split(strings.TrimSuffix(w.buf.String(), "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected the line to be folded, got %q", lines)
+	}
+
+	unfolded := lines[0]
+	for i, line := range lines {
+		if len(line) > maxLineOctets {
+			t.Errorf("line %d has %d octets", i, len(line))
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a character: %q", i, line)
+		}
+		if i > 0 {
+			if !strings.HasPrefix(line, " ") {
+				t.Errorf("continuation line %d doesn't start with a space", i)
+			}
+			unfolded += line[1:]
+		}
+	}
+	if unfolded != "SUMMARY:"+summary {
+		t.Errorf("unfolding doesn't give back the original line: %q", unfolded)
+	}
+}
@@ -0,0 +1,360 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParsedEvent is a VEVENT read from a file. Err is set when the VEVENT could
+// not be converted, for example because of an unknown TZID.
+type ParsedEvent struct {
+	Event
+	Err error
+}
+
+// property is one unfolded content line
+type property struct {
+	name   string // upper case
+	params map[string]string
+	value  string
+}
+
+// Parse reads the VEVENTs of an iCalendar file. Floating times, which have
+// neither a UTC marker nor a TZID, and all-day dates are read in loc. Each
+// VEVENT is converted on its own, so one broken event doesn't hide the
+// others; only a file that isn't iCalendar at all fails as a whole.
+func Parse(r io.Reader, loc *time.Location) ([]ParsedEvent, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []ParsedEvent{}
+	var stack []string
+	var current []property
+	for _, line := range lines {
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch p.name {
+		case "BEGIN":
+			name := strings.ToUpper(p.value)
+			if len(stack) == 0 && name != "VCALENDAR" {
+				return nil, errors.New("not an iCalendar file: it must start with BEGIN:VCALENDAR")
+			}
+			if name == "VEVENT" && len(stack) == 1 {
+				current = []property{}
+			}
+			stack = append(stack, name)
+		case "END":
+			name := strings.ToUpper(p.value)
+			if len(stack) == 0 || stack[len(stack)-1] != name {
+				return nil, fmt.Errorf("END:%s without matching BEGIN", name)
+			}
+			stack = stack[:len(stack)-1]
+			if name == "VEVENT" && len(stack) == 1 {
+				event, err := newEvent(current, loc)
+				events = append(events, ParsedEvent{Event: event, Err: err})
+				current = nil
+			}
+		default:
+			if len(stack) == 0 {
+				return nil, errors.New("not an iCalendar file: it must start with BEGIN:VCALENDAR")
+			}
+			// Properties of nested components such as VALARM are skipped
+			if len(stack) == 2 && stack[1] == "VEVENT" {
+				current = append(current, p)
+			}
+		}
+	}
+
+	if stack == nil {
+		return nil, errors.New("not an iCalendar file: BEGIN:VCALENDAR is missing")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("BEGIN:%s without matching END", stack[len(stack)-1])
+	}
+
+	return events, nil
+}
+
+// unfold splits the file into content lines, joining folded lines back together
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// parseLine splits a content line such as DTSTART;TZID="Asia/Tehran":20260301T180000
+// into its name, parameters and value
+func parseLine(line string) (property, error) {
+	p := property{params: map[string]string{}}
+
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return p, fmt.Errorf("invalid content line %q", line)
+	}
+	p.name = strings.ToUpper(line[:i])
+	rest := line[i:]
+
+	for len(rest) > 0 && rest[0] == ';' {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return p, fmt.Errorf("invalid parameter in %q", line)
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		var value strings.Builder
+		for len(rest) > 0 && rest[0] != ';' && rest[0] != ':' {
+			if rest[0] == '"' {
+				end := strings.IndexByte(rest[1:], '"')
+				if end < 0 {
+					return p, fmt.Errorf("unterminated quote in %q", line)
+				}
+				value.WriteString(rest[1 : end+1])
+				rest = rest[end+2:]
+				continue
+			}
+			end := strings.IndexAny(rest, `;:"`)
+			if end < 0 {
+				end = len(rest)
+			}
+			value.WriteString(rest[:end])
+			rest = rest[end:]
+		}
+		p.params[name] = value.String()
+	}
+
+	if len(rest) == 0 {
+		return p, fmt.Errorf("content line %q has no value", line)
+	}
+	p.value = rest[1:]
+	return p, nil
+}
+
+// newEvent converts the properties of a VEVENT
+func newEvent(props []property, loc *time.Location) (Event, error) {
+	event := Event{}
+	var start, end *property
+	var duration string
+
+	for i := range props {
+		p := &props[i]
+		switch p.name {
+		case "UID":
+			event.UID = p.value
+		case "SUMMARY":
+			event.Summary = unescapeText(p.value)
+		case "DESCRIPTION":
+			event.Description = unescapeText(p.value)
+		case "LOCATION":
+			event.Location = unescapeText(p.value)
+		case "STATUS":
+			event.Status = strings.ToUpper(p.value)
+		case "RRULE":
+			event.RRule = p.value
+		case "DTSTART":
+			start = p
+		case "DTEND":
+			end = p
+		case "DURATION":
+			duration = p.value
+		default:
+			if strings.HasPrefix(p.name, "X-") {
+				if event.Extra == nil {
+					event.Extra = map[string]string{}
+				}
+				event.Extra[p.name] = unescapeText(p.value)
+			}
+		}
+	}
+
+	if start == nil {
+		return event, errors.New("DTSTART is missing")
+	}
+	var err error
+	event.Start, event.AllDay, err = parseTime(start, loc)
+	if err != nil {
+		return event, fmt.Errorf("DTSTART: %v", err)
+	}
+
+	switch {
+	case end != nil:
+		var allDay bool
+		event.End, allDay, err = parseTime(end, loc)
+		if err != nil {
+			return event, fmt.Errorf("DTEND: %v", err)
+		}
+		if allDay != event.AllDay {
+			return event, errors.New("DTEND must be a date when DTSTART is one, and a date-time otherwise")
+		}
+	case duration != "":
+		days, clock, err := parseDuration(duration)
+		if err != nil {
+			return event, fmt.Errorf("DURATION: %v", err)
+		}
+		event.End = event.Start.AddDate(0, 0, days).Add(clock)
+	case event.AllDay:
+		// An all-day event without an end takes that one day
+		event.End = event.Start.AddDate(0, 0, 1)
+	default:
+		event.End = event.Start
+	}
+
+	return event, nil
+}
+
+// parseTime reads a DATE or DATE-TIME value; allDay reports a DATE
+func parseTime(p *property, loc *time.Location) (t time.Time, allDay bool, err error) {
+	value := p.value
+	if p.params["VALUE"] == "DATE" || len(value) == len(dateFormat) {
+		t, err = time.ParseInLocation(dateFormat, value, loc)
+		if err != nil {
+			return t, true, fmt.Errorf("invalid date %q", value)
+		}
+		return t, true, nil
+	}
+
+	zone := loc
+	format := localFormat
+	if strings.HasSuffix(value, "Z") {
+		zone = time.UTC
+		format = utcFormat
+	} else if tzid, ok := p.params["TZID"]; ok {
+		zone, err = loadZone(tzid)
+		if err != nil {
+			return t, false, err
+		}
+	}
+
+	t, err = time.ParseInLocation(format, value, zone)
+	if err != nil {
+		return t, false, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t, false, nil
+}
+
+// loadZone resolves a TZID. Only IANA names such as Europe/Berlin are known;
+// some producers put a slash in front of them.
+func loadZone(tzid string) (*time.Location, error) {
+	name := strings.TrimPrefix(tzid, "/")
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", tzid)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", tzid)
+	}
+	return loc, nil
+}
+
+// parseDuration reads a DURATION value such as PT1H30M or P1W. Days and weeks
+// are returned apart from the rest, because a day isn't always 24 hours long.
+func parseDuration(value string) (days int, clock time.Duration, err error) {
+	text := strings.TrimPrefix(value, "+")
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(text, "-")
+	if !strings.HasPrefix(text, "P") || len(text) == 1 {
+		return 0, 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	inTime := false
+	number := ""
+	parts := 0
+	for _, c := range text[1:] {
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+			continue
+		case c == 'T' && !inTime && number == "":
+			inTime = true
+			continue
+		}
+
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid duration %q", value)
+		}
+		number = ""
+		parts++
+		switch {
+		case c == 'W' && !inTime:
+			days += 7 * n
+		case c == 'D' && !inTime:
+			days += n
+		case c == 'H' && inTime:
+			clock += time.Duration(n) * time.Hour
+		case c == 'M' && inTime:
+			clock += time.Duration(n) * time.Minute
+		case c == 'S' && inTime:
+			clock += time.Duration(n) * time.Second
+		default:
+			return 0, 0, fmt.Errorf("invalid duration %q", value)
+		}
+	}
+	if number != "" || parts == 0 {
+		return 0, 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	if negative {
+		return -days, -clock, nil
+	}
+	return days, clock, nil
+}
+
+// unescapeText reverses EscapeText
+func unescapeText(text string) string {
+	if !strings.Contains(text, `\`) {
+		return text
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i+1 == len(text) {
+			b.WriteByte(text[i])
+			continue
+		}
+		i++
+		switch text[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		case '\\', ';', ',':
+			b.WriteByte(text[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
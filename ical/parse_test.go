@@ -0,0 +1,203 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseString(t *testing.T, text string, loc *time.Location) []ParsedEvent {
+	t.Helper()
+
+	events, err := Parse(strings.NewReader(strings.ReplaceAll(text, "\n", "\r\n")), loc)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return events
+}
+
+func TestParse(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	tehran, _ := time.LoadLocation("Asia/Tehran")
+
+	events := parseString(t, `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Example//EN
+BEGIN:VTIMEZONE
+TZID:Europe/Berlin
+BEGIN:STANDARD
+DTSTART:19701025T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:a@example.com
+SUMMARY:Go Meetup\, Berlin
+DESCRIPTION:Talks\nand pizza
+LOCATION:Hall 1
+DTSTART;TZID="Europe/Berlin":20260301T180000
+DTEND;TZID=Europe/Berlin:20260301T200000
+X-EVENT-CAPACITY:50
+BEGIN:VALARM
+ACTION:DISPLAY
+DESCRIPTION:Reminder
+TRIGGER:-PT15M
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:b@example.com
+SUMMARY:Workshop with a very long title that a calendar application folded
+  onto a second line
+DTSTART:20260302T090000Z
+DURATION:PT1H30M
+END:VEVENT
+BEGIN:VEVENT
+UID:c@example.com
+SUMMARY:Conference
+DTSTART;VALUE=DATE:20260310
+DTEND;VALUE=DATE:20260312
+END:VEVENT
+BEGIN:VEVENT
+UID:d@example.com
+SUMMARY:Floating
+DTSTART:20260315T100000
+END:VEVENT
+END:VCALENDAR
+`, tehran)
+
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+	for i, event := range events {
+		if event.Err != nil {
+			t.Fatalf("event %d: %v", i, event.Err)
+		}
+	}
+
+	first := events[0]
+	if first.UID != "a@example.com" || first.Summary != "Go Meetup, Berlin" || first.Description != "Talks\nand pizza" || first.Location != "Hall 1" {
+		t.Errorf("unexpected text fields: %+v", first.Event)
+	}
+	if !first.Start.Equal(time.Date(2026, 3, 1, 18, 0, 0, 0, berlin)) || !first.End.Equal(time.Date(2026, 3, 1, 20, 0, 0, 0, berlin)) {
+		t.Errorf("unexpected times %v - %v", first.Start, first.End)
+	}
+	if first.Extra["X-EVENT-CAPACITY"] != "50" {
+		t.Errorf("expected the X- property, got %v", first.Extra)
+	}
+
+	second := events[1]
+	if second.Summary != "Workshop with a very long title that a calendar application folded onto a second line" {
+		t.Errorf("expected the folded line to be joined, got %q", second.Summary)
+	}
+	if !second.End.Equal(time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected DURATION to give the end, got %v", second.End)
+	}
+
+	third := events[2]
+	if !third.AllDay || !third.Start.Equal(time.Date(2026, 3, 10, 0, 0, 0, 0, tehran)) || !third.End.Equal(time.Date(2026, 3, 12, 0, 0, 0, 0, tehran)) {
+		t.Errorf("unexpected all-day event %+v", third.Event)
+	}
+
+	if !events[3].Start.Equal(time.Date(2026, 3, 15, 10, 0, 0, 0, tehran)) {
+		t.Errorf("expected floating times in the given zone, got %v", events[3].Start)
+	}
+}
+
+func TestParseReportsBrokenEvents(t *testing.T) {
+	events := parseString(t, `BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:No start
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Unknown zone
+DTSTART;TZID=W. Europe Standard Time:20260301T180000
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Fine
+DTSTART:20260301T180000Z
+DTEND:20260301T190000Z
+END:VEVENT
+END:VCALENDAR
+`, time.UTC)
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if events[0].Err == nil || !strings.Contains(events[0].Err.Error(), "DTSTART") {
+		t.Errorf("expected a missing DTSTART error, got %v", events[0].Err)
+	}
+	if events[1].Err == nil || !strings.Contains(events[1].Err.Error(), "unknown time zone") {
+		t.Errorf("expected an unknown time zone error, got %v", events[1].Err)
+	}
+	if events[2].Err != nil {
+		t.Errorf("expected the last event to be fine, got %v", events[2].Err)
+	}
+}
+
+func TestParseRejectsInvalidFiles(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"hello world",
+		"BEGIN:VEVENT\r\nEND:VEVENT\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\n",
+	} {
+		if _, err := Parse(strings.NewReader(text), time.UTC); err == nil {
+			t.Errorf("expected %q to be rejected", text)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		days  int
+		clock time.Duration
+	}{
+		{"PT1H30M", 0, 90 * time.Minute},
+		{"P1W", 7, 0},
+		{"P1DT2H", 1, 2 * time.Hour},
+		{"-PT15M", 0, -15 * time.Minute},
+	}
+	for _, tt := range tests {
+		days, clock, err := parseDuration(tt.value)
+		if err != nil || days != tt.days || clock != tt.clock {
+			t.Errorf("%s = %d days %v, %v", tt.value, days, clock, err)
+		}
+	}
+
+	for _, value := range []string{"", "P", "PT", "1H", "PT1D", "P1H"} {
+		if _, _, err := parseDuration(value); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	calendar := &Calendar{Events: []Event{{
+		UID:         "event-1@example.com",
+		Summary:     "رویداد; با, کاراکترهای خاص\\",
+		Description: strings.Repeat("توضیحات طولانی ", 20),
+		Start:       time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC),
+		End:         time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC),
+		Extra:       map[string]string{"X-EVENT-CAPACITY": "25"},
+	}}}
+
+	events, err := Parse(strings.NewReader(string(calendar.Bytes())), time.UTC)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(events) != 1 || events[0].Err != nil {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	got, want := events[0].Event, calendar.Events[0]
+	if got.UID != want.UID || got.Summary != want.Summary || got.Description != want.Description ||
+		!got.Start.Equal(want.Start) || !got.End.Equal(want.End) || got.Extra["X-EVENT-CAPACITY"] != "25" {
+		t.Errorf("round trip changed the event:\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -1,15 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/event-system/config"
 	"github.com/event-system/database"
 	"github.com/event-system/docs"
 	_ "github.com/event-system/docs"
+	"github.com/event-system/migrations"
 	"github.com/event-system/routes"
+	"github.com/event-system/scheduler"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -51,10 +57,14 @@ func main() {
 	}
 	defer db.Close()
 
-	// Create tables if they don't exist
-	err = database.CreateTables(db)
+	// Bring the schema up to date
+	migrator, err := migrations.NewMigrator(db)
 	if err != nil {
-		log.Fatalf("Failed to create tables: %v", err)
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	err = migrator.Up()
+	if err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
 	// Initialize Fiber app
@@ -73,8 +83,10 @@ func main() {
 		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
 	}))
 
-	// Setup routes
-	routes.SetupRoutes(app, db)
+	// Setup routes and background jobs
+	jobs := scheduler.New(scheduler.NewLocker(db))
+	routes.SetupRoutes(app, db, jobs)
+	jobs.Start()
 
 	// Get port from environment variable or default to 8080
 	port := os.Getenv("PORT")
@@ -83,6 +95,26 @@ func main() {
 	}
 
 	// Start server
-	log.Printf("Server starting on port %s\n", port)
-	log.Fatal(app.Listen(fmt.Sprintf(":%s", port)))
+	go func() {
+		log.Printf("Server starting on port %s\n", port)
+		if err := app.Listen(fmt.Sprintf(":%s", port)); err != nil {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// Wait for a shutdown signal, then let requests and running jobs finish
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down")
+
+	if err := app.ShutdownWithTimeout(10 * time.Second); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := jobs.Stop(ctx); err != nil {
+		log.Printf("Error stopping background jobs: %v", err)
+	}
 }
@@ -26,15 +26,51 @@ func Protected(authService *services.AuthService) fiber.Handler {
 		// توکن رو استخراج میکنه
 		token := strings.TrimPrefix(authorization, "Bearer ")
 
-		// توکن رو اعتبارسنجی میکنه
-		userID, err := authService.ValidateToken(token)
+		// توکن رو اعتبارسنجی میکنه (شامل چک لیست توکن‌های باطل‌شده)
+		claims, err := authService.ValidateToken(token)
 		if err != nil {
 			return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired token")
 		}
 
-		// آیدی کاربر رو تو locals ذخیره میکنه
-		c.Locals("userID", userID)
+		// آیدی کاربر و اطلاعات توکن رو تو locals ذخیره میکنه
+		c.Locals("userID", claims.UserID)
+		c.Locals("claims", claims)
 
 		return c.Next()
 	}
 }
+
+// میدلور احراز هویت اختیاری برای مسیرهای عمومی که به کاربر واردشده چیز بیشتری نشون میدن.
+// درخواست بدون هدر احراز هویت به عنوان کاربر ناشناس ادامه پیدا میکنه، ولی توکن نامعتبر مثل Protected خطا میده
+func OptionalAuth(authService *services.AuthService) fiber.Handler {
+	protected := Protected(authService)
+	return func(c *fiber.Ctx) error {
+		// اگه هدر احراز هویت نباشه درخواست ناشناس ادامه پیدا میکنه
+		if c.Get("Authorization") == "" {
+			return c.Next()
+		}
+
+		return protected(c)
+	}
+}
+
+// میدلوری که فقط به کاربرهایی با یکی از نقش‌های داده‌شده اجازه عبور میده.
+// باید بعد از Protected استفاده بشه چون نقش رو از اطلاعات توکن میخونه
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// اطلاعات توکن رو که Protected ذخیره کرده میگیره
+		claims, ok := c.Locals("claims").(*services.TokenClaims)
+		if !ok {
+			return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+		}
+
+		// چک میکنه ببینه نقش کاربر جزو نقش‌های مجاز هست یا نه
+		for _, role := range roles {
+			if claims.Role == role {
+				return c.Next()
+			}
+		}
+
+		return fiber.NewError(fiber.StatusForbidden, "You don't have permission to access this resource")
+	}
+}
@@ -0,0 +1,344 @@
+// Package migrations keeps the database schema up to date.
+//
+// Every schema change lives in a pair of numbered SQL files
+// (for example 0002_participant_waitlist.up.sql and its .down.sql) that are
+// embedded into the binary. Each supported database has its own copy of the
+// files under sql/<dialect>/ with the same versions and names. Applied
+// versions are recorded in the schema_migrations table.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/event-system/database"
+)
+
+//go:embed sql/postgres/*.sql sql/sqlite/*.sql
+var files embed.FS
+
+// lockKey is the PostgreSQL advisory lock that makes sure only one instance migrates at a time
+const lockKey int64 = 7_240_331_905
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a single numbered schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes whether a migration has been applied
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies and rolls back migrations against a database
+type Migrator struct {
+	DB         *sql.DB
+	Dialect    string // database.DriverPostgres or database.DriverSQLite
+	Migrations []Migration
+}
+
+// NewMigrator creates a migrator with the embedded migrations for the dialect of db
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	dialect := database.Dialect(db)
+
+	migrations, err := load(files, "sql/"+dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{DB: db, Dialect: dialect, Migrations: migrations}, nil
+}
+
+// load reads the migration files in dir and pairs up their up and down halves
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up() error {
+	if len(m.Migrations) == 0 {
+		return nil
+	}
+	return m.To(m.Migrations[len(m.Migrations)-1].Version)
+}
+
+// Down rolls back the given number of applied migrations
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return errors.New("steps must be positive")
+	}
+
+	return m.withLock(func(conn *sql.Conn) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.Migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := m.Migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.rollback(conn, migration); err != nil {
+				return err
+			}
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// To migrates up or down so that exactly the migrations up to version are applied.
+// Version 0 rolls back everything.
+func (m *Migrator) To(version int) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.withLock(func(conn *sql.Conn) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		// Roll back newer migrations first, newest to oldest
+		for i := len(m.Migrations) - 1; i >= 0; i-- {
+			migration := m.Migrations[i]
+			if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+				continue
+			}
+			if err := m.rollback(conn, migration); err != nil {
+				return err
+			}
+		}
+
+		// Then apply missing ones, oldest to newest
+		for _, migration := range m.Migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			if err := m.apply(conn, migration); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status() ([]Status, error) {
+	var statuses []Status
+
+	err := m.withLock(func(conn *sql.Conn) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.Migrations {
+			appliedAt, ok := applied[migration.Version]
+			statuses = append(statuses, Status{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Applied:   ok,
+				AppliedAt: appliedAt,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// find returns the migration with the given version, if any
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.Migrations {
+		if m.Migrations[i].Version == version {
+			return &m.Migrations[i]
+		}
+	}
+	return nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock.
+// SQLite has no advisory locks; a database file belongs to a single instance
+// and every migration runs in a transaction that locks the whole file anyway.
+func (m *Migrator) withLock(fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+
+	// Advisory locks belong to a session, so everything has to run on one connection
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.Dialect != database.DriverSQLite {
+		_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey)
+		if err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer func() {
+			if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+				log.Printf("Error releasing migration lock: %v", err)
+			}
+		}()
+	}
+
+	_, err = conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)
+	`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// appliedVersions returns the applied migration versions with the time they were applied
+func appliedVersions(conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(context.Background(), `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// apply runs the up half of a migration and records it, all in one transaction
+func (m *Migrator) apply(conn *sql.Conn, migration Migration) error {
+	ctx := context.Background()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+		return fmt.Errorf("apply migration %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+		migration.Version, migration.Name, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	return nil
+}
+
+// rollback runs the down half of a migration and forgets it, all in one transaction
+func (m *Migrator) rollback(conn *sql.Conn, migration Migration) error {
+	ctx := context.Background()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+		return fmt.Errorf("roll back migration %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("Rolled back migration %04d_%s", migration.Version, migration.Name)
+	return nil
+}
@@ -0,0 +1,109 @@
+package migrations
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/event-system/database"
+)
+
+func TestEmbeddedMigrationsAreSequential(t *testing.T) {
+	for _, dialect := range []string{database.DriverPostgres, database.DriverSQLite} {
+		migrations, err := load(files, "sql/"+dialect)
+		if err != nil {
+			t.Fatalf("load embedded %s migrations: %v", dialect, err)
+		}
+
+		if len(migrations) == 0 {
+			t.Fatalf("expected at least one %s migration", dialect)
+		}
+
+		for i, migration := range migrations {
+			if migration.Version != i+1 {
+				t.Errorf("expected %s migration %d to have version %d, got %d", dialect, i, i+1, migration.Version)
+			}
+		}
+	}
+}
+
+func TestDialectsHaveTheSameMigrations(t *testing.T) {
+	postgres, err := load(files, "sql/"+database.DriverPostgres)
+	if err != nil {
+		t.Fatalf("load postgres migrations: %v", err)
+	}
+	sqlite, err := load(files, "sql/"+database.DriverSQLite)
+	if err != nil {
+		t.Fatalf("load sqlite migrations: %v", err)
+	}
+
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("postgres has %d migrations, sqlite has %d", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Errorf("migration %d is %04d_%s on postgres but %04d_%s on sqlite",
+				i, postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+}
+
+func TestSQLiteMigrationsUpAndDown(t *testing.T) {
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "migrations.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if migrator.Dialect != database.DriverSQLite {
+		t.Fatalf("dialect = %q, want %q", migrator.Dialect, database.DriverSQLite)
+	}
+
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := migrator.To(0); err != nil {
+		t.Fatalf("roll back everything: %v", err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			t.Errorf("migration %04d_%s is not applied", status.Version, status.Name)
+		}
+	}
+}
+
+func TestLoadRejectsBrokenMigrations(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing down": {
+			"sql/0001_init.up.sql": {Data: []byte("SELECT 1")},
+		},
+		"bad file name": {
+			"sql/init.up.sql":   {Data: []byte("SELECT 1")},
+			"sql/init.down.sql": {Data: []byte("SELECT 1")},
+		},
+		"mismatched names": {
+			"sql/0001_init.up.sql":    {Data: []byte("SELECT 1")},
+			"sql/0001_other.down.sql": {Data: []byte("SELECT 1")},
+		},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := load(fsys, "sql"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	username VARCHAR(50) UNIQUE NOT NULL,
+	email VARCHAR(100) UNIQUE NOT NULL,
+	password VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS events (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	description TEXT,
+	location VARCHAR(255),
+	start_time TIMESTAMP NOT NULL,
+	end_time TIMESTAMP NOT NULL,
+	capacity INTEGER NOT NULL,
+	organizer_id INTEGER NOT NULL REFERENCES users(id),
+	status VARCHAR(20) DEFAULT 'open',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT check_capacity CHECK (capacity > 0),
+	CONSTRAINT check_time CHECK (end_time > start_time)
+);
+
+-- Many-to-many relationship between users and events
+CREATE TABLE IF NOT EXISTS participants (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	event_id INTEGER NOT NULL REFERENCES events(id),
+	joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT unique_participant UNIQUE (user_id, event_id)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_participants_user;
+DROP INDEX IF EXISTS idx_events_organizer_start_time;
+DROP INDEX IF EXISTS idx_events_start_time_id;
@@ -0,0 +1,4 @@
+-- Keyset pagination walks events by (start_time, id)
+CREATE INDEX IF NOT EXISTS idx_events_start_time_id ON events (start_time, id);
+CREATE INDEX IF NOT EXISTS idx_events_organizer_start_time ON events (organizer_id, start_time, id);
+CREATE INDEX IF NOT EXISTS idx_participants_user ON participants (user_id, status);
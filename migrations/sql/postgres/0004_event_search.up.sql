@@ -0,0 +1,9 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+-- Existing rows get the same weighted document that EventRepository writes
+UPDATE events SET search_vector =
+	setweight(to_tsvector('simple', COALESCE(name, '')), 'A') ||
+	setweight(to_tsvector('simple', COALESCE(location, '')), 'B') ||
+	setweight(to_tsvector('simple', COALESCE(description, '')), 'C');
+
+CREATE INDEX IF NOT EXISTS idx_events_search_vector ON events USING GIN (search_vector);
@@ -0,0 +1,24 @@
+-- Rotating refresh tokens; only a SHA-256 hash of each token is stored.
+-- Tokens issued from the same login share a family_id so reuse of an old
+-- token can revoke the whole chain.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	family_id VARCHAR(64) NOT NULL,
+	access_token_id VARCHAR(64),
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP,
+	replaced_by INTEGER REFERENCES refresh_tokens(id) ON DELETE SET NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens (family_id);
+
+-- Access tokens revoked before they expire, keyed by their jti claim
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti VARCHAR(64) PRIMARY KEY,
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
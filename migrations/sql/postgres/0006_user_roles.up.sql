@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS check_role;
+ALTER TABLE users ADD CONSTRAINT check_role CHECK (role IN ('user', 'organizer', 'admin'));
+
+-- Everyone who already organizes events keeps being able to do so
+UPDATE users SET role = 'organizer'
+WHERE role = 'user' AND id IN (SELECT DISTINCT organizer_id FROM events);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_participants_series;
+ALTER TABLE participants DROP COLUMN IF EXISTS series_id;
+
+DROP INDEX IF EXISTS idx_events_series;
+ALTER TABLE events DROP COLUMN IF EXISTS recurrence_id;
+ALTER TABLE events DROP COLUMN IF EXISTS series_id;
+
+DROP TABLE IF EXISTS event_series;
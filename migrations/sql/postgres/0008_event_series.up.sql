@@ -0,0 +1,32 @@
+-- A recurring event. The occurrences are ordinary rows in events that point
+-- back to their series; recurrence_id is the start time the rule generated
+-- for an occurrence, before any edit of that single occurrence.
+CREATE TABLE IF NOT EXISTS event_series (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	description TEXT,
+	location VARCHAR(255),
+	start_time TIMESTAMP NOT NULL,
+	end_time TIMESTAMP NOT NULL,
+	capacity INTEGER NOT NULL,
+	organizer_id INTEGER NOT NULL REFERENCES users(id),
+	timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+	rrule VARCHAR(255) NOT NULL,
+	exdates TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT check_series_capacity CHECK (capacity > 0),
+	CONSTRAINT check_series_time CHECK (end_time > start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_series_organizer ON event_series (organizer_id);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS series_id INTEGER REFERENCES event_series(id);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS recurrence_id TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_events_series ON events (series_id, recurrence_id);
+
+-- Participants who joined the whole series instead of a single occurrence
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS series_id INTEGER REFERENCES event_series(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_participants_series ON participants (series_id, user_id);
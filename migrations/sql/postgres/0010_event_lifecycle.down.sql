@@ -0,0 +1,5 @@
+ALTER TABLE events DROP CONSTRAINT IF EXISTS check_event_status;
+ALTER TABLE events ALTER COLUMN status DROP NOT NULL;
+ALTER TABLE events ALTER COLUMN status SET DEFAULT 'open';
+
+UPDATE events SET status = CASE WHEN status = 'published' THEN 'open' ELSE 'closed' END;
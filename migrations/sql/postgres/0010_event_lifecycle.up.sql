@@ -0,0 +1,12 @@
+-- Events move through draft, published, registration_closed, ongoing,
+-- completed and cancelled instead of the free-form open/closed status
+UPDATE events SET status = 'published' WHERE status = 'open' OR status IS NULL;
+UPDATE events SET status = 'registration_closed'
+WHERE status NOT IN ('draft', 'published', 'registration_closed', 'ongoing', 'completed', 'cancelled');
+
+ALTER TABLE events ALTER COLUMN status SET DEFAULT 'published';
+ALTER TABLE events ALTER COLUMN status SET NOT NULL;
+
+ALTER TABLE events DROP CONSTRAINT IF EXISTS check_event_status;
+ALTER TABLE events ADD CONSTRAINT check_event_status
+	CHECK (status IN ('draft', 'published', 'registration_closed', 'ongoing', 'completed', 'cancelled'));
@@ -0,0 +1,3 @@
+ALTER TABLE events DROP COLUMN IF EXISTS registration_closes_at;
+ALTER TABLE events DROP COLUMN IF EXISTS registration_opens_at;
+ALTER TABLE events DROP COLUMN IF EXISTS publish_at;
@@ -0,0 +1,5 @@
+-- Optional times at which an event shows up in public listings and at which
+-- its registration opens and closes
+ALTER TABLE events ADD COLUMN publish_at TIMESTAMP;
+ALTER TABLE events ADD COLUMN registration_opens_at TIMESTAMP;
+ALTER TABLE events ADD COLUMN registration_closes_at TIMESTAMP;
@@ -0,0 +1,6 @@
+DELETE FROM participants WHERE status IN ('pending', 'rejected');
+
+ALTER TABLE participants DROP COLUMN IF EXISTS review_message;
+ALTER TABLE participants DROP COLUMN IF EXISTS reviewed_at;
+
+ALTER TABLE events DROP COLUMN IF EXISTS requires_approval;
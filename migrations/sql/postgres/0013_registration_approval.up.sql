@@ -0,0 +1,6 @@
+-- Events that require approval take registrations as pending until the
+-- organizer approves or rejects them
+ALTER TABLE events ADD COLUMN IF NOT EXISTS requires_approval BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS review_message TEXT;
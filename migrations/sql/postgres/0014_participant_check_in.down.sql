@@ -0,0 +1 @@
+ALTER TABLE participants DROP COLUMN IF EXISTS checked_in_at;
@@ -0,0 +1,2 @@
+-- When a participant was checked in at the door with their ticket
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS checked_in_at TIMESTAMP;
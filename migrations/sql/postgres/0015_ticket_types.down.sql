@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_participants_ticket_type;
+ALTER TABLE participants DROP COLUMN IF EXISTS ticket_type_id;
+
+DROP TABLE IF EXISTS ticket_types;
@@ -0,0 +1,24 @@
+-- Ticket types (tiers) of an event, each with its own quota and sales window.
+-- Hidden ones aren't listed to attendees. The capacity of the event stays the
+-- overall cap over all of its tiers.
+CREATE TABLE IF NOT EXISTS ticket_types (
+	id SERIAL PRIMARY KEY,
+	event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+	name VARCHAR(100) NOT NULL,
+	quota INTEGER NOT NULL,
+	sales_starts_at TIMESTAMP,
+	sales_ends_at TIMESTAMP,
+	visibility VARCHAR(20) NOT NULL DEFAULT 'public',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT check_ticket_type_quota CHECK (quota > 0),
+	CONSTRAINT check_ticket_type_visibility CHECK (visibility IN ('public', 'hidden')),
+	CONSTRAINT unique_ticket_type_name UNIQUE (event_id, name)
+);
+
+-- The ticket type a participant registered with; empty for events without
+-- tiers. A ticket type with registrations can't be deleted; NO ACTION is
+-- checked at the end of the statement, so deleting the event still cascades.
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS ticket_type_id INTEGER REFERENCES ticket_types(id) ON DELETE NO ACTION;
+
+CREATE INDEX IF NOT EXISTS idx_participants_ticket_type ON participants (ticket_type_id, status);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS orders;
+
+ALTER TABLE participants DROP COLUMN IF EXISTS reserved_until;
+ALTER TABLE ticket_types DROP COLUMN IF EXISTS currency;
+ALTER TABLE ticket_types DROP COLUMN IF EXISTS price;
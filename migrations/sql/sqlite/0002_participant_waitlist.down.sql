@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_participants_event_status;
+
+-- Waitlisted users never had a seat, so they don't survive the rollback
+DELETE FROM participants WHERE status <> 'confirmed';
+
+ALTER TABLE participants DROP COLUMN waitlist_position;
+ALTER TABLE participants DROP COLUMN status;
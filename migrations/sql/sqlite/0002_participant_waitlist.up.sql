@@ -0,0 +1,4 @@
+ALTER TABLE participants ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'confirmed';
+ALTER TABLE participants ADD COLUMN waitlist_position INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_participants_event_status ON participants (event_id, status);
@@ -0,0 +1,3 @@
+-- SQLite has no tsvector; EventRepository.Search matches the name,
+-- location and description columns directly instead
+SELECT 1;
@@ -0,0 +1,8 @@
+-- SQLite can't add a table constraint later, so the check goes on the column
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user'
+	CONSTRAINT check_role CHECK (role IN ('user', 'organizer', 'admin'));
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP;
+
+-- Everyone who already organizes events keeps being able to do so
+UPDATE users SET role = 'organizer'
+WHERE role = 'user' AND id IN (SELECT DISTINCT organizer_id FROM events);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS event_staff (
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (event_id, user_id),
+    CONSTRAINT check_staff_role CHECK (role IN ('co_organizer', 'checkin_staff', 'viewer'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_staff_user ON event_staff (user_id);
@@ -0,0 +1,8 @@
+-- Secret tokens of calendar feed URLs, one per user. Calendar clients can't
+-- send an Authorization header, so the token is part of the URL; only a
+-- SHA-256 hash of it is stored.
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+	user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS check_event_status_update;
+DROP TRIGGER IF EXISTS check_event_status_insert;
+
+UPDATE events SET status = CASE WHEN status = 'published' THEN 'open' ELSE 'closed' END;
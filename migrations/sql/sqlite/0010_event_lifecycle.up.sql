@@ -0,0 +1,21 @@
+-- Events move through draft, published, registration_closed, ongoing,
+-- completed and cancelled instead of the free-form open/closed status
+UPDATE events SET status = 'published' WHERE status = 'open' OR status IS NULL;
+UPDATE events SET status = 'registration_closed'
+WHERE status NOT IN ('draft', 'published', 'registration_closed', 'ongoing', 'completed', 'cancelled');
+
+-- SQLite can't add a check to an existing column without rebuilding the
+-- table, which participants reference, so triggers enforce it instead
+CREATE TRIGGER IF NOT EXISTS check_event_status_insert
+BEFORE INSERT ON events
+WHEN NEW.status IS NULL OR NEW.status NOT IN ('draft', 'published', 'registration_closed', 'ongoing', 'completed', 'cancelled')
+BEGIN
+	SELECT RAISE(ABORT, 'CHECK constraint failed: check_event_status');
+END;
+
+CREATE TRIGGER IF NOT EXISTS check_event_status_update
+BEFORE UPDATE OF status ON events
+WHEN NEW.status IS NULL OR NEW.status NOT IN ('draft', 'published', 'registration_closed', 'ongoing', 'completed', 'cancelled')
+BEGIN
+	SELECT RAISE(ABORT, 'CHECK constraint failed: check_event_status');
+END;
@@ -0,0 +1,3 @@
+ALTER TABLE events DROP COLUMN registration_closes_at;
+ALTER TABLE events DROP COLUMN registration_opens_at;
+ALTER TABLE events DROP COLUMN publish_at;
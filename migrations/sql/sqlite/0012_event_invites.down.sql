@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS event_invites;
+
+ALTER TABLE events DROP COLUMN visibility;
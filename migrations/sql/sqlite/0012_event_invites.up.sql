@@ -0,0 +1,22 @@
+-- public events are listed, unlisted ones are only reachable by their ID and
+-- private ones need an invite
+ALTER TABLE events ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'public'
+	CONSTRAINT check_event_visibility CHECK (visibility IN ('public', 'unlisted', 'private'));
+
+-- An invite is either for one user, for one email address, or a link whose
+-- signed token anyone may use, up to max_uses times
+CREATE TABLE IF NOT EXISTS event_invites (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+	created_by INTEGER NOT NULL REFERENCES users(id),
+	user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	email VARCHAR(255),
+	max_uses INTEGER,
+	uses INTEGER NOT NULL DEFAULT 0,
+	expires_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT check_invite_max_uses CHECK (max_uses IS NULL OR max_uses > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_invites_event ON event_invites (event_id);
+CREATE INDEX IF NOT EXISTS idx_event_invites_user ON event_invites (user_id);
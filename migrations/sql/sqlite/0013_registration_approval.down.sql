@@ -0,0 +1,6 @@
+DELETE FROM participants WHERE status IN ('pending', 'rejected');
+
+ALTER TABLE participants DROP COLUMN review_message;
+ALTER TABLE participants DROP COLUMN reviewed_at;
+
+ALTER TABLE events DROP COLUMN requires_approval;
@@ -0,0 +1,6 @@
+-- Events that require approval take registrations as pending until the
+-- organizer approves or rejects them
+ALTER TABLE events ADD COLUMN requires_approval BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE participants ADD COLUMN reviewed_at TIMESTAMP;
+ALTER TABLE participants ADD COLUMN review_message TEXT;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS orders;
+
+ALTER TABLE participants DROP COLUMN reserved_until;
+ALTER TABLE ticket_types DROP COLUMN currency;
+ALTER TABLE ticket_types DROP COLUMN price;
@@ -0,0 +1,33 @@
+-- Prices of ticket types in the smallest unit of their currency; 0 is free
+ALTER TABLE ticket_types ADD COLUMN price INTEGER NOT NULL DEFAULT 0 CONSTRAINT check_ticket_type_price CHECK (price >= 0);
+ALTER TABLE ticket_types ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Until when a reserved seat waits for the payment of its order
+ALTER TABLE participants ADD COLUMN reserved_until TIMESTAMP;
+
+-- Orders of priced tickets. A pending order holds a reserved seat until it
+-- expires; the webhook of the payment provider confirms or releases it.
+CREATE TABLE IF NOT EXISTS orders (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	ticket_type_id INTEGER REFERENCES ticket_types(id) ON DELETE SET NULL,
+	amount INTEGER NOT NULL,
+	currency VARCHAR(3) NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	provider VARCHAR(50),
+	provider_ref VARCHAR(255),
+	checkout_url TEXT,
+	expires_at TIMESTAMP NOT NULL,
+	paid_at TIMESTAMP,
+	refunded_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT check_order_amount CHECK (amount > 0),
+	CONSTRAINT check_order_status CHECK (status IN ('pending', 'paid', 'failed', 'expired', 'cancelled', 'refunding', 'refunded', 'refund_failed')),
+	CONSTRAINT unique_order_provider_ref UNIQUE (provider, provider_ref)
+);
+
+-- A user has at most one order waiting for payment per event
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_pending ON orders (event_id, user_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders (status, expires_at);
@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP COLUMN discount;
+ALTER TABLE participants DROP COLUMN promo_code_id;
+
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
@@ -0,0 +1,42 @@
+-- Promo codes of an event. A code can take a percentage or a fixed amount off
+-- priced tickets, unlock a hidden ticket type and hold a block of seats back
+-- for its holders, e.g. a partner organisation. Zero limits mean no limit.
+-- A ticket type can't be deleted while codes are tied to it. The rule is NO
+-- ACTION rather than RESTRICT, so it is checked at the end of the statement
+-- and deleting the event can cascade to its ticket types and codes in any order.
+CREATE TABLE IF NOT EXISTS promo_codes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+	code VARCHAR(50) NOT NULL,
+	discount_type VARCHAR(10) NOT NULL DEFAULT '',
+	discount_value INTEGER NOT NULL DEFAULT 0,
+	ticket_type_id INTEGER REFERENCES ticket_types(id) ON DELETE NO ACTION,
+	reserved_seats INTEGER NOT NULL DEFAULT 0,
+	max_uses INTEGER NOT NULL DEFAULT 0,
+	per_user_limit INTEGER NOT NULL DEFAULT 0,
+	expires_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT check_promo_code_discount CHECK (discount_type IN ('', 'percent', 'fixed') AND discount_value >= 0),
+	CONSTRAINT check_promo_code_limits CHECK (reserved_seats >= 0 AND max_uses >= 0 AND per_user_limit >= 0),
+	CONSTRAINT unique_promo_code UNIQUE (event_id, code)
+);
+
+-- Every use of a code. A redemption is released when the registration it
+-- was made for is given up; released ones still count for the per-user limit.
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	promo_code_id INTEGER NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+	event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	discount INTEGER NOT NULL DEFAULT 0,
+	redeemed_at TIMESTAMP NOT NULL,
+	released_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_code ON promo_redemptions (promo_code_id, released_at);
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_user ON promo_redemptions (event_id, user_id);
+
+-- The code a participant registered with, and what it took off their order
+ALTER TABLE participants ADD COLUMN promo_code_id INTEGER REFERENCES promo_codes(id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN discount INTEGER NOT NULL DEFAULT 0;
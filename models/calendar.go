@@ -0,0 +1,37 @@
+package models
+
+import "github.com/event-system/apperrors"
+
+// ساختار پاسخ آدرس اشتراک تقویم کاربر
+// آدرس خودش توکن مخفی رو داره چون برنامه‌های تقویم نمیتونن هدر Authorization بفرستن
+// swagger:model
+type CalendarFeedResponse struct {
+	URL string `json:"url"`
+}
+
+// تنظیمات وارد کردن رویدادها از فایل iCalendar
+type ImportOptions struct {
+	DryRun          bool   // فقط بررسی میکنه و چیزی نمیسازه
+	DefaultCapacity int    // ظرفیت رویدادهایی که X-EVENT-CAPACITY ندارن
+	TimeZone        string // منطقه زمانی زمان‌های بدون TZID و رویدادهای تمام‌روز؛ پیشفرض UTC
+}
+
+// ساختار پاسخ وارد کردن رویدادها
+// swagger:model
+type ImportResponse struct {
+	DryRun bool            `json:"dry_run"`
+	Total  int             `json:"total"`  // تعداد VEVENTهای فایل
+	Valid  int             `json:"valid"`  // تعداد رویدادهایی که ساخته شدن یا ساخته میشن
+	Events []EventResponse `json:"events"` // تو حالت dry_run شناسه ندارن
+	Errors []ImportError   `json:"errors,omitempty"`
+}
+
+// مشکلات یکی از رویدادهای فایل
+// swagger:model
+type ImportError struct {
+	Index   int                    `json:"index"` // جایگاه VEVENT تو فایل، از صفر
+	UID     string                 `json:"uid,omitempty"`
+	Summary string                 `json:"summary,omitempty"`
+	Message string                 `json:"message,omitempty"` // وقتی خود VEVENT قابل خوندن نیست
+	Fields  []apperrors.FieldError `json:"fields,omitempty"`
+}
@@ -13,18 +13,91 @@ type Event struct {
 	Capacity    int       `json:"capacity"`
 	OrganizerID int       `json:"organizer_id"`
 	Status      string    `json:"status"`
+	Visibility  string    `json:"visibility"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// ثبت‌نام‌ها اول در انتظار تأیید میمونن و تا برگزارکننده تأییدشون نکنه جا نمیگیرن
+	RequiresApproval bool `json:"requires_approval"`
+
+	// زمان‌بندی اختیاری: رویداد منتشرشده تا PublishAt توی لیست‌های عمومی نمیاد
+	// و کسی نمی‌تونه توش ثبت‌نام کنه، و ثبت‌نامش از RegistrationOpensAt باز و
+	// تو RegistrationClosesAt بسته میشه. بدون اینها ثبت‌نام تا شروع رویداد بازه
+	PublishAt            *time.Time `json:"publish_at,omitempty"`
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at,omitempty"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at,omitempty"`
+
+	// فقط برای رخدادهای یه مجموعه تکرارشونده پر میشن؛ RecurrenceID زمان شروعیه
+	// که قانون تکرار برای این رخداد ساخته، حتی اگه بعداً خودش جابجا شده باشه
+	SeriesID     *int       `json:"series_id,omitempty"`
+	RecurrenceID *time.Time `json:"recurrence_id,omitempty"`
+}
+
+// وضعیت‌های چرخه عمر یه رویداد. رویداد از پیش‌نویس شروع میشه، منتشر میشه،
+// ثبت‌نامش بسته میشه، برگزار میشه و تموم میشه؛ تا قبل از تموم شدن هم میشه لغوش کرد
+const (
+	EventStatusDraft              = "draft"
+	EventStatusPublished          = "published"
+	EventStatusRegistrationClosed = "registration_closed"
+	EventStatusOngoing            = "ongoing"
+	EventStatusCompleted          = "completed"
+	EventStatusCancelled          = "cancelled"
+)
+
+// همه وضعیت‌ها به ترتیب چرخه عمر
+var EventStatuses = []string{
+	EventStatusDraft,
+	EventStatusPublished,
+	EventStatusRegistrationClosed,
+	EventStatusOngoing,
+	EventStatusCompleted,
+	EventStatusCancelled,
 }
 
+// کی رویداد رو میبینه: عمومی‌ها توی لیست و جستجو میان، بقیه نه. رویداد
+// unlisted با آیدیش برای همه قابل دیدن و ثبت‌نامه، ولی رویداد private رو فقط
+// دعوت‌شده‌ها میبینن و فقط با دعوت‌نامه میشه توش ثبت‌نام کرد
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+)
+
+// بیشترین ظرفیتی که یه رویداد میتونه داشته باشه
+const MaxEventCapacity = 10000
+
 // ساختار درخواست ساخت/آپدیت رویداد
 type EventRequest struct {
-	Name        string    `json:"name" validate:"required"`
+	Name        string    `json:"name" validate:"required,max=100"`
 	Description string    `json:"description"`
-	Location    string    `json:"location"`
-	StartTime   time.Time `json:"start_time" validate:"required"`
+	Location    string    `json:"location" validate:"max=255"`
+	StartTime   time.Time `json:"start_time" validate:"required,future"`
 	EndTime     time.Time `json:"end_time" validate:"required,gtfield=StartTime"`
-	Capacity    int       `json:"capacity" validate:"required,gt=0"`
+	Capacity    int       `json:"capacity" validate:"required,gt=0,capacity"`
+	// پیشفرض public؛ موقع ویرایش اگه خالی باشه همون قبلی می‌مونه
+	Visibility string `json:"visibility,omitempty" validate:"omitempty,oneof=public unlisted private"`
+	// ثبت‌نام‌ها باید اول تأیید بشن
+	RequiresApproval bool `json:"requires_approval,omitempty"`
+
+	// همه اختیاری‌ان و باید قبل از شروع رویداد باشن؛ بستن ثبت‌نام هم بعد از باز شدنش
+	PublishAt            *time.Time `json:"publish_at,omitempty" validate:"omitempty,ltfield=StartTime"`
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at,omitempty" validate:"omitempty,ltfield=StartTime"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at,omitempty" validate:"omitempty,ltefield=StartTime"`
+
+	// رویداد به‌صورت پیش‌نویس ساخته بشه؛ فقط موقع ساخت خونده میشه
+	Draft bool `json:"draft,omitempty"`
+}
+
+// درخواست بردن رویداد به یه وضعیت دیگه
+type EventTransitionRequest struct {
+	Status string `json:"status" validate:"required"`
+	Reason string `json:"reason" validate:"max=500"` // دلیل لغو، که برای شرکت‌کننده‌ها فرستاده میشه
+}
+
+// وضعیت فعلی رویداد و وضعیت‌هایی که الان میشه بهشون رفت
+type EventTransitionsResponse struct {
+	Status      string   `json:"status"`
+	Transitions []string `json:"transitions"`
 }
 
 // ساختار پاسخ رویداد
@@ -38,8 +111,22 @@ type EventResponse struct {
 	Capacity    int       `json:"capacity"`
 	OrganizerID int       `json:"organizer_id"`
 	Status      string    `json:"status"`
+	Visibility  string    `json:"visibility"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	RequiresApproval bool `json:"requires_approval"`
+
+	PublishAt            *time.Time `json:"publish_at,omitempty"`
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at,omitempty"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at,omitempty"`
+	// الان میشه توی رویداد ثبت‌نام کرد یا نه (اگه پر باشه، توی صف انتظار)
+	RegistrationOpen bool `json:"registration_open"`
+	// اگه نمیشه، کد خطاییه که درخواست شرکت برمی‌گردونه، مثلا registration_not_open_yet
+	RegistrationClosedReason string `json:"registration_closed_reason,omitempty"`
+
+	SeriesID     *int       `json:"series_id,omitempty"`
+	RecurrenceID *time.Time `json:"recurrence_id,omitempty"`
 }
 
 // ساختار پاسخ رویداد همراه با شرکت‌کننده‌هاش
@@ -48,9 +135,124 @@ type EventWithParticipantsResponse struct {
 	Participants []UserResponse `json:"participants"`
 }
 
+// وضعیت‌های ممکن یه شرکت‌کننده تو رویداد. ثبت‌نام توی رویدادی که تأیید
+// لازم داره pending میشه و با تأیید confirmed یا waitlisted، یا با رد rejected.
+// جای بلیط پولی تا وقتی پرداخت نشده reserved میمونه و مثل confirmed جا میگیره
+const (
+	ParticipantStatusConfirmed  = "confirmed"
+	ParticipantStatusReserved   = "reserved"
+	ParticipantStatusWaitlisted = "waitlisted"
+	ParticipantStatusPending    = "pending"
+	ParticipantStatusRejected   = "rejected"
+)
+
 type Participant struct {
-	ID       int       `json:"id"`
-	UserID   int       `json:"user_id"`
-	EventID  int       `json:"event_id"`
-	JoinedAt time.Time `json:"joined_at"`
+	ID               int       `json:"id"`
+	UserID           int       `json:"user_id"`
+	EventID          int       `json:"event_id"`
+	Status           string    `json:"status"`
+	WaitlistPosition int       `json:"waitlist_position,omitempty"` // جایگاه تو صف انتظار (از 1 شروع میشه)
+	JoinedAt         time.Time `json:"joined_at"`
+	SeriesID         *int      `json:"series_id,omitempty"` // اگه کاربر کل مجموعه رو ثبت‌نام کرده
+
+	// زمان تأیید یا رد ثبت‌نام و پیغامی که برگزارکننده همراهش فرستاده
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	ReviewMessage string     `json:"review_message,omitempty"`
+
+	CheckedInAt  *time.Time `json:"checked_in_at,omitempty"`  // زمان ورود با بلیط
+	TicketTypeID *int       `json:"ticket_type_id,omitempty"` // نوع بلیطی که باهاش ثبت‌نام کرده
+
+	// برای جای رزروشده، مهلت پرداخت سفارشش
+	ReservedUntil *time.Time `json:"reserved_until,omitempty"`
+
+	PromoCodeID *int `json:"promo_code_id,omitempty"` // کد تخفیفی که باهاش ثبت‌نام کرده
+}
+
+// چیزهایی که سرویس قبل از ثبت‌نام بررسی کرده و ریپازیتوری داخل تراکنش ثبت‌نام لازم داره
+type JoinOptions struct {
+	InviteID     int    // دعوت‌نامه‌ای که امضای توکنش درست بوده؛ صفر یعنی توکنی نیومده
+	TicketTypeID int    // نوع بلیط انتخاب‌شده؛ صفر یعنی رویداد نوع بلیط نداره
+	PromoCode    string // کد تخفیف با حروف بزرگ؛ خالی یعنی کدی نیومده
+}
+
+// اندازه صفحه پیشفرض و حداکثر برای لیست رویدادها
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ترتیب‌های مجاز برای مرتب‌سازی لیست رویدادها
+const (
+	SortStartTimeAsc  = "start_time"
+	SortStartTimeDesc = "-start_time"
+)
+
+// فیلترهای مشترک لیست و جستجوی رویدادها
+type EventFilter struct {
+	From     *time.Time // فقط رویدادهایی که از این زمان به بعد شروع میشن
+	To       *time.Time // فقط رویدادهایی که تا این زمان شروع میشن
+	Location string     // بخشی از محل برگزاری (بدون حساسیت به حروف بزرگ و کوچیک)
+	HasSeats bool       // فقط رویدادهایی که هنوز جای خالی دارن
+}
+
+// فیلترها و صفحه‌بندی لیست رویدادها
+type EventListQuery struct {
+	EventFilter
+	Limit  int    // تعداد رویدادها تو هر صفحه
+	Cursor string // مکان‌نمای صفحه بعد که از پاسخ قبلی اومده
+	Sort   string // SortStartTimeAsc یا SortStartTimeDesc
+}
+
+// یه صفحه از رویدادها
+type EventPage struct {
+	Events     []Event
+	NextCursor string
+	Total      int
+}
+
+// ساختار پاسخ یه صفحه از رویدادها
+// swagger:model
+type EventPageResponse struct {
+	Events     []EventResponse `json:"events"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	Total      int             `json:"total"`
+}
+
+// پارامترهای جستجوی متنی رویدادها
+type EventSearchQuery struct {
+	EventFilter
+	Text   string // عبارت جستجو؛ هر کلمه به‌صورت پیشوندی تطبیق داده میشه
+	Status string // وضعیت رویدادها (پیشفرض published)؛ پیش‌نویس‌ها قابل جستجو نیستن
+	Limit  int
+	Offset int
+}
+
+// بخش‌هایی از رویداد که کلمات جستجو توشون با <mark> مشخص شدن؛ بقیه متن HTML-escape شده
+type EventHighlights struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Location    string `json:"location"`
+}
+
+// یه نتیجه جستجو همراه با امتیاز ارتباطش
+type EventSearchHit struct {
+	Event      Event
+	Rank       float64
+	Highlights EventHighlights
+}
+
+// swagger:model
+type EventSearchResult struct {
+	Event      EventResponse   `json:"event"`
+	Rank       float64         `json:"rank"`
+	Highlights EventHighlights `json:"highlights"`
+}
+
+// ساختار پاسخ جستجوی رویدادها
+// swagger:model
+type EventSearchResponse struct {
+	Results []EventSearchResult `json:"results"`
+	Total   int                 `json:"total"`
+	Limit   int                 `json:"limit"`
+	Offset  int                 `json:"offset"`
 }
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// یه دعوت به رویداد خصوصی. دعوت یا برای یه کاربر یا ایمیل مشخصه، یا یه لینک
+// که هر کسی توکنش رو داشته باشه میتونه باهاش ثبت‌نام کنه
+type EventInvite struct {
+	ID        int
+	EventID   int
+	CreatedBy int
+	UserID    *int   // دعوت یه کاربر مشخص
+	Email     string // دعوت یه ایمیل؛ کاربری که با این ایمیل ثبت‌نام کرده دعوت شده حساب میشه
+	MaxUses   *int   // خالی یعنی بی‌نهایت
+	Uses      int
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+}
+
+// درخواست ساختن لینک دعوت
+type InviteLinkRequest struct {
+	MaxUses   int        `json:"max_uses" validate:"omitempty,gt=0"` // صفر یعنی بی‌نهایت
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty,future"`
+}
+
+// درخواست دعوت کاربرها یا ایمیل‌های مشخص؛ دعوت فقط به درد همون کاربر میخوره، پس سقف استفاده نداره و کسی که رویداد رو ترک کرده میتونه دوباره ثبت‌نام کنه
+type InviteUsersRequest struct {
+	UserIDs   []int      `json:"user_ids" validate:"max=100,dive,gt=0"`
+	Emails    []string   `json:"emails" validate:"max=100,dive,email"`
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty,future"`
+}
+
+// ساختار پاسخ دعوت
+// swagger:model
+type InviteResponse struct {
+	ID        int        `json:"id"`
+	EventID   int        `json:"event_id"`
+	CreatedBy int        `json:"created_by"`
+	UserID    *int       `json:"user_id,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	// توکن امضاشده لینک دعوت؛ فقط برای دعوت‌هایی که کاربر یا ایمیل ندارن
+	Token string `json:"token,omitempty"`
+}
+
+// درخواست شرکت در رویداد؛ بدنه برای رویدادهای غیرخصوصی و بدون نوع بلیط لازم نیست
+type JoinEventRequest struct {
+	InviteToken  string `json:"invite_token"`                             // توکن لینک دعوت رویداد خصوصی
+	TicketTypeID int    `json:"ticket_type_id" validate:"omitempty,gt=0"` // برای رویدادهایی که نوع بلیط دارن لازمه
+	PromoCode    string `json:"promo_code" validate:"omitempty,max=50"`   // کد تخفیف اختیاری
+}
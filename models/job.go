@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// وضعیت یه کار پس‌زمینه زمان‌بندی‌شده
+// swagger:model
+type JobStatus struct {
+	Name     string `json:"name"`
+	Interval string `json:"interval"` // فاصله بین اجراها، مثلا 1m0s
+	Running  bool   `json:"running"`  // همین الان روی این نمونه داره اجرا میشه
+	Runs     int    `json:"runs"`     // تعداد اجراهای این نمونه از وقتی سرور بالا اومده
+	Failures int    `json:"failures"`
+	// دفعاتی که اجرا نشد چون یه نمونه دیگه قفلش رو داشت
+	Skipped int `json:"skipped"`
+
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastDuration   string     `json:"last_duration,omitempty"`
+	LastProcessed  int        `json:"last_processed"` // تعداد چیزهایی که آخرین اجرا عوض کرد
+	LastError      string     `json:"last_error,omitempty"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+}
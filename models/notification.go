@@ -0,0 +1,19 @@
+package models
+
+// نوع‌های پیغام‌هایی که برای کاربرها فرستاده میشن
+const (
+	NotificationEventCancelled = "event_cancelled"
+	NotificationEventInvite    = "event_invite"
+
+	NotificationRegistrationApproved = "registration_approved"
+	NotificationRegistrationRejected = "registration_rejected"
+	NotificationWaitlistPromoted     = "waitlist_promoted"
+)
+
+// یه پیغام برای کاربرها، مثلاً خبر لغو یه رویداد
+type Notification struct {
+	Type    string
+	EventID int
+	Subject string
+	Message string
+}
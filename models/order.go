@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// سفارش یه بلیط پولی. ثبت‌نام با نوع بلیطی که قیمت داره یه جای رزروشده و یه
+// سفارش pending میسازه؛ اگه تا ExpiresAt پرداخت نشه جا آزاد میشه
+type Order struct {
+	ID           int
+	EventID      int
+	UserID       int
+	TicketTypeID *int
+
+	Amount   int // به کوچیک‌ترین واحد پول، مثلاً سنت
+	Discount int // مبلغی که کد تخفیف از قیمت بلیط کم کرده؛ Amount بعد از کم شدنشه
+	Currency string
+	Status   string
+
+	// درگاهی که پرداخت روش ساخته شده و شناسه پرداخت پیش اون درگاه؛ تا
+	// وقتی پرداخت شروع نشده خالیه
+	Provider    string
+	ProviderRef string
+	CheckoutURL string
+
+	ExpiresAt  time.Time
+	PaidAt     *time.Time
+	RefundedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// وضعیت‌های سفارش. refunding یعنی پول گرفته شده ولی جایی بهش نمیرسه (کاربر
+// انصراف داده یا پرداخت بعد از آزاد شدن جا رسیده) و باید برگرده. refund_failed
+// یعنی درگاه اصلاً پرداخت رو نمیشناسه و پول باید دستی برگرده
+const (
+	OrderStatusPending      = "pending"
+	OrderStatusPaid         = "paid"
+	OrderStatusFailed       = "failed"
+	OrderStatusExpired      = "expired"
+	OrderStatusCancelled    = "cancelled"
+	OrderStatusRefunding    = "refunding"
+	OrderStatusRefunded     = "refunded"
+	OrderStatusRefundFailed = "refund_failed"
+)
+
+// واحد پول نوع بلیط وقتی برگزارکننده چیزی نفرستاده
+const DefaultCurrency = "USD"
+
+// جایی که کاربر پرداخت رو انجام میده؛ درگاه موقع ساختن پرداخت برمی‌گردونه
+type PaymentSession struct {
+	ProviderRef string
+	CheckoutURL string
+}
+
+// نتیجه‌های پرداخت که درگاه با وب‌هوک خبر میده
+const (
+	PaymentSucceeded = "succeeded"
+	PaymentDeclined  = "declined"
+)
+
+// یه وب‌هوک درگاه بعد از بررسی امضاش
+type PaymentEvent struct {
+	ProviderRef string
+	Outcome     string // PaymentSucceeded یا PaymentDeclined
+}
+
+// ساختار پاسخ سفارش؛ لینک پرداخت فقط تا وقتی سفارش pending هست میاد
+// swagger:model
+type OrderResponse struct {
+	ID           int        `json:"id"`
+	EventID      int        `json:"event_id"`
+	TicketTypeID *int       `json:"ticket_type_id,omitempty"`
+	Amount       int        `json:"amount"`
+	Discount     int        `json:"discount,omitempty"`
+	Currency     string     `json:"currency"`
+	Status       string     `json:"status"`
+	CheckoutURL  string     `json:"checkout_url,omitempty"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	PaidAt       *time.Time `json:"paid_at,omitempty"`
+	RefundedAt   *time.Time `json:"refunded_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// درخواست نتیجه پرداخت به درگاه آزمایشی؛ با تأخیر، وب‌هوک دیرتر میرسه
+type FakePaymentRequest struct {
+	Outcome      string `json:"outcome" validate:"required,oneof=succeeded declined"`
+	DelaySeconds int    `json:"delay_seconds" validate:"min=0,max=3600"`
+}
@@ -2,19 +2,81 @@ package models
 
 import "time"
 
+// وضعیت ثبت‌نام کاربر توی رویداد. is_participant فقط برای ثبت‌نام تأییدشده
+// true هست؛ status برای هر ثبت‌نامی پر میشه، از جمله در انتظار تأیید و ردشده
 // swagger:model
 type ParticipantStatusResponse struct {
-	IsParticipant bool `json:"is_participant"`
+	IsParticipant    bool       `json:"is_participant"`
+	Status           string     `json:"status,omitempty"`
+	WaitlistPosition int        `json:"waitlist_position,omitempty"`
+	ReviewedAt       *time.Time `json:"reviewed_at,omitempty"`
+	ReviewMessage    string     `json:"review_message,omitempty"`
+	CheckedInAt      *time.Time `json:"checked_in_at,omitempty"`
+	TicketTypeID     *int       `json:"ticket_type_id,omitempty"`
+	ReservedUntil    *time.Time `json:"reserved_until,omitempty"`
 }
 
+// تعداد شرکت‌کننده‌های قطعی؛ برای رویدادهایی که نوع بلیط دارن به تفکیک هر نوع
 // swagger:model
 type ParticipantCountResponse struct {
-	Count int `json:"count"`
+	Count       int               `json:"count"`
+	TicketTypes []TicketTypeCount `json:"ticket_types,omitempty"`
 }
 
 // swagger:model
 type ParticipantResponse struct {
-	UserID   int       `json:"user_id"`
-	EventID  int       `json:"event_id"`
-	JoinedAt time.Time `json:"joined_at"`
+	UserID           int       `json:"user_id"`
+	EventID          int       `json:"event_id"`
+	Status           string    `json:"status"`
+	WaitlistPosition int       `json:"waitlist_position,omitempty"`
+	JoinedAt         time.Time `json:"joined_at"`
+	SeriesID         *int      `json:"series_id,omitempty"`
+	TicketTypeID     *int      `json:"ticket_type_id,omitempty"`
+
+	ReservedUntil *time.Time     `json:"reserved_until,omitempty"`
+	Order         *OrderResponse `json:"order,omitempty"` // سفارش جای رزروشده با لینک پرداختش
+}
+
+// ساختار پاسخ شرکت در رویداد
+// swagger:model
+type JoinEventResponse struct {
+	Message          string `json:"message"`
+	Status           string `json:"status"`
+	WaitlistPosition int    `json:"waitlist_position,omitempty"`
+
+	// برای بلیط پولی: تا این زمان باید از لینک سفارش پرداخت بشه
+	ReservedUntil *time.Time     `json:"reserved_until,omitempty"`
+	Order         *OrderResponse `json:"order,omitempty"`
+}
+
+// swagger:model
+type WaitlistPositionResponse struct {
+	EventID  int `json:"event_id"`
+	Position int `json:"position"`
+}
+
+// یه ردیف از صف انتظار رویداد
+// swagger:model
+type WaitlistEntryResponse struct {
+	Position int          `json:"position"`
+	User     UserResponse `json:"user"`
+	JoinedAt time.Time    `json:"joined_at"`
+}
+
+// ساختار درخواست مرتب‌سازی دوباره صف انتظار
+type ReorderWaitlistRequest struct {
+	UserIDs []int `json:"user_ids" validate:"required"`
+}
+
+// یه ثبت‌نام در انتظار تأیید
+// swagger:model
+type PendingRegistrationResponse struct {
+	User      UserResponse `json:"user"`
+	AppliedAt time.Time    `json:"applied_at"`
+}
+
+// درخواست تأیید یا رد گروهی ثبت‌نام‌ها؛ پیغام برای همه کاربرها فرستاده میشه
+type ReviewRegistrationsRequest struct {
+	UserIDs []int  `json:"user_ids" validate:"required,min=1,max=100,dive,gt=0"`
+	Message string `json:"message" validate:"max=1000"`
 }
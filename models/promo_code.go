@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// کد تخفیف یه رویداد. کد میتونه درصد یا مبلغ ثابتی از قیمت بلیط کم کنه، یه
+// نوع بلیط مخفی رو باز کنه یا چندتا جا رو برای دارنده‌هاش (مثلاً یه سازمان
+// همکار) نگه داره. محدودیت‌های صفر یعنی بدون محدودیت
+type PromoCode struct {
+	ID      int
+	EventID int
+	Code    string // همیشه با حروف بزرگ ذخیره میشه
+
+	DiscountType  string // PromoDiscountPercent، PromoDiscountFixed یا خالی
+	DiscountValue int    // درصد، یا مبلغ به کوچیک‌ترین واحد پول
+
+	// اگه پر باشه کد فقط با این نوع بلیط کار میکنه، حتی اگه مخفی باشه
+	TicketTypeID *int
+
+	ReservedSeats int // جاهایی که فقط دارنده‌های کد میتونن بگیرن
+	MaxUses       int
+	PerUserLimit  int
+	ExpiresAt     *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// فقط موقع خوندن پر میشن: استفاده‌هایی که هنوز آزاد نشدن و جاهایی که
+	// ثبت‌نام‌های این کد گرفتن
+	Uses       int
+	SeatsTaken int
+}
+
+// نوع‌های تخفیف کد
+const (
+	PromoDiscountPercent = "percent"
+	PromoDiscountFixed   = "fixed"
+)
+
+// ساختار درخواست ساخت/آپدیت کد تخفیف
+type PromoCodeRequest struct {
+	Code          string     `json:"code" validate:"required,min=3,max=50"`
+	DiscountType  string     `json:"discount_type,omitempty" validate:"omitempty,oneof=percent fixed"`
+	DiscountValue int        `json:"discount_value" validate:"min=0"`
+	TicketTypeID  *int       `json:"ticket_type_id,omitempty" validate:"omitempty,gt=0"`
+	ReservedSeats int        `json:"reserved_seats" validate:"min=0,capacity"`
+	MaxUses       int        `json:"max_uses" validate:"min=0"`
+	PerUserLimit  int        `json:"per_user_limit" validate:"min=0"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+}
+
+// ساختار پاسخ کد تخفیف
+// swagger:model
+type PromoCodeResponse struct {
+	ID            int        `json:"id"`
+	EventID       int        `json:"event_id"`
+	Code          string     `json:"code"`
+	DiscountType  string     `json:"discount_type,omitempty"`
+	DiscountValue int        `json:"discount_value,omitempty"`
+	TicketTypeID  *int       `json:"ticket_type_id,omitempty"`
+	ReservedSeats int        `json:"reserved_seats"`
+	SeatsTaken    int        `json:"seats_taken"`
+	MaxUses       int        `json:"max_uses"`
+	PerUserLimit  int        `json:"per_user_limit"`
+	Uses          int        `json:"uses"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Expired       bool       `json:"expired"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// یه بار استفاده از کد. اگه کاربر انصراف بده یا جاش آزاد بشه ReleasedAt پر
+// میشه و وضعیت ثبت‌نامش خالی میمونه
+// swagger:model
+type PromoRedemptionResponse struct {
+	User       UserResponse `json:"user"`
+	Status     string       `json:"status,omitempty"` // وضعیت ثبت‌نامی که با کد انجام شده
+	Discount   int          `json:"discount"`         // مبلغی که از سفارش کم شده
+	RedeemedAt time.Time    `json:"redeemed_at"`
+	ReleasedAt *time.Time   `json:"released_at,omitempty"`
+}
+
+// گزارش استفاده‌های یه کد
+// swagger:model
+type PromoCodeReportResponse struct {
+	PromoCode     PromoCodeResponse         `json:"promo_code"`
+	Redemptions   []PromoRedemptionResponse `json:"redemptions"`
+	TotalDiscount int                       `json:"total_discount"`
+}
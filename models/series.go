@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// مجموعه رویدادهای تکرارشونده که با یه RRULE (طبق RFC 5545) تعریف میشه.
+// رخدادهاش رویدادهای معمولی‌ان که SeriesID شون به این مجموعه اشاره میکنه.
+type EventSeries struct {
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Location    string      `json:"location"`
+	StartTime   time.Time   `json:"start_time"` // شروع اولین رخداد (DTSTART)
+	EndTime     time.Time   `json:"end_time"`   // پایان اولین رخداد؛ طول همه رخدادها از این درمیاد
+	Capacity    int         `json:"capacity"`
+	OrganizerID int         `json:"organizer_id"`
+	TimeZone    string      `json:"timezone"` // منطقه زمانی IANA که تکرارها توش حساب میشن
+	RRule       string      `json:"rrule"`
+	ExDates     []time.Time `json:"exdates"` // رخدادهایی که از مجموعه حذف شدن (EXDATE)
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// محدوده‌های ویرایش یه رخداد از مجموعه
+const (
+	SeriesScopeThis      = "this"      // فقط همین رخداد
+	SeriesScopeFollowing = "following" // این رخداد و همه بعدی‌ها
+	SeriesScopeAll       = "all"       // همه رخدادهای مجموعه
+)
+
+// ساختار درخواست ساخت مجموعه تکرارشونده؛ زمان‌ها مال اولین رخداده
+type SeriesRequest struct {
+	Name        string      `json:"name" validate:"required,max=100"`
+	Description string      `json:"description"`
+	Location    string      `json:"location" validate:"max=255"`
+	StartTime   time.Time   `json:"start_time" validate:"required,future"`
+	EndTime     time.Time   `json:"end_time" validate:"required,gtfield=StartTime"`
+	Capacity    int         `json:"capacity" validate:"required,gt=0,capacity"`
+	TimeZone    string      `json:"timezone" validate:"max=64"`
+	RRule       string      `json:"rrule" validate:"required,max=255"`
+	ExDates     []time.Time `json:"exdates"`
+}
+
+// ساختار درخواست ویرایش یه رخداد از مجموعه. زمان‌ها زمان جدید همین رخدادن؛
+// برای following و all بقیه رخدادها هم به همون اندازه جابجا میشن.
+// اگه RRule یا ExDates خالی باشن همون قبلی‌ها میمونن.
+type SeriesUpdateRequest struct {
+	Scope       string      `json:"scope" validate:"required,oneof=this following all"`
+	Name        string      `json:"name" validate:"required,max=100"`
+	Description string      `json:"description"`
+	Location    string      `json:"location" validate:"max=255"`
+	StartTime   time.Time   `json:"start_time" validate:"required,future"`
+	EndTime     time.Time   `json:"end_time" validate:"required,gtfield=StartTime"`
+	Capacity    int         `json:"capacity" validate:"required,gt=0,capacity"`
+	RRule       string      `json:"rrule" validate:"max=255"`
+	ExDates     []time.Time `json:"exdates"`
+}
+
+// تغییراتی که یه ویرایش مجموعه لازم داره و باید همه با هم تو یه تراکنش اعمال بشن
+type SeriesChange struct {
+	Series  *EventSeries // مجموعه‌ای که ذخیره میشه؛ اگه ID نداشته باشه ساخته میشه
+	Split   *EventSeries // مجموعه قبلی وقتی «این و بعدی‌ها» ویرایش میشه
+	SplitAt time.Time    // رخدادهای Split از این RecurrenceID به بعد مال Series میشن
+	Update  []Event      // رخدادهای موجودی که عوض میشن
+	Create  []Event      // رخدادهای جدید
+	Remove  []int        // رخدادهایی که دیگه تو قانون تکرار نیستن
+}
+
+// ساختار پاسخ مجموعه همراه با رخدادهاش
+// swagger:model
+type SeriesResponse struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Location    string          `json:"location"`
+	StartTime   time.Time       `json:"start_time"`
+	EndTime     time.Time       `json:"end_time"`
+	Capacity    int             `json:"capacity"`
+	OrganizerID int             `json:"organizer_id"`
+	TimeZone    string          `json:"timezone"`
+	RRule       string          `json:"rrule"`
+	ExDates     []time.Time     `json:"exdates"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Occurrences []EventResponse `json:"occurrences"`
+}
+
+// ساختار پاسخ ثبت‌نام تو کل مجموعه؛ برای هر رخداد آینده یه ردیف
+// swagger:model
+type SeriesJoinResponse struct {
+	SeriesID    int                   `json:"series_id"`
+	Occurrences []ParticipantResponse `json:"occurrences"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// نقش‌هایی که صاحب رویداد میتونه به بقیه کاربرها بده
+const (
+	StaffRoleCoOrganizer = "co_organizer"  // مثل صاحب رویداد مدیریتش میکنه ولی نمیتونه حذفش کنه یا عضو اضافه کنه
+	StaffRoleCheckIn     = "checkin_staff" // فقط شرکت‌کننده‌ها رو میبینه و ورودشون رو ثبت میکنه
+	StaffRoleViewer      = "viewer"        // فقط شرکت‌کننده‌ها رو میبینه
+)
+
+// یه عضو کادر اجرایی رویداد رو نشون میده
+type EventStaff struct {
+	EventID   int       `json:"event_id"`
+	UserID    int       `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ساختار پاسخ عضو کادر اجرایی رویداد
+// swagger:model
+type StaffResponse struct {
+	User      UserResponse `json:"user"`
+	Role      string       `json:"role"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// ساختار درخواست اضافه کردن یا تغییر نقش عضو کادر اجرایی
+type AddStaffRequest struct {
+	UserID int    `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"required,oneof=co_organizer checkin_staff viewer"`
+}
+
+// ساختار درخواست انتقال مالکیت رویداد
+type TransferOwnershipRequest struct {
+	UserID int `json:"user_id" validate:"required"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// درخواست ورود یه شرکت‌کننده با کدی که از QR بلیطش اسکن شده
+type CheckInRequest struct {
+	TicketCode string `json:"ticket_code" validate:"required,max=200"`
+}
+
+// ساختار پاسخ ورود؛ کادر اجرایی دم در میبینه بلیط مال کیه
+// swagger:model
+type CheckInResponse struct {
+	EventID     int          `json:"event_id"`
+	User        UserResponse `json:"user"`
+	CheckedInAt time.Time    `json:"checked_in_at"`
+}
+
+// خلاصه حضور توی رویداد: چند نفر از شرکت‌کننده‌های قطعی وارد شدن
+// swagger:model
+type AttendanceResponse struct {
+	EventID      int `json:"event_id"`
+	Registered   int `json:"registered"`
+	CheckedIn    int `json:"checked_in"`
+	NotCheckedIn int `json:"not_checked_in"`
+}
+
+// درخواست همگام‌سازی ورودهایی که کادر اجرایی بدون اینترنت ثبت کرده
+type CheckInSyncRequest struct {
+	CheckIns []OfflineCheckInRequest `json:"check_ins" validate:"required,min=1,max=1000,dive"`
+}
+
+// یه ورود آفلاین با زمانی که دستگاه دم در ثبت کرده
+type OfflineCheckInRequest struct {
+	TicketCode string    `json:"ticket_code" validate:"required,max=200"`
+	ScannedAt  time.Time `json:"scanned_at" validate:"required"`
+}
+
+// یه ورود آفلاین که امضای بلیطش چک شده و آماده ثبت توی دیتابیسه
+type OfflineCheckIn struct {
+	ParticipantID int
+	ScannedAt     time.Time
+}
+
+// نتیجه هر ورود آفلاین بعد از همگام‌سازی
+const (
+	CheckInSynced    = "checked_in" // ورود با زمان همین اسکن ثبت شد
+	CheckInDuplicate = "duplicate"  // بلیط قبلاً زودتر (یا همزمان) اسکن شده بود
+	CheckInInvalid   = "invalid"    // بلیط معتبر نیست؛ کد خطا توی error هست
+)
+
+// نتیجه یه ورود آفلاین، به همون ترتیبی که توی درخواست اومده
+// swagger:model
+type CheckInSyncResult struct {
+	TicketCode string `json:"ticket_code"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	UserID     int    `json:"user_id,omitempty"`
+	// زمان ورودی که بعد از همگام‌سازی ثبت مونده
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty"`
+	// زمان ورود قبلی که این اسکن زودتر جاش رو گرفت
+	SupersededAt *time.Time `json:"superseded_at,omitempty"`
+}
+
+// ساختار پاسخ همگام‌سازی ورودها
+// swagger:model
+type CheckInSyncResponse struct {
+	EventID    int                 `json:"event_id"`
+	CheckedIn  int                 `json:"checked_in"`
+	Duplicates int                 `json:"duplicates"`
+	Invalid    int                 `json:"invalid"`
+	Results    []CheckInSyncResult `json:"results"`
+}
+
+// یه بلیط توی فهرست آفلاین؛ به جای کد بلیط فقط هش SHA-256 اون میاد
+type ManifestTicket struct {
+	Hash        string     `json:"hash"`
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty"`
+}
+
+// فهرست امضاشده بلیط‌های یه رویداد برای ورود آفلاین. manifest یه JWT با
+// امضای EdDSA هست و بلیط‌ها توی ادعای tickets اون هستن؛ با public_key بدون
+// اینترنت هم میشه امضاش رو چک کرد
+// swagger:model
+type CheckInManifestResponse struct {
+	EventID     int       `json:"event_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	TicketCount int       `json:"ticket_count"`
+	Manifest    string    `json:"manifest"`
+	PublicKey   string    `json:"public_key"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// یه نوع بلیط (مثلاً زودهنگام، عادی، دانشجویی یا VIP) با سهمیه و بازه فروش
+// خودش. وقتی رویداد نوع بلیط داره هر ثبت‌نام باید یکیش رو انتخاب کنه و ظرفیت
+// رویداد سقف کلی همه نوع‌ها میمونه
+type TicketType struct {
+	ID      int
+	EventID int
+	Name    string
+	Quota   int
+
+	// بازه فروش اختیاریه؛ بدونش تا وقتی ثبت‌نام رویداد بازه میشه خرید
+	SalesStartsAt *time.Time
+	SalesEndsAt   *time.Time
+
+	Visibility string
+
+	// قیمت به کوچیک‌ترین واحد پول؛ صفر یعنی رایگان
+	Price    int
+	Currency string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Sold int // تعداد جاهای گرفته‌شده این نوع، با رزروهای منتظر پرداخت؛ فقط موقع خوندن پر میشه
+}
+
+// نوع بلیط hidden توی لیست عمومی نمیاد و کاربرها نمی‌تونن خودشون انتخابش کنن
+const (
+	TicketTypePublic = "public"
+	TicketTypeHidden = "hidden"
+)
+
+// ساختار درخواست ساخت/آپدیت نوع بلیط
+type TicketTypeRequest struct {
+	Name          string     `json:"name" validate:"required,max=100"`
+	Quota         int        `json:"quota" validate:"required,gt=0,capacity"`
+	SalesStartsAt *time.Time `json:"sales_starts_at,omitempty"`
+	SalesEndsAt   *time.Time `json:"sales_ends_at,omitempty"`
+	// پیشفرض public
+	Visibility string `json:"visibility,omitempty" validate:"omitempty,oneof=public hidden"`
+	// به کوچیک‌ترین واحد پول؛ صفر یعنی رایگان. واحد پول پیشفرض USD
+	Price    int    `json:"price" validate:"min=0"`
+	Currency string `json:"currency,omitempty" validate:"omitempty,len=3,uppercase"`
+}
+
+// ساختار پاسخ نوع بلیط
+// swagger:model
+type TicketTypeResponse struct {
+	ID            int        `json:"id"`
+	EventID       int        `json:"event_id"`
+	Name          string     `json:"name"`
+	Quota         int        `json:"quota"`
+	Sold          int        `json:"sold"`
+	Available     int        `json:"available"`
+	SalesStartsAt *time.Time `json:"sales_starts_at,omitempty"`
+	SalesEndsAt   *time.Time `json:"sales_ends_at,omitempty"`
+	Visibility    string     `json:"visibility"`
+	Price         int        `json:"price"`
+	Currency      string     `json:"currency"`
+	OnSale        bool       `json:"on_sale"` // الان توی بازه فروشه
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// تعداد شرکت‌کننده‌های قطعی یه نوع بلیط
+// swagger:model
+type TicketTypeCount struct {
+	TicketTypeID int    `json:"ticket_type_id"`
+	Name         string `json:"name"`
+	Count        int    `json:"count"`
+	Quota        int    `json:"quota"`
+	Visibility   string `json:"visibility"`
+}
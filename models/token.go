@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// توکن رفرش که فقط هش‌ش تو دیتابیس ذخیره میشه
+type RefreshToken struct {
+	ID            int
+	UserID        int
+	TokenHash     string
+	FamilyID      string // همه توکن‌هایی که از یه ورود ساخته شدن یه خانواده دارن
+	AccessTokenID string // jti آخرین توکن دسترسی که همراه این توکن صادر شده
+	ExpiresAt     time.Time
+	RevokedAt     *time.Time
+	CreatedAt     time.Time
+}
+
+// ساختار درخواست گرفتن توکن جدید با توکن رفرش
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// ساختار درخواست خروج از سیستم
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -1,32 +1,73 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/event-system/apperrors"
+)
+
+// نقش‌های کاربر؛ هر نقش همه دسترسی‌های نقش‌های قبلی رو هم داره
+const (
+	RoleUser      = "user"
+	RoleOrganizer = "organizer"
+	RoleAdmin     = "admin"
+)
 
 // کاربر رو تو سیستم نشون میده
 type User struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Password  string    `json:"-"` // رمز عبور تو پاسخ‌های JSON نشون داده نمیشه
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          int        `json:"id"`
+	Username    string     `json:"username"`
+	Email       string     `json:"email"`
+	Password    string     `json:"-"` // رمز عبور تو پاسخ‌های JSON نشون داده نمیشه
+	Role        string     `json:"role"`
+	SuspendedAt *time.Time `json:"suspended_at,omitempty"` // اگه مقدار داشته باشه کاربر تعلیق شده
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 // ساختار پاسخ برای اطلاعات کاربر (بدون اطلاعات حساس)
 // ساختار پاسخ خطای API
 // swagger:model
 type ErrorResponse struct {
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+	Error   bool                   `json:"error"`
+	Code    string                 `json:"code"` // کد ثابت و قابل پردازش خطا، مثلا event_not_found
+	Message string                 `json:"message"`
+	Fields  []apperrors.FieldError `json:"fields,omitempty"` // فقط برای خطاهای اعتبارسنجی
 }
 
 type UserResponse struct {
 	ID        int       `json:"id"`
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
+	Role      string    `json:"role,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// ساختار پاسخ اطلاعات کاربر برای مدیر سیستم
+// swagger:model
+type AdminUserResponse struct {
+	ID          int        `json:"id"`
+	Username    string     `json:"username"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	SuspendedAt *time.Time `json:"suspended_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ساختار پاسخ لیست کاربران برای مدیر سیستم
+// swagger:model
+type AdminUserListResponse struct {
+	Users  []AdminUserResponse `json:"users"`
+	Total  int                 `json:"total"`
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
+}
+
+// ساختار درخواست تغییر نقش کاربر
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user organizer admin"`
+}
+
 // ساختار درخواست ورود به سیستم
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
@@ -42,7 +83,9 @@ type RegisterRequest struct {
 
 // ساختار پاسخ توکن JWT
 type TokenResponse struct {
-	Token     string       `json:"token"`
-	ExpiresAt time.Time    `json:"expires_at"`
-	User      UserResponse `json:"user"`
+	Token                 string       `json:"token"`
+	ExpiresAt             time.Time    `json:"expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  UserResponse `json:"user"`
 }
@@ -0,0 +1,408 @@
+// Package recurrence parses RFC 5545 recurrence rules and expands them into
+// occurrence start times. It covers the part of the RFC event series need:
+// DAILY, WEEKLY and MONTHLY rules with INTERVAL, COUNT, UNTIL, BYDAY and
+// BYMONTHDAY.
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the FREQ part of a rule
+type Frequency string
+
+// Supported frequencies
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// WeekdayNum is one BYDAY entry. N picks the nth such weekday of the month,
+// counting from the end when negative; 0 means every such weekday.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule is a parsed RRULE
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int       // 0 when the rule has no COUNT
+	Until      time.Time // zero when the rule has no UNTIL
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+}
+
+// maxPeriods bounds how many days, weeks or months expansion walks through,
+// so rules that hardly ever match still finish
+const maxPeriods = 10000
+
+var weekdayNames = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10". An "RRULE:"
+// prefix is allowed. UNTIL values without a trailing Z are read in loc, and a
+// date-only UNTIL includes the whole day.
+func Parse(text string, loc *time.Location) (*Rule, error) {
+	text = strings.TrimSpace(text)
+	if len(text) >= 6 && strings.EqualFold(text[:6], "RRULE:") {
+		text = text[6:]
+	}
+	if text == "" {
+		return nil, fmt.Errorf("rule is empty")
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(text, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, fmt.Errorf("malformed part %q", part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%s is given more than once", key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			rule.Freq = Frequency(value)
+			if rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly {
+				err = fmt.Errorf("unsupported FREQ %s, use DAILY, WEEKLY or MONTHLY", value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = positive(key, value)
+		case "COUNT":
+			rule.Count, err = positive(key, value)
+		case "UNTIL":
+			rule.Until, err = parseUntil(value, loc)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseByMonthDay(value)
+		case "WKST":
+			// Weeks always start on Monday, the RFC default
+			if value != "MO" {
+				err = fmt.Errorf("only WKST=MO is supported")
+			}
+		default:
+			err = fmt.Errorf("unsupported part %s", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("COUNT and UNTIL can't be used together")
+	}
+	if rule.Freq != Monthly {
+		for _, day := range rule.ByDay {
+			if day.N != 0 {
+				return nil, fmt.Errorf("numbered BYDAY values are only allowed with FREQ=MONTHLY")
+			}
+		}
+	}
+	if rule.Freq == Weekly && len(rule.ByMonthDay) > 0 {
+		return nil, fmt.Errorf("BYMONTHDAY is not allowed with FREQ=WEEKLY")
+	}
+
+	return rule, nil
+}
+
+func positive(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive number", key)
+	}
+	return n, nil
+}
+
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("UNTIL must look like 20261231T235959Z or 20261231")
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	days := []WeekdayNum{}
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY value %q", item)
+		}
+		day, ok := weekdayNames[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY value %q", item)
+		}
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("invalid BYDAY value %q", item)
+			}
+		}
+		days = append(days, WeekdayNum{N: n, Day: day})
+	}
+	return days, nil
+}
+
+func parseByMonthDay(value string) ([]int, error) {
+	days := []int{}
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n == 0 || n < -31 || n > 31 {
+			return nil, fmt.Errorf("invalid BYMONTHDAY value %q", item)
+		}
+		days = append(days, n)
+	}
+	return days, nil
+}
+
+// String formats the rule back into RRULE syntax, without the "RRULE:" prefix
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = strings.ToUpper(day.Day.String()[:2])
+			if day.N != 0 {
+				days[i] = strconv.Itoa(day.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Between returns the occurrences of the rule that start in [from, to), at
+// most limit of them when limit is positive. dtstart is always the first
+// occurrence and COUNT counts from there. Occurrences keep the wall clock
+// time of dtstart in its location, so a weekly 18:00 meeting stays at 18:00
+// when daylight saving time begins or ends.
+func (r *Rule) Between(dtstart, from, to time.Time, limit int) []time.Time {
+	occurrences := []time.Time{}
+	r.iterate(dtstart, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if !t.Before(from) {
+			occurrences = append(occurrences, t)
+		}
+		return limit <= 0 || len(occurrences) < limit
+	})
+	return occurrences
+}
+
+// iterate calls yield with every occurrence in order until yield returns
+// false or the rule ends
+func (r *Rule) iterate(dtstart time.Time, yield func(t time.Time) bool) {
+	count := 0
+	emit := func(t time.Time) bool {
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		count++
+		if !yield(t) {
+			return false
+		}
+		return r.Count == 0 || count < r.Count
+	}
+
+	if !emit(dtstart) {
+		return
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	for period := 0; period < maxPeriods; period++ {
+		for _, t := range r.period(dtstart, period*interval) {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// period returns the candidates of the nth day, week or month after dtstart in order
+func (r *Rule) period(dtstart time.Time, n int) []time.Time {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+	}
+
+	switch r.Freq {
+	case Daily:
+		day := dtstart.AddDate(0, 0, n)
+		if !r.matchesWeekday(day.Weekday()) || !r.matchesMonthDay(day) {
+			return nil
+		}
+		return []time.Time{at(day.Year(), day.Month(), day.Day())}
+
+	case Weekly:
+		// Monday of the week n weeks after the one dtstart falls in
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		monday := at(dtstart.Year(), dtstart.Month(), dtstart.Day()-offset+7*n)
+
+		weekdays := []time.Weekday{dtstart.Weekday()}
+		if len(r.ByDay) > 0 {
+			weekdays = weekdays[:0]
+			for _, day := range r.ByDay {
+				weekdays = append(weekdays, day.Day)
+			}
+		}
+
+		days := []int{}
+		for _, weekday := range weekdays {
+			days = append(days, (int(weekday)+6)%7)
+		}
+		occurrences := []time.Time{}
+		for _, day := range sortedUnique(days) {
+			occurrences = append(occurrences, at(monday.Year(), monday.Month(), monday.Day()+day))
+		}
+		return occurrences
+
+	case Monthly:
+		first := time.Date(dtstart.Year(), dtstart.Month()+time.Month(n), 1, 0, 0, 0, 0, dtstart.Location())
+		year, month := first.Year(), first.Month()
+		daysInMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+		days := []int{}
+		switch {
+		case len(r.ByMonthDay) > 0:
+			for _, day := range r.ByMonthDay {
+				if day < 0 {
+					day = daysInMonth + day + 1
+				}
+				if day >= 1 && day <= daysInMonth && r.matchesMonthlyByDay(year, month, day, daysInMonth) {
+					days = append(days, day)
+				}
+			}
+		case len(r.ByDay) > 0:
+			for day := 1; day <= daysInMonth; day++ {
+				if r.matchesMonthlyByDay(year, month, day, daysInMonth) {
+					days = append(days, day)
+				}
+			}
+		default:
+			if dtstart.Day() <= daysInMonth {
+				days = append(days, dtstart.Day())
+			}
+		}
+
+		occurrences := []time.Time{}
+		for _, day := range sortedUnique(days) {
+			occurrences = append(occurrences, at(year, month, day))
+		}
+		return occurrences
+	}
+
+	return nil
+}
+
+// matchesWeekday checks a plain BYDAY filter; no BYDAY matches every day
+func (r *Rule) matchesWeekday(weekday time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMonthDay checks a BYMONTHDAY filter; no BYMONTHDAY matches every day
+func (r *Rule) matchesMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, day := range r.ByMonthDay {
+		if day == t.Day() || daysInMonth+day+1 == t.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMonthlyByDay checks a day of a month against BYDAY entries such as
+// 2TU (second Tuesday) or -1FR (last Friday); no BYDAY matches every day
+func (r *Rule) matchesMonthlyByDay(year int, month time.Month, day, daysInMonth int) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	weekday := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()
+	for _, byDay := range r.ByDay {
+		if byDay.Day != weekday {
+			continue
+		}
+		switch {
+		case byDay.N == 0:
+			return true
+		case byDay.N > 0 && (day-1)/7+1 == byDay.N:
+			return true
+		case byDay.N < 0 && (daysInMonth-day)/7+1 == -byDay.N:
+			return true
+		}
+	}
+	return false
+}
+
+func sortedUnique(values []int) []int {
+	sort.Ints(values)
+	unique := values[:0]
+	for i, value := range values {
+		if i == 0 || value != values[i-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,177 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+// expand parses rule and returns its occurrences from dtstart as formatted local times
+func expand(t *testing.T, rule string, dtstart time.Time, limit int) []string {
+	t.Helper()
+
+	r, err := Parse(rule, dtstart.Location())
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", rule, err)
+	}
+
+	occurrences := []string{}
+	for _, o := range r.Between(dtstart, dtstart, dtstart.AddDate(5, 0, 0), limit) {
+		occurrences = append(occurrences, o.Format("2006-01-02 Mon 15:04"))
+	}
+	return occurrences
+}
+
+func assertOccurrences(t *testing.T, got []string, want ...string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d occurrences %v, got %d %v", len(want), want, len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("occurrence %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestDaily(t *testing.T) {
+	start := time.Date(2026, 3, 30, 9, 0, 0, 0, time.UTC)
+
+	assertOccurrences(t, expand(t, "FREQ=DAILY;INTERVAL=2;COUNT=3", start, 0),
+		"2026-03-30 Mon 09:00",
+		"2026-04-01 Wed 09:00",
+		"2026-04-03 Fri 09:00",
+	)
+
+	assertOccurrences(t, expand(t, "RRULE:FREQ=DAILY;UNTIL=20260401", start, 0),
+		"2026-03-30 Mon 09:00",
+		"2026-03-31 Tue 09:00",
+		"2026-04-01 Wed 09:00",
+	)
+}
+
+func TestWeekly(t *testing.T) {
+	// A Wednesday; Mondays and Fridays around it follow in weekday order
+	start := time.Date(2026, 4, 1, 18, 30, 0, 0, time.UTC)
+
+	assertOccurrences(t, expand(t, "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=5", start, 0),
+		"2026-04-01 Wed 18:30",
+		"2026-04-03 Fri 18:30",
+		"2026-04-06 Mon 18:30",
+		"2026-04-08 Wed 18:30",
+		"2026-04-10 Fri 18:30",
+	)
+
+	assertOccurrences(t, expand(t, "FREQ=WEEKLY;INTERVAL=2", start, 3),
+		"2026-04-01 Wed 18:30",
+		"2026-04-15 Wed 18:30",
+		"2026-04-29 Wed 18:30",
+	)
+}
+
+func TestWeeklyKeepsLocalTimeAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	// Daylight saving time starts on 29 March 2026 in Berlin
+	start := time.Date(2026, 3, 23, 18, 0, 0, 0, berlin)
+	occurrences := expand(t, "FREQ=WEEKLY;COUNT=2", start, 0)
+	assertOccurrences(t, occurrences, "2026-03-23 Mon 18:00", "2026-03-30 Mon 18:00")
+
+	r, _ := Parse("FREQ=WEEKLY;COUNT=2", berlin)
+	times := r.Between(start, start, start.AddDate(1, 0, 0), 0)
+	if gap := times[1].Sub(times[0]); gap != 7*24*time.Hour-time.Hour {
+		t.Errorf("expected the second occurrence one hour earlier in UTC, gap is %v", gap)
+	}
+}
+
+func TestMonthly(t *testing.T) {
+	start := time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	// Months without a 31st are skipped
+	assertOccurrences(t, expand(t, "FREQ=MONTHLY;COUNT=3", start, 0),
+		"2026-01-31 Sat 10:00",
+		"2026-03-31 Tue 10:00",
+		"2026-05-31 Sun 10:00",
+	)
+
+	// The last day of every month
+	assertOccurrences(t, expand(t, "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3", start, 0),
+		"2026-01-31 Sat 10:00",
+		"2026-02-28 Sat 10:00",
+		"2026-03-31 Tue 10:00",
+	)
+
+	// Second Tuesday and last Friday of the month
+	start = time.Date(2026, 1, 13, 19, 0, 0, 0, time.UTC)
+	assertOccurrences(t, expand(t, "FREQ=MONTHLY;BYDAY=2TU,-1FR;COUNT=4", start, 0),
+		"2026-01-13 Tue 19:00",
+		"2026-01-30 Fri 19:00",
+		"2026-02-10 Tue 19:00",
+		"2026-02-27 Fri 19:00",
+	)
+}
+
+func TestBetweenWindow(t *testing.T) {
+	start := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	r, err := Parse("FREQ=DAILY", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := start.AddDate(0, 0, 10)
+	times := r.Between(start, from, from.AddDate(0, 0, 3), 0)
+	if len(times) != 3 || !times[0].Equal(from) {
+		t.Fatalf("expected 3 occurrences from %v, got %v", from, times)
+	}
+
+	// A rule without COUNT or UNTIL stops at the end of the window
+	times = r.Between(start, start, start.AddDate(1, 0, 0), 0)
+	if len(times) != 365 {
+		t.Errorf("expected 365 occurrences in a year, got %d", len(times))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	rules := []string{
+		"",
+		"COUNT=3",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20261231",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=2MO",
+		"FREQ=WEEKLY;BYMONTHDAY=3",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=DAILY;UNTIL=tomorrow",
+	}
+
+	for _, rule := range rules {
+		if _, err := Parse(rule, time.UTC); err == nil {
+			t.Errorf("expected Parse(%q) to fail", rule)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	rules := map[string]string{
+		"freq=weekly;byday=mo,fr;interval=2":              "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+		"FREQ=MONTHLY;BYDAY=-1FR;COUNT=6":                 "FREQ=MONTHLY;COUNT=6;BYDAY=-1FR",
+		"FREQ=DAILY;UNTIL=20261231T090000Z":               "FREQ=DAILY;UNTIL=20261231T090000Z",
+		"FREQ=MONTHLY;BYMONTHDAY=1,15;INTERVAL=1;WKST=MO": "FREQ=MONTHLY;BYMONTHDAY=1,15",
+	}
+
+	for rule, want := range rules {
+		r, err := Parse(rule, time.UTC)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", rule, err)
+		}
+		if got := r.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", rule, got, want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import "github.com/event-system/apperrors"
+
+// Errors returned by the repositories. Errors not listed here are unexpected
+// database failures and are reported to clients as internal errors.
+var (
+	ErrEventNotFound        = apperrors.NotFound("event_not_found", "event not found")
+	ErrUserNotFound         = apperrors.NotFound("user_not_found", "user not found")
+	ErrUserExists           = apperrors.Conflict("user_exists", "username or email already exists")
+	ErrEventNotOwned        = apperrors.Forbidden("not_event_organizer", "event not found or you are not the organizer")
+	ErrNotEventOwner        = apperrors.Forbidden("not_event_owner", "you are not the owner of this event")
+	ErrEventHasParticipants = apperrors.Conflict("event_has_participants", "cannot delete event with participants")
+	ErrEventHasPayments     = apperrors.Conflict("event_has_payments", "cannot delete an event that holds payments; cancel it and let the refunds finish")
+	ErrEventNotOpen         = apperrors.Conflict("event_not_open", "event is not open for registration")
+	ErrEventCancelled       = apperrors.Conflict("event_cancelled", "event has been cancelled")
+	ErrEventNotPublished    = apperrors.Conflict("event_not_published", "event is not published yet")
+	ErrRegistrationNotOpen  = apperrors.Conflict("registration_not_open_yet", "registration has not opened yet")
+	ErrRegistrationClosed   = apperrors.Conflict("registration_closed", "registration has closed")
+	ErrStatusChanged        = apperrors.Conflict("event_status_changed", "the status of the event was changed in the meantime")
+	ErrCapacityBelowTaken   = apperrors.Conflict("capacity_below_taken", "capacity can't be lower than the seats already taken or held by promo codes")
+	ErrAlreadyParticipant   = apperrors.Conflict("already_participant", "user is already a participant of this event")
+	ErrActiveEventLimit     = apperrors.Conflict("active_event_limit", "user has reached the maximum number of active events")
+	ErrNotParticipant       = apperrors.NotFound("not_participant", "user is not a participant of this event")
+	ErrNotOnWaitlist        = apperrors.NotFound("not_on_waitlist", "user is not on the waitlist of this event")
+	ErrRegistrationRejected = apperrors.Forbidden("registration_rejected", "your registration for this event was rejected")
+	ErrNotPending           = apperrors.Conflict("not_pending", "registration is not pending approval")
+	ErrTicketNotFound       = apperrors.NotFound("ticket_not_found", "the ticket doesn't belong to a confirmed participant of this event")
+	ErrAlreadyCheckedIn     = apperrors.Conflict("already_checked_in", "this ticket has already been checked in")
+	ErrTicketTypeNotFound   = apperrors.NotFound("ticket_type_not_found", "ticket type not found")
+	ErrTicketTypeRequired   = apperrors.Validation("ticket_type_required", "choose a ticket type for this event")
+	ErrTicketTypeExists     = apperrors.Conflict("ticket_type_exists", "the event already has a ticket type with this name")
+	ErrTicketTypeInUse      = apperrors.Conflict("ticket_type_in_use", "ticket type has registrations")
+	ErrTicketSalesNotOpen   = apperrors.Conflict("ticket_sales_not_open_yet", "sales of this ticket type have not started yet")
+	ErrTicketSalesEnded     = apperrors.Conflict("ticket_sales_ended", "sales of this ticket type have ended")
+	ErrQuotaBelowSold       = apperrors.Conflict("quota_below_sold", "quota can't be lower than the number of tickets already sold")
+	ErrPromoCodeNotFound    = apperrors.NotFound("promo_code_not_found", "promo code not found")
+	ErrPromoCodeExists      = apperrors.Conflict("promo_code_exists", "the event already has this promo code")
+	ErrPromoCodeInUse       = apperrors.Conflict("promo_code_in_use", "promo code has been redeemed; let it expire instead")
+	ErrPromoCodeExpired     = apperrors.Conflict("promo_code_expired", "this promo code has expired")
+	ErrPromoCodeUsedUp      = apperrors.Conflict("promo_code_used_up", "this promo code has no uses left")
+	ErrPromoCodeUserLimit   = apperrors.Conflict("promo_code_user_limit", "you have already used this promo code as often as allowed")
+	ErrOrderNotFound        = apperrors.NotFound("order_not_found", "order not found")
+	ErrOrderNotPending      = apperrors.Conflict("order_not_pending", "the order is no longer waiting for payment")
+	ErrOrderNotRefunding    = apperrors.Conflict("order_not_refunding", "the order is not waiting for a refund")
+	ErrInvalidWaitlistOrder = apperrors.Validation("invalid_waitlist_order", "new order must contain every waitlisted user exactly once")
+	ErrInvalidSort          = apperrors.Validation("invalid_sort", "invalid sort order")
+	ErrNotStaff             = apperrors.NotFound("not_staff", "user is not a staff member of this event")
+	ErrInviteNotFound       = apperrors.NotFound("invite_not_found", "invite not found")
+	ErrInviteRequired       = apperrors.Forbidden("invite_required", "this event is private and needs a valid invite")
+	ErrSeriesNotFound       = apperrors.NotFound("series_not_found", "event series not found")
+	ErrNotSeriesOwner       = apperrors.Forbidden("not_series_organizer", "you are not the organizer of this series")
+	ErrSeriesChanged        = apperrors.Conflict("series_changed", "the series was changed in the meantime")
+
+	ErrOccurrenceHasParticipants = apperrors.Conflict("occurrence_has_participants", "the change would remove occurrences that already have participants")
+	ErrPromoCodeNotApplicable    = apperrors.Validation("promo_code_not_applicable", "this promo code is for another ticket type")
+	ErrTicketTypeHasPromoCodes   = apperrors.Conflict("ticket_type_has_promo_codes", "promo codes are tied to this ticket type; delete them or move them to another ticket type first")
+	ErrPromoSeatsAboveCapacity   = apperrors.Conflict("reserved_seats_above_capacity", "the event doesn't have that many seats left to hold back")
+)
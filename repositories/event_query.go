@@ -0,0 +1,220 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+)
+
+// ErrInvalidCursor is returned when a paging cursor can't be decoded
+var ErrInvalidCursor = apperrors.Validation("invalid_cursor", "invalid cursor")
+
+const eventColumns = `e.id, e.name, e.description, e.location, e.start_time, e.end_time, e.capacity, e.organizer_id, e.status, e.visibility, e.created_at, e.updated_at,
+	e.requires_approval, e.publish_at, e.registration_opens_at, e.registration_closes_at, e.series_id, e.recurrence_id`
+
+// eventFields returns the scan destinations for eventColumns
+func eventFields(event *models.Event) []interface{} {
+	return []interface{}{
+		&event.ID,
+		&event.Name,
+		&event.Description,
+		&event.Location,
+		&event.StartTime,
+		&event.EndTime,
+		&event.Capacity,
+		&event.OrganizerID,
+		&event.Status,
+		&event.Visibility,
+		&event.CreatedAt,
+		&event.UpdatedAt,
+		&event.RequiresApproval,
+		&event.PublishAt,
+		&event.RegistrationOpensAt,
+		&event.RegistrationClosesAt,
+		&event.SeriesID,
+		&event.RecurrenceID,
+	}
+}
+
+// whereBuilder collects SQL conditions and numbers their placeholders
+type whereBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add appends a condition; every %s in it is replaced by the placeholder of the next argument
+func (b *whereBuilder) add(condition string, args ...interface{}) {
+	placeholders := make([]interface{}, len(args))
+	for i, arg := range args {
+		b.args = append(b.args, arg)
+		placeholders[i] = "$" + strconv.Itoa(len(b.args))
+	}
+	b.conditions = append(b.conditions, fmt.Sprintf(condition, placeholders...))
+}
+
+// placeholder registers an argument without a condition and returns its placeholder
+func (b *whereBuilder) placeholder(arg interface{}) string {
+	b.args = append(b.args, arg)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *whereBuilder) sql() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// listEvents returns one page of events from the given FROM clause, narrowed by
+// the base conditions already in where and by the filters of q.
+// Paging is keyset based on (start_time, id), so deep pages stay cheap.
+func (r *EventRepository) listEvents(from string, where whereBuilder, q models.EventListQuery) (*models.EventPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = models.DefaultPageSize
+	}
+	if limit > models.MaxPageSize {
+		limit = models.MaxPageSize
+	}
+
+	descending := false
+	switch q.Sort {
+	case "", models.SortStartTimeAsc:
+	case models.SortStartTimeDesc:
+		descending = true
+	default:
+		return nil, ErrInvalidSort
+	}
+
+	applyEventFilter(&where, q.EventFilter)
+
+	// The total ignores the cursor so it stays the same on every page
+	countQuery := `SELECT COUNT(*) FROM ` + from + ` ` + where.sql()
+
+	var total int
+	err := r.DB.QueryRow(countQuery, where.args...).Scan(&total)
+	if err != nil {
+		log.Printf("Error counting events: %v", err)
+		return nil, err
+	}
+
+	if q.Cursor != "" {
+		startTime, id, err := decodeEventCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		operator := ">"
+		if descending {
+			operator = "<"
+		}
+		where.add("(e.start_time, e.id) "+operator+" (%s, %s)", startTime, id)
+	}
+
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	query := `SELECT ` + eventColumns + ` FROM ` + from + ` ` + where.sql() +
+		` ORDER BY e.start_time ` + direction + `, e.id ` + direction +
+		` LIMIT ` + where.placeholder(limit+1)
+
+	rows, err := r.DB.Query(query, where.args...)
+	if err != nil {
+		log.Printf("Error listing events: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.Event{}
+	for rows.Next() {
+		event := models.Event{}
+		err := rows.Scan(eventFields(&event)...)
+		if err != nil {
+			log.Printf("Error scanning event: %v", err)
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating events: %v", err)
+		return nil, err
+	}
+
+	page := &models.EventPage{Events: events, Total: total}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		last := page.Events[limit-1]
+		page.NextCursor = encodeEventCursor(last.StartTime, last.ID)
+	}
+
+	return page, nil
+}
+
+// applyEventFilter adds the conditions of the shared listing filters
+func applyEventFilter(where *whereBuilder, f models.EventFilter) {
+	if f.From != nil {
+		where.add("e.start_time >= %s", *f.From)
+	}
+	if f.To != nil {
+		where.add("e.start_time <= %s", *f.To)
+	}
+	if f.Location != "" {
+		where.add(`LOWER(e.location) LIKE %s ESCAPE '\'`, "%"+escapeLike(strings.ToLower(f.Location))+"%")
+	}
+	if f.HasSeats {
+		where.add("e.capacity > (SELECT COUNT(*) FROM participants sp WHERE sp.event_id = e.id AND sp.status IN (%s, %s))",
+			models.ParticipantStatusConfirmed, models.ParticipantStatusReserved)
+	}
+}
+
+// addPublished leaves out events that aren't public or whose publish time is
+// still ahead of now
+func addPublished(where *whereBuilder, now time.Time) {
+	where.add("e.visibility = %s", models.VisibilityPublic)
+	where.add("(e.publish_at IS NULL OR e.publish_at <= %s)", now)
+}
+
+// encodeEventCursor turns the sort key of the last event on a page into an opaque cursor
+func encodeEventCursor(startTime time.Time, id int) string {
+	raw := startTime.Format(time.RFC3339Nano) + "|" + strconv.Itoa(id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeEventCursor is the inverse of encodeEventCursor
+func decodeEventCursor(cursor string) (time.Time, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	startTime, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	return startTime, id, nil
+}
+
+// escapeLike escapes the LIKE wildcards in user input
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
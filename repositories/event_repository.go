@@ -2,10 +2,11 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
+	"fmt"
 	"log"
 	"time"
 
+	"github.com/event-system/apperrors"
 	"github.com/event-system/models"
 )
 
@@ -21,21 +22,58 @@ func NewEventRepository(db *sql.DB) *EventRepository {
 
 // Create inserts a new event into the database
 func (r *EventRepository) Create(event *models.Event) error {
-	query := `
-	INSERT INTO events (name, description, location, start_time, end_time, capacity, organizer_id, status, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	RETURNING id
-	`
+	err := insertEvent(r.DB, hasSearchVector(r.DB), event)
+	if err != nil {
+		log.Printf("Error creating event: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// CreateMany inserts several events in one transaction; either all of them are
+// created or none
+func (r *EventRepository) CreateMany(events []*models.Event) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting create transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	searchVector := hasSearchVector(r.DB)
+	for _, event := range events {
+		if err = insertEvent(tx, searchVector, event); err != nil {
+			log.Printf("Error creating event: %v", err)
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing create transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
 
+// insertEvent inserts an event through q, which may be the database or a
+// transaction, and sets its ID and timestamps
+func insertEvent(q rowQuerier, searchVector bool, event *models.Event) error {
 	now := time.Now()
 	event.CreatedAt = now
 	event.UpdatedAt = now
 	if event.Status == "" {
-		event.Status = "open"
+		event.Status = models.EventStatusPublished
+	}
+	if event.Visibility == "" {
+		event.Visibility = models.VisibilityPublic
 	}
 
-	err := r.DB.QueryRow(
-		query,
+	columns := `name, description, location, start_time, end_time, capacity, organizer_id, status, created_at, updated_at, series_id, recurrence_id,
+	    publish_at, registration_opens_at, registration_closes_at, visibility, requires_approval`
+	values := `$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17`
+	args := []interface{}{
 		event.Name,
 		event.Description,
 		event.Location,
@@ -46,42 +84,39 @@ func (r *EventRepository) Create(event *models.Event) error {
 		event.Status,
 		event.CreatedAt,
 		event.UpdatedAt,
-	).Scan(&event.ID)
-
-	if err != nil {
-		log.Printf("Error creating event: %v", err)
-		return err
+		event.SeriesID,
+		event.RecurrenceID,
+		event.PublishAt,
+		event.RegistrationOpensAt,
+		event.RegistrationClosesAt,
+		event.Visibility,
+		event.RequiresApproval,
+	}
+	if searchVector {
+		columns += `, search_vector`
+		values += `, ` + searchVectorSQL("$18", "$19", "$20")
+		args = append(args, event.Name, event.Description, event.Location)
 	}
 
-	return nil
+	query := `INSERT INTO events (` + columns + `) VALUES (` + values + `) RETURNING id`
+
+	return q.QueryRow(query, args...).Scan(&event.ID)
 }
 
 // GetByID retrieves an event by ID
 func (r *EventRepository) GetByID(id int) (*models.Event, error) {
 	query := `
-	SELECT id, name, description, location, start_time, end_time, capacity, organizer_id, status, created_at, updated_at
-	FROM events
-	WHERE id = $1
+	SELECT ` + eventColumns + `
+	FROM events e
+	WHERE e.id = $1
 	`
 
 	event := &models.Event{}
-	err := r.DB.QueryRow(query, id).Scan(
-		&event.ID,
-		&event.Name,
-		&event.Description,
-		&event.Location,
-		&event.StartTime,
-		&event.EndTime,
-		&event.Capacity,
-		&event.OrganizerID,
-		&event.Status,
-		&event.CreatedAt,
-		&event.UpdatedAt,
-	)
+	err := r.DB.QueryRow(query, id).Scan(eventFields(event)...)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("event not found")
+			return nil, ErrEventNotFound
 		}
 		log.Printf("Error getting event by ID: %v", err)
 		return nil, err
@@ -90,36 +125,98 @@ func (r *EventRepository) GetByID(id int) (*models.Event, error) {
 	return event, nil
 }
 
-// Update updates an existing event
-func (r *EventRepository) Update(event *models.Event) error {
-	query := `
-	UPDATE events
-	SET name = $1, description = $2, location = $3, start_time = $4, end_time = $5, 
-	    capacity = $6, status = $7, updated_at = $8
-	WHERE id = $9 AND organizer_id = $10
-	RETURNING id
-	`
+// Update updates an existing event. The capacity can't drop below the seats
+// that are taken or held by promo codes.
+// If the capacity went up, waitlisted users are promoted in the same
+// transaction; it returns them.
+func (r *EventRepository) Update(event *models.Event) ([]models.Participant, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting update transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = updateEvent(tx, hasSearchVector(r.DB), event)
+	if err != nil {
+		return nil, err
+	}
+
+	// The UPDATE holds the row lock, so checking and promoting here can't race with joins
+	if err = checkCapacity(tx, event.ID, event.Capacity, time.Now()); err != nil {
+		return nil, err
+	}
+	promoted, err := promoteWaitlisted(tx, event.ID, event.Capacity)
+	if err != nil {
+		return nil, err
+	}
 
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing update transaction: %v", err)
+		return nil, err
+	}
+
+	return promoted, nil
+}
+
+// checkCapacity makes sure the seats taken by participants and reservations
+// and the seats held by promo codes still fit into the capacity of an event.
+// The caller must hold the lock on the event row.
+func checkCapacity(tx *sql.Tx, eventID, capacity int, now time.Time) error {
+	taken, err := seatsTaken(tx, eventID)
+	if err != nil {
+		return err
+	}
+	held, err := heldSeats(tx, eventID, now)
+	if err != nil {
+		return err
+	}
+	if needed := taken + seatsHeldFor(held, nil); capacity < needed {
+		return apperrors.Conflict(ErrCapacityBelowTaken.Code, fmt.Sprintf("%s: %d needed", ErrCapacityBelowTaken.Message, needed))
+	}
+	return nil
+}
+
+// updateEvent writes all fields of an event but its status inside tx, and
+// reads the current status back into event. The status only changes through
+// UpdateStatus, so an edit based on a stale copy can't undo a status change.
+// It fails with ErrEventNotOwned when the event is gone or belongs to someone else.
+func updateEvent(tx *sql.Tx, searchVector bool, event *models.Event) error {
 	event.UpdatedAt = time.Now()
 
-	var id int
-	err := r.DB.QueryRow(
-		query,
+	set := `name = $1, description = $2, location = $3, start_time = $4, end_time = $5,
+	    capacity = $6, updated_at = $7, series_id = $10, recurrence_id = $11,
+	    publish_at = $12, registration_opens_at = $13, registration_closes_at = $14, visibility = $15,
+	    requires_approval = $16`
+	args := []interface{}{
 		event.Name,
 		event.Description,
 		event.Location,
 		event.StartTime,
 		event.EndTime,
 		event.Capacity,
-		event.Status,
 		event.UpdatedAt,
 		event.ID,
 		event.OrganizerID,
-	).Scan(&id)
+		event.SeriesID,
+		event.RecurrenceID,
+		event.PublishAt,
+		event.RegistrationOpensAt,
+		event.RegistrationClosesAt,
+		event.Visibility,
+		event.RequiresApproval,
+	}
+	if searchVector {
+		set += `, search_vector = ` + searchVectorSQL("$17", "$18", "$19")
+		args = append(args, event.Name, event.Description, event.Location)
+	}
 
+	query := `UPDATE events SET ` + set + ` WHERE id = $8 AND organizer_id = $9 RETURNING status`
+
+	err := tx.QueryRow(query, args...).Scan(&event.Status)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return errors.New("event not found or you are not the organizer")
+			return ErrEventNotOwned
 		}
 		log.Printf("Error updating event: %v", err)
 		return err
@@ -128,147 +225,257 @@ func (r *EventRepository) Update(event *models.Event) error {
 	return nil
 }
 
-// Delete deletes an event by ID if it has no participants
+// Delete deletes an event by ID if it has no participants.
+// The event row is locked first so nobody can join between the check and the delete.
 func (r *EventRepository) Delete(id int, organizerID int) error {
-	// First check if the event has participants
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting delete transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	seriesID, recurrenceID, err := lockSeriesOf(tx, id)
+	if err != nil {
+		return err
+	}
+
+	// Lock the event row and check ownership
+	eventQuery := `
+	SELECT id FROM events WHERE id = $1 AND organizer_id = $2 FOR UPDATE
+	`
+	var lockedID int
+	err = tx.QueryRow(eventQuery, id, organizerID).Scan(&lockedID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrEventNotOwned
+		}
+		log.Printf("Error locking event: %v", err)
+		return err
+	}
+
+	// Check if the event has participants
 	var count int
 	participantsQuery := `
 	SELECT COUNT(*) FROM participants WHERE event_id = $1
 	`
-	err := r.DB.QueryRow(participantsQuery, id).Scan(&count)
+	err = tx.QueryRow(participantsQuery, id).Scan(&count)
 	if err != nil {
 		log.Printf("Error checking participants: %v", err)
 		return err
 	}
 
 	if count > 0 {
-		return errors.New("cannot delete event with participants")
+		return ErrEventHasParticipants
+	}
+
+	// Refunds of users who left are still tied to the event
+	if err = checkHeldPayments(tx, id); err != nil {
+		return err
 	}
 
 	// Delete the event
 	query := `
 	DELETE FROM events
-	WHERE id = $1 AND organizer_id = $2
-	RETURNING id
+	WHERE id = $1
 	`
 
-	var deletedID int
-	err = r.DB.QueryRow(query, id, organizerID).Scan(&deletedID)
+	_, err = tx.Exec(query, id)
+	if err != nil {
+		log.Printf("Error deleting event: %v", err)
+		return err
+	}
+
+	// A deleted occurrence must not come back when its series is edited
+	if seriesID.Valid && recurrenceID.Valid {
+		err = excludeOccurrence(tx, seriesID.Int64, recurrenceID.Time)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing delete transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ForceDelete deletes an event together with all its participants, regardless of who organizes it.
+// It refuses while the event has paid orders or refunds that haven't finished.
+func (r *EventRepository) ForceDelete(id int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting delete transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	seriesID, recurrenceID, err := lockSeriesOf(tx, id)
+	if err != nil {
+		return err
+	}
+
+	// Lock the event row so nobody joins while it is being removed
+	eventQuery := `
+	SELECT id FROM events WHERE id = $1 FOR UPDATE
+	`
+	var lockedID int
+	err = tx.QueryRow(eventQuery, id).Scan(&lockedID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return errors.New("event not found or you are not the organizer")
+			return ErrEventNotFound
 		}
+		log.Printf("Error locking event: %v", err)
+		return err
+	}
+
+	// Orders go with the event, so it can't be deleted while it holds money
+	if err = checkHeldPayments(tx, id); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM participants WHERE event_id = $1`, id)
+	if err != nil {
+		log.Printf("Error deleting participants: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM events WHERE id = $1`, id)
+	if err != nil {
 		log.Printf("Error deleting event: %v", err)
 		return err
 	}
 
+	// A deleted occurrence must not come back when its series is edited
+	if seriesID.Valid && recurrenceID.Valid {
+		err = excludeOccurrence(tx, seriesID.Int64, recurrenceID.Time)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing delete transaction: %v", err)
+		return err
+	}
+
 	return nil
 }
 
-// GetAllPublic retrieves all open events
-func (r *EventRepository) GetAllPublic() ([]models.Event, error) {
+// UpdateStatus moves an event to another status, but only if it still has
+// the status in event.Status. It fails with ErrStatusChanged when someone
+// else changed the status in the meantime.
+func (r *EventRepository) UpdateStatus(event *models.Event, status string) error {
 	query := `
-	SELECT id, name, description, location, start_time, end_time, capacity, organizer_id, status, created_at, updated_at
-	FROM events
-	WHERE status = 'open'
-	ORDER BY start_time ASC
+	UPDATE events SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4
 	`
 
-	rows, err := r.DB.Query(query)
+	tx, err := r.DB.Begin()
 	if err != nil {
-		log.Printf("Error getting public events: %v", err)
-		return nil, err
+		log.Printf("Error starting status transaction: %v", err)
+		return err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	events := []models.Event{}
-	for rows.Next() {
-		event := models.Event{}
-		err := rows.Scan(
-			&event.ID,
-			&event.Name,
-			&event.Description,
-			&event.Location,
-			&event.StartTime,
-			&event.EndTime,
-			&event.Capacity,
-			&event.OrganizerID,
-			&event.Status,
-			&event.CreatedAt,
-			&event.UpdatedAt,
-		)
-		if err != nil {
-			log.Printf("Error scanning event: %v", err)
-			return nil, err
+	now := time.Now()
+	result, err := tx.Exec(query, status, now, event.ID, event.Status)
+	if err != nil {
+		log.Printf("Error updating event status: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Error updating event status: %v", err)
+		return err
+	}
+
+	if rows == 0 {
+		tx.Rollback()
+		// Tell a missing event apart from a changed status
+		if _, err := r.GetByID(event.ID); err != nil {
+			return err
 		}
-		events = append(events, event)
+		return ErrStatusChanged
 	}
 
-	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating events: %v", err)
-		return nil, err
+	if status == models.EventStatusCancelled {
+		if err := cancelEventOrders(tx, event.ID, now); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing event status: %v", err)
+		return err
 	}
 
-	return events, nil
+	event.Status = status
+	event.UpdatedAt = now
+	return nil
 }
 
-// GetByOrganizer retrieves all events created by a specific organizer
-func (r *EventRepository) GetByOrganizer(organizerID int) ([]models.Event, error) {
+// GetDue retrieves up to limit events that need a status change at now:
+// published events and events with closed registration that have started,
+// and ongoing events that have ended
+func (r *EventRepository) GetDue(now time.Time, limit int) ([]models.Event, error) {
 	query := `
-	SELECT id, name, description, location, start_time, end_time, capacity, organizer_id, status, created_at, updated_at
-	FROM events
-	WHERE organizer_id = $1
-	ORDER BY start_time ASC
+	SELECT ` + eventColumns + `
+	FROM events e
+	WHERE (e.status IN ($1, $2) AND e.start_time <= $3) OR (e.status = $4 AND e.end_time <= $3)
+	ORDER BY e.start_time ASC, e.id ASC
+	LIMIT $5
 	`
 
-	rows, err := r.DB.Query(query, organizerID)
+	rows, err := r.DB.Query(query, models.EventStatusPublished, models.EventStatusRegistrationClosed, now, models.EventStatusOngoing, limit)
 	if err != nil {
-		log.Printf("Error getting events by organizer: %v", err)
+		log.Printf("Error getting due events: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
 	events := []models.Event{}
 	for rows.Next() {
-		event := models.Event{}
-		err := rows.Scan(
-			&event.ID,
-			&event.Name,
-			&event.Description,
-			&event.Location,
-			&event.StartTime,
-			&event.EndTime,
-			&event.Capacity,
-			&event.OrganizerID,
-			&event.Status,
-			&event.CreatedAt,
-			&event.UpdatedAt,
-		)
-		if err != nil {
-			log.Printf("Error scanning event: %v", err)
+		var event models.Event
+		if err := rows.Scan(eventFields(&event)...); err != nil {
+			log.Printf("Error scanning due event: %v", err)
 			return nil, err
 		}
 		events = append(events, event)
 	}
 
-	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating events: %v", err)
-		return nil, err
-	}
+	return events, rows.Err()
+}
+
+// GetAllPublic retrieves one page of published public events; drafts, unlisted
+// and private events and events whose publish time hasn't come yet never show up here
+func (r *EventRepository) GetAllPublic(q models.EventListQuery) (*models.EventPage, error) {
+	where := whereBuilder{}
+	where.add("e.status = %s", models.EventStatusPublished)
+	addPublished(&where, time.Now())
+
+	return r.listEvents("events e", where, q)
+}
 
-	return events, nil
+// GetByOrganizer retrieves one page of events created by a specific organizer
+func (r *EventRepository) GetByOrganizer(organizerID int, q models.EventListQuery) (*models.EventPage, error) {
+	where := whereBuilder{}
+	where.add("e.organizer_id = %s", organizerID)
+
+	return r.listEvents("events e", where, q)
 }
 
-// GetParticipantsByEventID retrieves all participants for a specific event
+// GetParticipantsByEventID retrieves all confirmed participants for a specific event
 func (r *EventRepository) GetParticipantsByEventID(eventID int) ([]models.UserResponse, error) {
 	query := `
 	SELECT u.id, u.username, u.email, u.created_at
 	FROM users u
 	JOIN participants p ON u.id = p.user_id
-	WHERE p.event_id = $1
+	WHERE p.event_id = $1 AND p.status = $2
 	`
 
-	rows, err := r.DB.Query(query, eventID)
+	rows, err := r.DB.Query(query, eventID, models.ParticipantStatusConfirmed)
 	if err != nil {
 		log.Printf("Error getting participants: %v", err)
 		return nil, err
@@ -299,50 +506,11 @@ func (r *EventRepository) GetParticipantsByEventID(eventID int) ([]models.UserRe
 	return participants, nil
 }
 
-// GetEventsByParticipant retrieves all events a user is participating in
-func (r *EventRepository) GetEventsByParticipant(userID int) ([]models.Event, error) {
-	query := `
-	SELECT e.id, e.name, e.description, e.location, e.start_time, e.end_time, e.capacity, e.organizer_id, e.status, e.created_at, e.updated_at
-	FROM events e
-	JOIN participants p ON e.id = p.event_id
-	WHERE p.user_id = $1
-	ORDER BY e.start_time ASC
-	`
-
-	rows, err := r.DB.Query(query, userID)
-	if err != nil {
-		log.Printf("Error getting events by participant: %v", err)
-		return nil, err
-	}
-	defer rows.Close()
-
-	events := []models.Event{}
-	for rows.Next() {
-		event := models.Event{}
-		err := rows.Scan(
-			&event.ID,
-			&event.Name,
-			&event.Description,
-			&event.Location,
-			&event.StartTime,
-			&event.EndTime,
-			&event.Capacity,
-			&event.OrganizerID,
-			&event.Status,
-			&event.CreatedAt,
-			&event.UpdatedAt,
-		)
-		if err != nil {
-			log.Printf("Error scanning event: %v", err)
-			return nil, err
-		}
-		events = append(events, event)
-	}
-
-	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating events: %v", err)
-		return nil, err
-	}
+// GetEventsByParticipant retrieves one page of events a user is a confirmed participant of
+func (r *EventRepository) GetEventsByParticipant(userID int, q models.EventListQuery) (*models.EventPage, error) {
+	where := whereBuilder{}
+	where.add("p.user_id = %s", userID)
+	where.add("p.status = %s", models.ParticipantStatusConfirmed)
 
-	return events, nil
+	return r.listEvents("events e JOIN participants p ON e.id = p.event_id", where, q)
 }
@@ -0,0 +1,342 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+func TestEventCursorRoundTrip(t *testing.T) {
+	startTime := time.Date(2025, 3, 14, 9, 26, 53, 589793000, time.UTC)
+
+	gotTime, gotID, err := decodeEventCursor(encodeEventCursor(startTime, 42))
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	if !gotTime.Equal(startTime) || gotID != 42 {
+		t.Errorf("expected (%v, 42), got (%v, %d)", startTime, gotTime, gotID)
+	}
+
+	invalid := []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("no-separator")),
+		base64.RawURLEncoding.EncodeToString([]byte("yesterday|1")),
+		base64.RawURLEncoding.EncodeToString([]byte("2025-03-14T09:26:53Z|abc")),
+	}
+	for _, cursor := range invalid {
+		if _, _, err := decodeEventCursor(cursor); err != ErrInvalidCursor {
+			t.Errorf("expected ErrInvalidCursor for %q, got %v", cursor, err)
+		}
+	}
+}
+
+func TestGetByOrganizerPagesThroughAllEvents(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 1)
+	organizer := users[0]
+
+	// Several events share a start time so the id tie-breaker is exercised
+	base := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	repo := NewEventRepository(db)
+	for i := 0; i < 7; i++ {
+		event := &models.Event{
+			Name:        "Paging test",
+			Location:    "Hall A",
+			StartTime:   base.Add(time.Duration(i/2) * time.Hour),
+			EndTime:     base.Add(time.Duration(i/2)*time.Hour + time.Hour),
+			Capacity:    10,
+			OrganizerID: organizer.ID,
+		}
+		if err := repo.Create(event); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+	}
+
+	for _, sort := range []string{models.SortStartTimeAsc, models.SortStartTimeDesc} {
+		seen := map[int]bool{}
+		query := models.EventListQuery{
+			EventFilter: models.EventFilter{Location: "hall"},
+			Limit:       3,
+			Sort:        sort,
+		}
+		var previous *models.Event
+
+		for pages := 0; ; pages++ {
+			if pages > 5 {
+				t.Fatalf("%s: paging did not terminate", sort)
+			}
+
+			page, err := repo.GetByOrganizer(organizer.ID, query)
+			if err != nil {
+				t.Fatalf("%s: get page: %v", sort, err)
+			}
+			if page.Total != 7 {
+				t.Errorf("%s: expected total 7, got %d", sort, page.Total)
+			}
+
+			for i := range page.Events {
+				event := page.Events[i]
+				if seen[event.ID] {
+					t.Errorf("%s: event %d returned twice", sort, event.ID)
+				}
+				seen[event.ID] = true
+
+				if previous != nil {
+					before := previous.StartTime.Before(event.StartTime) ||
+						(previous.StartTime.Equal(event.StartTime) && previous.ID < event.ID)
+					if before != (sort == models.SortStartTimeAsc) {
+						t.Errorf("%s: events out of order: %d then %d", sort, previous.ID, event.ID)
+					}
+				}
+				previous = &event
+			}
+
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+
+		if len(seen) != 7 {
+			t.Errorf("%s: expected 7 events across pages, got %d", sort, len(seen))
+		}
+	}
+}
+
+func TestPrefixTSQuery(t *testing.T) {
+	tests := map[string]string{
+		"go meetup":          "go:* & meetup:*",
+		"  Go!! & | meetup ": "go:* & meetup:*",
+		"کارگاه گو":          "کارگاه:* & گو:*",
+		"':*()":              "",
+	}
+
+	for input, expected := range tests {
+		if got := prefixTSQuery(input); got != expected {
+			t.Errorf("prefixTSQuery(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestMarkHeadline(t *testing.T) {
+	headline := `<script>alert("x")</script> ` + headlineStart + `go` + headlineStop + ` & more`
+	want := `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; <mark>go</mark> &amp; more`
+	if got := markHeadline(headline); got != want {
+		t.Errorf("markHeadline = %q, expected %q", got, want)
+	}
+}
+
+func TestSearchRanksNameMatchesFirst(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 1)
+	organizer := users[0]
+	marker := fmt.Sprintf("zq%d", time.Now().UnixNano())
+
+	repo := NewEventRepository(db)
+	start := time.Now().Add(72 * time.Hour)
+	inDescription := &models.Event{
+		Name:        "Weekly gathering",
+		Description: "Bring your laptop, we talk about " + marker + "ology",
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		Capacity:    10,
+		OrganizerID: organizer.ID,
+	}
+	inName := &models.Event{
+		Name:        marker + "ology workshop",
+		StartTime:   start.Add(time.Hour),
+		EndTime:     start.Add(2 * time.Hour),
+		Capacity:    10,
+		OrganizerID: organizer.ID,
+	}
+	for _, event := range []*models.Event{inDescription, inName} {
+		if err := repo.Create(event); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+	}
+
+	// A prefix of the word has to be enough
+	hits, total, err := repo.Search(models.EventSearchQuery{Text: marker})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if total != 2 || len(hits) != 2 {
+		t.Fatalf("expected 2 hits, got %d (total %d)", len(hits), total)
+	}
+	if hits[0].Event.ID != inName.ID {
+		t.Errorf("expected the name match to rank first")
+	}
+	if !strings.Contains(hits[0].Highlights.Name, "<mark>") {
+		t.Errorf("expected highlighted name, got %q", hits[0].Highlights.Name)
+	}
+
+	// Markup in the text comes back escaped around the marks
+	withScript := &models.Event{
+		Name:        "Scripted",
+		Description: "<script>alert(1)</script> x" + marker + " & more",
+		StartTime:   start.Add(2 * time.Hour),
+		EndTime:     start.Add(3 * time.Hour),
+		Capacity:    10,
+		OrganizerID: organizer.ID,
+	}
+	if err := repo.Create(withScript); err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	hits, _, err = repo.Search(models.EventSearchQuery{Text: "x" + marker})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	want := "&lt;script&gt;alert(1)&lt;/script&gt; <mark>x" + marker + "</mark> &amp; more"
+	if len(hits) != 1 || hits[0].Highlights.Description != want {
+		t.Errorf("got %+v, want the description highlight %q", hits, want)
+	}
+
+	// Updates have to refresh the search document
+	inName.Name = "Renamed"
+	if _, err := repo.Update(inName); err != nil {
+		t.Fatalf("update event: %v", err)
+	}
+	_, total, err = repo.Search(models.EventSearchQuery{Text: marker})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if total != 1 {
+		t.Errorf("expected 1 hit after rename, got %d", total)
+	}
+}
+
+func TestCreateManyIsAtomic(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 1)
+	repo := NewEventRepository(db)
+	start := time.Now().Add(24 * time.Hour)
+	newEvent := func(capacity int) *models.Event {
+		return &models.Event{
+			Name:        "Imported",
+			StartTime:   start,
+			EndTime:     start.Add(time.Hour),
+			Capacity:    capacity,
+			OrganizerID: users[0].ID,
+		}
+	}
+
+	// The capacity check constraint rejects the last event and with it the first
+	if err := repo.CreateMany([]*models.Event{newEvent(5), newEvent(0)}); err == nil {
+		t.Fatal("expected the invalid event to fail")
+	}
+	page, err := repo.GetByOrganizer(users[0].ID, models.EventListQuery{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if page.Total != 0 {
+		t.Fatalf("expected nothing to be created, got %d events", page.Total)
+	}
+
+	events := []*models.Event{newEvent(5), newEvent(10)}
+	if err := repo.CreateMany(events); err != nil {
+		t.Fatalf("create many: %v", err)
+	}
+	if events[0].ID == 0 || events[1].ID == 0 || events[0].Status != models.EventStatusPublished {
+		t.Errorf("expected the events to get IDs and the published status, got %+v", events)
+	}
+}
+
+func TestEventStatusIsChecked(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 2)
+	event := createTestEvent(t, db, users[0].ID, 5)
+	repo := NewEventRepository(db)
+
+	if err := repo.UpdateStatus(event, "open"); err == nil {
+		t.Fatal("expected the database to reject an unknown status")
+	}
+	if err := repo.UpdateStatus(event, models.EventStatusCancelled); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := NewParticipantRepository(db).JoinEvent(users[1].ID, event.ID, models.JoinOptions{}); err != ErrEventCancelled {
+		t.Errorf("join cancelled event: got %v, want ErrEventCancelled", err)
+	}
+}
+
+func TestUpdateStatusDetectsConcurrentChanges(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 1)
+	event := createTestEvent(t, db, users[0].ID, 5)
+	repo := NewEventRepository(db)
+
+	stale := *event
+	if err := repo.UpdateStatus(event, models.EventStatusRegistrationClosed); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := repo.UpdateStatus(&stale, models.EventStatusCancelled); err != ErrStatusChanged {
+		t.Errorf("update from a stale status: got %v, want ErrStatusChanged", err)
+	}
+
+	// An edit of the stale copy keeps the new status
+	stale.Name = "Renamed"
+	if _, err := repo.Update(&stale); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if stale.Status != models.EventStatusRegistrationClosed {
+		t.Errorf("status after edit = %q, want registration_closed", stale.Status)
+	}
+
+	missing := models.Event{ID: event.ID + 1000, Status: models.EventStatusPublished}
+	if err := repo.UpdateStatus(&missing, models.EventStatusCancelled); err != ErrEventNotFound {
+		t.Errorf("update missing event: got %v, want ErrEventNotFound", err)
+	}
+}
+
+func TestGetDue(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 1)
+	repo := NewEventRepository(db)
+	now := time.Now()
+	create := func(start, end time.Time, status string) *models.Event {
+		event := &models.Event{
+			Name:        "Due",
+			StartTime:   start,
+			EndTime:     end,
+			Capacity:    5,
+			OrganizerID: users[0].ID,
+			Status:      status,
+		}
+		if err := repo.Create(event); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+		return event
+	}
+
+	started := create(now.Add(-time.Hour), now.Add(time.Hour), models.EventStatusPublished)
+	closed := create(now.Add(-30*time.Minute), now.Add(time.Hour), models.EventStatusRegistrationClosed)
+	ended := create(now.Add(-2*time.Hour), now.Add(-time.Minute), models.EventStatusOngoing)
+	create(now.Add(time.Hour), now.Add(2*time.Hour), models.EventStatusPublished)
+	create(now.Add(-time.Hour), now.Add(time.Hour), models.EventStatusOngoing)
+	create(now.Add(-time.Hour), now.Add(-time.Minute), models.EventStatusDraft)
+
+	events, err := repo.GetDue(now, 10)
+	if err != nil {
+		t.Fatalf("get due: %v", err)
+	}
+	ids := []int{}
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	if fmt.Sprint(ids) != fmt.Sprint([]int{ended.ID, started.ID, closed.ID}) {
+		t.Errorf("due events = %v, want %v", ids, []int{ended.ID, started.ID, closed.ID})
+	}
+
+	if events, _ := repo.GetDue(now, 1); len(events) != 1 {
+		t.Errorf("expected the limit to apply, got %d events", len(events))
+	}
+}
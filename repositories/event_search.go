@@ -0,0 +1,322 @@
+package repositories
+
+import (
+	"database/sql"
+	"html"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/database"
+	"github.com/event-system/models"
+)
+
+// ErrEmptySearch is returned when a search text contains no searchable words
+var ErrEmptySearch = apperrors.Validation("empty_search", "search query must contain at least one word")
+
+// ts_headline wraps the matched words in two private use characters instead of
+// <mark> tags. The text around them is user input, so markHeadline escapes it
+// as HTML before it swaps the sentinels for the tags.
+const (
+	headlineStart   = "\uE000"
+	headlineStop    = "\uE001"
+	headlineOptions = `StartSel=` + headlineStart + `, StopSel=` + headlineStop + `, MaxWords=35, MinWords=15, HighlightAll=false`
+)
+
+// hasSearchVector reports whether events carry a tsvector column. Only
+// PostgreSQL has one; on SQLite Search matches the text columns instead.
+func hasSearchVector(db *sql.DB) bool {
+	return database.Dialect(db) == database.DriverPostgres
+}
+
+// searchVectorSQL builds the weighted search document of an event from three
+// text placeholders. The name weighs most, then the location, then the description.
+func searchVectorSQL(name, description, location string) string {
+	return `setweight(to_tsvector('simple', ` + name + `), 'A') || ` +
+		`setweight(to_tsvector('simple', ` + location + `), 'B') || ` +
+		`setweight(to_tsvector('simple', ` + description + `), 'C')`
+}
+
+// prefixTSQuery turns free text into a tsquery where every word matches as a prefix,
+// e.g. "go meet" becomes "go:* & meet:*". Anything but letters and digits is dropped
+// so user input can never produce tsquery syntax errors.
+func prefixTSQuery(text string) string {
+	words := searchWords(text)
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// Search ranks events by how well name, description and location match the search text
+func (r *EventRepository) Search(q models.EventSearchQuery) ([]models.EventSearchHit, int, error) {
+	tsQuery := prefixTSQuery(q.Text)
+	if tsQuery == "" {
+		return nil, 0, ErrEmptySearch
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = models.DefaultPageSize
+	}
+	if limit > models.MaxPageSize {
+		limit = models.MaxPageSize
+	}
+	offset := q.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	status := q.Status
+	if status == "" {
+		status = models.EventStatusPublished
+	}
+
+	if !hasSearchVector(r.DB) {
+		return r.searchText(q, status, limit, offset)
+	}
+
+	where := whereBuilder{}
+	where.add("e.search_vector @@ to_tsquery('simple', %s)", tsQuery)
+	where.add("e.status = %s", status)
+	addPublished(&where, time.Now())
+	applyEventFilter(&where, q.EventFilter)
+
+	countQuery := `SELECT COUNT(*) FROM events e ` + where.sql()
+
+	var total int
+	err := r.DB.QueryRow(countQuery, where.args...).Scan(&total)
+	if err != nil {
+		log.Printf("Error counting search results: %v", err)
+		return nil, 0, err
+	}
+
+	tsQueryParam := where.placeholder(tsQuery)
+	matched := `to_tsquery('simple', ` + tsQueryParam + `)`
+	query := `
+	SELECT ` + eventColumns + `,
+		ts_rank(e.search_vector, ` + matched + `) AS rank,
+		ts_headline('simple', e.name, ` + matched + `, '` + headlineOptions + `'),
+		ts_headline('simple', COALESCE(e.description, ''), ` + matched + `, '` + headlineOptions + `'),
+		ts_headline('simple', COALESCE(e.location, ''), ` + matched + `, '` + headlineOptions + `')
+	FROM events e
+	` + where.sql() + `
+	ORDER BY rank DESC, e.start_time ASC, e.id ASC
+	LIMIT ` + where.placeholder(limit) + ` OFFSET ` + where.placeholder(offset)
+
+	rows, err := r.DB.Query(query, where.args...)
+	if err != nil {
+		log.Printf("Error searching events: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	hits := []models.EventSearchHit{}
+	for rows.Next() {
+		hit := models.EventSearchHit{}
+		err := rows.Scan(append(eventFields(&hit.Event),
+			&hit.Rank,
+			&hit.Highlights.Name,
+			&hit.Highlights.Description,
+			&hit.Highlights.Location,
+		)...)
+		if err != nil {
+			log.Printf("Error scanning search result: %v", err)
+			return nil, 0, err
+		}
+		hit.Highlights.Name = markHeadline(hit.Highlights.Name)
+		hit.Highlights.Description = markHeadline(hit.Highlights.Description)
+		hit.Highlights.Location = markHeadline(hit.Highlights.Location)
+		hits = append(hits, hit)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating search results: %v", err)
+		return nil, 0, err
+	}
+
+	return hits, total, nil
+}
+
+// searchText is Search for databases without full text search. Every word has
+// to appear in the name, location or description; the candidates are then
+// ranked and highlighted in Go the same way MemoryStore does it.
+func (r *EventRepository) searchText(q models.EventSearchQuery, status string, limit, offset int) ([]models.EventSearchHit, int, error) {
+	terms := searchWords(q.Text)
+
+	where := whereBuilder{}
+	where.add("e.status = %s", status)
+	addPublished(&where, time.Now())
+	for _, term := range terms {
+		pattern := "%" + escapeLike(term) + "%"
+		where.add(`(LOWER(e.name) LIKE %s ESCAPE '\' OR LOWER(COALESCE(e.location, '')) LIKE %s ESCAPE '\' OR LOWER(COALESCE(e.description, '')) LIKE %s ESCAPE '\')`,
+			pattern, pattern, pattern)
+	}
+	applyEventFilter(&where, q.EventFilter)
+
+	query := `SELECT ` + eventColumns + ` FROM events e ` + where.sql()
+
+	rows, err := r.DB.Query(query, where.args...)
+	if err != nil {
+		log.Printf("Error searching events: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	hits := []models.EventSearchHit{}
+	for rows.Next() {
+		event := models.Event{}
+		err := rows.Scan(eventFields(&event)...)
+		if err != nil {
+			log.Printf("Error scanning search result: %v", err)
+			return nil, 0, err
+		}
+
+		// LIKE also matches inside words, searchRank keeps only prefix matches
+		rank, ok := searchRank(&event, terms)
+		if !ok {
+			continue
+		}
+		hits = append(hits, models.EventSearchHit{
+			Event: event,
+			Rank:  rank,
+			Highlights: models.EventHighlights{
+				Name:        highlight(event.Name, terms),
+				Description: highlight(event.Description, terms),
+				Location:    highlight(event.Location, terms),
+			},
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating search results: %v", err)
+		return nil, 0, err
+	}
+
+	return pageSearchHits(hits, limit, offset), len(hits), nil
+}
+
+// pageSearchHits orders hits like the PostgreSQL search and cuts out one page
+func pageSearchHits(hits []models.EventSearchHit, limit, offset int) []models.EventSearchHit {
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.Event.StartTime.Equal(b.Event.StartTime) {
+			return a.Event.StartTime.Before(b.Event.StartTime)
+		}
+		return a.Event.ID < b.Event.ID
+	})
+
+	if offset > len(hits) {
+		offset = len(hits)
+	}
+	end := offset + limit
+	if end > len(hits) {
+		end = len(hits)
+	}
+
+	return hits[offset:end]
+}
+
+// Search weights for name, location and description, the same as the
+// 'A', 'B' and 'C' weights ts_rank uses by default
+const (
+	searchWeightName        = 1.0
+	searchWeightLocation    = 0.4
+	searchWeightDescription = 0.2
+)
+
+// searchWords splits text into lower-case words; anything but letters and digits separates them
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchRank scores an event for the search terms; ok is false unless every term matches
+func searchRank(event *models.Event, terms []string) (rank float64, ok bool) {
+	fields := []struct {
+		words  []string
+		weight float64
+	}{
+		{searchWords(event.Name), searchWeightName},
+		{searchWords(event.Location), searchWeightLocation},
+		{searchWords(event.Description), searchWeightDescription},
+	}
+
+	for _, term := range terms {
+		best := 0.0
+		for _, field := range fields {
+			if field.weight > best && hasPrefixWord(field.words, term) {
+				best = field.weight
+			}
+		}
+		if best == 0 {
+			return 0, false
+		}
+		rank += best
+	}
+
+	return rank / float64(len(terms)), true
+}
+
+func hasPrefixWord(words []string, term string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// markHeadline escapes a ts_headline result as HTML and turns its sentinels into <mark> tags
+func markHeadline(headline string) string {
+	headline = html.EscapeString(headline)
+	headline = strings.ReplaceAll(headline, headlineStart, "<mark>")
+	return strings.ReplaceAll(headline, headlineStop, "</mark>")
+}
+
+// highlight escapes text as HTML and wraps every word that starts with one of
+// the terms in <mark> tags
+func highlight(text string, terms []string) string {
+	var b strings.Builder
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+			j++
+		}
+		word := string(runes[i:j])
+		if matchesAnyTerm(strings.ToLower(word), terms) {
+			b.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+		i = j
+	}
+
+	return b.String()
+}
+
+func matchesAnyTerm(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
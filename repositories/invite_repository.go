@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// InviteRepository handles database operations related to invites to private events
+type InviteRepository struct {
+	DB *sql.DB
+}
+
+// NewInviteRepository creates a new invite repository instance
+func NewInviteRepository(db *sql.DB) *InviteRepository {
+	return &InviteRepository{DB: db}
+}
+
+const inviteColumns = `id, event_id, created_by, user_id, email, max_uses, uses, expires_at, created_at`
+
+// inviteFields returns the scan destinations for inviteColumns
+func inviteFields(invite *models.EventInvite, email *sql.NullString) []interface{} {
+	return []interface{}{
+		&invite.ID,
+		&invite.EventID,
+		&invite.CreatedBy,
+		&invite.UserID,
+		email,
+		&invite.MaxUses,
+		&invite.Uses,
+		&invite.ExpiresAt,
+		&invite.CreatedAt,
+	}
+}
+
+// CreateMany stores several invites in one transaction and sets their IDs
+func (r *InviteRepository) CreateMany(invites []*models.EventInvite) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting invite transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO event_invites (event_id, created_by, user_id, email, max_uses, uses, expires_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, 0, $6, $7)
+	RETURNING id
+	`
+	now := time.Now()
+	for _, invite := range invites {
+		email := sql.NullString{String: invite.Email, Valid: invite.Email != ""}
+		err = tx.QueryRow(query, invite.EventID, invite.CreatedBy, invite.UserID, email, invite.MaxUses, invite.ExpiresAt, now).Scan(&invite.ID)
+		if err != nil {
+			log.Printf("Error creating invite: %v", err)
+			return err
+		}
+		invite.Uses = 0
+		invite.CreatedAt = now
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing invite transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetByID retrieves an invite by ID
+func (r *InviteRepository) GetByID(id int) (*models.EventInvite, error) {
+	invite := &models.EventInvite{}
+	var email sql.NullString
+	err := r.DB.QueryRow(`SELECT `+inviteColumns+` FROM event_invites WHERE id = $1`, id).Scan(inviteFields(invite, &email)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInviteNotFound
+		}
+		log.Printf("Error getting invite: %v", err)
+		return nil, err
+	}
+	invite.Email = email.String
+
+	return invite, nil
+}
+
+// GetByEvent retrieves all invites of an event, oldest first
+func (r *InviteRepository) GetByEvent(eventID int) ([]models.EventInvite, error) {
+	rows, err := r.DB.Query(`SELECT `+inviteColumns+` FROM event_invites WHERE event_id = $1 ORDER BY id ASC`, eventID)
+	if err != nil {
+		log.Printf("Error getting invites: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []models.EventInvite{}
+	for rows.Next() {
+		var invite models.EventInvite
+		var email sql.NullString
+		if err := rows.Scan(inviteFields(&invite, &email)...); err != nil {
+			log.Printf("Error scanning invite: %v", err)
+			return nil, err
+		}
+		invite.Email = email.String
+		invites = append(invites, invite)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating invites: %v", err)
+		return nil, err
+	}
+
+	return invites, nil
+}
+
+// Delete revokes an invite of an event. Its token stops working right away.
+func (r *InviteRepository) Delete(eventID, id int) error {
+	result, err := r.DB.Exec(`DELETE FROM event_invites WHERE id = $1 AND event_id = $2`, id, eventID)
+	if err != nil {
+		log.Printf("Error deleting invite: %v", err)
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInviteNotFound
+	}
+
+	return nil
+}
+
+// IsInvited reports whether a user takes part in an event or holds an
+// unexpired invite for their account or email address
+func (r *InviteRepository) IsInvited(eventID, userID int, now time.Time) (bool, error) {
+	query := `
+	SELECT EXISTS (SELECT 1 FROM participants WHERE event_id = $1 AND user_id = $2)
+	    OR EXISTS (
+		SELECT 1 FROM event_invites i
+		WHERE i.event_id = $1 AND (i.expires_at IS NULL OR i.expires_at > $3)
+		  AND (i.user_id = $2 OR LOWER(i.email) = (SELECT LOWER(email) FROM users WHERE id = $2))
+	    )
+	`
+
+	var invited bool
+	err := r.DB.QueryRow(query, eventID, userID, now).Scan(&invited)
+	if err != nil {
+		log.Printf("Error checking invite: %v", err)
+		return false, err
+	}
+
+	return invited, nil
+}
+
+// useInvite finds a usable invite of a user to an event inside the join
+// transaction and counts one use of it. Invites for the user's account or
+// email come before the link invite inviteID, so a link isn't used up by
+// someone who was invited anyway. The event row is locked by the caller, so
+// two joins can't both take the last use of an invite.
+func useInvite(tx *sql.Tx, eventID, userID, inviteID int, now time.Time) error {
+	query := `
+	SELECT i.id FROM event_invites i
+	WHERE i.event_id = $1
+	  AND (i.expires_at IS NULL OR i.expires_at > $2)
+	  AND (i.max_uses IS NULL OR i.uses < i.max_uses)
+	  AND (i.id = $3 OR i.user_id = $4 OR LOWER(i.email) = (SELECT LOWER(email) FROM users WHERE id = $4))
+	ORDER BY CASE WHEN i.id = $3 THEN 1 ELSE 0 END, i.id
+	LIMIT 1
+	`
+
+	var id int
+	err := tx.QueryRow(query, eventID, now, inviteID, userID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInviteRequired
+		}
+		log.Printf("Error finding invite: %v", err)
+		return err
+	}
+
+	if _, err = tx.Exec(`UPDATE event_invites SET uses = uses + 1 WHERE id = $1`, id); err != nil {
+		log.Printf("Error using invite: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+func TestJoinPrivateEventUsesInvites(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 5)
+	organizer, direct, byEmail, linked, stranger := users[0], users[1], users[2], users[3], users[4]
+	event := createTestEvent(t, db, organizer.ID, 10)
+	event.Visibility = models.VisibilityPrivate
+	if _, err := NewEventRepository(db).Update(event); err != nil {
+		t.Fatalf("update event: %v", err)
+	}
+
+	repo := NewInviteRepository(db)
+	participants := NewParticipantRepository(db)
+	one := 1
+	link := &models.EventInvite{EventID: event.ID, CreatedBy: organizer.ID, MaxUses: &one}
+	invites := []*models.EventInvite{
+		{EventID: event.ID, CreatedBy: organizer.ID, UserID: &direct.ID, MaxUses: &one},
+		{EventID: event.ID, CreatedBy: organizer.ID, Email: strings.ToUpper(byEmail.Email), MaxUses: &one},
+		link,
+	}
+	if err := repo.CreateMany(invites); err != nil {
+		t.Fatalf("create invites: %v", err)
+	}
+
+	now := time.Now()
+	for _, user := range []*models.User{direct, byEmail} {
+		invited, err := repo.IsInvited(event.ID, user.ID, now)
+		if err != nil || !invited {
+			t.Errorf("expected user %d to be invited, got %v, %v", user.ID, invited, err)
+		}
+	}
+	if invited, _ := repo.IsInvited(event.ID, linked.ID, now); invited {
+		t.Error("expected a link invite not to count for everyone")
+	}
+
+	if _, err := participants.JoinEvent(stranger.ID, event.ID, models.JoinOptions{}); !errors.Is(err, ErrInviteRequired) {
+		t.Errorf("join without invite: got %v, want ErrInviteRequired", err)
+	}
+
+	// Direct invites are used before the link, so the link stays usable
+	if _, err := participants.JoinEvent(direct.ID, event.ID, models.JoinOptions{InviteID: link.ID}); err != nil {
+		t.Fatalf("join with direct invite: %v", err)
+	}
+	if _, err := participants.JoinEvent(byEmail.ID, event.ID, models.JoinOptions{}); err != nil {
+		t.Fatalf("join with email invite: %v", err)
+	}
+	if _, err := participants.JoinEvent(linked.ID, event.ID, models.JoinOptions{InviteID: link.ID}); err != nil {
+		t.Fatalf("join with link: %v", err)
+	}
+	if _, err := participants.JoinEvent(stranger.ID, event.ID, models.JoinOptions{InviteID: link.ID}); !errors.Is(err, ErrInviteRequired) {
+		t.Errorf("join with used up link: got %v, want ErrInviteRequired", err)
+	}
+
+	stored, err := repo.GetByEvent(event.ID)
+	if err != nil {
+		t.Fatalf("get invites: %v", err)
+	}
+	if len(stored) != 3 {
+		t.Fatalf("expected 3 invites, got %d", len(stored))
+	}
+	for _, invite := range stored {
+		if invite.Uses != 1 {
+			t.Errorf("invite %d used %d times, want 1", invite.ID, invite.Uses)
+		}
+	}
+
+	// Joining made the link user a participant, who may see the event from now on
+	if invited, _ := repo.IsInvited(event.ID, linked.ID, now); !invited {
+		t.Error("expected a participant to count as invited")
+	}
+
+	if err := repo.Delete(event.ID, link.ID); err != nil {
+		t.Fatalf("delete invite: %v", err)
+	}
+	if err := repo.Delete(event.ID, link.ID); !errors.Is(err, ErrInviteNotFound) {
+		t.Errorf("delete again: got %v, want ErrInviteNotFound", err)
+	}
+}
@@ -0,0 +1,1028 @@
+package repositories
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+)
+
+// memoryEvents implements EventStore
+type memoryEvents struct{ s *MemoryStore }
+
+func (m memoryEvents) Create(event *models.Event) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	m.s.createEvent(event)
+	return nil
+}
+
+func (m memoryEvents) CreateMany(events []*models.Event) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	for _, event := range events {
+		m.s.createEvent(event)
+	}
+	return nil
+}
+
+func (m memoryEvents) GetByID(id int) (*models.Event, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	event, ok := m.s.events[id]
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+	found := *event
+	return &found, nil
+}
+
+func (m memoryEvents) Update(event *models.Event) ([]models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	stored, ok := m.s.events[event.ID]
+	if !ok || stored.OrganizerID != event.OrganizerID {
+		return nil, ErrEventNotOwned
+	}
+	if err := m.s.checkCapacity(event.ID, event.Capacity, time.Now()); err != nil {
+		return nil, err
+	}
+
+	event.UpdatedAt = time.Now()
+	event.CreatedAt = stored.CreatedAt
+	event.Status = stored.Status
+	*stored = *event
+
+	return m.s.promoteWaitlisted(event.ID, event.Capacity), nil
+}
+
+func (m memoryEvents) UpdateStatus(event *models.Event, status string) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	stored, ok := m.s.events[event.ID]
+	if !ok {
+		return ErrEventNotFound
+	}
+	if stored.Status != event.Status {
+		return ErrStatusChanged
+	}
+
+	now := time.Now()
+	stored.Status = status
+	stored.UpdatedAt = now
+	if status == models.EventStatusCancelled {
+		m.s.cancelEventOrders(event.ID, now)
+	}
+	event.Status = status
+	event.UpdatedAt = now
+	return nil
+}
+
+// GetDue matches the conditions of EventRepository.GetDue
+func (m memoryEvents) GetDue(now time.Time, limit int) ([]models.Event, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	events := []models.Event{}
+	for _, event := range m.s.events {
+		started := !event.StartTime.After(now) &&
+			(event.Status == models.EventStatusPublished || event.Status == models.EventStatusRegistrationClosed)
+		ended := !event.EndTime.After(now) && event.Status == models.EventStatusOngoing
+		if started || ended {
+			events = append(events, *event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].StartTime.Equal(events[j].StartTime) {
+			return events[i].StartTime.Before(events[j].StartTime)
+		}
+		return events[i].ID < events[j].ID
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (m memoryEvents) Delete(id int, organizerID int) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	event, ok := m.s.events[id]
+	if !ok || event.OrganizerID != organizerID {
+		return ErrEventNotOwned
+	}
+	if len(m.s.eventParticipants(id, "")) > 0 {
+		return ErrEventHasParticipants
+	}
+	if err := m.s.checkHeldPayments(id); err != nil {
+		return err
+	}
+
+	m.s.excludeOccurrence(m.s.events[id])
+	m.s.deleteEvent(id)
+	return nil
+}
+
+func (m memoryEvents) ForceDelete(id int) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.events[id]; !ok {
+		return ErrEventNotFound
+	}
+	if err := m.s.checkHeldPayments(id); err != nil {
+		return err
+	}
+
+	m.s.excludeOccurrence(m.s.events[id])
+	m.s.deleteEvent(id)
+	return nil
+}
+
+// deleteEvent removes an event with its participants, staff, invites, ticket
+// types, promo codes and orders, like the foreign keys do in the database
+func (s *MemoryStore) deleteEvent(id int) {
+	for _, p := range s.eventParticipants(id, "") {
+		delete(s.participants, p.ID)
+	}
+	for key := range s.staff {
+		if key.eventID == id {
+			delete(s.staff, key)
+		}
+	}
+	for inviteID, invite := range s.invites {
+		if invite.EventID == id {
+			delete(s.invites, inviteID)
+		}
+	}
+	for ticketTypeID, ticketType := range s.ticketTypes {
+		if ticketType.EventID == id {
+			delete(s.ticketTypes, ticketTypeID)
+		}
+	}
+	for codeID, code := range s.promoCodes {
+		if code.EventID == id {
+			delete(s.promoCodes, codeID)
+		}
+	}
+	for redemptionID, redemption := range s.redemptions {
+		if redemption.eventID == id {
+			delete(s.redemptions, redemptionID)
+		}
+	}
+	for orderID, order := range s.orders {
+		if order.EventID == id {
+			delete(s.orders, orderID)
+		}
+	}
+	delete(s.events, id)
+}
+
+func (m memoryEvents) GetAllPublic(q models.EventListQuery) (*models.EventPage, error) {
+	now := time.Now()
+	return m.list(q, func(event *models.Event) bool {
+		return event.Status == models.EventStatusPublished && isPublished(event, now)
+	})
+}
+
+// isPublished is the in-memory version of addPublished
+func isPublished(event *models.Event, now time.Time) bool {
+	return event.Visibility == models.VisibilityPublic && (event.PublishAt == nil || !event.PublishAt.After(now))
+}
+
+func (m memoryEvents) GetByOrganizer(organizerID int, q models.EventListQuery) (*models.EventPage, error) {
+	return m.list(q, func(event *models.Event) bool { return event.OrganizerID == organizerID })
+}
+
+func (m memoryEvents) GetEventsByParticipant(userID int, q models.EventListQuery) (*models.EventPage, error) {
+	return m.list(q, func(event *models.Event) bool {
+		p := m.s.findParticipant(userID, event.ID)
+		return p != nil && p.Status == models.ParticipantStatusConfirmed
+	})
+}
+
+// list mirrors listEvents: filters, keyset paging on (start_time, id) and a
+// total that ignores the cursor
+func (m memoryEvents) list(q models.EventListQuery, match func(event *models.Event) bool) (*models.EventPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = models.DefaultPageSize
+	}
+	if limit > models.MaxPageSize {
+		limit = models.MaxPageSize
+	}
+
+	descending := false
+	switch q.Sort {
+	case "", models.SortStartTimeAsc:
+	case models.SortStartTimeDesc:
+		descending = true
+	default:
+		return nil, ErrInvalidSort
+	}
+
+	var cursorTime time.Time
+	var cursorID int
+	if q.Cursor != "" {
+		var err error
+		cursorTime, cursorID, err = decodeEventCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	matched := []models.Event{}
+	for _, event := range m.s.events {
+		if match(event) && m.s.matchesFilter(event, q.EventFilter) {
+			matched = append(matched, *event)
+		}
+	}
+
+	// before reports whether a sorts before b in the requested direction
+	before := func(aTime time.Time, aID int, bTime time.Time, bID int) bool {
+		if !aTime.Equal(bTime) {
+			return aTime.Before(bTime) != descending
+		}
+		return (aID < bID) != descending
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return before(matched[i].StartTime, matched[i].ID, matched[j].StartTime, matched[j].ID)
+	})
+
+	events := []models.Event{}
+	for _, event := range matched {
+		if q.Cursor != "" && !before(cursorTime, cursorID, event.StartTime, event.ID) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	page := &models.EventPage{Events: events, Total: len(matched)}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		last := page.Events[limit-1]
+		page.NextCursor = encodeEventCursor(last.StartTime, last.ID)
+	}
+
+	return page, nil
+}
+
+// matchesFilter is the in-memory version of applyEventFilter
+func (s *MemoryStore) matchesFilter(event *models.Event, f models.EventFilter) bool {
+	if f.From != nil && event.StartTime.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && event.StartTime.After(*f.To) {
+		return false
+	}
+	if f.Location != "" && !strings.Contains(strings.ToLower(event.Location), strings.ToLower(f.Location)) {
+		return false
+	}
+	if f.HasSeats && s.seatsTaken(event.ID) >= event.Capacity {
+		return false
+	}
+	return true
+}
+
+func (m memoryEvents) GetParticipantsByEventID(eventID int) ([]models.UserResponse, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	participants := []models.UserResponse{}
+	for _, p := range m.s.eventParticipants(eventID, models.ParticipantStatusConfirmed) {
+		if user, ok := m.s.users[p.UserID]; ok {
+			participants = append(participants, userResponse(user))
+		}
+	}
+	return participants, nil
+}
+
+// Search matches every word of the text as a prefix like prefixTSQuery does.
+// The rank is a simpler weighted score than ts_rank, but it orders name
+// matches above location and description matches the same way.
+func (m memoryEvents) Search(q models.EventSearchQuery) ([]models.EventSearchHit, int, error) {
+	terms := searchWords(q.Text)
+	if len(terms) == 0 {
+		return nil, 0, ErrEmptySearch
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = models.DefaultPageSize
+	}
+	if limit > models.MaxPageSize {
+		limit = models.MaxPageSize
+	}
+	offset := q.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	status := q.Status
+	if status == "" {
+		status = models.EventStatusPublished
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	now := time.Now()
+	hits := []models.EventSearchHit{}
+	for _, event := range m.s.events {
+		if event.Status != status || !isPublished(event, now) || !m.s.matchesFilter(event, q.EventFilter) {
+			continue
+		}
+
+		rank, ok := searchRank(event, terms)
+		if !ok {
+			continue
+		}
+		hits = append(hits, models.EventSearchHit{
+			Event: *event,
+			Rank:  rank,
+			Highlights: models.EventHighlights{
+				Name:        highlight(event.Name, terms),
+				Description: highlight(event.Description, terms),
+				Location:    highlight(event.Location, terms),
+			},
+		})
+	}
+
+	return pageSearchHits(hits, limit, offset), len(hits), nil
+}
+
+// memoryParticipants implements ParticipantStore
+type memoryParticipants struct{ s *MemoryStore }
+
+// JoinEvent runs the same checks in the same order as ParticipantRepository.JoinEvent
+func (m memoryParticipants) JoinEvent(userID, eventID int, opts models.JoinOptions) (*models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	event, ok := m.s.events[eventID]
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+	now := time.Now()
+	if err := CheckRegistration(event, now); err != nil {
+		return nil, err
+	}
+	if _, ok := m.s.users[userID]; !ok {
+		return nil, ErrUserNotFound
+	}
+	if existing := m.s.findParticipant(userID, eventID); existing != nil {
+		if existing.Status == models.ParticipantStatusRejected {
+			return nil, ErrRegistrationRejected
+		}
+		return nil, ErrAlreadyParticipant
+	}
+
+	if m.s.activeEventCount(userID) >= 5 {
+		return nil, ErrActiveEventLimit
+	}
+
+	var promoCodeID, unlocked *int
+	if opts.PromoCode != "" {
+		code, err := m.s.redeemablePromoCode(eventID, userID, opts.PromoCode, now)
+		if err != nil {
+			return nil, err
+		}
+		promoCodeID, unlocked = &code.ID, code.TicketTypeID
+	}
+
+	ticketTypeID, err := m.s.chooseTicketType(eventID, opts.TicketTypeID, unlocked, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.Visibility == models.VisibilityPrivate {
+		if err := m.s.useInvite(eventID, userID, opts.InviteID, now); err != nil {
+			return nil, err
+		}
+	}
+	if promoCodeID != nil {
+		m.s.recordRedemption(*promoCodeID, eventID, userID, now)
+	}
+
+	if event.RequiresApproval {
+		participant := &models.Participant{
+			ID:           m.s.nextID(),
+			UserID:       userID,
+			EventID:      eventID,
+			Status:       models.ParticipantStatusPending,
+			JoinedAt:     time.Now(),
+			TicketTypeID: ticketTypeID,
+			PromoCodeID:  promoCodeID,
+		}
+		stored := *participant
+		m.s.participants[participant.ID] = &stored
+		return participant, nil
+	}
+
+	return m.s.addParticipant(userID, event, nil, ticketTypeID, promoCodeID), nil
+}
+
+// activeEventCount mirrors checkActiveEventLimit: events that haven't ended
+// or been cancelled and weren't rejected, where a series joined as a whole
+// counts once
+func (s *MemoryStore) activeEventCount(userID int) int {
+	now := time.Now()
+	count := 0
+	series := map[int]bool{}
+	for _, p := range s.participants {
+		joined, ok := s.events[p.EventID]
+		if !ok || p.UserID != userID || !joined.EndTime.After(now) || joined.Status == models.EventStatusCancelled || p.Status == models.ParticipantStatusRejected {
+			continue
+		}
+		if p.SeriesID == nil {
+			count++
+		} else if !series[*p.SeriesID] {
+			series[*p.SeriesID] = true
+			count++
+		}
+	}
+	return count
+}
+
+// addParticipant mirrors the repository function of the same name
+func (s *MemoryStore) addParticipant(userID int, event *models.Event, seriesID, ticketTypeID, promoCodeID *int) *models.Participant {
+	participant := &models.Participant{
+		ID:           s.nextID(),
+		UserID:       userID,
+		EventID:      event.ID,
+		JoinedAt:     time.Now(),
+		SeriesID:     seriesID,
+		TicketTypeID: ticketTypeID,
+		PromoCodeID:  promoCodeID,
+	}
+	participant.Status, participant.WaitlistPosition = s.nextSeat(event, ticketTypeID, promoCodeID)
+	s.reserveSeat(participant, participant.JoinedAt, reservationHold)
+
+	stored := *participant
+	s.participants[participant.ID] = &stored
+
+	if participant.Status == models.ParticipantStatusWaitlisted {
+		participant.WaitlistPosition = s.waitlistRank(event.ID, stored.WaitlistPosition)
+	}
+
+	return participant
+}
+
+// nextSeat mirrors the repository function of the same name
+func (s *MemoryStore) nextSeat(event *models.Event, ticketTypeID, promoCodeID *int) (string, int) {
+	held := s.heldSeats(event.ID, time.Now())
+	full := s.seatsTaken(event.ID)+seatsHeldFor(held, promoCodeID) >= event.Capacity
+	if !full && ticketTypeID != nil {
+		full = s.ticketTypeFull(*ticketTypeID)
+	}
+	if !full {
+		return models.ParticipantStatusConfirmed, 0
+	}
+
+	position := 0
+	for _, p := range s.eventParticipants(event.ID, models.ParticipantStatusWaitlisted) {
+		if p.WaitlistPosition > position {
+			position = p.WaitlistPosition
+		}
+	}
+	return models.ParticipantStatusWaitlisted, position + 1
+}
+
+func (m memoryParticipants) LeaveEvent(userID, eventID int) ([]models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	event, ok := m.s.events[eventID]
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+
+	p := m.s.findParticipant(userID, eventID)
+	if p == nil || p.Status == models.ParticipantStatusRejected {
+		return nil, ErrNotParticipant
+	}
+	delete(m.s.participants, p.ID)
+
+	now := time.Now()
+	for _, order := range m.s.orders {
+		if order.EventID != eventID || order.UserID != userID {
+			continue
+		}
+		switch order.Status {
+		case models.OrderStatusPending:
+			order.Status = models.OrderStatusCancelled
+			order.UpdatedAt = now
+		case models.OrderStatusPaid:
+			order.Status = models.OrderStatusRefunding
+			order.UpdatedAt = now
+		}
+	}
+	m.s.releaseRedemption(eventID, userID, now)
+
+	return m.s.promoteWaitlisted(eventID, event.Capacity), nil
+}
+
+func (m memoryParticipants) IsParticipant(userID, eventID int) (bool, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	p := m.s.findParticipant(userID, eventID)
+	return p != nil && p.Status == models.ParticipantStatusConfirmed, nil
+}
+
+func (m memoryParticipants) GetRegistration(userID, eventID int) (*models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	p := m.s.findParticipant(userID, eventID)
+	if p == nil {
+		return nil, ErrNotParticipant
+	}
+	found := *p
+	if found.Status == models.ParticipantStatusWaitlisted {
+		found.WaitlistPosition = m.s.waitlistRank(eventID, p.WaitlistPosition)
+	}
+	return &found, nil
+}
+
+func (m memoryParticipants) GetParticipantUserIDs(eventID int) ([]int, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	userIDs := []int{}
+	for _, p := range m.s.eventParticipants(eventID, "") {
+		if p.Status != models.ParticipantStatusRejected {
+			userIDs = append(userIDs, p.UserID)
+		}
+	}
+	return userIDs, nil
+}
+
+func (m memoryParticipants) GetParticipantCount(eventID int) (*models.ParticipantCountResponse, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	confirmed := m.s.eventParticipants(eventID, models.ParticipantStatusConfirmed)
+	count := &models.ParticipantCountResponse{Count: len(confirmed)}
+	for _, ticketType := range m.s.eventTicketTypes(eventID) {
+		holders := 0
+		for _, p := range confirmed {
+			if p.TicketTypeID != nil && *p.TicketTypeID == ticketType.ID {
+				holders++
+			}
+		}
+		count.TicketTypes = append(count.TicketTypes, models.TicketTypeCount{
+			TicketTypeID: ticketType.ID,
+			Name:         ticketType.Name,
+			Count:        holders,
+			Quota:        ticketType.Quota,
+			Visibility:   ticketType.Visibility,
+		})
+	}
+	return count, nil
+}
+
+func (m memoryParticipants) GetWaitlistPosition(userID, eventID int) (int, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	p := m.s.findParticipant(userID, eventID)
+	if p == nil || p.Status != models.ParticipantStatusWaitlisted {
+		return 0, ErrNotOnWaitlist
+	}
+	return m.s.waitlistRank(eventID, p.WaitlistPosition), nil
+}
+
+func (m memoryParticipants) GetWaitlist(eventID int) ([]models.WaitlistEntryResponse, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	entries := []models.WaitlistEntryResponse{}
+	for _, p := range m.s.waitlist(eventID) {
+		user, ok := m.s.users[p.UserID]
+		if !ok {
+			continue
+		}
+		entries = append(entries, models.WaitlistEntryResponse{
+			Position: len(entries) + 1,
+			User:     userResponse(user),
+			JoinedAt: p.JoinedAt,
+		})
+	}
+	return entries, nil
+}
+
+func (m memoryParticipants) ReorderWaitlist(eventID int, userIDs []int) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.events[eventID]; !ok {
+		return ErrEventNotFound
+	}
+
+	waitlisted := map[int]*models.Participant{}
+	for _, p := range m.s.eventParticipants(eventID, models.ParticipantStatusWaitlisted) {
+		waitlisted[p.UserID] = p
+	}
+
+	if len(userIDs) != len(waitlisted) {
+		return ErrInvalidWaitlistOrder
+	}
+	seen := map[int]bool{}
+	for _, userID := range userIDs {
+		if waitlisted[userID] == nil || seen[userID] {
+			return ErrInvalidWaitlistOrder
+		}
+		seen[userID] = true
+	}
+
+	for i, userID := range userIDs {
+		waitlisted[userID].WaitlistPosition = i + 1
+	}
+	return nil
+}
+
+func (m memoryParticipants) GetPendingRegistrations(eventID int) ([]models.PendingRegistrationResponse, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	pending := []models.PendingRegistrationResponse{}
+	for _, p := range m.s.eventParticipants(eventID, models.ParticipantStatusPending) {
+		user, ok := m.s.users[p.UserID]
+		if !ok {
+			continue
+		}
+		pending = append(pending, models.PendingRegistrationResponse{
+			User:      userResponse(user),
+			AppliedAt: p.JoinedAt,
+		})
+	}
+	return pending, nil
+}
+
+func (m memoryParticipants) ReviewRegistrations(eventID int, userIDs []int, approve bool, message string) ([]models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	event, ok := m.s.events[eventID]
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+	if approve && event.Status == models.EventStatusCancelled {
+		return nil, ErrEventCancelled
+	}
+
+	// Check everything first, so a failure changes nothing like a rollback
+	seen := map[int]bool{}
+	for _, userID := range userIDs {
+		p := m.s.findParticipant(userID, eventID)
+		if p == nil || p.Status != models.ParticipantStatusPending || seen[userID] {
+			return nil, apperrors.Conflict(ErrNotPending.Code, fmt.Sprintf("%s: user %d", ErrNotPending.Message, userID))
+		}
+		seen[userID] = true
+	}
+
+	now := time.Now()
+	reviewed := make([]models.Participant, 0, len(userIDs))
+	for _, userID := range userIDs {
+		p := m.s.findParticipant(userID, eventID)
+		p.Status = models.ParticipantStatusRejected
+		if approve {
+			p.Status, p.WaitlistPosition = m.s.nextSeat(event, p.TicketTypeID, p.PromoCodeID)
+			m.s.reserveSeat(p, now, reservationHold)
+		} else {
+			m.s.releaseRedemption(eventID, userID, now)
+		}
+		reviewedAt := now
+		p.ReviewedAt = &reviewedAt
+		p.ReviewMessage = message
+
+		participant := *p
+		if participant.Status == models.ParticipantStatusWaitlisted {
+			participant.WaitlistPosition = m.s.waitlistRank(eventID, p.WaitlistPosition)
+		}
+		reviewed = append(reviewed, participant)
+	}
+	return reviewed, nil
+}
+
+func (m memoryParticipants) CheckIn(eventID, participantID int, at time.Time) (*models.CheckInResponse, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	p, ok := m.s.participants[participantID]
+	if !ok || p.EventID != eventID || p.Status != models.ParticipantStatusConfirmed {
+		return nil, ErrTicketNotFound
+	}
+	if p.CheckedInAt != nil {
+		return nil, apperrors.Conflict(ErrAlreadyCheckedIn.Code,
+			fmt.Sprintf("%s at %s", ErrAlreadyCheckedIn.Message, p.CheckedInAt.UTC().Format(time.RFC3339)))
+	}
+	checkedInAt := at
+	p.CheckedInAt = &checkedInAt
+
+	checkIn := &models.CheckInResponse{EventID: eventID, CheckedInAt: at}
+	if user, ok := m.s.users[p.UserID]; ok {
+		checkIn.User = userResponse(user)
+	}
+	return checkIn, nil
+}
+
+func (m memoryParticipants) GetAttendance(eventID int) (*models.AttendanceResponse, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	attendance := &models.AttendanceResponse{EventID: eventID}
+	for _, p := range m.s.eventParticipants(eventID, models.ParticipantStatusConfirmed) {
+		attendance.Registered++
+		if p.CheckedInAt != nil {
+			attendance.CheckedIn++
+		}
+	}
+	attendance.NotCheckedIn = attendance.Registered - attendance.CheckedIn
+	return attendance, nil
+}
+
+func (m memoryParticipants) GetConfirmedParticipants(eventID int) ([]models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	participants := []models.Participant{}
+	for _, p := range m.s.eventParticipants(eventID, models.ParticipantStatusConfirmed) {
+		participants = append(participants, *p)
+	}
+	return participants, nil
+}
+
+// SyncCheckIns resolves conflicts like ParticipantRepository.SyncCheckIns
+func (m memoryParticipants) SyncCheckIns(eventID int, checkIns []models.OfflineCheckIn) ([]models.CheckInSyncResult, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.events[eventID]; !ok {
+		return nil, ErrEventNotFound
+	}
+
+	results := make([]models.CheckInSyncResult, len(checkIns))
+	for _, i := range scanOrder(checkIns) {
+		checkIn := checkIns[i]
+
+		p, ok := m.s.participants[checkIn.ParticipantID]
+		if !ok || p.EventID != eventID || p.Status != models.ParticipantStatusConfirmed {
+			results[i] = models.CheckInSyncResult{Status: models.CheckInInvalid, Error: ErrTicketNotFound.Code}
+			continue
+		}
+		results[i].UserID = p.UserID
+
+		recorded := p.CheckedInAt
+		if recorded != nil && !checkIn.ScannedAt.Before(*recorded) {
+			results[i].Status = models.CheckInDuplicate
+			results[i].CheckedInAt = recorded
+			continue
+		}
+
+		scannedAt := checkIn.ScannedAt
+		p.CheckedInAt = &scannedAt
+		results[i].Status = models.CheckInSynced
+		results[i].CheckedInAt = &scannedAt
+		results[i].SupersededAt = recorded
+	}
+	return results, nil
+}
+
+// memoryStaff implements StaffStore
+type memoryStaff struct{ s *MemoryStore }
+
+func (m memoryStaff) AddStaff(staff *models.EventStaff) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.events[staff.EventID]; !ok {
+		return ErrEventNotFound
+	}
+	if _, ok := m.s.users[staff.UserID]; !ok {
+		return ErrUserNotFound
+	}
+
+	key := staffKey{staff.EventID, staff.UserID}
+	if existing, ok := m.s.staff[key]; ok {
+		existing.Role = staff.Role
+		staff.CreatedAt = existing.CreatedAt
+		return nil
+	}
+
+	staff.CreatedAt = time.Now()
+	stored := *staff
+	m.s.staff[key] = &stored
+	return nil
+}
+
+func (m memoryStaff) RemoveStaff(eventID, userID int) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	key := staffKey{eventID, userID}
+	if _, ok := m.s.staff[key]; !ok {
+		return ErrNotStaff
+	}
+	delete(m.s.staff, key)
+	return nil
+}
+
+func (m memoryStaff) GetStaffRole(eventID, userID int) (string, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if staff, ok := m.s.staff[staffKey{eventID, userID}]; ok {
+		return staff.Role, nil
+	}
+	return "", nil
+}
+
+func (m memoryStaff) GetStaff(eventID int) ([]models.StaffResponse, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	staff := []models.StaffResponse{}
+	for key, member := range m.s.staff {
+		user, ok := m.s.users[key.userID]
+		if key.eventID != eventID || !ok {
+			continue
+		}
+		staff = append(staff, models.StaffResponse{
+			User:      userResponse(user),
+			Role:      member.Role,
+			CreatedAt: member.CreatedAt,
+		})
+	}
+
+	sort.Slice(staff, func(i, j int) bool {
+		if !staff[i].CreatedAt.Equal(staff[j].CreatedAt) {
+			return staff[i].CreatedAt.Before(staff[j].CreatedAt)
+		}
+		return staff[i].User.ID < staff[j].User.ID
+	})
+	return staff, nil
+}
+
+func (m memoryStaff) TransferOwnership(eventID, ownerID, newOwnerID int) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	event, ok := m.s.events[eventID]
+	if !ok {
+		return ErrEventNotFound
+	}
+	if event.OrganizerID != ownerID {
+		return ErrNotEventOwner
+	}
+
+	now := time.Now()
+	event.OrganizerID = newOwnerID
+	event.UpdatedAt = now
+
+	delete(m.s.staff, staffKey{eventID, newOwnerID})
+
+	key := staffKey{eventID, ownerID}
+	if existing, ok := m.s.staff[key]; ok {
+		existing.Role = models.StaffRoleCoOrganizer
+	} else {
+		m.s.staff[key] = &models.EventStaff{
+			EventID:   eventID,
+			UserID:    ownerID,
+			Role:      models.StaffRoleCoOrganizer,
+			CreatedAt: now,
+		}
+	}
+	return nil
+}
+
+// memoryInvites implements InviteStore
+type memoryInvites struct{ s *MemoryStore }
+
+func (m memoryInvites) CreateMany(invites []*models.EventInvite) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	now := time.Now()
+	for _, invite := range invites {
+		if _, ok := m.s.events[invite.EventID]; !ok {
+			return ErrEventNotFound
+		}
+	}
+	for _, invite := range invites {
+		invite.ID = m.s.nextID()
+		invite.Uses = 0
+		invite.CreatedAt = now
+		stored := *invite
+		m.s.invites[invite.ID] = &stored
+	}
+	return nil
+}
+
+func (m memoryInvites) GetByID(id int) (*models.EventInvite, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	invite, ok := m.s.invites[id]
+	if !ok {
+		return nil, ErrInviteNotFound
+	}
+	found := *invite
+	return &found, nil
+}
+
+func (m memoryInvites) GetByEvent(eventID int) ([]models.EventInvite, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	invites := []models.EventInvite{}
+	for _, invite := range m.s.invites {
+		if invite.EventID == eventID {
+			invites = append(invites, *invite)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].ID < invites[j].ID })
+	return invites, nil
+}
+
+func (m memoryInvites) Delete(eventID, id int) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	invite, ok := m.s.invites[id]
+	if !ok || invite.EventID != eventID {
+		return ErrInviteNotFound
+	}
+	delete(m.s.invites, id)
+	return nil
+}
+
+func (m memoryInvites) IsInvited(eventID, userID int, now time.Time) (bool, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if m.s.findParticipant(userID, eventID) != nil {
+		return true, nil
+	}
+	for _, invite := range m.s.invites {
+		if invite.EventID == eventID && !inviteExpired(invite, now) && m.s.invitesUser(invite, userID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// useInvite mirrors the repository function of the same name
+func (s *MemoryStore) useInvite(eventID, userID, inviteID int, now time.Time) error {
+	// Same order as the query: the link invite last, then by ID
+	less := func(a, b *models.EventInvite) bool {
+		if (a.ID == inviteID) != (b.ID == inviteID) {
+			return b.ID == inviteID
+		}
+		return a.ID < b.ID
+	}
+
+	var found *models.EventInvite
+	for _, invite := range s.invites {
+		if invite.EventID != eventID || inviteExpired(invite, now) || (invite.MaxUses != nil && invite.Uses >= *invite.MaxUses) {
+			continue
+		}
+		if invite.ID != inviteID && !s.invitesUser(invite, userID) {
+			continue
+		}
+		if found == nil || less(invite, found) {
+			found = invite
+		}
+	}
+	if found == nil {
+		return ErrInviteRequired
+	}
+	found.Uses++
+	return nil
+}
+
+// invitesUser reports whether an invite is addressed to a user's account or email
+func (s *MemoryStore) invitesUser(invite *models.EventInvite, userID int) bool {
+	if invite.UserID != nil && *invite.UserID == userID {
+		return true
+	}
+	user, ok := s.users[userID]
+	return ok && invite.Email != "" && strings.EqualFold(invite.Email, user.Email)
+}
+
+// inviteExpired reports whether an invite can't be used anymore at now
+func inviteExpired(invite *models.EventInvite, now time.Time) bool {
+	return invite.ExpiresAt != nil && !invite.ExpiresAt.After(now)
+}
@@ -0,0 +1,264 @@
+package repositories
+
+import (
+	"sort"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// memoryOrders implements OrderStore
+type memoryOrders struct{ s *MemoryStore }
+
+func (m memoryOrders) GetByID(id int) (*models.Order, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	order, ok := m.s.orders[id]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	found := *order
+	return &found, nil
+}
+
+func (m memoryOrders) GetByProviderRef(provider, ref string) (*models.Order, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	for _, order := range m.s.orders {
+		if order.Provider == provider && order.ProviderRef == ref {
+			found := *order
+			return &found, nil
+		}
+	}
+	return nil, ErrOrderNotFound
+}
+
+func (m memoryOrders) GetByUser(eventID, userID int) ([]models.Order, error) {
+	orders := m.list(func(order *models.Order) bool { return order.EventID == eventID && order.UserID == userID })
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+	return orders, nil
+}
+
+func (m memoryOrders) GetByEvent(eventID int) ([]models.Order, error) {
+	return m.list(func(order *models.Order) bool { return order.EventID == eventID }), nil
+}
+
+func (m memoryOrders) GetRefunding(limit int) ([]models.Order, error) {
+	orders := m.list(func(order *models.Order) bool { return order.Status == models.OrderStatusRefunding })
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+// list returns copies of the matching orders, oldest first
+func (m memoryOrders) list(match func(order *models.Order) bool) []models.Order {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	orders := []models.Order{}
+	for _, order := range m.s.orders {
+		if match(order) {
+			orders = append(orders, *order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders
+}
+
+func (m memoryOrders) SetCheckout(id int, provider string, session *models.PaymentSession) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	order, ok := m.s.orders[id]
+	if !ok || order.Status != models.OrderStatusPending {
+		return ErrOrderNotPending
+	}
+	order.Provider = provider
+	order.ProviderRef = session.ProviderRef
+	order.CheckoutURL = session.CheckoutURL
+	order.UpdatedAt = time.Now()
+	return nil
+}
+
+// ConfirmPayment follows the rules of OrderRepository.ConfirmPayment
+func (m memoryOrders) ConfirmPayment(id int, now time.Time) (*models.Order, error) {
+	return m.settle(id, func(order *models.Order, event *models.Event) {
+		switch order.Status {
+		case models.OrderStatusPending:
+			order.Status = models.OrderStatusRefunding
+			if p := m.s.findParticipant(order.UserID, order.EventID); p != nil && p.Status == models.ParticipantStatusReserved {
+				p.Status = models.ParticipantStatusConfirmed
+				p.ReservedUntil = nil
+				order.Status = models.OrderStatusPaid
+			}
+		case models.OrderStatusFailed, models.OrderStatusExpired, models.OrderStatusCancelled:
+			order.Status = models.OrderStatusRefunding
+		default:
+			return
+		}
+		paidAt := now
+		order.PaidAt = &paidAt
+		order.UpdatedAt = now
+	})
+}
+
+// FailPayment follows the rules of OrderRepository.FailPayment
+func (m memoryOrders) FailPayment(id int, now time.Time) (*models.Order, []models.Participant, error) {
+	var promoted []models.Participant
+	order, err := m.settle(id, func(order *models.Order, event *models.Event) {
+		if order.Status == models.OrderStatusPending {
+			promoted = m.s.releaseReservation(order, models.OrderStatusFailed, event, now)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, promoted, nil
+}
+
+func (m memoryOrders) ReleaseExpired(now time.Time) (int, []models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	expired := []*models.Order{}
+	for _, order := range m.s.orders {
+		if order.Status == models.OrderStatusPending && !order.ExpiresAt.After(now) {
+			expired = append(expired, order)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ID < expired[j].ID })
+
+	promoted := []models.Participant{}
+	for _, order := range expired {
+		promoted = append(promoted, m.s.releaseReservation(order, models.OrderStatusExpired, m.s.events[order.EventID], now)...)
+	}
+	return len(expired), promoted, nil
+}
+
+func (m memoryOrders) MarkRefunded(id int, now time.Time) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	order, ok := m.s.orders[id]
+	if !ok || order.Status != models.OrderStatusRefunding {
+		return ErrOrderNotRefunding
+	}
+	refundedAt := now
+	order.Status = models.OrderStatusRefunded
+	order.RefundedAt = &refundedAt
+	order.UpdatedAt = now
+	return nil
+}
+
+func (m memoryOrders) MarkRefundFailed(id int, now time.Time) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	order, ok := m.s.orders[id]
+	if !ok || order.Status != models.OrderStatusRefunding {
+		return ErrOrderNotRefunding
+	}
+	order.Status = models.OrderStatusRefundFailed
+	order.UpdatedAt = now
+	return nil
+}
+
+// settle mirrors OrderRepository.settle
+func (m memoryOrders) settle(id int, change func(order *models.Order, event *models.Event)) (*models.Order, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	order, ok := m.s.orders[id]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	change(order, m.s.events[order.EventID])
+
+	found := *order
+	return &found, nil
+}
+
+// cancelEventOrders mirrors the repository function of the same name
+func (s *MemoryStore) cancelEventOrders(eventID int, now time.Time) {
+	for _, order := range s.orders {
+		if order.EventID != eventID {
+			continue
+		}
+		switch order.Status {
+		case models.OrderStatusPaid:
+			order.Status = models.OrderStatusRefunding
+		case models.OrderStatusPending:
+			order.Status = models.OrderStatusCancelled
+		default:
+			continue
+		}
+		order.UpdatedAt = now
+	}
+}
+
+// checkHeldPayments mirrors the repository function of the same name
+func (s *MemoryStore) checkHeldPayments(eventID int) error {
+	for _, order := range s.orders {
+		if order.EventID != eventID {
+			continue
+		}
+		switch order.Status {
+		case models.OrderStatusPaid, models.OrderStatusRefunding, models.OrderStatusRefundFailed:
+			return ErrEventHasPayments
+		}
+	}
+	return nil
+}
+
+// releaseReservation mirrors the repository function of the same name
+func (s *MemoryStore) releaseReservation(order *models.Order, status string, event *models.Event, now time.Time) []models.Participant {
+	if p := s.findParticipant(order.UserID, order.EventID); p != nil && p.Status == models.ParticipantStatusReserved {
+		delete(s.participants, p.ID)
+	}
+	order.Status = status
+	order.UpdatedAt = now
+	s.releaseRedemption(order.EventID, order.UserID, now)
+
+	return s.promoteWaitlisted(event.ID, event.Capacity)
+}
+
+// reserveSeat mirrors the repository function of the same name
+func (s *MemoryStore) reserveSeat(p *models.Participant, now time.Time, hold time.Duration) {
+	if p.Status != models.ParticipantStatusConfirmed || p.TicketTypeID == nil {
+		return
+	}
+	ticketType, ok := s.ticketTypes[*p.TicketTypeID]
+	if !ok || ticketType.Price == 0 {
+		return
+	}
+	discount := 0
+	if p.PromoCodeID != nil {
+		discount = s.redemptionDiscount(p, ticketType.Price)
+		if discount == ticketType.Price {
+			return
+		}
+	}
+
+	until := now.Add(hold)
+	p.Status = models.ParticipantStatusReserved
+	p.ReservedUntil = &until
+
+	ticketTypeID := *p.TicketTypeID
+	order := &models.Order{
+		ID:           s.nextID(),
+		EventID:      p.EventID,
+		UserID:       p.UserID,
+		TicketTypeID: &ticketTypeID,
+		Amount:       ticketType.Price - discount,
+		Discount:     discount,
+		Currency:     ticketType.Currency,
+		Status:       models.OrderStatusPending,
+		ExpiresAt:    until,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	s.orders[order.ID] = order
+}
@@ -0,0 +1,291 @@
+package repositories
+
+import (
+	"sort"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// memoryRedemption is a row of promo_redemptions
+type memoryRedemption struct {
+	id         int
+	codeID     int
+	eventID    int
+	userID     int
+	discount   int
+	redeemedAt time.Time
+	releasedAt *time.Time
+}
+
+// memoryPromoCodes implements PromoCodeStore
+type memoryPromoCodes struct{ s *MemoryStore }
+
+func (m memoryPromoCodes) Create(code *models.PromoCode) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	event, ok := m.s.events[code.EventID]
+	if !ok {
+		return ErrEventNotFound
+	}
+	if m.s.promoCodeTaken(code) {
+		return ErrPromoCodeExists
+	}
+	now := time.Now()
+	if err := m.s.checkSeatBlock(code, nil, event.Capacity, now); err != nil {
+		return err
+	}
+
+	code.ID = m.s.nextID()
+	code.CreatedAt = now
+	code.UpdatedAt = now
+	code.Uses = 0
+	code.SeatsTaken = 0
+	stored := *code
+	m.s.promoCodes[code.ID] = &stored
+	return nil
+}
+
+func (m memoryPromoCodes) GetByID(id int) (*models.PromoCode, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	code, ok := m.s.promoCodes[id]
+	if !ok {
+		return nil, ErrPromoCodeNotFound
+	}
+	return m.s.promoCodeCopy(code), nil
+}
+
+func (m memoryPromoCodes) GetByEvent(eventID int) ([]models.PromoCode, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	codes := []models.PromoCode{}
+	for _, code := range m.s.eventPromoCodes(eventID) {
+		codes = append(codes, *m.s.promoCodeCopy(code))
+	}
+	return codes, nil
+}
+
+// Update follows the rules of PromoCodeRepository.Update
+func (m memoryPromoCodes) Update(code *models.PromoCode) ([]models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	event, ok := m.s.events[code.EventID]
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+	if m.s.promoCodeTaken(code) {
+		return nil, ErrPromoCodeExists
+	}
+	stored, ok := m.s.promoCodes[code.ID]
+	if !ok || stored.EventID != code.EventID {
+		return nil, ErrPromoCodeNotFound
+	}
+	if err := m.s.checkSeatBlock(code, m.s.promoCodeCopy(stored), event.Capacity, time.Now()); err != nil {
+		return nil, err
+	}
+
+	code.CreatedAt = stored.CreatedAt
+	code.UpdatedAt = time.Now()
+	*stored = *code
+
+	promoted := m.s.promoteWaitlisted(event.ID, event.Capacity)
+	*code = *m.s.promoCodeCopy(stored)
+	return promoted, nil
+}
+
+func (m memoryPromoCodes) Delete(eventID, id int) ([]models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	event, ok := m.s.events[eventID]
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+	for _, redemption := range m.s.redemptions {
+		if redemption.codeID == id {
+			return nil, ErrPromoCodeInUse
+		}
+	}
+	code, ok := m.s.promoCodes[id]
+	if !ok || code.EventID != eventID {
+		return nil, ErrPromoCodeNotFound
+	}
+
+	delete(m.s.promoCodes, id)
+	return m.s.promoteWaitlisted(event.ID, event.Capacity), nil
+}
+
+func (m memoryPromoCodes) GetRedemptions(codeID int) ([]models.PromoRedemptionResponse, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	list := []*memoryRedemption{}
+	for _, redemption := range m.s.redemptions {
+		if redemption.codeID == codeID {
+			list = append(list, redemption)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].id < list[j].id })
+
+	redemptions := []models.PromoRedemptionResponse{}
+	for _, redemption := range list {
+		user, ok := m.s.users[redemption.userID]
+		if !ok {
+			continue
+		}
+		response := models.PromoRedemptionResponse{
+			User:       userResponse(user),
+			Discount:   redemption.discount,
+			RedeemedAt: redemption.redeemedAt,
+			ReleasedAt: redemption.releasedAt,
+		}
+		p := m.s.findParticipant(redemption.userID, redemption.eventID)
+		if redemption.releasedAt == nil && p != nil && p.PromoCodeID != nil && *p.PromoCodeID == codeID {
+			response.Status = p.Status
+		}
+		redemptions = append(redemptions, response)
+	}
+	return redemptions, nil
+}
+
+// eventPromoCodes returns the promo codes of an event, oldest first
+func (s *MemoryStore) eventPromoCodes(eventID int) []*models.PromoCode {
+	list := []*models.PromoCode{}
+	for _, code := range s.promoCodes {
+		if code.EventID == eventID {
+			list = append(list, code)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// promoCodeTaken reports whether another promo code of the same event has the code
+func (s *MemoryStore) promoCodeTaken(code *models.PromoCode) bool {
+	for _, other := range s.eventPromoCodes(code.EventID) {
+		if other.ID != code.ID && other.Code == code.Code {
+			return true
+		}
+	}
+	return false
+}
+
+// promoCodeCopy returns a copy of a stored code with the counts promoCodeColumns selects
+func (s *MemoryStore) promoCodeCopy(code *models.PromoCode) *models.PromoCode {
+	found := *code
+	found.Uses = 0
+	for _, redemption := range s.redemptions {
+		if redemption.codeID == code.ID && redemption.releasedAt == nil {
+			found.Uses++
+		}
+	}
+	found.SeatsTaken = s.promoCodeSeats(code.ID)
+	return &found
+}
+
+// promoCodeSeats counts the seats taken by participants who joined with a code
+func (s *MemoryStore) promoCodeSeats(codeID int) int {
+	taken := 0
+	for _, p := range s.participants {
+		seated := p.Status == models.ParticipantStatusConfirmed || p.Status == models.ParticipantStatusReserved
+		if p.PromoCodeID != nil && *p.PromoCodeID == codeID && seated {
+			taken++
+		}
+	}
+	return taken
+}
+
+// redeemablePromoCode runs the checks of redeemPromoCode. There is no
+// rollback here, so JoinEvent records the redemption with recordRedemption
+// once nothing else can fail.
+func (s *MemoryStore) redeemablePromoCode(eventID, userID int, value string, now time.Time) (*models.PromoCode, error) {
+	var code *models.PromoCode
+	for _, candidate := range s.eventPromoCodes(eventID) {
+		if candidate.Code == value {
+			code = s.promoCodeCopy(candidate)
+		}
+	}
+	if code == nil {
+		return nil, ErrPromoCodeNotFound
+	}
+
+	redeemed := 0
+	for _, redemption := range s.redemptions {
+		if redemption.codeID == code.ID && redemption.userID == userID {
+			redeemed++
+		}
+	}
+	if err := CheckPromoCode(code, redeemed, now); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// recordRedemption stores a use of a promo code
+func (s *MemoryStore) recordRedemption(codeID, eventID, userID int, now time.Time) {
+	redemption := &memoryRedemption{id: s.nextID(), codeID: codeID, eventID: eventID, userID: userID, redeemedAt: now}
+	s.redemptions[redemption.id] = redemption
+}
+
+// releaseRedemption mirrors the repository function of the same name
+func (s *MemoryStore) releaseRedemption(eventID, userID int, now time.Time) {
+	for _, redemption := range s.redemptions {
+		if redemption.eventID == eventID && redemption.userID == userID && redemption.releasedAt == nil {
+			releasedAt := now
+			redemption.releasedAt = &releasedAt
+		}
+	}
+}
+
+// checkSeatBlock mirrors the repository function of the same name
+func (s *MemoryStore) checkSeatBlock(code, current *models.PromoCode, capacity int, now time.Time) error {
+	block, seated := activeBlock(code, now), 0
+	if current != nil {
+		if block <= activeBlock(current, now) {
+			return nil
+		}
+		seated = current.SeatsTaken
+	}
+	if block <= seated {
+		return nil
+	}
+
+	if s.seatsTaken(code.EventID)+seatsHeldFor(s.heldSeats(code.EventID, now), &code.ID)+block-seated > capacity {
+		return ErrPromoSeatsAboveCapacity
+	}
+	return nil
+}
+
+// heldSeats mirrors the repository function of the same name
+func (s *MemoryStore) heldSeats(eventID int, now time.Time) map[int]int {
+	held := map[int]int{}
+	for _, code := range s.eventPromoCodes(eventID) {
+		if code.ReservedSeats == 0 || (code.ExpiresAt != nil && !code.ExpiresAt.After(now)) {
+			continue
+		}
+		if seats := code.ReservedSeats - s.promoCodeSeats(code.ID); seats > 0 {
+			held[code.ID] = seats
+		}
+	}
+	return held
+}
+
+// redemptionDiscount mirrors the repository function of the same name
+func (s *MemoryStore) redemptionDiscount(p *models.Participant, price int) int {
+	code, ok := s.promoCodes[*p.PromoCodeID]
+	if !ok {
+		return 0
+	}
+	discount := promoDiscount(code.DiscountType, code.DiscountValue, price)
+	for _, redemption := range s.redemptions {
+		if redemption.codeID == code.ID && redemption.eventID == p.EventID && redemption.userID == p.UserID && redemption.releasedAt == nil {
+			redemption.discount = discount
+		}
+	}
+	return discount
+}
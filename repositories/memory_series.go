@@ -0,0 +1,370 @@
+package repositories
+
+import (
+	"sort"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// memorySeries implements SeriesStore
+type memorySeries struct{ s *MemoryStore }
+
+// copySeries copies a series including its exception dates
+func copySeries(series *models.EventSeries) *models.EventSeries {
+	copied := *series
+	copied.ExDates = append([]time.Time{}, series.ExDates...)
+	return &copied
+}
+
+// excludeOccurrence mirrors the repository function of the same name
+func (s *MemoryStore) excludeOccurrence(event *models.Event) {
+	if event.SeriesID == nil || event.RecurrenceID == nil {
+		return
+	}
+	if series, ok := s.series[*event.SeriesID]; ok {
+		series.ExDates = append(series.ExDates, *event.RecurrenceID)
+		series.UpdatedAt = time.Now()
+	}
+}
+
+// occurrences returns the occurrences of a series in start time order
+func (s *MemoryStore) occurrences(seriesID int) []*models.Event {
+	list := []*models.Event{}
+	for _, event := range s.events {
+		if event.SeriesID != nil && *event.SeriesID == seriesID {
+			list = append(list, event)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].StartTime.Equal(list[j].StartTime) {
+			return list[i].StartTime.Before(list[j].StartTime)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// createEvent stores a new event like memoryEvents.Create
+func (s *MemoryStore) createEvent(event *models.Event) {
+	now := time.Now()
+	event.ID = s.nextID()
+	event.CreatedAt = now
+	event.UpdatedAt = now
+	if event.Status == "" {
+		event.Status = models.EventStatusPublished
+	}
+	if event.Visibility == "" {
+		event.Visibility = models.VisibilityPublic
+	}
+
+	stored := *event
+	s.events[event.ID] = &stored
+}
+
+func (m memorySeries) Create(series *models.EventSeries, occurrences []models.Event) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	now := time.Now()
+	series.ID = m.s.nextID()
+	series.CreatedAt = now
+	series.UpdatedAt = now
+	m.s.series[series.ID] = copySeries(series)
+
+	for i := range occurrences {
+		seriesID := series.ID
+		occurrences[i].SeriesID = &seriesID
+		m.s.createEvent(&occurrences[i])
+	}
+	return nil
+}
+
+func (m memorySeries) GetByID(id int) (*models.EventSeries, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	series, ok := m.s.series[id]
+	if !ok {
+		return nil, ErrSeriesNotFound
+	}
+	return copySeries(series), nil
+}
+
+func (m memorySeries) GetOccurrences(seriesID int) ([]models.Event, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	events := []models.Event{}
+	for _, event := range m.s.occurrences(seriesID) {
+		events = append(events, *event)
+	}
+	return events, nil
+}
+
+// Save mirrors SeriesRepository.Save. Every check runs before anything is
+// written, which stands in for the rollback of the transaction.
+func (m memorySeries) Save(change *models.SeriesChange) ([]models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if change.Split != nil {
+		if _, ok := m.s.series[change.Split.ID]; !ok {
+			return nil, ErrSeriesNotFound
+		}
+	}
+	series := change.Series
+	if series.ID != 0 {
+		if _, ok := m.s.series[series.ID]; !ok {
+			return nil, ErrSeriesNotFound
+		}
+	}
+
+	// Occurrences that are still in the old series until the split is done
+	seriesOf := func(event *models.Event) int {
+		if change.Split != nil && event.SeriesID != nil && *event.SeriesID == change.Split.ID &&
+			event.RecurrenceID != nil && !event.RecurrenceID.Before(change.SplitAt) {
+			return series.ID
+		}
+		if event.SeriesID == nil {
+			return 0
+		}
+		return *event.SeriesID
+	}
+	for _, id := range change.Remove {
+		event, ok := m.s.events[id]
+		if !ok || seriesOf(event) != series.ID {
+			return nil, ErrEventNotFound
+		}
+		if len(m.s.eventParticipants(id, "")) > 0 {
+			return nil, ErrOccurrenceHasParticipants
+		}
+	}
+	for _, event := range change.Update {
+		stored, ok := m.s.events[event.ID]
+		if !ok || stored.OrganizerID != event.OrganizerID {
+			return nil, ErrEventNotOwned
+		}
+		if err := m.s.checkCapacity(event.ID, event.Capacity, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if change.Split != nil {
+		change.Split.UpdatedAt = now
+		m.s.series[change.Split.ID] = copySeries(change.Split)
+	}
+	if series.ID == 0 {
+		series.ID = m.s.nextID()
+		series.CreatedAt = now
+	}
+	series.UpdatedAt = now
+	m.s.series[series.ID] = copySeries(series)
+
+	if change.Split != nil {
+		for _, event := range m.s.occurrences(change.Split.ID) {
+			if seriesOf(event) != series.ID {
+				continue
+			}
+			seriesID := series.ID
+			event.SeriesID = &seriesID
+			for _, p := range m.s.eventParticipants(event.ID, "") {
+				if p.SeriesID != nil && *p.SeriesID == change.Split.ID {
+					p.SeriesID = &seriesID
+				}
+			}
+		}
+	}
+
+	for _, id := range change.Remove {
+		m.s.deleteEvent(id)
+	}
+
+	promoted := []models.Participant{}
+	for i := range change.Update {
+		event := &change.Update[i]
+		seriesID := series.ID
+		event.SeriesID = &seriesID
+		event.UpdatedAt = now
+
+		stored := m.s.events[event.ID]
+		event.CreatedAt = stored.CreatedAt
+		event.Status = stored.Status
+		*stored = *event
+		promoted = append(promoted, m.s.promoteWaitlisted(event.ID, event.Capacity)...)
+	}
+
+	members := m.s.seriesMembers(series.ID)
+	for i := range change.Create {
+		event := &change.Create[i]
+		seriesID := series.ID
+		event.SeriesID = &seriesID
+		m.s.createEvent(event)
+		for _, userID := range members {
+			m.s.addParticipant(userID, m.s.events[event.ID], &seriesID, nil, nil)
+		}
+	}
+
+	return promoted, nil
+}
+
+func (m memorySeries) GetIDs() ([]int, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	ids := []int{}
+	for id := range m.s.series {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (m memorySeries) AddOccurrences(series *models.EventSeries, occurrences []models.Event) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	stored, ok := m.s.series[series.ID]
+	if !ok {
+		return ErrSeriesNotFound
+	}
+	if !stored.UpdatedAt.Equal(series.UpdatedAt) {
+		return ErrSeriesChanged
+	}
+
+	members := m.s.seriesMembers(series.ID)
+	for i := range occurrences {
+		event := &occurrences[i]
+		seriesID := series.ID
+		event.SeriesID = &seriesID
+		m.s.createEvent(event)
+		for _, userID := range members {
+			m.s.addParticipant(userID, m.s.events[event.ID], &seriesID, nil, nil)
+		}
+	}
+	return nil
+}
+
+// seriesMembers returns the users who joined a series as a whole, in the order they joined
+func (s *MemoryStore) seriesMembers(seriesID int) []int {
+	first := map[int]int{}
+	for _, p := range s.participants {
+		if p.SeriesID == nil || *p.SeriesID != seriesID {
+			continue
+		}
+		if id, ok := first[p.UserID]; !ok || p.ID < id {
+			first[p.UserID] = p.ID
+		}
+	}
+
+	members := []int{}
+	for userID := range first {
+		members = append(members, userID)
+	}
+	sort.Slice(members, func(i, j int) bool { return first[members[i]] < first[members[j]] })
+	return members
+}
+
+func (m memorySeries) Delete(id int, organizerID int) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	series, ok := m.s.series[id]
+	if !ok {
+		return ErrSeriesNotFound
+	}
+	if series.OrganizerID != organizerID {
+		return ErrNotSeriesOwner
+	}
+
+	occurrences := m.s.occurrences(id)
+	for _, event := range occurrences {
+		if len(m.s.eventParticipants(event.ID, "")) > 0 {
+			return ErrEventHasParticipants
+		}
+	}
+
+	for _, event := range occurrences {
+		m.s.deleteEvent(event.ID)
+	}
+	delete(m.s.series, id)
+	return nil
+}
+
+// JoinSeries runs the same checks in the same order as SeriesRepository.JoinSeries
+func (m memorySeries) JoinSeries(userID, seriesID int) ([]models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.series[seriesID]; !ok {
+		return nil, ErrSeriesNotFound
+	}
+
+	now := time.Now()
+	open := []*models.Event{}
+	for _, event := range m.s.occurrences(seriesID) {
+		if CheckRegistration(event, now) == nil && event.Visibility != models.VisibilityPrivate && !event.RequiresApproval &&
+			len(m.s.eventTicketTypes(event.ID)) == 0 {
+			open = append(open, event)
+		}
+	}
+	if len(open) == 0 {
+		return nil, ErrEventNotOpen
+	}
+
+	if _, ok := m.s.users[userID]; !ok {
+		return nil, ErrUserNotFound
+	}
+	for _, p := range m.s.participants {
+		if p.UserID == userID && p.SeriesID != nil && *p.SeriesID == seriesID {
+			return nil, ErrAlreadyParticipant
+		}
+	}
+	if m.s.activeEventCount(userID) >= 5 {
+		return nil, ErrActiveEventLimit
+	}
+
+	participants := []models.Participant{}
+	for _, event := range open {
+		if m.s.findParticipant(userID, event.ID) != nil {
+			continue
+		}
+		id := seriesID
+		participants = append(participants, *m.s.addParticipant(userID, event, &id, nil, nil))
+	}
+
+	if len(participants) == 0 {
+		return nil, ErrAlreadyParticipant
+	}
+	return participants, nil
+}
+
+func (m memorySeries) LeaveSeries(userID, seriesID int) ([]models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.series[seriesID]; !ok {
+		return nil, ErrSeriesNotFound
+	}
+
+	now := time.Now()
+	left := []*models.Event{}
+	for _, event := range m.s.occurrences(seriesID) {
+		p := m.s.findParticipant(userID, event.ID)
+		if p == nil || p.SeriesID == nil || *p.SeriesID != seriesID || !event.StartTime.After(now) {
+			continue
+		}
+		delete(m.s.participants, p.ID)
+		left = append(left, event)
+	}
+
+	if len(left) == 0 {
+		return nil, ErrNotParticipant
+	}
+	promoted := []models.Participant{}
+	for _, event := range left {
+		promoted = append(promoted, m.s.promoteWaitlisted(event.ID, event.Capacity)...)
+	}
+	return promoted, nil
+}
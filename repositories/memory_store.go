@@ -0,0 +1,455 @@
+package repositories
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+)
+
+// MemoryStore keeps users, events, series, participants, staff, invites, ticket types, promo codes, orders and tokens in memory.
+// It follows the same rules as the PostgreSQL repositories (capacity, waitlist,
+// uniqueness, ownership) and returns the same errors, so services can be tested
+// without a database. A single mutex plays the role of the row locks.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	users        map[int]*models.User
+	events       map[int]*models.Event
+	series       map[int]*models.EventSeries
+	participants map[int]*models.Participant
+	staff        map[staffKey]*models.EventStaff
+	invites      map[int]*models.EventInvite
+	ticketTypes  map[int]*models.TicketType
+	promoCodes   map[int]*models.PromoCode
+	redemptions  map[int]*memoryRedemption
+	orders       map[int]*models.Order
+	tokens       map[string]*models.RefreshToken // by token hash
+	revoked      map[string]time.Time            // access token jti -> expiry
+	calendar     map[int]string                  // user ID -> calendar feed token hash
+
+	lastID int
+}
+
+type staffKey struct {
+	eventID int
+	userID  int
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:        map[int]*models.User{},
+		events:       map[int]*models.Event{},
+		series:       map[int]*models.EventSeries{},
+		participants: map[int]*models.Participant{},
+		staff:        map[staffKey]*models.EventStaff{},
+		invites:      map[int]*models.EventInvite{},
+		ticketTypes:  map[int]*models.TicketType{},
+		promoCodes:   map[int]*models.PromoCode{},
+		redemptions:  map[int]*memoryRedemption{},
+		orders:       map[int]*models.Order{},
+		tokens:       map[string]*models.RefreshToken{},
+		revoked:      map[string]time.Time{},
+		calendar:     map[int]string{},
+	}
+}
+
+// Users returns the user store
+func (s *MemoryStore) Users() UserStore { return memoryUsers{s} }
+
+// Events returns the event store
+func (s *MemoryStore) Events() EventStore { return memoryEvents{s} }
+
+// Series returns the series store
+func (s *MemoryStore) Series() SeriesStore { return memorySeries{s} }
+
+// Participants returns the participant store
+func (s *MemoryStore) Participants() ParticipantStore { return memoryParticipants{s} }
+
+// Staff returns the staff store
+func (s *MemoryStore) Staff() StaffStore { return memoryStaff{s} }
+
+// Invites returns the invite store
+func (s *MemoryStore) Invites() InviteStore { return memoryInvites{s} }
+
+// TicketTypes returns the ticket type store
+func (s *MemoryStore) TicketTypes() TicketTypeStore { return memoryTicketTypes{s} }
+
+// PromoCodes returns the promo code store
+func (s *MemoryStore) PromoCodes() PromoCodeStore { return memoryPromoCodes{s} }
+
+// Orders returns the order store
+func (s *MemoryStore) Orders() OrderStore { return memoryOrders{s} }
+
+// Tokens returns the token store
+func (s *MemoryStore) Tokens() TokenStore { return memoryTokens{s} }
+
+// nextID hands out increasing IDs like a SERIAL column
+func (s *MemoryStore) nextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+// eventParticipants returns the participants of an event in insertion order
+func (s *MemoryStore) eventParticipants(eventID int, status string) []*models.Participant {
+	list := []*models.Participant{}
+	for _, p := range s.participants {
+		if p.EventID == eventID && (status == "" || p.Status == status) {
+			list = append(list, p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// findParticipant returns the participant row of a user in an event, if any
+func (s *MemoryStore) findParticipant(userID, eventID int) *models.Participant {
+	for _, p := range s.participants {
+		if p.UserID == userID && p.EventID == eventID {
+			return p
+		}
+	}
+	return nil
+}
+
+// waitlist returns the waitlisted participants of an event in queue order
+func (s *MemoryStore) waitlist(eventID int) []*models.Participant {
+	list := s.eventParticipants(eventID, models.ParticipantStatusWaitlisted)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].WaitlistPosition < list[j].WaitlistPosition
+	})
+	return list
+}
+
+// waitlistRank is the 1-based place of a stored waitlist position in the queue
+func (s *MemoryStore) waitlistRank(eventID, position int) int {
+	rank := 0
+	for _, p := range s.eventParticipants(eventID, models.ParticipantStatusWaitlisted) {
+		if p.WaitlistPosition <= position {
+			rank++
+		}
+	}
+	return rank
+}
+
+// seatsTaken mirrors the repository function of the same name
+func (s *MemoryStore) seatsTaken(eventID int) int {
+	return len(s.eventParticipants(eventID, models.ParticipantStatusConfirmed)) +
+		len(s.eventParticipants(eventID, models.ParticipantStatusReserved))
+}
+
+// checkCapacity mirrors the repository function of the same name
+func (s *MemoryStore) checkCapacity(eventID, capacity int, now time.Time) error {
+	if needed := s.seatsTaken(eventID) + seatsHeldFor(s.heldSeats(eventID, now), nil); capacity < needed {
+		return apperrors.Conflict(ErrCapacityBelowTaken.Code, fmt.Sprintf("%s: %d needed", ErrCapacityBelowTaken.Message, needed))
+	}
+	return nil
+}
+
+// promoteWaitlisted moves users from the head of the waitlist into free
+// seats, skipping users whose ticket type is sold out and keeping seats held
+// by promo codes for their holders. Users of priced ticket types get a
+// reservation for promotionHold. It returns copies of the promoted
+// participants.
+func (s *MemoryStore) promoteWaitlisted(eventID, capacity int) []models.Participant {
+	free := capacity - s.seatsTaken(eventID)
+	now := time.Now()
+	held := s.heldSeats(eventID, now)
+	open := free - seatsHeldFor(held, nil)
+	promoted := []models.Participant{}
+	for _, p := range s.waitlist(eventID) {
+		if free <= 0 {
+			break
+		}
+		if p.TicketTypeID != nil && s.ticketTypeFull(*p.TicketTypeID) {
+			continue
+		}
+		switch {
+		case p.PromoCodeID != nil && held[*p.PromoCodeID] > 0:
+			held[*p.PromoCodeID]--
+		case open > 0:
+			open--
+		default:
+			continue
+		}
+		p.Status = models.ParticipantStatusConfirmed
+		p.WaitlistPosition = 0
+		s.reserveSeat(p, now, promotionHold)
+		promoted = append(promoted, *p)
+		free--
+	}
+	return promoted
+}
+
+// userResponse converts a stored user to the public response format
+func userResponse(user *models.User) models.UserResponse {
+	return models.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// memoryUsers implements UserStore
+type memoryUsers struct{ s *MemoryStore }
+
+func (m memoryUsers) Create(user *models.User) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	for _, existing := range m.s.users {
+		if existing.Username == user.Username || existing.Email == user.Email {
+			return ErrUserExists
+		}
+	}
+
+	now := time.Now()
+	user.ID = m.s.nextID()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+
+	stored := *user
+	m.s.users[user.ID] = &stored
+	return nil
+}
+
+func (m memoryUsers) GetByID(id int) (*models.User, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	user, ok := m.s.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+func (m memoryUsers) GetByEmail(email string) (*models.User, error) {
+	return m.find(func(user *models.User) bool { return user.Email == email })
+}
+
+func (m memoryUsers) GetByUsername(username string) (*models.User, error) {
+	return m.find(func(user *models.User) bool { return user.Username == username })
+}
+
+func (m memoryUsers) find(match func(user *models.User) bool) (*models.User, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	for _, user := range m.s.users {
+		if match(user) {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (m memoryUsers) List(limit, offset int) ([]models.User, int, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	users := make([]models.User, 0, len(m.s.users))
+	for _, user := range m.s.users {
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	total := len(users)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	return users[offset:end], total, nil
+}
+
+func (m memoryUsers) SetRole(id int, role string) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	user, ok := m.s.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+func (m memoryUsers) SetSuspended(id int, suspended bool) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	user, ok := m.s.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	now := time.Now()
+	user.SuspendedAt = nil
+	if suspended {
+		user.SuspendedAt = &now
+	}
+	user.UpdatedAt = now
+	return nil
+}
+
+// memoryTokens implements TokenStore
+type memoryTokens struct{ s *MemoryStore }
+
+func (m memoryTokens) CreateRefreshToken(token *models.RefreshToken) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	token.ID = m.s.nextID()
+	token.CreatedAt = time.Now()
+	stored := *token
+	m.s.tokens[token.TokenHash] = &stored
+	return nil
+}
+
+func (m memoryTokens) RotateRefreshToken(hash string, next *models.RefreshToken, accessExpiresAt time.Time) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	old, ok := m.s.tokens[hash]
+	if !ok {
+		return ErrInvalidRefreshToken
+	}
+	next.UserID = old.UserID
+	next.FamilyID = old.FamilyID
+
+	if old.RevokedAt != nil {
+		m.revoke(func(token *models.RefreshToken) bool { return token.FamilyID == old.FamilyID }, accessExpiresAt)
+		return ErrRefreshTokenReused
+	}
+	if time.Now().After(old.ExpiresAt) {
+		return ErrInvalidRefreshToken
+	}
+
+	next.ID = m.s.nextID()
+	next.CreatedAt = time.Now()
+	stored := *next
+	m.s.tokens[next.TokenHash] = &stored
+
+	revokedAt := next.CreatedAt
+	old.RevokedAt = &revokedAt
+	return nil
+}
+
+func (m memoryTokens) RevokeFamilyByToken(userID int, hash string, accessExpiresAt time.Time) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	token, ok := m.s.tokens[hash]
+	if !ok || token.UserID != userID {
+		return ErrInvalidRefreshToken
+	}
+
+	familyID := token.FamilyID
+	m.revoke(func(token *models.RefreshToken) bool { return token.FamilyID == familyID }, accessExpiresAt)
+	return nil
+}
+
+func (m memoryTokens) RevokeAllForUser(userID int, accessExpiresAt time.Time) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	m.revoke(func(token *models.RefreshToken) bool { return token.UserID == userID }, accessExpiresAt)
+	return nil
+}
+
+// revoke revokes the matching refresh tokens and the access tokens issued with them
+func (m memoryTokens) revoke(match func(token *models.RefreshToken) bool, accessExpiresAt time.Time) {
+	now := time.Now()
+	for _, token := range m.s.tokens {
+		if !match(token) {
+			continue
+		}
+		if token.AccessTokenID != "" {
+			if _, ok := m.s.revoked[token.AccessTokenID]; !ok {
+				m.s.revoked[token.AccessTokenID] = accessExpiresAt
+			}
+		}
+		if token.RevokedAt == nil {
+			revokedAt := now
+			token.RevokedAt = &revokedAt
+		}
+	}
+}
+
+func (m memoryTokens) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.revoked[jti]; !ok {
+		m.s.revoked[jti] = expiresAt
+	}
+	return nil
+}
+
+func (m memoryTokens) IsAccessTokenRevoked(jti string) (bool, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	_, ok := m.s.revoked[jti]
+	return ok, nil
+}
+
+func (m memoryTokens) DeleteExpired() error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	now := time.Now()
+	for jti, expiresAt := range m.s.revoked {
+		if expiresAt.Before(now) {
+			delete(m.s.revoked, jti)
+		}
+	}
+	for hash, token := range m.s.tokens {
+		if token.ExpiresAt.Before(now) {
+			delete(m.s.tokens, hash)
+		}
+	}
+	return nil
+}
+
+func (m memoryTokens) SetCalendarToken(userID int, hash string) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	m.s.calendar[userID] = hash
+	return nil
+}
+
+func (m memoryTokens) GetCalendarTokenUser(hash string) (int, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	for userID, stored := range m.s.calendar {
+		if stored == hash {
+			return userID, nil
+		}
+	}
+	return 0, ErrInvalidCalendarToken
+}
+
+func (m memoryTokens) DeleteCalendarToken(userID int) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	delete(m.s.calendar, userID)
+	return nil
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+func createMemoryEvent(t *testing.T, store *MemoryStore, name, location string, capacity int) *models.Event {
+	t.Helper()
+
+	start := time.Now().Add(24 * time.Hour)
+	event := &models.Event{
+		Name:      name,
+		Location:  location,
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		Capacity:  capacity,
+	}
+	if err := store.Events().Create(event); err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	return event
+}
+
+func TestMemoryUserUniqueness(t *testing.T) {
+	store := NewMemoryStore()
+
+	if err := store.Users().Create(&models.User{Username: "alice", Email: "alice@example.com"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	err := store.Users().Create(&models.User{Username: "alice", Email: "other@example.com"})
+	if !errors.Is(err, ErrUserExists) {
+		t.Errorf("duplicate username: got %v, want ErrUserExists", err)
+	}
+}
+
+func TestMemoryListFilters(t *testing.T) {
+	store := NewMemoryStore()
+	user := &models.User{Username: "alice", Email: "alice@example.com"}
+	if err := store.Users().Create(user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	full := createMemoryEvent(t, store, "Full", "Tehran", 1)
+	createMemoryEvent(t, store, "Free", "Tehran", 5)
+	createMemoryEvent(t, store, "Elsewhere", "Shiraz", 5)
+	if _, err := store.Participants().JoinEvent(user.ID, full.ID, models.JoinOptions{}); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	page, err := store.Events().GetAllPublic(models.EventListQuery{
+		EventFilter: models.EventFilter{Location: "tehr", HasSeats: true},
+	})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if page.Total != 1 || page.Events[0].Name != "Free" {
+		t.Errorf("got %+v, want only the event with free seats in Tehran", page.Events)
+	}
+
+	if _, err := store.Events().GetAllPublic(models.EventListQuery{Sort: "name"}); !errors.Is(err, ErrInvalidSort) {
+		t.Errorf("bad sort: got %v, want ErrInvalidSort", err)
+	}
+	if _, err := store.Events().GetAllPublic(models.EventListQuery{Cursor: "!"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("bad cursor: got %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestMemorySearch(t *testing.T) {
+	store := NewMemoryStore()
+	inLocation := createMemoryEvent(t, store, "Meetup", "Golang House", 5)
+	inName := createMemoryEvent(t, store, "Golang Meetup", "Tehran", 5)
+	createMemoryEvent(t, store, "Rust Meetup", "Tehran", 5)
+
+	hits, total, err := store.Events().Search(models.EventSearchQuery{Text: "go meet"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if total != 2 {
+		t.Fatalf("total = %d, want 2", total)
+	}
+	if hits[0].Event.ID != inName.ID || hits[1].Event.ID != inLocation.ID {
+		t.Errorf("order = [%d %d], want the name match first", hits[0].Event.ID, hits[1].Event.ID)
+	}
+	if hits[0].Highlights.Name != "<mark>Golang</mark> <mark>Meetup</mark>" {
+		t.Errorf("highlight = %q", hits[0].Highlights.Name)
+	}
+
+	// The text around the marks is escaped, so highlights are safe to render as HTML
+	inDescription := createMemoryEvent(t, store, "Workshop", "Tehran", 5)
+	inDescription.Description = `Learn <script>alert("xss")</script> scripting & more`
+	if _, err := store.Events().Update(inDescription); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	hits, _, err = store.Events().Search(models.EventSearchQuery{Text: "script"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	want := `Learn &lt;<mark>script</mark>&gt;alert(&#34;xss&#34;)&lt;/<mark>script</mark>&gt; <mark>scripting</mark> &amp; more`
+	if len(hits) != 1 || hits[0].Highlights.Description != want {
+		t.Errorf("got %+v, want the description highlight %q", hits, want)
+	}
+
+	if _, _, err := store.Events().Search(models.EventSearchQuery{Text: "  !!"}); !errors.Is(err, ErrEmptySearch) {
+		t.Errorf("empty search: got %v, want ErrEmptySearch", err)
+	}
+}
@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+)
+
+// memoryTicketTypes implements TicketTypeStore
+type memoryTicketTypes struct{ s *MemoryStore }
+
+func (m memoryTicketTypes) Create(ticketType *models.TicketType) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if m.s.ticketTypeNameTaken(ticketType) {
+		return ErrTicketTypeExists
+	}
+
+	now := time.Now()
+	ticketType.ID = m.s.nextID()
+	ticketType.CreatedAt = now
+	ticketType.UpdatedAt = now
+	ticketType.Sold = 0
+	stored := *ticketType
+	m.s.ticketTypes[ticketType.ID] = &stored
+	return nil
+}
+
+func (m memoryTicketTypes) GetByID(id int) (*models.TicketType, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	ticketType, ok := m.s.ticketTypes[id]
+	if !ok {
+		return nil, ErrTicketTypeNotFound
+	}
+	found := *ticketType
+	found.Sold = m.s.ticketTypeSold(id)
+	return &found, nil
+}
+
+func (m memoryTicketTypes) GetByEvent(eventID int) ([]models.TicketType, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	ticketTypes := []models.TicketType{}
+	for _, ticketType := range m.s.eventTicketTypes(eventID) {
+		found := *ticketType
+		found.Sold = m.s.ticketTypeSold(ticketType.ID)
+		ticketTypes = append(ticketTypes, found)
+	}
+	return ticketTypes, nil
+}
+
+// Update follows the rules of TicketTypeRepository.Update
+func (m memoryTicketTypes) Update(ticketType *models.TicketType) ([]models.Participant, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	event, ok := m.s.events[ticketType.EventID]
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+	sold := m.s.ticketTypeSold(ticketType.ID)
+	if ticketType.Quota < sold {
+		return nil, apperrors.Conflict(ErrQuotaBelowSold.Code, fmt.Sprintf("%s: %d sold", ErrQuotaBelowSold.Message, sold))
+	}
+	stored, ok := m.s.ticketTypes[ticketType.ID]
+	if !ok || stored.EventID != ticketType.EventID {
+		return nil, ErrTicketTypeNotFound
+	}
+	if m.s.ticketTypeNameTaken(ticketType) {
+		return nil, ErrTicketTypeExists
+	}
+
+	ticketType.CreatedAt = stored.CreatedAt
+	ticketType.UpdatedAt = time.Now()
+	*stored = *ticketType
+
+	promoted := m.s.promoteWaitlisted(event.ID, event.Capacity)
+	ticketType.Sold = m.s.ticketTypeSold(ticketType.ID)
+	return promoted, nil
+}
+
+func (m memoryTicketTypes) Delete(eventID, id int) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.events[eventID]; !ok {
+		return ErrEventNotFound
+	}
+	for _, p := range m.s.participants {
+		if p.TicketTypeID != nil && *p.TicketTypeID == id {
+			return ErrTicketTypeInUse
+		}
+	}
+	for _, code := range m.s.promoCodes {
+		if code.TicketTypeID != nil && *code.TicketTypeID == id {
+			return ErrTicketTypeHasPromoCodes
+		}
+	}
+	ticketType, ok := m.s.ticketTypes[id]
+	if !ok || ticketType.EventID != eventID {
+		return ErrTicketTypeNotFound
+	}
+
+	delete(m.s.ticketTypes, id)
+	return nil
+}
+
+// eventTicketTypes returns the ticket types of an event, oldest first
+func (s *MemoryStore) eventTicketTypes(eventID int) []*models.TicketType {
+	list := []*models.TicketType{}
+	for _, ticketType := range s.ticketTypes {
+		if ticketType.EventID == eventID {
+			list = append(list, ticketType)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// ticketTypeNameTaken reports whether another ticket type of the same event has the name
+func (s *MemoryStore) ticketTypeNameTaken(ticketType *models.TicketType) bool {
+	for _, other := range s.eventTicketTypes(ticketType.EventID) {
+		if other.ID != ticketType.ID && other.Name == ticketType.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// ticketTypeSold mirrors the repository function of the same name
+func (s *MemoryStore) ticketTypeSold(id int) int {
+	sold := 0
+	for _, p := range s.participants {
+		taken := p.Status == models.ParticipantStatusConfirmed || p.Status == models.ParticipantStatusReserved
+		if p.TicketTypeID != nil && *p.TicketTypeID == id && taken {
+			sold++
+		}
+	}
+	return sold
+}
+
+// ticketTypeFull mirrors the repository function of the same name
+func (s *MemoryStore) ticketTypeFull(id int) bool {
+	ticketType, ok := s.ticketTypes[id]
+	return ok && s.ticketTypeSold(id) >= ticketType.Quota
+}
+
+// chooseTicketType mirrors the repository function of the same name
+func (s *MemoryStore) chooseTicketType(eventID, ticketTypeID int, unlocked *int, now time.Time) (*int, error) {
+	if unlocked != nil {
+		if ticketTypeID != 0 && ticketTypeID != *unlocked {
+			return nil, ErrPromoCodeNotApplicable
+		}
+		ticketTypeID = *unlocked
+	}
+	if ticketTypeID == 0 {
+		if len(s.eventTicketTypes(eventID)) > 0 {
+			return nil, ErrTicketTypeRequired
+		}
+		return nil, nil
+	}
+
+	ticketType, ok := s.ticketTypes[ticketTypeID]
+	if !ok || ticketType.EventID != eventID || (ticketType.Visibility == models.TicketTypeHidden && unlocked == nil) {
+		return nil, ErrTicketTypeNotFound
+	}
+	if err := CheckTicketSales(ticketType, now); err != nil {
+		return nil, err
+	}
+
+	return &ticketTypeID, nil
+}
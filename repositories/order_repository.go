@@ -0,0 +1,526 @@
+package repositories
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/event-system/database"
+	"github.com/event-system/models"
+)
+
+// reservationHold is how long a reserved seat waits for the payment of its
+// order when the user reserved it. A seat the user got from the waitlist waits
+// for promotionHold, since they first have to hear about it.
+const (
+	reservationHold = 15 * time.Minute
+	promotionHold   = 24 * time.Hour
+)
+
+// OrderRepository handles database operations related to the orders of priced tickets
+type OrderRepository struct {
+	DB *sql.DB
+}
+
+// NewOrderRepository creates a new order repository instance
+func NewOrderRepository(db *sql.DB) *OrderRepository {
+	return &OrderRepository{DB: db}
+}
+
+// orderColumns selects an order; orderFields returns its scan destinations
+const orderColumns = `id, event_id, user_id, ticket_type_id, amount, discount, currency, status, provider, provider_ref, checkout_url,
+	expires_at, paid_at, refunded_at, created_at, updated_at`
+
+// orderRow holds the nullable columns of an order while it is scanned
+type orderRow struct {
+	provider, providerRef, checkoutURL sql.NullString
+}
+
+func orderFields(order *models.Order, row *orderRow) []interface{} {
+	return []interface{}{
+		&order.ID,
+		&order.EventID,
+		&order.UserID,
+		&order.TicketTypeID,
+		&order.Amount,
+		&order.Discount,
+		&order.Currency,
+		&order.Status,
+		&row.provider,
+		&row.providerRef,
+		&row.checkoutURL,
+		&order.ExpiresAt,
+		&order.PaidAt,
+		&order.RefundedAt,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	}
+}
+
+// fill copies the nullable columns into the order
+func (row *orderRow) fill(order *models.Order) {
+	order.Provider = row.provider.String
+	order.ProviderRef = row.providerRef.String
+	order.CheckoutURL = row.checkoutURL.String
+}
+
+// GetByID retrieves an order by ID
+func (r *OrderRepository) GetByID(id int) (*models.Order, error) {
+	return r.getOne(`SELECT `+orderColumns+` FROM orders WHERE id = $1`, id)
+}
+
+// GetByProviderRef retrieves the order a payment provider knows by ref
+func (r *OrderRepository) GetByProviderRef(provider, ref string) (*models.Order, error) {
+	return r.getOne(`SELECT `+orderColumns+` FROM orders WHERE provider = $1 AND provider_ref = $2`, provider, ref)
+}
+
+func (r *OrderRepository) getOne(query string, args ...interface{}) (*models.Order, error) {
+	order := &models.Order{}
+	var row orderRow
+	err := r.DB.QueryRow(query, args...).Scan(orderFields(order, &row)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOrderNotFound
+		}
+		log.Printf("Error getting order: %v", err)
+		return nil, err
+	}
+	row.fill(order)
+
+	return order, nil
+}
+
+// GetByUser retrieves the orders of a user in an event, newest first
+func (r *OrderRepository) GetByUser(eventID, userID int) ([]models.Order, error) {
+	return r.getMany(`SELECT `+orderColumns+` FROM orders WHERE event_id = $1 AND user_id = $2 ORDER BY id DESC`, eventID, userID)
+}
+
+// GetByEvent retrieves all orders of an event, oldest first
+func (r *OrderRepository) GetByEvent(eventID int) ([]models.Order, error) {
+	return r.getMany(`SELECT `+orderColumns+` FROM orders WHERE event_id = $1 ORDER BY id ASC`, eventID)
+}
+
+// GetRefunding retrieves up to limit orders waiting for their refund, oldest first
+func (r *OrderRepository) GetRefunding(limit int) ([]models.Order, error) {
+	return r.getMany(`SELECT `+orderColumns+` FROM orders WHERE status = $1 ORDER BY id ASC LIMIT $2`, models.OrderStatusRefunding, limit)
+}
+
+func (r *OrderRepository) getMany(query string, args ...interface{}) ([]models.Order, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		log.Printf("Error getting orders: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		var order models.Order
+		var row orderRow
+		if err := rows.Scan(orderFields(&order, &row)...); err != nil {
+			log.Printf("Error scanning order: %v", err)
+			return nil, err
+		}
+		row.fill(&order)
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating orders: %v", err)
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// SetCheckout stores the payment a provider started for a pending order
+func (r *OrderRepository) SetCheckout(id int, provider string, session *models.PaymentSession) error {
+	query := `
+	UPDATE orders SET provider = $1, provider_ref = $2, checkout_url = $3, updated_at = $4
+	WHERE id = $5 AND status = $6
+	`
+
+	result, err := r.DB.Exec(query, provider, session.ProviderRef, session.CheckoutURL, time.Now(), id, models.OrderStatusPending)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return ErrOrderNotPending
+		}
+		log.Printf("Error storing checkout: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrOrderNotPending
+	}
+
+	return nil
+}
+
+// ConfirmPayment records that an order was paid. The reserved seat of a
+// pending order becomes a confirmed one. An order that lost its seat in the
+// meantime (it expired or the user left) is marked for a refund instead.
+// Orders that are already paid stay as they are, so repeated webhooks are
+// harmless.
+func (r *OrderRepository) ConfirmPayment(id int, now time.Time) (*models.Order, error) {
+	return r.settle(id, func(tx *sql.Tx, order *models.Order, capacity int) error {
+		switch order.Status {
+		case models.OrderStatusPending:
+			confirmQuery := `
+			UPDATE participants SET status = $1, reserved_until = NULL
+			WHERE event_id = $2 AND user_id = $3 AND status = $4
+			`
+			result, err := tx.Exec(confirmQuery, models.ParticipantStatusConfirmed, order.EventID, order.UserID, models.ParticipantStatusReserved)
+			if err != nil {
+				log.Printf("Error confirming reserved seat: %v", err)
+				return err
+			}
+			rows, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			order.Status = models.OrderStatusPaid
+			if rows == 0 {
+				order.Status = models.OrderStatusRefunding
+			}
+		case models.OrderStatusFailed, models.OrderStatusExpired, models.OrderStatusCancelled:
+			order.Status = models.OrderStatusRefunding
+		default:
+			return nil
+		}
+
+		order.PaidAt = &now
+		return updateOrderStatus(tx, order, now)
+	})
+}
+
+// FailPayment records that the payment of a pending order was declined and
+// releases its reserved seat. Other orders stay as they are. It returns the
+// participants who moved up from the waitlist into the seat.
+func (r *OrderRepository) FailPayment(id int, now time.Time) (*models.Order, []models.Participant, error) {
+	var promoted []models.Participant
+	order, err := r.settle(id, func(tx *sql.Tx, order *models.Order, capacity int) error {
+		if order.Status != models.OrderStatusPending {
+			return nil
+		}
+		var err error
+		promoted, err = releaseReservation(tx, order, models.OrderStatusFailed, capacity, now)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, promoted, nil
+}
+
+// ReleaseExpired releases the reserved seats whose orders weren't paid in time
+// and hands them to the waitlist. It returns the number of released seats and
+// the participants who moved up into them.
+func (r *OrderRepository) ReleaseExpired(now time.Time) (int, []models.Participant, error) {
+	query := `
+	SELECT id FROM orders WHERE status = $1 AND expires_at <= $2 ORDER BY id ASC
+	`
+	rows, err := r.DB.Query(query, models.OrderStatusPending, now)
+	if err != nil {
+		log.Printf("Error getting expired orders: %v", err)
+		return 0, nil, err
+	}
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			log.Printf("Error scanning expired order: %v", err)
+			return 0, nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating expired orders: %v", err)
+		return 0, nil, err
+	}
+
+	// Each order runs in its own transaction; one that was paid meanwhile is skipped
+	released := 0
+	promoted := []models.Participant{}
+	for _, id := range ids {
+		_, err := r.settle(id, func(tx *sql.Tx, order *models.Order, capacity int) error {
+			if order.Status != models.OrderStatusPending || order.ExpiresAt.After(now) {
+				return nil
+			}
+			moved, err := releaseReservation(tx, order, models.OrderStatusExpired, capacity, now)
+			if err != nil {
+				return err
+			}
+			released++
+			promoted = append(promoted, moved...)
+			return nil
+		})
+		if err != nil {
+			return released, promoted, err
+		}
+	}
+
+	return released, promoted, nil
+}
+
+// MarkRefunded records that the money of an order waiting for its refund went back
+func (r *OrderRepository) MarkRefunded(id int, now time.Time) error {
+	query := `
+	UPDATE orders SET status = $1, refunded_at = $2, updated_at = $2 WHERE id = $3 AND status = $4
+	`
+
+	result, err := r.DB.Exec(query, models.OrderStatusRefunded, now, id, models.OrderStatusRefunding)
+	if err != nil {
+		log.Printf("Error marking order refunded: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrOrderNotRefunding
+	}
+
+	return nil
+}
+
+// MarkRefundFailed gives up on the refund of an order the provider can't pay
+// back, so it isn't retried. The order keeps its amount for a manual refund.
+func (r *OrderRepository) MarkRefundFailed(id int, now time.Time) error {
+	query := `
+	UPDATE orders SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4
+	`
+
+	result, err := r.DB.Exec(query, models.OrderStatusRefundFailed, now, id, models.OrderStatusRefunding)
+	if err != nil {
+		log.Printf("Error marking refund of order failed: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrOrderNotRefunding
+	}
+
+	return nil
+}
+
+// settle runs change on an order in a transaction that holds the lock on the
+// row of its event, like JoinEvent and LeaveEvent, and returns the order as
+// change left it
+func (r *OrderRepository) settle(id int, change func(tx *sql.Tx, order *models.Order, capacity int) error) (*models.Order, error) {
+	order, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting order transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var capacity int
+	err = tx.QueryRow(`SELECT capacity FROM events WHERE id = $1 FOR UPDATE`, order.EventID).Scan(&capacity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOrderNotFound
+		}
+		log.Printf("Error locking event: %v", err)
+		return nil, err
+	}
+
+	// Read the order again now that nobody else can change it
+	var row orderRow
+	err = tx.QueryRow(`SELECT `+orderColumns+` FROM orders WHERE id = $1`, id).Scan(orderFields(order, &row)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOrderNotFound
+		}
+		log.Printf("Error getting order: %v", err)
+		return nil, err
+	}
+	row.fill(order)
+
+	if err = change(tx, order, capacity); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing order transaction: %v", err)
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// updateOrderStatus stores the status and payment time of an order
+func updateOrderStatus(tx *sql.Tx, order *models.Order, now time.Time) error {
+	query := `
+	UPDATE orders SET status = $1, paid_at = $2, updated_at = $3 WHERE id = $4
+	`
+
+	if _, err := tx.Exec(query, order.Status, order.PaidAt, now, order.ID); err != nil {
+		log.Printf("Error updating order: %v", err)
+		return err
+	}
+	order.UpdatedAt = now
+
+	return nil
+}
+
+// cancelEventOrders ends the orders of an event that is being cancelled: paid
+// orders wait for their refund and pending ones are cancelled, so a payment
+// that still arrives for them is refunded too. It runs in the transaction
+// that cancels the event.
+func cancelEventOrders(tx *sql.Tx, eventID int, now time.Time) error {
+	query := `
+	UPDATE orders SET status = $1, updated_at = $2 WHERE event_id = $3 AND status = $4
+	`
+
+	if _, err := tx.Exec(query, models.OrderStatusRefunding, now, eventID, models.OrderStatusPaid); err != nil {
+		log.Printf("Error refunding orders of cancelled event: %v", err)
+		return err
+	}
+	if _, err := tx.Exec(query, models.OrderStatusCancelled, now, eventID, models.OrderStatusPending); err != nil {
+		log.Printf("Error cancelling orders of cancelled event: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// checkHeldPayments fails with ErrEventHasPayments while the event has orders
+// whose money hasn't been given back, so deleting the event can't take them
+// along. The caller must hold the lock on the event row.
+func checkHeldPayments(tx *sql.Tx, eventID int) error {
+	query := `
+	SELECT COUNT(*) FROM orders WHERE event_id = $1 AND status IN ($2, $3, $4)
+	`
+
+	var count int
+	err := tx.QueryRow(query, eventID, models.OrderStatusPaid, models.OrderStatusRefunding, models.OrderStatusRefundFailed).Scan(&count)
+	if err != nil {
+		log.Printf("Error checking paid orders: %v", err)
+		return err
+	}
+	if count > 0 {
+		return ErrEventHasPayments
+	}
+
+	return nil
+}
+
+// releaseReservation ends a pending order with status, frees its reserved
+// seat, gives back the use of its promo code and promotes the waitlist into
+// it. It returns the promoted participants. The caller must hold the lock on
+// the event row.
+func releaseReservation(tx *sql.Tx, order *models.Order, status string, capacity int, now time.Time) ([]models.Participant, error) {
+	deleteQuery := `
+	DELETE FROM participants WHERE event_id = $1 AND user_id = $2 AND status = $3
+	`
+	if _, err := tx.Exec(deleteQuery, order.EventID, order.UserID, models.ParticipantStatusReserved); err != nil {
+		log.Printf("Error releasing reserved seat: %v", err)
+		return nil, err
+	}
+
+	order.Status = status
+	if err := updateOrderStatus(tx, order, now); err != nil {
+		return nil, err
+	}
+	if err := releaseRedemption(tx, order.EventID, order.UserID, now); err != nil {
+		return nil, err
+	}
+
+	return promoteWaitlisted(tx, order.EventID, capacity)
+}
+
+// reserveSeat turns a new seat of a priced ticket type into a reservation:
+// the participant stays reserved until the pending order it gets is paid, and
+// loses the seat when the order expires after hold. The promo code of the
+// participant lowers the amount of the order. Seats of free ticket types, and
+// of ones the code makes free, stay confirmed. The caller must hold the lock
+// on the event row.
+func reserveSeat(tx *sql.Tx, participant *models.Participant, now time.Time, hold time.Duration) error {
+	if participant.Status != models.ParticipantStatusConfirmed || participant.TicketTypeID == nil {
+		return nil
+	}
+
+	var price int
+	var currency string
+	err := tx.QueryRow(`SELECT price, currency FROM ticket_types WHERE id = $1`, *participant.TicketTypeID).Scan(&price, &currency)
+	if err != nil {
+		log.Printf("Error getting ticket price: %v", err)
+		return err
+	}
+	if price == 0 {
+		return nil
+	}
+
+	discount := 0
+	if participant.PromoCodeID != nil {
+		discount, err = redemptionDiscount(tx, participant, price)
+		if err != nil {
+			return err
+		}
+		if discount == price {
+			return nil
+		}
+	}
+
+	until := now.Add(hold)
+	reserveQuery := `
+	UPDATE participants SET status = $1, reserved_until = $2 WHERE id = $3
+	`
+	if _, err = tx.Exec(reserveQuery, models.ParticipantStatusReserved, until, participant.ID); err != nil {
+		log.Printf("Error reserving seat: %v", err)
+		return err
+	}
+
+	orderQuery := `
+	INSERT INTO orders (event_id, user_id, ticket_type_id, amount, discount, currency, status, expires_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+	`
+	_, err = tx.Exec(orderQuery, participant.EventID, participant.UserID, participant.TicketTypeID, price-discount, discount, currency,
+		models.OrderStatusPending, until, now)
+	if err != nil {
+		log.Printf("Error creating order: %v", err)
+		return err
+	}
+
+	participant.Status = models.ParticipantStatusReserved
+	participant.ReservedUntil = &until
+	return nil
+}
+
+// redemptionDiscount works out what the promo code of a participant takes off
+// a price and records it on the redemption for the report of the code
+func redemptionDiscount(tx *sql.Tx, participant *models.Participant, price int) (int, error) {
+	var discountType string
+	var value int
+	err := tx.QueryRow(`SELECT discount_type, discount_value FROM promo_codes WHERE id = $1`, *participant.PromoCodeID).Scan(&discountType, &value)
+	if err != nil {
+		log.Printf("Error getting promo code discount: %v", err)
+		return 0, err
+	}
+	discount := promoDiscount(discountType, value, price)
+
+	updateQuery := `
+	UPDATE promo_redemptions SET discount = $1
+	WHERE promo_code_id = $2 AND event_id = $3 AND user_id = $4 AND released_at IS NULL
+	`
+	if _, err = tx.Exec(updateQuery, discount, *participant.PromoCodeID, participant.EventID, participant.UserID); err != nil {
+		log.Printf("Error recording promo code discount: %v", err)
+		return 0, err
+	}
+
+	return discount, nil
+}
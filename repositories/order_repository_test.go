@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// pendingOrder returns the only order of a user in an event, which must be pending
+func pendingOrder(t *testing.T, orders *OrderRepository, eventID, userID int) *models.Order {
+	t.Helper()
+
+	list, err := orders.GetByUser(eventID, userID)
+	if err != nil {
+		t.Fatalf("get orders: %v", err)
+	}
+	if len(list) != 1 || list[0].Status != models.OrderStatusPending {
+		t.Fatalf("expected one pending order for user %d, got %+v", userID, list)
+	}
+	return &list[0]
+}
+
+func TestPaidSeatReservation(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 4)
+	organizer, payer, slow, waiting := users[0], users[1], users[2], users[3]
+	event := createTestEvent(t, db, organizer.ID, 2)
+
+	ticketTypes := NewTicketTypeRepository(db)
+	standard := &models.TicketType{EventID: event.ID, Name: "Standard", Quota: 10, Visibility: models.TicketTypePublic, Price: 2500, Currency: "EUR"}
+	if err := ticketTypes.Create(standard); err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+
+	repo := NewParticipantRepository(db)
+	orders := NewOrderRepository(db)
+	for _, user := range []*models.User{payer, slow} {
+		joined, err := repo.JoinEvent(user.ID, event.ID, models.JoinOptions{TicketTypeID: standard.ID})
+		if err != nil {
+			t.Fatalf("join: %v", err)
+		}
+		if joined.Status != models.ParticipantStatusReserved || joined.ReservedUntil == nil {
+			t.Errorf("expected a reserved seat, got %+v", joined)
+		}
+	}
+
+	// Reserved seats are taken: the next user waits
+	joined, err := repo.JoinEvent(waiting.ID, event.ID, models.JoinOptions{TicketTypeID: standard.ID})
+	if err != nil {
+		t.Fatalf("join full event: %v", err)
+	}
+	if joined.Status != models.ParticipantStatusWaitlisted {
+		t.Errorf("expected waitlisted, got %q", joined.Status)
+	}
+	stored, err := ticketTypes.GetByID(standard.ID)
+	if err != nil {
+		t.Fatalf("get ticket type: %v", err)
+	}
+	if stored.Sold != 2 {
+		t.Errorf("expected reservations to count as sold, got %d", stored.Sold)
+	}
+
+	order := pendingOrder(t, orders, event.ID, payer.ID)
+	if order.Amount != 2500 || order.Currency != "EUR" {
+		t.Errorf("expected the order to carry the price of the ticket type, got %+v", order)
+	}
+	if err := orders.SetCheckout(order.ID, "fake", &models.PaymentSession{ProviderRef: "ref-payer", CheckoutURL: "/pay"}); err != nil {
+		t.Fatalf("set checkout: %v", err)
+	}
+	if found, err := orders.GetByProviderRef("fake", "ref-payer"); err != nil || found.ID != order.ID {
+		t.Errorf("get by provider ref: got %+v (%v)", found, err)
+	}
+
+	// Paying confirms the seat; a repeated webhook changes nothing
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		paid, err := orders.ConfirmPayment(order.ID, now)
+		if err != nil {
+			t.Fatalf("confirm payment: %v", err)
+		}
+		if paid.Status != models.OrderStatusPaid {
+			t.Errorf("expected a paid order, got %q", paid.Status)
+		}
+	}
+	registration, err := repo.GetRegistration(payer.ID, event.ID)
+	if err != nil {
+		t.Fatalf("get registration: %v", err)
+	}
+	if registration.Status != models.ParticipantStatusConfirmed || registration.ReservedUntil != nil {
+		t.Errorf("expected a confirmed seat, got %+v", registration)
+	}
+
+	// The slow payer's reservation expires and the waitlisted user moves up
+	// into a new reservation of their own, which waits longer since they
+	// haven't heard of it yet
+	slowOrder := pendingOrder(t, orders, event.ID, slow.ID)
+	expiredAt := now.Add(reservationHold + time.Minute)
+	released, promoted, err := orders.ReleaseExpired(expiredAt)
+	if err != nil {
+		t.Fatalf("release expired: %v", err)
+	}
+	if released != 1 {
+		t.Errorf("expected 1 released order, got %d", released)
+	}
+	if len(promoted) != 1 || promoted[0].UserID != waiting.ID || promoted[0].Status != models.ParticipantStatusReserved {
+		t.Errorf("expected the waitlisted user to be reported as promoted, got %+v", promoted)
+	}
+	if _, err := repo.GetRegistration(slow.ID, event.ID); !errors.Is(err, ErrNotParticipant) {
+		t.Errorf("expired reservation: got %v, want ErrNotParticipant", err)
+	}
+	registration, err = repo.GetRegistration(waiting.ID, event.ID)
+	if err != nil {
+		t.Fatalf("get registration: %v", err)
+	}
+	if registration.Status != models.ParticipantStatusReserved {
+		t.Errorf("expected the promoted user to get a reservation, got %q", registration.Status)
+	}
+	if registration.ReservedUntil == nil || registration.ReservedUntil.Before(now.Add(promotionHold-time.Minute)) {
+		t.Errorf("expected the promotion to be held for %v, got %v", promotionHold, registration.ReservedUntil)
+	}
+	pendingOrder(t, orders, event.ID, waiting.ID)
+	if released, _, err := orders.ReleaseExpired(expiredAt.Add(reservationHold)); err != nil || released != 0 {
+		t.Errorf("expected the promotion to outlast a checkout hold, released %d (%v)", released, err)
+	}
+
+	// A payment that arrives after the seat was released waits for a refund
+	late, err := orders.ConfirmPayment(slowOrder.ID, now)
+	if err != nil {
+		t.Fatalf("confirm late payment: %v", err)
+	}
+	if late.Status != models.OrderStatusRefunding {
+		t.Errorf("expected a late payment to be refunded, got %q", late.Status)
+	}
+	if err := orders.MarkRefunded(late.ID, now); err != nil {
+		t.Fatalf("mark refunded: %v", err)
+	}
+	if err := orders.MarkRefunded(late.ID, now); !errors.Is(err, ErrOrderNotRefunding) {
+		t.Errorf("refunding twice: got %v, want ErrOrderNotRefunding", err)
+	}
+
+	// Leaving after paying asks for a refund
+	if _, err := repo.LeaveEvent(payer.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	refunding, err := orders.GetRefunding(10)
+	if err != nil {
+		t.Fatalf("get refunding: %v", err)
+	}
+	if len(refunding) != 1 || refunding[0].ID != order.ID {
+		t.Errorf("expected the order of the leaving user to wait for a refund, got %+v", refunding)
+	}
+
+	// A refund the provider can't make is given up on
+	if err := orders.MarkRefundFailed(order.ID, now); err != nil {
+		t.Fatalf("mark refund failed: %v", err)
+	}
+	refunding, err = orders.GetRefunding(10)
+	if err != nil {
+		t.Fatalf("get refunding: %v", err)
+	}
+	if len(refunding) != 0 {
+		t.Errorf("expected no refunds to retry, got %+v", refunding)
+	}
+}
+
+func TestCancellingEventEndsItsOrders(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 3)
+	organizer, payer, slow := users[0], users[1], users[2]
+	event := createTestEvent(t, db, organizer.ID, 10)
+
+	ticketTypes := NewTicketTypeRepository(db)
+	standard := &models.TicketType{EventID: event.ID, Name: "Standard", Quota: 10, Visibility: models.TicketTypePublic, Price: 2500, Currency: "EUR"}
+	if err := ticketTypes.Create(standard); err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+
+	repo := NewParticipantRepository(db)
+	orders := NewOrderRepository(db)
+	for _, user := range []*models.User{payer, slow} {
+		if _, err := repo.JoinEvent(user.ID, event.ID, models.JoinOptions{TicketTypeID: standard.ID}); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+	paid := pendingOrder(t, orders, event.ID, payer.ID)
+	if _, err := orders.ConfirmPayment(paid.ID, time.Now()); err != nil {
+		t.Fatalf("confirm payment: %v", err)
+	}
+	unpaid := pendingOrder(t, orders, event.ID, slow.ID)
+
+	if err := NewEventRepository(db).UpdateStatus(event, models.EventStatusCancelled); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	for _, want := range []struct {
+		id     int
+		status string
+	}{
+		{paid.ID, models.OrderStatusRefunding},
+		{unpaid.ID, models.OrderStatusCancelled},
+	} {
+		order, err := orders.GetByID(want.id)
+		if err != nil {
+			t.Fatalf("get order: %v", err)
+		}
+		if order.Status != want.status {
+			t.Errorf("order %d: got status %q, want %q", want.id, order.Status, want.status)
+		}
+	}
+
+	// The event can't be deleted, not even by an admin, until the refund is done
+	events := NewEventRepository(db)
+	if err := events.ForceDelete(event.ID); !errors.Is(err, ErrEventHasPayments) {
+		t.Errorf("force delete with a pending refund: got %v, want ErrEventHasPayments", err)
+	}
+	if err := orders.MarkRefunded(paid.ID, time.Now()); err != nil {
+		t.Fatalf("mark refunded: %v", err)
+	}
+	if err := events.ForceDelete(event.ID); err != nil {
+		t.Errorf("force delete after the refund: %v", err)
+	}
+}
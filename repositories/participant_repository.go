@@ -2,9 +2,13 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
+	"fmt"
 	"log"
+	"sort"
 	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
 )
 
 // ParticipantRepository handles database operations related to event participants
@@ -17,130 +21,233 @@ func NewParticipantRepository(db *sql.DB) *ParticipantRepository {
 	return &ParticipantRepository{DB: db}
 }
 
-// JoinEvent adds a user as a participant to an event
-func (r *ParticipantRepository) JoinEvent(userID, eventID int) error {
-	// First check if the event exists and is open
+// CheckRegistration returns the reason nobody can join an event at now, or
+// nil when its registration is open. Registration closes when the event
+// starts at the latest. A full event is still open; joining it puts the user
+// on the waitlist.
+func CheckRegistration(event *models.Event, now time.Time) error {
+	switch {
+	case event.Status == models.EventStatusCancelled:
+		return ErrEventCancelled
+	case event.Status != models.EventStatusPublished:
+		return ErrEventNotOpen
+	case event.PublishAt != nil && now.Before(*event.PublishAt):
+		return ErrEventNotPublished
+	case event.RegistrationOpensAt != nil && now.Before(*event.RegistrationOpensAt):
+		return apperrors.Conflict(ErrRegistrationNotOpen.Code, fmt.Sprintf("%s: opens at %s",
+			ErrRegistrationNotOpen.Message, event.RegistrationOpensAt.UTC().Format(time.RFC3339)))
+	case event.RegistrationClosesAt != nil && !now.Before(*event.RegistrationClosesAt):
+		return ErrRegistrationClosed
+	case !now.Before(event.StartTime):
+		return ErrRegistrationClosed
+	}
+	return nil
+}
+
+// JoinEvent adds a user as a participant to an event.
+// All checks and the insert run in a single transaction that locks the event
+// row, so concurrent joins can never push an event past its capacity.
+// When the event is full the user is put at the end of the waitlist instead.
+// Joining a private event needs an invite of the user or the link invite
+// opts.InviteID. Events with ticket types need opts.TicketTypeID, and a seat
+// takes a ticket of that type as well as one of the capacity of the event.
+// A seat of a priced ticket type is only reserved until its order is paid.
+// opts.PromoCode takes one use of the code, which may unlock a hidden ticket
+// type, give access to the seats it holds and lower the price of the order.
+// Events that require approval only get a pending registration.
+func (r *ParticipantRepository) JoinEvent(userID, eventID int, opts models.JoinOptions) (*models.Participant, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting join transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// First check if the event exists and is open, locking the row until commit
 	eventQuery := `
-	SELECT status,capacity FROM events WHERE id = $1
+	SELECT status, visibility, requires_approval, capacity, start_time, publish_at, registration_opens_at, registration_closes_at
+	FROM events WHERE id = $1 FOR UPDATE
 	`
-	var status string
-	var capacity int
-	err := r.DB.QueryRow(eventQuery, eventID).Scan(&status, &capacity)
+	event := models.Event{ID: eventID}
+	err = tx.QueryRow(eventQuery, eventID).Scan(&event.Status, &event.Visibility, &event.RequiresApproval, &event.Capacity, &event.StartTime,
+		&event.PublishAt, &event.RegistrationOpensAt, &event.RegistrationClosesAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return errors.New("event not found")
+			return nil, ErrEventNotFound
 		}
-		return err
+		log.Printf("Error locking event: %v", err)
+		return nil, err
 	}
 
-	if status != "open" {
-		return errors.New("event is not open for registration")
+	now := time.Now()
+	if err = CheckRegistration(&event, now); err != nil {
+		return nil, err
 	}
 
-	// Check if the event is full
-	countQuery := `
-	SELECT COUNT(*) FROM participants WHERE event_id = $1
+	// Lock the user row as well so parallel joins to different events
+	// can't slip past the active events limit
+	userQuery := `
+	SELECT id FROM users WHERE id = $1 FOR UPDATE
 	`
-	var count int
-	err = r.DB.QueryRow(countQuery, eventID).Scan(&count)
+	var lockedUserID int
+	err = tx.QueryRow(userQuery, userID).Scan(&lockedUserID)
 	if err != nil {
-		log.Printf("Error checking participant count: %v", err)
-		return err
-	}
-
-	if count >= capacity {
-		return errors.New("event is at full capacity")
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		log.Printf("Error locking user: %v", err)
+		return nil, err
 	}
 
-	// Check if user is already a participant
+	// Check if user is already a participant. A rejected registration stays,
+	// so the user can't simply apply again.
 	checkQuery := `
-	SELECT id FROM participants WHERE user_id = $1 AND event_id = $2
+	SELECT status FROM participants WHERE user_id = $1 AND event_id = $2
 	`
-	var participantID int
-	err = r.DB.QueryRow(checkQuery, userID, eventID).Scan(&participantID)
+	var existingStatus string
+	err = tx.QueryRow(checkQuery, userID, eventID).Scan(&existingStatus)
 	if err == nil {
-		return errors.New("user is already a participant of this event")
+		if existingStatus == models.ParticipantStatusRejected {
+			return nil, ErrRegistrationRejected
+		}
+		return nil, ErrAlreadyParticipant
 	} else if err != sql.ErrNoRows {
 		log.Printf("Error checking existing participant: %v", err)
-		return err
+		return nil, err
 	}
 
-	// Check if user has reached the maximum number of active events
-	// For this example, we'll set a limit of 5 active events per user
-	activeEventsQuery := `
-	SELECT COUNT(*) FROM participants p
-	JOIN events e ON p.event_id = e.id
-	WHERE p.user_id = $1 AND e.end_time > $2
-	`
-	var activeCount int
-	err = r.DB.QueryRow(activeEventsQuery, userID, time.Now()).Scan(&activeCount)
+	err = checkActiveEventLimit(tx, userID)
 	if err != nil {
-		log.Printf("Error checking active events count: %v", err)
-		return err
+		return nil, err
 	}
 
-	if activeCount >= 5 {
-		return errors.New("user has reached the maximum number of active events")
+	// A promo code is redeemed under the event lock too, so its usage limit
+	// holds against concurrent joins
+	var promoCodeID, unlocked *int
+	if opts.PromoCode != "" {
+		code, err := redeemPromoCode(tx, eventID, userID, opts.PromoCode, now)
+		if err != nil {
+			return nil, err
+		}
+		promoCodeID, unlocked = &code.ID, code.TicketTypeID
 	}
 
-	// Add user as participant
-	insertQuery := `
-	INSERT INTO participants (user_id, event_id, joined_at)
-	VALUES ($1, $2, $3)
-	RETURNING id
-	`
+	ticketTypeID, err := chooseTicketType(tx, eventID, opts.TicketTypeID, unlocked, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Private events take one use of an invite
+	if event.Visibility == models.VisibilityPrivate {
+		if err = useInvite(tx, eventID, userID, opts.InviteID, now); err != nil {
+			return nil, err
+		}
+	}
 
-	var newID int
-	err = r.DB.QueryRow(insertQuery, userID, eventID, time.Now()).Scan(&newID)
+	var participant *models.Participant
+	if event.RequiresApproval {
+		participant, err = addApplicant(tx, userID, eventID, ticketTypeID, promoCodeID)
+	} else {
+		participant, err = addParticipant(tx, userID, eventID, event.Capacity, nil, ticketTypeID, promoCodeID)
+	}
 	if err != nil {
-		log.Printf("Error adding participant: %v", err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing join transaction: %v", err)
+		return nil, err
+	}
+
+	return participant, nil
 }
 
-// LeaveEvent removes a user as a participant from an event
-func (r *ParticipantRepository) LeaveEvent(userID, eventID int) error {
-	// Check if the user is a participant
-	checkQuery := `
-	SELECT id FROM participants WHERE user_id = $1 AND event_id = $2
+// LeaveEvent removes a user as a participant from an event, or withdraws a
+// pending registration. Rejected registrations stay.
+// It locks the event row like JoinEvent so leaves and joins are serialized,
+// and promotes the next waitlisted user into a seat that was freed up; it
+// returns the promoted participants.
+// The pending order of a reserved seat is cancelled, and a paid order is
+// marked for a refund. A promo code the user joined with gets its use back.
+func (r *ParticipantRepository) LeaveEvent(userID, eventID int) ([]models.Participant, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting leave transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the event row
+	eventQuery := `
+	SELECT capacity FROM events WHERE id = $1 FOR UPDATE
 	`
-	var participantID int
-	err := r.DB.QueryRow(checkQuery, userID, eventID).Scan(&participantID)
+	var capacity int
+	err = tx.QueryRow(eventQuery, eventID).Scan(&capacity)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return errors.New("user is not a participant of this event")
+			return nil, ErrEventNotFound
 		}
-		log.Printf("Error checking participant: %v", err)
-		return err
+		log.Printf("Error locking event: %v", err)
+		return nil, err
 	}
 
 	// Remove participant
 	deleteQuery := `
 	DELETE FROM participants
-	WHERE user_id = $1 AND event_id = $2
+	WHERE user_id = $1 AND event_id = $2 AND status <> $3
 	RETURNING id
 	`
 
 	var deletedID int
-	err = r.DB.QueryRow(deleteQuery, userID, eventID).Scan(&deletedID)
+	err = tx.QueryRow(deleteQuery, userID, eventID, models.ParticipantStatusRejected).Scan(&deletedID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotParticipant
+		}
 		log.Printf("Error removing participant: %v", err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	// Orders of the user in this event don't get a seat anymore
+	ordersQuery := `
+	UPDATE orders SET status = $1, updated_at = $2 WHERE event_id = $3 AND user_id = $4 AND status = $5
+	`
+	now := time.Now()
+	for _, change := range [][2]string{
+		{models.OrderStatusPending, models.OrderStatusCancelled},
+		{models.OrderStatusPaid, models.OrderStatusRefunding},
+	} {
+		if _, err = tx.Exec(ordersQuery, change[1], now, eventID, userID, change[0]); err != nil {
+			log.Printf("Error updating orders of leaving participant: %v", err)
+			return nil, err
+		}
+	}
+	if err = releaseRedemption(tx, eventID, userID, now); err != nil {
+		return nil, err
+	}
+
+	// Hand the free seat to the next user on the waitlist
+	promoted, err := promoteWaitlisted(tx, eventID, capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing leave transaction: %v", err)
+		return nil, err
+	}
+
+	return promoted, nil
 }
 
-// IsParticipant checks if a user is a participant of an event
+// IsParticipant checks if a user is a confirmed participant of an event
 func (r *ParticipantRepository) IsParticipant(userID, eventID int) (bool, error) {
 	query := `
-	SELECT id FROM participants WHERE user_id = $1 AND event_id = $2
+	SELECT id FROM participants WHERE user_id = $1 AND event_id = $2 AND status = $3
 	`
 
 	var id int
-	err := r.DB.QueryRow(query, userID, eventID).Scan(&id)
+	err := r.DB.QueryRow(query, userID, eventID, models.ParticipantStatusConfirmed).Scan(&id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return false, nil
@@ -152,18 +259,861 @@ func (r *ParticipantRepository) IsParticipant(userID, eventID int) (bool, error)
 	return true, nil
 }
 
-// GetParticipantCount returns the number of participants for an event
-func (r *ParticipantRepository) GetParticipantCount(eventID int) (int, error) {
+// GetRegistration returns the registration of a user in an event in any
+// status, with the place in the queue for waitlisted users
+func (r *ParticipantRepository) GetRegistration(userID, eventID int) (*models.Participant, error) {
 	query := `
-	SELECT COUNT(*) FROM participants WHERE event_id = $1
+	SELECT id, status, waitlist_position, joined_at, series_id, reviewed_at, review_message, checked_in_at, ticket_type_id, reserved_until,
+		promo_code_id
+	FROM participants WHERE user_id = $1 AND event_id = $2
 	`
 
-	var count int
-	err := r.DB.QueryRow(query, eventID).Scan(&count)
+	participant := &models.Participant{UserID: userID, EventID: eventID}
+	var position sql.NullInt64
+	var message sql.NullString
+	err := r.DB.QueryRow(query, userID, eventID).Scan(&participant.ID, &participant.Status, &position,
+		&participant.JoinedAt, &participant.SeriesID, &participant.ReviewedAt, &message, &participant.CheckedInAt,
+		&participant.TicketTypeID, &participant.ReservedUntil, &participant.PromoCodeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotParticipant
+		}
+		log.Printf("Error getting registration: %v", err)
+		return nil, err
+	}
+	participant.ReviewMessage = message.String
+
+	if participant.Status == models.ParticipantStatusWaitlisted {
+		participant.WaitlistPosition, err = waitlistRank(r.DB, eventID, int(position.Int64))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return participant, nil
+}
+
+// GetParticipantUserIDs returns the users holding or reserving a seat,
+// waiting for one or waiting for approval in an event
+func (r *ParticipantRepository) GetParticipantUserIDs(eventID int) ([]int, error) {
+	query := `
+	SELECT user_id FROM participants WHERE event_id = $1 AND status <> $2 ORDER BY id
+	`
+
+	rows, err := r.DB.Query(query, eventID, models.ParticipantStatusRejected)
+	if err != nil {
+		log.Printf("Error getting participant user IDs: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := []int{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			log.Printf("Error scanning participant user ID: %v", err)
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// GetParticipantCount returns the number of confirmed participants for an
+// event, broken down by ticket type for events that have them
+func (r *ParticipantRepository) GetParticipantCount(eventID int) (*models.ParticipantCountResponse, error) {
+	query := `
+	SELECT COUNT(*) FROM participants WHERE event_id = $1 AND status = $2
+	`
+
+	count := &models.ParticipantCountResponse{}
+	err := r.DB.QueryRow(query, eventID, models.ParticipantStatusConfirmed).Scan(&count.Count)
 	if err != nil {
 		log.Printf("Error getting participant count: %v", err)
+		return nil, err
+	}
+
+	ticketTypesQuery := `
+	SELECT t.id, t.name, t.quota, t.visibility, COUNT(p.id)
+	FROM ticket_types t
+	LEFT JOIN participants p ON p.ticket_type_id = t.id AND p.status = $2
+	WHERE t.event_id = $1
+	GROUP BY t.id, t.name, t.quota, t.visibility
+	ORDER BY t.id ASC
+	`
+	rows, err := r.DB.Query(ticketTypesQuery, eventID, models.ParticipantStatusConfirmed)
+	if err != nil {
+		log.Printf("Error getting ticket type counts: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ticketType models.TicketTypeCount
+		if err := rows.Scan(&ticketType.TicketTypeID, &ticketType.Name, &ticketType.Quota, &ticketType.Visibility, &ticketType.Count); err != nil {
+			log.Printf("Error scanning ticket type count: %v", err)
+			return nil, err
+		}
+		count.TicketTypes = append(count.TicketTypes, ticketType)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating ticket type counts: %v", err)
+		return nil, err
+	}
+
+	return count, nil
+}
+
+// GetWaitlistPosition returns the 1-based position of a user on the waitlist of an event
+func (r *ParticipantRepository) GetWaitlistPosition(userID, eventID int) (int, error) {
+	query := `
+	SELECT waitlist_position FROM participants WHERE user_id = $1 AND event_id = $2 AND status = $3
+	`
+
+	var position int
+	err := r.DB.QueryRow(query, userID, eventID, models.ParticipantStatusWaitlisted).Scan(&position)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrNotOnWaitlist
+		}
+		log.Printf("Error getting waitlist position: %v", err)
+		return 0, err
+	}
+
+	return waitlistRank(r.DB, eventID, position)
+}
+
+// GetWaitlist returns the waitlisted users of an event in queue order
+func (r *ParticipantRepository) GetWaitlist(eventID int) ([]models.WaitlistEntryResponse, error) {
+	query := `
+	SELECT u.id, u.username, u.email, u.created_at, p.joined_at
+	FROM participants p
+	JOIN users u ON u.id = p.user_id
+	WHERE p.event_id = $1 AND p.status = $2
+	ORDER BY p.waitlist_position ASC, p.id ASC
+	`
+
+	rows, err := r.DB.Query(query, eventID, models.ParticipantStatusWaitlisted)
+	if err != nil {
+		log.Printf("Error getting waitlist: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.WaitlistEntryResponse{}
+	for rows.Next() {
+		entry := models.WaitlistEntryResponse{Position: len(entries) + 1}
+		err := rows.Scan(
+			&entry.User.ID,
+			&entry.User.Username,
+			&entry.User.Email,
+			&entry.User.CreatedAt,
+			&entry.JoinedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning waitlist entry: %v", err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating waitlist: %v", err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetPendingRegistrations returns the registrations of an event that wait
+// for approval, oldest first
+func (r *ParticipantRepository) GetPendingRegistrations(eventID int) ([]models.PendingRegistrationResponse, error) {
+	query := `
+	SELECT u.id, u.username, u.email, u.created_at, p.joined_at
+	FROM participants p
+	JOIN users u ON u.id = p.user_id
+	WHERE p.event_id = $1 AND p.status = $2
+	ORDER BY p.joined_at ASC, p.id ASC
+	`
+
+	rows, err := r.DB.Query(query, eventID, models.ParticipantStatusPending)
+	if err != nil {
+		log.Printf("Error getting pending registrations: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := []models.PendingRegistrationResponse{}
+	for rows.Next() {
+		var registration models.PendingRegistrationResponse
+		err := rows.Scan(
+			&registration.User.ID,
+			&registration.User.Username,
+			&registration.User.Email,
+			&registration.User.CreatedAt,
+			&registration.AppliedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning pending registration: %v", err)
+			return nil, err
+		}
+		pending = append(pending, registration)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating pending registrations: %v", err)
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// ReviewRegistrations approves or rejects pending registrations of an event
+// in the given order, all or none of them. Approved users get a seat while
+// there are free ones and go to the end of the waitlist after that. Seats of
+// priced ticket types are reserved until paid. Rejected users get the use of
+// their promo code back.
+func (r *ParticipantRepository) ReviewRegistrations(eventID int, userIDs []int, approve bool, message string) ([]models.Participant, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting review transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the event row so seats are handed out one at a time
+	eventQuery := `
+	SELECT status, capacity FROM events WHERE id = $1 FOR UPDATE
+	`
+	var status string
+	var capacity int
+	err = tx.QueryRow(eventQuery, eventID).Scan(&status, &capacity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEventNotFound
+		}
+		log.Printf("Error locking event: %v", err)
+		return nil, err
+	}
+	if approve && status == models.EventStatusCancelled {
+		return nil, ErrEventCancelled
+	}
+
+	pendingQuery := `
+	SELECT id, joined_at, ticket_type_id, promo_code_id FROM participants WHERE event_id = $1 AND user_id = $2 AND status = $3
+	`
+	updateQuery := `
+	UPDATE participants SET status = $1, waitlist_position = $2, reviewed_at = $3, review_message = $4
+	WHERE id = $5
+	`
+	now := time.Now()
+	reviewMessage := sql.NullString{String: message, Valid: message != ""}
+	reviewed := make([]models.Participant, 0, len(userIDs))
+	for _, userID := range userIDs {
+		participant := models.Participant{
+			UserID:        userID,
+			EventID:       eventID,
+			Status:        models.ParticipantStatusRejected,
+			ReviewedAt:    &now,
+			ReviewMessage: message,
+		}
+		err = tx.QueryRow(pendingQuery, eventID, userID, models.ParticipantStatusPending).Scan(&participant.ID, &participant.JoinedAt, &participant.TicketTypeID,
+			&participant.PromoCodeID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, apperrors.Conflict(ErrNotPending.Code, fmt.Sprintf("%s: user %d", ErrNotPending.Message, userID))
+			}
+			log.Printf("Error getting pending registration: %v", err)
+			return nil, err
+		}
+
+		var position sql.NullInt64
+		if approve {
+			participant.Status, position, err = nextSeat(tx, eventID, capacity, participant.TicketTypeID, participant.PromoCodeID)
+			if err != nil {
+				return nil, err
+			}
+		} else if err = releaseRedemption(tx, eventID, userID, now); err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(updateQuery, participant.Status, position, now, reviewMessage, participant.ID)
+		if err != nil {
+			log.Printf("Error reviewing registration: %v", err)
+			return nil, err
+		}
+		if err = reserveSeat(tx, &participant, now, reservationHold); err != nil {
+			return nil, err
+		}
+
+		if participant.Status == models.ParticipantStatusWaitlisted {
+			participant.WaitlistPosition, err = waitlistRank(tx, eventID, int(position.Int64))
+			if err != nil {
+				return nil, err
+			}
+		}
+		reviewed = append(reviewed, participant)
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing review transaction: %v", err)
+		return nil, err
+	}
+
+	return reviewed, nil
+}
+
+// CheckIn records that the confirmed participant with the given registration
+// ID entered the event at the given time. A ticket can only be used once; the
+// update is conditional, so two scans of the same ticket can't both succeed.
+func (r *ParticipantRepository) CheckIn(eventID, participantID int, at time.Time) (*models.CheckInResponse, error) {
+	updateQuery := `
+	UPDATE participants SET checked_in_at = $1
+	WHERE id = $2 AND event_id = $3 AND status = $4 AND checked_in_at IS NULL
+	`
+	result, err := r.DB.Exec(updateQuery, at, participantID, eventID, models.ParticipantStatusConfirmed)
+	if err != nil {
+		log.Printf("Error checking in participant: %v", err)
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Error checking in participant: %v", err)
+		return nil, err
+	}
+
+	query := `
+	SELECT u.id, u.username, u.email, u.created_at, p.status, p.checked_in_at
+	FROM participants p
+	JOIN users u ON u.id = p.user_id
+	WHERE p.id = $1 AND p.event_id = $2
+	`
+	checkIn := &models.CheckInResponse{EventID: eventID}
+	var status string
+	var checkedInAt sql.NullTime
+	err = r.DB.QueryRow(query, participantID, eventID).Scan(
+		&checkIn.User.ID,
+		&checkIn.User.Username,
+		&checkIn.User.Email,
+		&checkIn.User.CreatedAt,
+		&status,
+		&checkedInAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTicketNotFound
+		}
+		log.Printf("Error getting checked in participant: %v", err)
+		return nil, err
+	}
+	if status != models.ParticipantStatusConfirmed {
+		return nil, ErrTicketNotFound
+	}
+	checkIn.CheckedInAt = checkedInAt.Time
+
+	// Nothing was updated because the ticket was used before
+	if rows == 0 {
+		return nil, apperrors.Conflict(ErrAlreadyCheckedIn.Code,
+			fmt.Sprintf("%s at %s", ErrAlreadyCheckedIn.Message, checkIn.CheckedInAt.UTC().Format(time.RFC3339)))
+	}
+
+	return checkIn, nil
+}
+
+// GetAttendance counts the confirmed participants of an event and how many
+// of them have checked in
+func (r *ParticipantRepository) GetAttendance(eventID int) (*models.AttendanceResponse, error) {
+	query := `
+	SELECT COUNT(*), COUNT(checked_in_at) FROM participants WHERE event_id = $1 AND status = $2
+	`
+
+	attendance := &models.AttendanceResponse{EventID: eventID}
+	err := r.DB.QueryRow(query, eventID, models.ParticipantStatusConfirmed).Scan(&attendance.Registered, &attendance.CheckedIn)
+	if err != nil {
+		log.Printf("Error getting attendance: %v", err)
+		return nil, err
+	}
+	attendance.NotCheckedIn = attendance.Registered - attendance.CheckedIn
+
+	return attendance, nil
+}
+
+// GetConfirmedParticipants returns the confirmed participants of an event
+// with their check-in time, in the order they joined
+func (r *ParticipantRepository) GetConfirmedParticipants(eventID int) ([]models.Participant, error) {
+	query := `
+	SELECT id, user_id, joined_at, checked_in_at
+	FROM participants WHERE event_id = $1 AND status = $2
+	ORDER BY id
+	`
+
+	rows, err := r.DB.Query(query, eventID, models.ParticipantStatusConfirmed)
+	if err != nil {
+		log.Printf("Error getting confirmed participants: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	participants := []models.Participant{}
+	for rows.Next() {
+		participant := models.Participant{EventID: eventID, Status: models.ParticipantStatusConfirmed}
+		err := rows.Scan(&participant.ID, &participant.UserID, &participant.JoinedAt, &participant.CheckedInAt)
+		if err != nil {
+			log.Printf("Error scanning confirmed participant: %v", err)
+			return nil, err
+		}
+		participants = append(participants, participant)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating confirmed participants: %v", err)
+		return nil, err
+	}
+
+	return participants, nil
+}
+
+// SyncCheckIns records check-ins made offline. The earliest scan of a ticket
+// wins no matter in which order the devices sync: scans are applied by their
+// time (then by their order in the batch), a scan earlier than the recorded
+// check-in replaces it and any other scan is a duplicate. The results are in
+// the order of checkIns.
+func (r *ParticipantRepository) SyncCheckIns(eventID int, checkIns []models.OfflineCheckIn) ([]models.CheckInSyncResult, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting check-in sync transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the event row so two devices syncing at once see each other's check-ins
+	eventQuery := `
+	SELECT id FROM events WHERE id = $1 FOR UPDATE
+	`
+	var lockedEventID int
+	err = tx.QueryRow(eventQuery, eventID).Scan(&lockedEventID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEventNotFound
+		}
+		log.Printf("Error locking event: %v", err)
+		return nil, err
+	}
+
+	participantQuery := `
+	SELECT user_id, status, checked_in_at FROM participants WHERE id = $1 AND event_id = $2
+	`
+	updateQuery := `
+	UPDATE participants SET checked_in_at = $1 WHERE id = $2
+	`
+	results := make([]models.CheckInSyncResult, len(checkIns))
+	for _, i := range scanOrder(checkIns) {
+		checkIn := checkIns[i]
+
+		var status string
+		var recorded *time.Time
+		err = tx.QueryRow(participantQuery, checkIn.ParticipantID, eventID).Scan(&results[i].UserID, &status, &recorded)
+		if err != nil && err != sql.ErrNoRows {
+			log.Printf("Error getting participant to check in: %v", err)
+			return nil, err
+		}
+		if err == sql.ErrNoRows || status != models.ParticipantStatusConfirmed {
+			results[i] = models.CheckInSyncResult{Status: models.CheckInInvalid, Error: ErrTicketNotFound.Code}
+			continue
+		}
+
+		if recorded != nil && !checkIn.ScannedAt.Before(*recorded) {
+			results[i].Status = models.CheckInDuplicate
+			results[i].CheckedInAt = recorded
+			continue
+		}
+
+		_, err = tx.Exec(updateQuery, checkIn.ScannedAt, checkIn.ParticipantID)
+		if err != nil {
+			log.Printf("Error syncing check-in: %v", err)
+			return nil, err
+		}
+		scannedAt := checkIn.ScannedAt
+		results[i].Status = models.CheckInSynced
+		results[i].CheckedInAt = &scannedAt
+		results[i].SupersededAt = recorded
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing check-in sync transaction: %v", err)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// scanOrder returns the indexes of offline check-ins sorted by scan time,
+// keeping the order of the batch for scans made at the same time
+func scanOrder(checkIns []models.OfflineCheckIn) []int {
+	order := make([]int, len(checkIns))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return checkIns[order[a]].ScannedAt.Before(checkIns[order[b]].ScannedAt)
+	})
+	return order
+}
+
+// ReorderWaitlist rewrites the waitlist of an event in the given order.
+// userIDs must contain every waitlisted user of the event exactly once.
+func (r *ParticipantRepository) ReorderWaitlist(eventID int, userIDs []int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting reorder transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the event row so nobody joins or leaves while we reorder
+	eventQuery := `
+	SELECT id FROM events WHERE id = $1 FOR UPDATE
+	`
+	var lockedEventID int
+	err = tx.QueryRow(eventQuery, eventID).Scan(&lockedEventID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrEventNotFound
+		}
+		log.Printf("Error locking event: %v", err)
+		return err
+	}
+
+	waitlistQuery := `
+	SELECT user_id FROM participants WHERE event_id = $1 AND status = $2
+	`
+	rows, err := tx.Query(waitlistQuery, eventID, models.ParticipantStatusWaitlisted)
+	if err != nil {
+		log.Printf("Error getting waitlist: %v", err)
+		return err
+	}
+
+	waitlisted := map[int]bool{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			log.Printf("Error scanning waitlist entry: %v", err)
+			return err
+		}
+		waitlisted[userID] = true
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating waitlist: %v", err)
+		return err
+	}
+
+	if len(userIDs) != len(waitlisted) {
+		return ErrInvalidWaitlistOrder
+	}
+	seen := map[int]bool{}
+	for _, userID := range userIDs {
+		if !waitlisted[userID] || seen[userID] {
+			return ErrInvalidWaitlistOrder
+		}
+		seen[userID] = true
+	}
+
+	updateQuery := `
+	UPDATE participants SET waitlist_position = $1 WHERE event_id = $2 AND user_id = $3
+	`
+	for i, userID := range userIDs {
+		_, err = tx.Exec(updateQuery, i+1, eventID, userID)
+		if err != nil {
+			log.Printf("Error updating waitlist position: %v", err)
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing reorder transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// checkActiveEventLimit fails with ErrActiveEventLimit when a user already takes
+// part in 5 events that haven't ended and weren't cancelled. Waitlist entries
+// and pending registrations count as well, otherwise a user could queue for
+// everything; rejected ones don't. A series joined as a whole counts once.
+func checkActiveEventLimit(tx *sql.Tx, userID int) error {
+	activeEventsQuery := `
+	SELECT
+		(SELECT COUNT(*) FROM participants p
+		JOIN events e ON p.event_id = e.id
+		WHERE p.user_id = $1 AND e.end_time > $2 AND e.status <> $3 AND p.status <> $4 AND p.series_id IS NULL) +
+		(SELECT COUNT(DISTINCT p.series_id) FROM participants p
+		JOIN events e ON p.event_id = e.id
+		WHERE p.user_id = $1 AND e.end_time > $2 AND e.status <> $3 AND p.status <> $4 AND p.series_id IS NOT NULL)
+	`
+	var activeCount int
+	err := tx.QueryRow(activeEventsQuery, userID, time.Now(), models.EventStatusCancelled, models.ParticipantStatusRejected).Scan(&activeCount)
+	if err != nil {
+		log.Printf("Error checking active events count: %v", err)
+		return err
+	}
+
+	if activeCount >= 5 {
+		return ErrActiveEventLimit
+	}
+
+	return nil
+}
+
+// addParticipant gives a user a seat in an event, or puts them at the end of
+// the waitlist when the event or their ticket type is full. A seat of a priced
+// ticket type is reserved until paid. seriesID is set for joins of a whole
+// series. The caller must hold the lock on the event row.
+func addParticipant(tx *sql.Tx, userID, eventID, capacity int, seriesID, ticketTypeID, promoCodeID *int) (*models.Participant, error) {
+	participant := &models.Participant{
+		UserID:       userID,
+		EventID:      eventID,
+		JoinedAt:     time.Now(),
+		SeriesID:     seriesID,
+		TicketTypeID: ticketTypeID,
+		PromoCodeID:  promoCodeID,
+	}
+
+	status, position, err := nextSeat(tx, eventID, capacity, ticketTypeID, promoCodeID)
+	if err != nil {
+		return nil, err
+	}
+	participant.Status = status
+
+	// Add user as participant
+	insertQuery := `
+	INSERT INTO participants (user_id, event_id, status, waitlist_position, joined_at, series_id, ticket_type_id, promo_code_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id
+	`
+
+	err = tx.QueryRow(insertQuery, userID, eventID, participant.Status, position, participant.JoinedAt, seriesID, ticketTypeID,
+		promoCodeID).Scan(&participant.ID)
+	if err != nil {
+		log.Printf("Error adding participant: %v", err)
+		return nil, err
+	}
+	if err = reserveSeat(tx, participant, participant.JoinedAt, reservationHold); err != nil {
+		return nil, err
+	}
+
+	if participant.Status == models.ParticipantStatusWaitlisted {
+		participant.WaitlistPosition, err = waitlistRank(tx, eventID, int(position.Int64))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return participant, nil
+}
+
+// nextSeat returns where the next confirmed user of an event goes: a seat, or
+// the end of the waitlist when the event or the ticket type is full. Seats
+// held back by promo codes only go to users with the code that holds them.
+// The caller must hold the lock on the event row.
+func nextSeat(tx *sql.Tx, eventID, capacity int, ticketTypeID, promoCodeID *int) (string, sql.NullInt64, error) {
+	var position sql.NullInt64
+
+	count, err := seatsTaken(tx, eventID)
+	if err != nil {
+		return "", position, err
+	}
+	held, err := heldSeats(tx, eventID, time.Now())
+	if err != nil {
+		return "", position, err
+	}
+	full := count+seatsHeldFor(held, promoCodeID) >= capacity
+	if !full && ticketTypeID != nil {
+		full, err = ticketTypeFull(tx, *ticketTypeID)
+		if err != nil {
+			return "", position, err
+		}
+	}
+	if !full {
+		return models.ParticipantStatusConfirmed, position, nil
+	}
+
+	positionQuery := `
+	SELECT COALESCE(MAX(waitlist_position), 0) + 1 FROM participants WHERE event_id = $1 AND status = $2
+	`
+	err = tx.QueryRow(positionQuery, eventID, models.ParticipantStatusWaitlisted).Scan(&position)
+	if err != nil {
+		log.Printf("Error getting next waitlist position: %v", err)
+		return "", position, err
+	}
+
+	return models.ParticipantStatusWaitlisted, position, nil
+}
+
+// addApplicant adds a pending registration of a user, which takes no seat
+// until it is approved. The caller must hold the lock on the event row.
+func addApplicant(tx *sql.Tx, userID, eventID int, ticketTypeID, promoCodeID *int) (*models.Participant, error) {
+	participant := &models.Participant{
+		UserID:       userID,
+		EventID:      eventID,
+		Status:       models.ParticipantStatusPending,
+		JoinedAt:     time.Now(),
+		TicketTypeID: ticketTypeID,
+		PromoCodeID:  promoCodeID,
+	}
+
+	insertQuery := `
+	INSERT INTO participants (user_id, event_id, status, joined_at, ticket_type_id, promo_code_id)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id
+	`
+	err := tx.QueryRow(insertQuery, userID, eventID, participant.Status, participant.JoinedAt, ticketTypeID, promoCodeID).Scan(&participant.ID)
+	if err != nil {
+		log.Printf("Error adding applicant: %v", err)
+		return nil, err
+	}
+
+	return participant, nil
+}
+
+// promoteWaitlisted moves users from the head of the waitlist into free seats.
+// Users whose ticket type is sold out keep their place, and the next user in
+// line gets the seat; seats held by a promo code only go to its holders.
+// Users of priced ticket types get a reservation for promotionHold. It
+// returns the promoted participants, who haven't heard about their seat yet.
+// It must run inside a transaction that already holds the lock on the event
+// row.
+func promoteWaitlisted(tx *sql.Tx, eventID, capacity int) ([]models.Participant, error) {
+	taken, err := seatsTaken(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	free := capacity - taken
+	if free <= 0 {
+		return nil, nil
+	}
+
+	room, err := ticketTypeRoom(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	held, err := heldSeats(tx, eventID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return promoteInOrder(tx, eventID, free, held, room)
+}
+
+// promoteInOrder walks the waitlist of an event in order and confirms up to
+// free users who fit
+func promoteInOrder(tx *sql.Tx, eventID, free int, held, room map[int]int) ([]models.Participant, error) {
+	promoted, err := promotable(tx, eventID, free, held, room)
+	if err != nil {
+		return nil, err
+	}
+
+	promoteQuery := `
+	UPDATE participants SET status = $1, waitlist_position = NULL WHERE id = $2
+	`
+	now := time.Now()
+	for i := range promoted {
+		if _, err = tx.Exec(promoteQuery, models.ParticipantStatusConfirmed, promoted[i].ID); err != nil {
+			log.Printf("Error promoting waitlisted participant: %v", err)
+			return nil, err
+		}
+		promoted[i].Status = models.ParticipantStatusConfirmed
+		if err = reserveSeat(tx, &promoted[i], now, promotionHold); err != nil {
+			return nil, err
+		}
+	}
+
+	return promoted, nil
+}
+
+// promotable returns the waitlisted participants promoteInOrder confirms.
+// A user takes a seat held by their promo code first and one of the seats
+// anyone may take otherwise.
+func promotable(tx *sql.Tx, eventID, free int, held, room map[int]int) ([]models.Participant, error) {
+	waitlistQuery := `
+	SELECT id, user_id, ticket_type_id, promo_code_id FROM participants
+	WHERE event_id = $1 AND status = $2
+	ORDER BY waitlist_position ASC, id ASC
+	`
+	rows, err := tx.Query(waitlistQuery, eventID, models.ParticipantStatusWaitlisted)
+	if err != nil {
+		log.Printf("Error getting waitlist: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	open := free - seatsHeldFor(held, nil)
+	promoted := []models.Participant{}
+	for len(promoted) < free && rows.Next() {
+		participant := models.Participant{EventID: eventID}
+		if err := rows.Scan(&participant.ID, &participant.UserID, &participant.TicketTypeID, &participant.PromoCodeID); err != nil {
+			log.Printf("Error scanning waitlist: %v", err)
+			return nil, err
+		}
+		if participant.TicketTypeID != nil && room[*participant.TicketTypeID] <= 0 {
+			continue
+		}
+		switch {
+		case participant.PromoCodeID != nil && held[*participant.PromoCodeID] > 0:
+			held[*participant.PromoCodeID]--
+		case open > 0:
+			open--
+		default:
+			continue
+		}
+		if participant.TicketTypeID != nil {
+			room[*participant.TicketTypeID]--
+		}
+		promoted = append(promoted, participant)
+	}
+
+	return promoted, rows.Err()
+}
+
+// seatsTaken counts the seats of an event held by confirmed participants and
+// by reservations waiting for payment
+func seatsTaken(q rowQuerier, eventID int) (int, error) {
+	countQuery := `
+	SELECT COUNT(*) FROM participants WHERE event_id = $1 AND status IN ($2, $3)
+	`
+
+	var count int
+	err := q.QueryRow(countQuery, eventID, models.ParticipantStatusConfirmed, models.ParticipantStatusReserved).Scan(&count)
+	if err != nil {
+		log.Printf("Error checking participant count: %v", err)
 		return 0, err
 	}
 
 	return count, nil
 }
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// waitlistRank turns a stored waitlist position into a 1-based place in the queue
+func waitlistRank(q rowQuerier, eventID, position int) (int, error) {
+	rankQuery := `
+	SELECT COUNT(*) FROM participants WHERE event_id = $1 AND status = $2 AND waitlist_position <= $3
+	`
+
+	var rank int
+	err := q.QueryRow(rankQuery, eventID, models.ParticipantStatusWaitlisted, position).Scan(&rank)
+	if err != nil {
+		log.Printf("Error getting waitlist rank: %v", err)
+		return 0, err
+	}
+
+	return rank, nil
+}
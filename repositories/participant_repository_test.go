@@ -0,0 +1,602 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/event-system/database"
+	"github.com/event-system/migrations"
+	"github.com/event-system/models"
+)
+
+// openTestDB connects to the PostgreSQL database named by TEST_DATABASE_URL
+// and makes sure the schema exists. When it isn't set the tests run against
+// a fresh SQLite database instead.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	var db *sql.DB
+	var err error
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		db, err = sql.Open(database.DriverPostgres, dsn)
+	} else {
+		db, err = database.OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	}
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// Stay well below the default max_connections of PostgreSQL
+	db.SetMaxOpenConns(20)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	return db
+}
+
+// createTestUsers inserts n users with unique names and removes them when the test ends
+func createTestUsers(t *testing.T, db *sql.DB, n int) []*models.User {
+	t.Helper()
+
+	userRepo := NewUserRepository(db)
+	prefix := fmt.Sprintf("t%d", time.Now().UnixNano())
+
+	users := make([]*models.User, 0, n)
+	for i := 0; i < n; i++ {
+		user := &models.User{
+			Username: fmt.Sprintf("%s_%d", prefix, i),
+			Email:    fmt.Sprintf("%s_%d@example.com", prefix, i),
+			Password: "hashed",
+		}
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		users = append(users, user)
+	}
+
+	t.Cleanup(func() {
+		// Children go first, so nothing is left behind on a shared PostgreSQL
+		// database and a foreign key that blocks the cleanup fails the test
+		owned := `SELECT id FROM events WHERE organizer_id = $1`
+		statements := []string{
+			`DELETE FROM refresh_tokens WHERE user_id = $1`,
+			`DELETE FROM calendar_tokens WHERE user_id = $1`,
+			`DELETE FROM promo_redemptions WHERE user_id = $1 OR event_id IN (` + owned + `)`,
+			`DELETE FROM orders WHERE user_id = $1 OR event_id IN (` + owned + `)`,
+			`DELETE FROM participants WHERE user_id = $1 OR event_id IN (` + owned + `)`,
+			`DELETE FROM event_staff WHERE user_id = $1 OR event_id IN (` + owned + `)`,
+			`DELETE FROM event_invites WHERE user_id = $1 OR created_by = $1 OR event_id IN (` + owned + `)`,
+			`DELETE FROM promo_codes WHERE event_id IN (` + owned + `)`,
+			`DELETE FROM ticket_types WHERE event_id IN (` + owned + `)`,
+			`DELETE FROM events WHERE organizer_id = $1`,
+			`DELETE FROM event_series WHERE organizer_id = $1`,
+		}
+		for _, user := range users {
+			for _, statement := range statements {
+				if _, err := db.Exec(statement, user.ID); err != nil {
+					t.Errorf("clean up user %d: %v", user.ID, err)
+				}
+			}
+		}
+		for _, user := range users {
+			if _, err := db.Exec(`DELETE FROM users WHERE id = $1`, user.ID); err != nil {
+				t.Errorf("delete user %d: %v", user.ID, err)
+			}
+		}
+	})
+
+	return users
+}
+
+func createTestEvent(t *testing.T, db *sql.DB, organizerID, capacity int) *models.Event {
+	t.Helper()
+
+	event := &models.Event{
+		Name:        "Concurrency test",
+		StartTime:   time.Now().Add(24 * time.Hour),
+		EndTime:     time.Now().Add(26 * time.Hour),
+		Capacity:    capacity,
+		OrganizerID: organizerID,
+	}
+	if err := NewEventRepository(db).Create(event); err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+
+	return event
+}
+
+// On SQLite the joins are serialized by BEGIN IMMEDIATE, so the row lock on
+// the event is only exercised when TEST_DATABASE_URL points at PostgreSQL.
+func TestJoinEventConcurrentNeverExceedsCapacity(t *testing.T) {
+	db := openTestDB(t)
+
+	const joiners = 300
+	const capacity = 5
+
+	users := createTestUsers(t, db, joiners+1)
+	organizer, joining := users[0], users[1:]
+	event := createTestEvent(t, db, organizer.ID, capacity)
+
+	repo := NewParticipantRepository(db)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	confirmed := 0
+	positions := map[int]bool{}
+	start := make(chan struct{})
+
+	for _, user := range joining {
+		wg.Add(1)
+		go func(userID int) {
+			defer wg.Done()
+			<-start
+
+			participant, err := repo.JoinEvent(userID, event.ID, models.JoinOptions{})
+			if err != nil {
+				t.Errorf("unexpected join error: %v", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch participant.Status {
+			case models.ParticipantStatusConfirmed:
+				confirmed++
+			case models.ParticipantStatusWaitlisted:
+				if positions[participant.WaitlistPosition] {
+					t.Errorf("waitlist position %d handed out twice", participant.WaitlistPosition)
+				}
+				positions[participant.WaitlistPosition] = true
+			default:
+				t.Errorf("unexpected participant status %q", participant.Status)
+			}
+		}(user.ID)
+	}
+
+	close(start)
+	wg.Wait()
+
+	if confirmed != capacity {
+		t.Errorf("expected %d confirmed joins, got %d", capacity, confirmed)
+	}
+	if len(positions) != joiners-capacity {
+		t.Errorf("expected %d waitlisted joins, got %d", joiners-capacity, len(positions))
+	}
+
+	count, err := repo.GetParticipantCount(event.ID)
+	if err != nil {
+		t.Fatalf("get participant count: %v", err)
+	}
+	if count.Count > capacity {
+		t.Fatalf("event overfilled: %d participants for capacity %d", count.Count, capacity)
+	}
+	if count.Count != capacity {
+		t.Errorf("expected %d participants, got %d", capacity, count.Count)
+	}
+}
+
+func TestJoinEventConcurrentSameUserJoinsOnce(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 2)
+	organizer, user := users[0], users[1]
+	event := createTestEvent(t, db, organizer.ID, 10)
+
+	repo := NewParticipantRepository(db)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	joined := 0
+	start := make(chan struct{})
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			if _, err := repo.JoinEvent(user.ID, event.ID, models.JoinOptions{}); err == nil {
+				mu.Lock()
+				joined++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	if joined != 1 {
+		t.Errorf("expected exactly one successful join, got %d", joined)
+	}
+}
+
+func TestDeleteEventRacingJoin(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 2)
+	organizer, user := users[0], users[1]
+	event := createTestEvent(t, db, organizer.ID, 10)
+
+	eventRepo := NewEventRepository(db)
+	participantRepo := NewParticipantRepository(db)
+
+	var wg sync.WaitGroup
+	var joinErr, deleteErr error
+	start := make(chan struct{})
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-start
+		_, joinErr = participantRepo.JoinEvent(user.ID, event.ID, models.JoinOptions{})
+	}()
+	go func() {
+		defer wg.Done()
+		<-start
+		deleteErr = eventRepo.Delete(event.ID, organizer.ID)
+	}()
+
+	close(start)
+	wg.Wait()
+
+	// Exactly one of the two operations may win
+	if (joinErr == nil) == (deleteErr == nil) {
+		t.Fatalf("expected exactly one of join/delete to succeed, got join=%v delete=%v", joinErr, deleteErr)
+	}
+}
+
+func TestLeaveEventPromotesWaitlist(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 4)
+	organizer, first, second, third := users[0], users[1], users[2], users[3]
+	event := createTestEvent(t, db, organizer.ID, 1)
+
+	repo := NewParticipantRepository(db)
+	for _, user := range []*models.User{first, second, third} {
+		if _, err := repo.JoinEvent(user.ID, event.ID, models.JoinOptions{}); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+
+	// Put the last user in front of the queue
+	if err := repo.ReorderWaitlist(event.ID, []int{third.ID, second.ID}); err != nil {
+		t.Fatalf("reorder waitlist: %v", err)
+	}
+
+	if _, err := repo.LeaveEvent(first.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+
+	promoted, err := repo.IsParticipant(third.ID, event.ID)
+	if err != nil {
+		t.Fatalf("is participant: %v", err)
+	}
+	if !promoted {
+		t.Errorf("expected head of the waitlist to be promoted")
+	}
+
+	position, err := repo.GetWaitlistPosition(second.ID, event.ID)
+	if err != nil {
+		t.Fatalf("get waitlist position: %v", err)
+	}
+	if position != 1 {
+		t.Errorf("expected remaining user to be first on the waitlist, got %d", position)
+	}
+}
+
+func TestCapacityIncreasePromotesWaitlist(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 4)
+	organizer := users[0]
+	event := createTestEvent(t, db, organizer.ID, 1)
+
+	repo := NewParticipantRepository(db)
+	for _, user := range users[1:] {
+		if _, err := repo.JoinEvent(user.ID, event.ID, models.JoinOptions{}); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+
+	event.Capacity = 3
+	if _, err := NewEventRepository(db).Update(event); err != nil {
+		t.Fatalf("update event: %v", err)
+	}
+
+	count, err := repo.GetParticipantCount(event.ID)
+	if err != nil {
+		t.Fatalf("get participant count: %v", err)
+	}
+	if count.Count != 3 {
+		t.Errorf("expected all waitlisted users to be promoted, got %d participants", count.Count)
+	}
+
+	waitlist, err := repo.GetWaitlist(event.ID)
+	if err != nil {
+		t.Fatalf("get waitlist: %v", err)
+	}
+	if len(waitlist) != 0 {
+		t.Errorf("expected empty waitlist, got %d entries", len(waitlist))
+	}
+}
+
+func TestJoinEventRespectsRegistrationWindow(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 2)
+	organizer, user := users[0], users[1]
+	event := createTestEvent(t, db, organizer.ID, 5)
+
+	events := NewEventRepository(db)
+	repo := NewParticipantRepository(db)
+	update := func() {
+		t.Helper()
+		if _, err := events.Update(event); err != nil {
+			t.Fatalf("update event: %v", err)
+		}
+	}
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		when := now.Add(d)
+		return &when
+	}
+
+	// Announced, but not published yet
+	event.PublishAt = at(time.Hour)
+	update()
+	if _, err := repo.JoinEvent(user.ID, event.ID, models.JoinOptions{}); !errors.Is(err, ErrEventNotPublished) {
+		t.Errorf("join before publishing: got %v, want ErrEventNotPublished", err)
+	}
+	page, err := events.GetAllPublic(models.EventListQuery{})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	for _, listed := range page.Events {
+		if listed.ID == event.ID {
+			t.Error("expected the event to stay out of the public listing until it is published")
+		}
+	}
+
+	event.PublishAt = at(-time.Hour)
+	event.RegistrationOpensAt = at(time.Hour)
+	update()
+	if _, err := repo.JoinEvent(user.ID, event.ID, models.JoinOptions{}); !errors.Is(err, ErrRegistrationNotOpen) {
+		t.Errorf("join before registration opens: got %v, want ErrRegistrationNotOpen", err)
+	}
+
+	event.RegistrationOpensAt = at(-time.Hour)
+	event.RegistrationClosesAt = at(-time.Minute)
+	update()
+	if _, err := repo.JoinEvent(user.ID, event.ID, models.JoinOptions{}); !errors.Is(err, ErrRegistrationClosed) {
+		t.Errorf("join after registration closed: got %v, want ErrRegistrationClosed", err)
+	}
+
+	event.RegistrationClosesAt = at(time.Hour)
+	update()
+	if _, err := repo.JoinEvent(user.ID, event.ID, models.JoinOptions{}); err != nil {
+		t.Fatalf("join while registration is open: %v", err)
+	}
+
+	stored, err := events.GetByID(event.ID)
+	if err != nil {
+		t.Fatalf("get event: %v", err)
+	}
+	if stored.PublishAt == nil || !stored.PublishAt.Equal(*event.PublishAt) || stored.RegistrationClosesAt == nil || !stored.RegistrationClosesAt.Equal(*event.RegistrationClosesAt) {
+		t.Errorf("expected the schedule to be stored, got %+v", stored)
+	}
+}
+
+func TestReviewRegistrations(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 5)
+	organizer, first, second, third, rejected := users[0], users[1], users[2], users[3], users[4]
+	event := createTestEvent(t, db, organizer.ID, 1)
+	event.RequiresApproval = true
+	if _, err := NewEventRepository(db).Update(event); err != nil {
+		t.Fatalf("update event: %v", err)
+	}
+
+	repo := NewParticipantRepository(db)
+	for _, user := range users[1:] {
+		participant, err := repo.JoinEvent(user.ID, event.ID, models.JoinOptions{})
+		if err != nil {
+			t.Fatalf("join: %v", err)
+		}
+		if participant.Status != models.ParticipantStatusPending {
+			t.Errorf("status = %q, want pending", participant.Status)
+		}
+	}
+
+	// Pending registrations don't take a seat
+	if count, err := repo.GetParticipantCount(event.ID); err != nil || count.Count != 0 {
+		t.Errorf("expected no participants before the review, got %v (%v)", count, err)
+	}
+	pending, err := repo.GetPendingRegistrations(event.ID)
+	if err != nil {
+		t.Fatalf("get pending: %v", err)
+	}
+	if len(pending) != 4 || pending[0].User.ID != first.ID {
+		t.Fatalf("unexpected pending registrations %+v", pending)
+	}
+
+	// A user that isn't pending fails the whole batch
+	_, err = repo.ReviewRegistrations(event.ID, []int{first.ID, organizer.ID}, true, "")
+	if !errors.Is(err, ErrNotPending) {
+		t.Errorf("review a non-applicant: got %v, want ErrNotPending", err)
+	}
+
+	reviewed, err := repo.ReviewRegistrations(event.ID, []int{first.ID, second.ID}, true, "Welcome")
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if len(reviewed) != 2 || reviewed[0].Status != models.ParticipantStatusConfirmed ||
+		reviewed[1].Status != models.ParticipantStatusWaitlisted || reviewed[1].WaitlistPosition != 1 {
+		t.Errorf("unexpected approvals %+v", reviewed)
+	}
+	if _, err := repo.ReviewRegistrations(event.ID, []int{rejected.ID}, false, "Sorry"); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+
+	registration, err := repo.GetRegistration(rejected.ID, event.ID)
+	if err != nil {
+		t.Fatalf("get registration: %v", err)
+	}
+	if registration.Status != models.ParticipantStatusRejected || registration.ReviewMessage != "Sorry" || registration.ReviewedAt == nil {
+		t.Errorf("unexpected rejected registration %+v", registration)
+	}
+	if _, err := repo.JoinEvent(rejected.ID, event.ID, models.JoinOptions{}); !errors.Is(err, ErrRegistrationRejected) {
+		t.Errorf("join after rejection: got %v, want ErrRegistrationRejected", err)
+	}
+	if _, err := repo.LeaveEvent(rejected.ID, event.ID); !errors.Is(err, ErrNotParticipant) {
+		t.Errorf("leave after rejection: got %v, want ErrNotParticipant", err)
+	}
+
+	// The seat freed by a leaving participant goes to the waitlist, not to pending applicants
+	if _, err := repo.LeaveEvent(first.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if promoted, _ := repo.IsParticipant(second.ID, event.ID); !promoted {
+		t.Error("expected the waitlisted user to be promoted")
+	}
+	if registration, _ := repo.GetRegistration(third.ID, event.ID); registration == nil || registration.Status != models.ParticipantStatusPending {
+		t.Errorf("expected the third user to stay pending, got %+v", registration)
+	}
+}
+
+func TestCheckIn(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 3)
+	organizer, guest, waiting := users[0], users[1], users[2]
+	event := createTestEvent(t, db, organizer.ID, 1)
+
+	repo := NewParticipantRepository(db)
+	confirmed, err := repo.JoinEvent(guest.ID, event.ID, models.JoinOptions{})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	waitlisted, err := repo.JoinEvent(waiting.ID, event.ID, models.JoinOptions{})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	if _, err := repo.CheckIn(event.ID, waitlisted.ID, time.Now()); !errors.Is(err, ErrTicketNotFound) {
+		t.Errorf("check in a waitlisted user: got %v, want ErrTicketNotFound", err)
+	}
+	if _, err := repo.CheckIn(event.ID+1, confirmed.ID, time.Now()); !errors.Is(err, ErrTicketNotFound) {
+		t.Errorf("check in at another event: got %v, want ErrTicketNotFound", err)
+	}
+
+	// Concurrent scans of the same ticket let exactly one through
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	admitted, duplicates := 0, 0
+	start := make(chan struct{})
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			checkIn, err := repo.CheckIn(event.ID, confirmed.ID, time.Now())
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil && checkIn.User.ID == guest.ID:
+				admitted++
+			case errors.Is(err, ErrAlreadyCheckedIn):
+				duplicates++
+			default:
+				t.Errorf("unexpected check-in result %+v, %v", checkIn, err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if admitted != 1 || duplicates != 19 {
+		t.Errorf("expected 1 check-in and 19 duplicates, got %d and %d", admitted, duplicates)
+	}
+
+	attendance, err := repo.GetAttendance(event.ID)
+	if err != nil {
+		t.Fatalf("get attendance: %v", err)
+	}
+	if attendance.Registered != 1 || attendance.CheckedIn != 1 || attendance.NotCheckedIn != 0 {
+		t.Errorf("unexpected attendance %+v", attendance)
+	}
+	registration, err := repo.GetRegistration(guest.ID, event.ID)
+	if err != nil || registration.CheckedInAt == nil {
+		t.Errorf("expected the check-in time to be stored, got %+v, %v", registration, err)
+	}
+}
+
+func TestSyncCheckInsEarliestScanWins(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 3)
+	organizer, guest, waiting := users[0], users[1], users[2]
+	event := createTestEvent(t, db, organizer.ID, 1)
+
+	repo := NewParticipantRepository(db)
+	confirmed, err := repo.JoinEvent(guest.ID, event.ID, models.JoinOptions{})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	waitlisted, err := repo.JoinEvent(waiting.ID, event.ID, models.JoinOptions{})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	results, err := repo.SyncCheckIns(event.ID, []models.OfflineCheckIn{
+		{ParticipantID: confirmed.ID, ScannedAt: base.Add(2 * time.Minute)},
+		{ParticipantID: confirmed.ID, ScannedAt: base.Add(time.Minute)},
+		{ParticipantID: waitlisted.ID, ScannedAt: base},
+	})
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if results[0].Status != models.CheckInDuplicate || results[1].Status != models.CheckInSynced ||
+		results[2].Status != models.CheckInInvalid || results[2].Error != ErrTicketNotFound.Code {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if results[1].UserID != guest.ID || results[1].SupersededAt != nil {
+		t.Errorf("unexpected check-in %+v", results[1])
+	}
+
+	// A later batch with an earlier scan replaces the stored time
+	results, err = repo.SyncCheckIns(event.ID, []models.OfflineCheckIn{{ParticipantID: confirmed.ID, ScannedAt: base}})
+	if err != nil {
+		t.Fatalf("sync again: %v", err)
+	}
+	if results[0].Status != models.CheckInSynced || results[0].SupersededAt == nil || !results[0].SupersededAt.Equal(base.Add(time.Minute)) {
+		t.Errorf("expected the earlier scan to win, got %+v", results[0])
+	}
+
+	participants, err := repo.GetConfirmedParticipants(event.ID)
+	if err != nil {
+		t.Fatalf("get confirmed participants: %v", err)
+	}
+	if len(participants) != 1 || participants[0].CheckedInAt == nil || !participants[0].CheckedInAt.Equal(base) {
+		t.Errorf("unexpected confirmed participants %+v", participants)
+	}
+	if _, err := repo.CheckIn(event.ID, confirmed.ID, time.Now()); !errors.Is(err, ErrAlreadyCheckedIn) {
+		t.Errorf("online check-in after sync: got %v, want ErrAlreadyCheckedIn", err)
+	}
+}
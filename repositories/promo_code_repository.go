@@ -0,0 +1,492 @@
+package repositories
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/event-system/database"
+	"github.com/event-system/models"
+)
+
+// PromoCodeRepository handles database operations related to the promo codes of events
+type PromoCodeRepository struct {
+	DB *sql.DB
+}
+
+// NewPromoCodeRepository creates a new promo code repository instance
+func NewPromoCodeRepository(db *sql.DB) *PromoCodeRepository {
+	return &PromoCodeRepository{DB: db}
+}
+
+// promoCodeColumns selects a promo code with its redemptions that weren't
+// released and the seats its participants hold. Queries using it pass the
+// confirmed and reserved statuses as $1 and $2.
+const promoCodeColumns = `c.id, c.event_id, c.code, c.discount_type, c.discount_value, c.ticket_type_id, c.reserved_seats,
+	c.max_uses, c.per_user_limit, c.expires_at, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM promo_redemptions r WHERE r.promo_code_id = c.id AND r.released_at IS NULL),
+	(SELECT COUNT(*) FROM participants p WHERE p.promo_code_id = c.id AND p.status IN ($1, $2))`
+
+// promoCodeFields returns the scan destinations for promoCodeColumns
+func promoCodeFields(code *models.PromoCode) []interface{} {
+	return []interface{}{
+		&code.ID,
+		&code.EventID,
+		&code.Code,
+		&code.DiscountType,
+		&code.DiscountValue,
+		&code.TicketTypeID,
+		&code.ReservedSeats,
+		&code.MaxUses,
+		&code.PerUserLimit,
+		&code.ExpiresAt,
+		&code.CreatedAt,
+		&code.UpdatedAt,
+		&code.Uses,
+		&code.SeatsTaken,
+	}
+}
+
+// Create stores a new promo code of an event. The seats it holds back are
+// taken from the free seats of the event right away, so the event row is
+// locked and the block has to fit into the seats nobody took or holds yet.
+func (r *PromoCodeRepository) Create(code *models.PromoCode) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting promo code transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	var capacity int
+	err = tx.QueryRow(`SELECT capacity FROM events WHERE id = $1 FOR UPDATE`, code.EventID).Scan(&capacity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrEventNotFound
+		}
+		log.Printf("Error locking event: %v", err)
+		return err
+	}
+
+	now := time.Now()
+	if err = checkSeatBlock(tx, code, nil, capacity, now); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO promo_codes (event_id, code, discount_type, discount_value, ticket_type_id, reserved_seats, max_uses, per_user_limit,
+		expires_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+	RETURNING id
+	`
+
+	err = tx.QueryRow(query, code.EventID, code.Code, code.DiscountType, code.DiscountValue, code.TicketTypeID,
+		code.ReservedSeats, code.MaxUses, code.PerUserLimit, code.ExpiresAt, now).Scan(&code.ID)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return ErrPromoCodeExists
+		}
+		log.Printf("Error creating promo code: %v", err)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing promo code transaction: %v", err)
+		return err
+	}
+	code.CreatedAt = now
+	code.UpdatedAt = now
+	code.Uses = 0
+	code.SeatsTaken = 0
+
+	return nil
+}
+
+// GetByID retrieves a promo code by ID
+func (r *PromoCodeRepository) GetByID(id int) (*models.PromoCode, error) {
+	return getPromoCode(r.DB, `SELECT `+promoCodeColumns+` FROM promo_codes c WHERE c.id = $3`, id)
+}
+
+// getPromoCode runs a query built on promoCodeColumns that returns one code
+func getPromoCode(q rowQuerier, query string, args ...interface{}) (*models.PromoCode, error) {
+	args = append([]interface{}{models.ParticipantStatusConfirmed, models.ParticipantStatusReserved}, args...)
+
+	code := &models.PromoCode{}
+	err := q.QueryRow(query, args...).Scan(promoCodeFields(code)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPromoCodeNotFound
+		}
+		log.Printf("Error getting promo code: %v", err)
+		return nil, err
+	}
+
+	return code, nil
+}
+
+// GetByEvent retrieves all promo codes of an event, oldest first
+func (r *PromoCodeRepository) GetByEvent(eventID int) ([]models.PromoCode, error) {
+	rows, err := r.DB.Query(`SELECT `+promoCodeColumns+` FROM promo_codes c WHERE c.event_id = $3 ORDER BY c.id ASC`,
+		models.ParticipantStatusConfirmed, models.ParticipantStatusReserved, eventID)
+	if err != nil {
+		log.Printf("Error getting promo codes: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := []models.PromoCode{}
+	for rows.Next() {
+		var code models.PromoCode
+		if err := rows.Scan(promoCodeFields(&code)...); err != nil {
+			log.Printf("Error scanning promo code: %v", err)
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating promo codes: %v", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Update changes a promo code of an event. It locks the event row like
+// JoinEvent, and seats a smaller block or a new expiry gives back go to the
+// waitlist right away; it returns the participants who moved up. Lowering the
+// limits doesn't undo past redemptions.
+func (r *PromoCodeRepository) Update(code *models.PromoCode) ([]models.Participant, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting promo code transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var capacity int
+	err = tx.QueryRow(`SELECT capacity FROM events WHERE id = $1 FOR UPDATE`, code.EventID).Scan(&capacity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEventNotFound
+		}
+		log.Printf("Error locking event: %v", err)
+		return nil, err
+	}
+
+	current, err := getPromoCode(tx, `SELECT `+promoCodeColumns+` FROM promo_codes c WHERE c.id = $3 AND c.event_id = $4`, code.ID, code.EventID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkSeatBlock(tx, code, current, capacity, time.Now()); err != nil {
+		return nil, err
+	}
+
+	updateQuery := `
+	UPDATE promo_codes
+	SET code = $1, discount_type = $2, discount_value = $3, ticket_type_id = $4, reserved_seats = $5, max_uses = $6,
+		per_user_limit = $7, expires_at = $8, updated_at = $9
+	WHERE id = $10 AND event_id = $11
+	`
+	result, err := tx.Exec(updateQuery, code.Code, code.DiscountType, code.DiscountValue, code.TicketTypeID, code.ReservedSeats,
+		code.MaxUses, code.PerUserLimit, code.ExpiresAt, time.Now(), code.ID, code.EventID)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return nil, ErrPromoCodeExists
+		}
+		log.Printf("Error updating promo code: %v", err)
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrPromoCodeNotFound
+	}
+
+	promoted, err := promoteWaitlisted(tx, code.EventID, capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := getPromoCode(tx, `SELECT `+promoCodeColumns+` FROM promo_codes c WHERE c.id = $3`, code.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing promo code transaction: %v", err)
+		return nil, err
+	}
+	*code = *updated
+
+	return promoted, nil
+}
+
+// Delete removes a promo code of an event that was never redeemed and gives
+// the seats it held back to the waitlist. It returns the participants who
+// moved up into them.
+func (r *PromoCodeRepository) Delete(eventID, id int) ([]models.Participant, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting promo code transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the event row so nobody redeems the code meanwhile
+	var capacity int
+	err = tx.QueryRow(`SELECT capacity FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(&capacity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEventNotFound
+		}
+		log.Printf("Error locking event: %v", err)
+		return nil, err
+	}
+
+	var used bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM promo_redemptions WHERE promo_code_id = $1)`, id).Scan(&used)
+	if err != nil {
+		log.Printf("Error checking promo code redemptions: %v", err)
+		return nil, err
+	}
+	if used {
+		return nil, ErrPromoCodeInUse
+	}
+
+	result, err := tx.Exec(`DELETE FROM promo_codes WHERE id = $1 AND event_id = $2`, id, eventID)
+	if err != nil {
+		log.Printf("Error deleting promo code: %v", err)
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrPromoCodeNotFound
+	}
+
+	promoted, err := promoteWaitlisted(tx, eventID, capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing promo code transaction: %v", err)
+		return nil, err
+	}
+
+	return promoted, nil
+}
+
+// GetRedemptions returns every use of a promo code, oldest first, with the
+// status of the registration each one is still held by
+func (r *PromoCodeRepository) GetRedemptions(codeID int) ([]models.PromoRedemptionResponse, error) {
+	query := `
+	SELECT u.id, u.username, u.email, u.created_at, p.status, r.discount, r.redeemed_at, r.released_at
+	FROM promo_redemptions r
+	JOIN users u ON u.id = r.user_id
+	LEFT JOIN participants p ON r.released_at IS NULL AND p.event_id = r.event_id AND p.user_id = r.user_id
+		AND p.promo_code_id = r.promo_code_id
+	WHERE r.promo_code_id = $1
+	ORDER BY r.redeemed_at ASC, r.id ASC
+	`
+
+	rows, err := r.DB.Query(query, codeID)
+	if err != nil {
+		log.Printf("Error getting promo code redemptions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := []models.PromoRedemptionResponse{}
+	for rows.Next() {
+		var redemption models.PromoRedemptionResponse
+		var status sql.NullString
+		err := rows.Scan(
+			&redemption.User.ID,
+			&redemption.User.Username,
+			&redemption.User.Email,
+			&redemption.User.CreatedAt,
+			&status,
+			&redemption.Discount,
+			&redemption.RedeemedAt,
+			&redemption.ReleasedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning promo code redemption: %v", err)
+			return nil, err
+		}
+		redemption.Status = status.String
+		redemptions = append(redemptions, redemption)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating promo code redemptions: %v", err)
+		return nil, err
+	}
+
+	return redemptions, nil
+}
+
+// CheckPromoCode returns the reason a user can't redeem a promo code at now,
+// or nil. redeemed is how often the user has used the code before, released
+// uses included.
+func CheckPromoCode(code *models.PromoCode, redeemed int, now time.Time) error {
+	switch {
+	case code.ExpiresAt != nil && !now.Before(*code.ExpiresAt):
+		return ErrPromoCodeExpired
+	case code.MaxUses > 0 && code.Uses >= code.MaxUses:
+		return ErrPromoCodeUsedUp
+	case code.PerUserLimit > 0 && redeemed >= code.PerUserLimit:
+		return ErrPromoCodeUserLimit
+	}
+	return nil
+}
+
+// redeemPromoCode records a use of a promo code by a user joining an event
+// and returns the code. It runs inside the join transaction that holds the
+// lock on the event row, so two joins can't both take the last use.
+func redeemPromoCode(tx *sql.Tx, eventID, userID int, value string, now time.Time) (*models.PromoCode, error) {
+	code, err := getPromoCode(tx, `SELECT `+promoCodeColumns+` FROM promo_codes c WHERE c.event_id = $3 AND c.code = $4`, eventID, value)
+	if err != nil {
+		return nil, err
+	}
+
+	var redeemed int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM promo_redemptions WHERE promo_code_id = $1 AND user_id = $2`, code.ID, userID).Scan(&redeemed)
+	if err != nil {
+		log.Printf("Error counting promo code redemptions: %v", err)
+		return nil, err
+	}
+	if err = CheckPromoCode(code, redeemed, now); err != nil {
+		return nil, err
+	}
+
+	insertQuery := `
+	INSERT INTO promo_redemptions (promo_code_id, event_id, user_id, redeemed_at) VALUES ($1, $2, $3, $4)
+	`
+	if _, err = tx.Exec(insertQuery, code.ID, eventID, userID, now); err != nil {
+		log.Printf("Error redeeming promo code: %v", err)
+		return nil, err
+	}
+	code.Uses++
+
+	return code, nil
+}
+
+// releaseRedemption gives back the use of a promo code held by the
+// registration of a user that ends, so the code can be used again
+func releaseRedemption(tx *sql.Tx, eventID, userID int, now time.Time) error {
+	query := `
+	UPDATE promo_redemptions SET released_at = $1 WHERE event_id = $2 AND user_id = $3 AND released_at IS NULL
+	`
+
+	if _, err := tx.Exec(query, now, eventID, userID); err != nil {
+		log.Printf("Error releasing promo code redemption: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// heldSeats returns the seats the promo codes of an event still hold back for
+// their holders, by code: the block of each code that hasn't expired less the
+// seats its participants already took. The caller must hold the lock on the
+// event row.
+func heldSeats(tx *sql.Tx, eventID int, now time.Time) (map[int]int, error) {
+	query := `
+	SELECT c.id, c.reserved_seats - (SELECT COUNT(*) FROM participants p WHERE p.promo_code_id = c.id AND p.status IN ($1, $2))
+	FROM promo_codes c
+	WHERE c.event_id = $3 AND c.reserved_seats > 0 AND (c.expires_at IS NULL OR c.expires_at > $4)
+	`
+
+	rows, err := tx.Query(query, models.ParticipantStatusConfirmed, models.ParticipantStatusReserved, eventID, now)
+	if err != nil {
+		log.Printf("Error getting held seats: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	held := map[int]int{}
+	for rows.Next() {
+		var codeID, seats int
+		if err := rows.Scan(&codeID, &seats); err != nil {
+			log.Printf("Error scanning held seats: %v", err)
+			return nil, err
+		}
+		if seats > 0 {
+			held[codeID] = seats
+		}
+	}
+
+	return held, rows.Err()
+}
+
+// checkSeatBlock fails with ErrPromoSeatsAboveCapacity when the block of a
+// promo code grows beyond the seats of the event that are neither taken nor
+// held back by other codes. current is the stored code, or nil for a new one;
+// seats its participants already took count towards its block. The caller
+// must hold the lock on the event row.
+func checkSeatBlock(tx *sql.Tx, code, current *models.PromoCode, capacity int, now time.Time) error {
+	block, seated := activeBlock(code, now), 0
+	if current != nil {
+		if block <= activeBlock(current, now) {
+			return nil
+		}
+		seated = current.SeatsTaken
+	}
+	if block <= seated {
+		return nil
+	}
+
+	taken, err := seatsTaken(tx, code.EventID)
+	if err != nil {
+		return err
+	}
+	held, err := heldSeats(tx, code.EventID, now)
+	if err != nil {
+		return err
+	}
+	if taken+seatsHeldFor(held, &code.ID)+block-seated > capacity {
+		return ErrPromoSeatsAboveCapacity
+	}
+
+	return nil
+}
+
+// activeBlock returns the seats a promo code holds back at now: its block,
+// until the code expires
+func activeBlock(code *models.PromoCode, now time.Time) int {
+	if code.ExpiresAt != nil && !code.ExpiresAt.After(now) {
+		return 0
+	}
+	return code.ReservedSeats
+}
+
+// seatsHeldFor returns the seats held back from a user who joins with the
+// promo code codeID, or without one when it is nil: all blocks but their own
+func seatsHeldFor(held map[int]int, codeID *int) int {
+	total := 0
+	for id, seats := range held {
+		if codeID == nil || id != *codeID {
+			total += seats
+		}
+	}
+	return total
+}
+
+// promoDiscount returns what a promo code takes off a price; never more than the price
+func promoDiscount(discountType string, value, price int) int {
+	var discount int
+	switch discountType {
+	case models.PromoDiscountPercent:
+		discount = price * value / 100
+	case models.PromoDiscountFixed:
+		discount = value
+	}
+	return min(discount, price)
+}
@@ -0,0 +1,269 @@
+package repositories
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+func TestRedeemPromoCodeConcurrentNeverExceedsMaxUses(t *testing.T) {
+	db := openTestDB(t)
+
+	const joiners = 30
+	const maxUses = 4
+
+	users := createTestUsers(t, db, joiners+1)
+	organizer, joining := users[0], users[1:]
+	event := createTestEvent(t, db, organizer.ID, 100)
+
+	codes := NewPromoCodeRepository(db)
+	code := &models.PromoCode{EventID: event.ID, Code: "EARLY", DiscountType: models.PromoDiscountPercent, DiscountValue: 20, MaxUses: maxUses}
+	if err := codes.Create(code); err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+
+	repo := NewParticipantRepository(db)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	joined, usedUp := 0, 0
+	start := make(chan struct{})
+	for _, user := range joining {
+		wg.Add(1)
+		go func(userID int) {
+			defer wg.Done()
+			<-start
+			_, err := repo.JoinEvent(userID, event.ID, models.JoinOptions{PromoCode: "EARLY"})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				joined++
+			case errors.Is(err, ErrPromoCodeUsedUp):
+				usedUp++
+			default:
+				t.Errorf("unexpected join error: %v", err)
+			}
+		}(user.ID)
+	}
+	close(start)
+	wg.Wait()
+
+	if joined != maxUses || usedUp != joiners-maxUses {
+		t.Errorf("expected %d joins and %d refusals, got %d and %d", maxUses, joiners-maxUses, joined, usedUp)
+	}
+	stored, err := codes.GetByID(code.ID)
+	if err != nil {
+		t.Fatalf("get promo code: %v", err)
+	}
+	if stored.Uses != maxUses {
+		t.Errorf("expected %d uses, got %d", maxUses, stored.Uses)
+	}
+}
+
+func TestPromoCodeSeatBlockAndHiddenTier(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 5)
+	organizer, first, second, partner, other := users[0], users[1], users[2], users[3], users[4]
+	event := createTestEvent(t, db, organizer.ID, 3)
+
+	ticketTypes := NewTicketTypeRepository(db)
+	standard := &models.TicketType{EventID: event.ID, Name: "Standard", Quota: 10, Visibility: models.TicketTypePublic}
+	partnerTier := &models.TicketType{EventID: event.ID, Name: "Partner", Quota: 10, Visibility: models.TicketTypeHidden, Price: 3000, Currency: "EUR"}
+	for _, ticketType := range []*models.TicketType{standard, partnerTier} {
+		if err := ticketTypes.Create(ticketType); err != nil {
+			t.Fatalf("create ticket type: %v", err)
+		}
+	}
+
+	// The partner code holds 2 of the 3 seats, unlocks the hidden tier and
+	// takes a third off its price
+	codes := NewPromoCodeRepository(db)
+	code := &models.PromoCode{EventID: event.ID, Code: "PARTNER", DiscountType: models.PromoDiscountFixed, DiscountValue: 1000,
+		TicketTypeID: &partnerTier.ID, ReservedSeats: 2, PerUserLimit: 1}
+	if err := codes.Create(code); err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+	if err := codes.Create(&models.PromoCode{EventID: event.ID, Code: "SPONSOR", ReservedSeats: 2}); !errors.Is(err, ErrPromoSeatsAboveCapacity) {
+		t.Errorf("block beyond the free seats: got %v, want ErrPromoSeatsAboveCapacity", err)
+	}
+	if err := codes.Create(&models.PromoCode{EventID: event.ID, Code: "PARTNER"}); !errors.Is(err, ErrPromoCodeExists) {
+		t.Errorf("duplicate code: got %v, want ErrPromoCodeExists", err)
+	}
+	expired := time.Now().Add(-time.Hour)
+	if err := codes.Create(&models.PromoCode{EventID: event.ID, Code: "LASTYEAR", ExpiresAt: &expired}); err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+
+	repo := NewParticipantRepository(db)
+	for _, tc := range []struct {
+		name string
+		opts models.JoinOptions
+		want error
+	}{
+		{"hidden tier without the code", models.JoinOptions{TicketTypeID: partnerTier.ID}, ErrTicketTypeNotFound},
+		{"code for another tier", models.JoinOptions{TicketTypeID: standard.ID, PromoCode: "PARTNER"}, ErrPromoCodeNotApplicable},
+		{"unknown code", models.JoinOptions{TicketTypeID: standard.ID, PromoCode: "NOPE"}, ErrPromoCodeNotFound},
+		{"expired code", models.JoinOptions{TicketTypeID: standard.ID, PromoCode: "LASTYEAR"}, ErrPromoCodeExpired},
+	} {
+		if _, err := repo.JoinEvent(other.ID, event.ID, tc.opts); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// Only one seat is open to everyone else
+	joins := []struct {
+		user *models.User
+		opts models.JoinOptions
+		want string
+	}{
+		{first, models.JoinOptions{TicketTypeID: standard.ID}, models.ParticipantStatusConfirmed},
+		{second, models.JoinOptions{TicketTypeID: standard.ID}, models.ParticipantStatusWaitlisted},
+		{partner, models.JoinOptions{PromoCode: "PARTNER"}, models.ParticipantStatusReserved},
+	}
+	for _, join := range joins {
+		participant, err := repo.JoinEvent(join.user.ID, event.ID, join.opts)
+		if err != nil {
+			t.Fatalf("join: %v", err)
+		}
+		if participant.Status != join.want {
+			t.Errorf("user %d: got status %q, want %q", join.user.ID, participant.Status, join.want)
+		}
+	}
+	registration, err := repo.GetRegistration(partner.ID, event.ID)
+	if err != nil {
+		t.Fatalf("get registration: %v", err)
+	}
+	if registration.TicketTypeID == nil || *registration.TicketTypeID != partnerTier.ID {
+		t.Errorf("expected the code to choose the partner tier, got %v", registration.TicketTypeID)
+	}
+	order := pendingOrder(t, NewOrderRepository(db), event.ID, partner.ID)
+	if order.Amount != 2000 || order.Discount != 1000 {
+		t.Errorf("expected an order of 2000 after a discount of 1000, got %+v", order)
+	}
+
+	// Leaving gives the use back, but the user has reached their own limit
+	if _, err := repo.LeaveEvent(partner.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	stored, err := codes.GetByID(code.ID)
+	if err != nil {
+		t.Fatalf("get promo code: %v", err)
+	}
+	if stored.Uses != 0 || stored.SeatsTaken != 0 {
+		t.Errorf("expected the use and the seat to be released, got %+v", stored)
+	}
+	if _, err := repo.JoinEvent(partner.ID, event.ID, models.JoinOptions{PromoCode: "PARTNER"}); !errors.Is(err, ErrPromoCodeUserLimit) {
+		t.Errorf("rejoin: got %v, want ErrPromoCodeUserLimit", err)
+	}
+
+	// A smaller block gives a seat to the waitlist
+	stored.ReservedSeats = 1
+	if _, err := codes.Update(stored); err != nil {
+		t.Fatalf("update promo code: %v", err)
+	}
+	grown := *stored
+	grown.ReservedSeats = 2
+	if _, err := codes.Update(&grown); !errors.Is(err, ErrPromoSeatsAboveCapacity) {
+		t.Errorf("grow the block into taken seats: got %v, want ErrPromoSeatsAboveCapacity", err)
+	}
+	promoted, err := repo.GetRegistration(second.ID, event.ID)
+	if err != nil {
+		t.Fatalf("get registration: %v", err)
+	}
+	if promoted.Status != models.ParticipantStatusConfirmed {
+		t.Errorf("expected the waitlisted user to get the freed seat, got %q", promoted.Status)
+	}
+
+	redemptions, err := codes.GetRedemptions(code.ID)
+	if err != nil {
+		t.Fatalf("get redemptions: %v", err)
+	}
+	if len(redemptions) != 1 || redemptions[0].User.ID != partner.ID || redemptions[0].ReleasedAt == nil || redemptions[0].Status != "" {
+		t.Errorf("expected one released redemption, got %+v", redemptions)
+	}
+	if _, err := codes.Delete(event.ID, code.ID); !errors.Is(err, ErrPromoCodeInUse) {
+		t.Errorf("delete redeemed code: got %v, want ErrPromoCodeInUse", err)
+	}
+
+	// The tier of the code stays, but the whole event can still go
+	if err := ticketTypes.Delete(event.ID, partnerTier.ID); !errors.Is(err, ErrTicketTypeHasPromoCodes) {
+		t.Errorf("delete the tier of a code: got %v, want ErrTicketTypeHasPromoCodes", err)
+	}
+	if err := NewEventRepository(db).ForceDelete(event.ID); err != nil {
+		t.Errorf("force delete: %v", err)
+	}
+}
+
+func TestDeleteEventWithTierBoundPromoCode(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 1)
+	organizer := users[0]
+	events := NewEventRepository(db)
+	ticketTypes := NewTicketTypeRepository(db)
+	codes := NewPromoCodeRepository(db)
+
+	// The event goes with its ticket types and the codes tied to them,
+	// whichever the database deletes first
+	for name, remove := range map[string]func(eventID int) error{
+		"delete":       func(eventID int) error { return events.Delete(eventID, organizer.ID) },
+		"force delete": events.ForceDelete,
+	} {
+		event := createTestEvent(t, db, organizer.ID, 10)
+		tier := &models.TicketType{EventID: event.ID, Name: "Partner", Quota: 5, Visibility: models.TicketTypeHidden}
+		if err := ticketTypes.Create(tier); err != nil {
+			t.Fatalf("%s: create ticket type: %v", name, err)
+		}
+		code := &models.PromoCode{EventID: event.ID, Code: "PARTNER", TicketTypeID: &tier.ID, ReservedSeats: 2}
+		if err := codes.Create(code); err != nil {
+			t.Fatalf("%s: create promo code: %v", name, err)
+		}
+
+		if err := remove(event.ID); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if _, err := codes.GetByID(code.ID); !errors.Is(err, ErrPromoCodeNotFound) {
+			t.Errorf("%s: promo code: got %v, want ErrPromoCodeNotFound", name, err)
+		}
+	}
+}
+
+func TestCapacityKeepsTakenAndHeldSeats(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 2)
+	organizer, guest := users[0], users[1]
+	event := createTestEvent(t, db, organizer.ID, 5)
+	events := NewEventRepository(db)
+
+	if _, err := NewParticipantRepository(db).JoinEvent(guest.ID, event.ID, models.JoinOptions{}); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	code := &models.PromoCode{EventID: event.ID, Code: "PARTNER", ReservedSeats: 2}
+	if err := NewPromoCodeRepository(db).Create(code); err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+
+	// One seat is taken and two are held
+	event.Capacity = 2
+	if _, err := events.Update(event); !errors.Is(err, ErrCapacityBelowTaken) {
+		t.Errorf("capacity below held seats: got %v, want ErrCapacityBelowTaken", err)
+	}
+	stored, err := events.GetByID(event.ID)
+	if err != nil {
+		t.Fatalf("get event: %v", err)
+	}
+	if stored.Capacity != 5 {
+		t.Errorf("expected the rejected update to keep capacity 5, got %d", stored.Capacity)
+	}
+
+	event.Capacity = 3
+	if _, err := events.Update(event); err != nil {
+		t.Errorf("capacity that fits every seat: %v", err)
+	}
+}
@@ -0,0 +1,740 @@
+package repositories
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// SeriesRepository handles database operations related to recurring event series
+type SeriesRepository struct {
+	DB *sql.DB
+}
+
+// NewSeriesRepository creates a new series repository instance
+func NewSeriesRepository(db *sql.DB) *SeriesRepository {
+	return &SeriesRepository{DB: db}
+}
+
+const seriesColumns = `id, name, description, location, start_time, end_time, capacity, organizer_id, timezone, rrule, exdates, created_at, updated_at`
+
+// formatExDates stores exception dates as comma separated RFC 3339 times in UTC
+func formatExDates(dates []time.Time) string {
+	values := make([]string, len(dates))
+	for i, date := range dates {
+		values[i] = date.UTC().Format(time.RFC3339)
+	}
+	return strings.Join(values, ",")
+}
+
+func parseExDates(value string) ([]time.Time, error) {
+	dates := []time.Time{}
+	if value == "" {
+		return dates, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		date, err := time.Parse(time.RFC3339, item)
+		if err != nil {
+			return nil, err
+		}
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+func scanSeries(row *sql.Row) (*models.EventSeries, error) {
+	series := &models.EventSeries{}
+	var exdates string
+	err := row.Scan(
+		&series.ID,
+		&series.Name,
+		&series.Description,
+		&series.Location,
+		&series.StartTime,
+		&series.EndTime,
+		&series.Capacity,
+		&series.OrganizerID,
+		&series.TimeZone,
+		&series.RRule,
+		&exdates,
+		&series.CreatedAt,
+		&series.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSeriesNotFound
+		}
+		log.Printf("Error scanning series: %v", err)
+		return nil, err
+	}
+
+	series.ExDates, err = parseExDates(exdates)
+	if err != nil {
+		log.Printf("Error parsing series exception dates: %v", err)
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// Create inserts a series together with its first occurrences in one transaction
+func (r *SeriesRepository) Create(series *models.EventSeries, occurrences []models.Event) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting series transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	err = insertSeries(tx, series)
+	if err != nil {
+		return err
+	}
+
+	searchVector := hasSearchVector(r.DB)
+	for i := range occurrences {
+		occurrences[i].SeriesID = &series.ID
+		err = insertEvent(tx, searchVector, &occurrences[i])
+		if err != nil {
+			log.Printf("Error creating occurrence: %v", err)
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing series transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func insertSeries(tx *sql.Tx, series *models.EventSeries) error {
+	now := time.Now()
+	series.CreatedAt = now
+	series.UpdatedAt = now
+
+	query := `
+	INSERT INTO event_series (name, description, location, start_time, end_time, capacity, organizer_id, timezone, rrule, exdates, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	RETURNING id
+	`
+	err := tx.QueryRow(query,
+		series.Name,
+		series.Description,
+		series.Location,
+		series.StartTime,
+		series.EndTime,
+		series.Capacity,
+		series.OrganizerID,
+		series.TimeZone,
+		series.RRule,
+		formatExDates(series.ExDates),
+		series.CreatedAt,
+		series.UpdatedAt,
+	).Scan(&series.ID)
+	if err != nil {
+		log.Printf("Error creating series: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func updateSeries(tx *sql.Tx, series *models.EventSeries) error {
+	series.UpdatedAt = time.Now()
+
+	query := `
+	UPDATE event_series
+	SET name = $1, description = $2, location = $3, start_time = $4, end_time = $5,
+	    capacity = $6, timezone = $7, rrule = $8, exdates = $9, updated_at = $10
+	WHERE id = $11
+	`
+	_, err := tx.Exec(query,
+		series.Name,
+		series.Description,
+		series.Location,
+		series.StartTime,
+		series.EndTime,
+		series.Capacity,
+		series.TimeZone,
+		series.RRule,
+		formatExDates(series.ExDates),
+		series.UpdatedAt,
+		series.ID,
+	)
+	if err != nil {
+		log.Printf("Error updating series: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// lockSeries locks a series row until the end of tx
+func lockSeries(tx *sql.Tx, id int) (*models.EventSeries, error) {
+	query := `SELECT ` + seriesColumns + ` FROM event_series WHERE id = $1 FOR UPDATE`
+	return scanSeries(tx.QueryRow(query, id))
+}
+
+// GetByID retrieves a series by ID
+func (r *SeriesRepository) GetByID(id int) (*models.EventSeries, error) {
+	query := `SELECT ` + seriesColumns + ` FROM event_series WHERE id = $1`
+	return scanSeries(r.DB.QueryRow(query, id))
+}
+
+// GetOccurrences retrieves all occurrences of a series in start time order
+func (r *SeriesRepository) GetOccurrences(seriesID int) ([]models.Event, error) {
+	query := `
+	SELECT ` + eventColumns + `
+	FROM events e
+	WHERE e.series_id = $1
+	ORDER BY e.start_time ASC, e.id ASC
+	`
+
+	rows, err := r.DB.Query(query, seriesID)
+	if err != nil {
+		log.Printf("Error getting occurrences: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.Event{}
+	for rows.Next() {
+		event := models.Event{}
+		if err := rows.Scan(eventFields(&event)...); err != nil {
+			log.Printf("Error scanning occurrence: %v", err)
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating occurrences: %v", err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Save applies an edit of a series in one transaction: it updates or creates
+// the series, splits it off an older series when needed, and updates, creates
+// and removes occurrences. Occurrences that would be removed must have no
+// participants. Whoever joined the whole series gets a seat, or a waitlist
+// spot, in every new occurrence. It returns the participants who moved up
+// from the waitlist of an updated occurrence.
+func (r *SeriesRepository) Save(change *models.SeriesChange) ([]models.Participant, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting series transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the series first and the occurrences after, like JoinSeries
+	if change.Split != nil {
+		if _, err = lockSeries(tx, change.Split.ID); err != nil {
+			return nil, err
+		}
+		if err = updateSeries(tx, change.Split); err != nil {
+			return nil, err
+		}
+	}
+
+	series := change.Series
+	if series.ID == 0 {
+		err = insertSeries(tx, series)
+	} else {
+		if _, err = lockSeries(tx, series.ID); err != nil {
+			return nil, err
+		}
+		err = updateSeries(tx, series)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if change.Split != nil {
+		err = moveOccurrences(tx, change.Split.ID, series.ID, change.SplitAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range change.Remove {
+		err = removeOccurrence(tx, series.ID, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	searchVector := hasSearchVector(r.DB)
+	promoted := []models.Participant{}
+	for i := range change.Update {
+		event := &change.Update[i]
+		event.SeriesID = &series.ID
+		if err = updateEvent(tx, searchVector, event); err != nil {
+			return nil, err
+		}
+		if err = checkCapacity(tx, event.ID, event.Capacity, time.Now()); err != nil {
+			return nil, err
+		}
+		moved, err := promoteWaitlisted(tx, event.ID, event.Capacity)
+		if err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, moved...)
+	}
+
+	members, err := seriesMembers(tx, series.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range change.Create {
+		event := &change.Create[i]
+		event.SeriesID = &series.ID
+		if err = insertEvent(tx, searchVector, event); err != nil {
+			log.Printf("Error creating occurrence: %v", err)
+			return nil, err
+		}
+		for _, userID := range members {
+			if _, err = addParticipant(tx, userID, event.ID, event.Capacity, &series.ID, nil, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing series transaction: %v", err)
+		return nil, err
+	}
+
+	return promoted, nil
+}
+
+// GetIDs returns the IDs of all series
+func (r *SeriesRepository) GetIDs() ([]int, error) {
+	rows, err := r.DB.Query(`SELECT id FROM event_series ORDER BY id`)
+	if err != nil {
+		log.Printf("Error getting series IDs: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("Error scanning series ID: %v", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// AddOccurrences creates more occurrences of a series and gives everyone who
+// joined the whole series a seat in them. It fails with ErrSeriesChanged when
+// the series was edited since it was loaded, because the occurrences were
+// planned from the old version.
+func (r *SeriesRepository) AddOccurrences(series *models.EventSeries, occurrences []models.Event) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting series transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	locked, err := lockSeries(tx, series.ID)
+	if err != nil {
+		return err
+	}
+	if !locked.UpdatedAt.Equal(series.UpdatedAt) {
+		return ErrSeriesChanged
+	}
+
+	members, err := seriesMembers(tx, series.ID)
+	if err != nil {
+		return err
+	}
+	searchVector := hasSearchVector(r.DB)
+	for i := range occurrences {
+		event := &occurrences[i]
+		event.SeriesID = &series.ID
+		if err = insertEvent(tx, searchVector, event); err != nil {
+			log.Printf("Error creating occurrence: %v", err)
+			return err
+		}
+		for _, userID := range members {
+			if _, err = addParticipant(tx, userID, event.ID, event.Capacity, &series.ID, nil, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing series transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// moveOccurrences hands the occurrences of a series from splitAt on, and the
+// series joins to them, over to another series
+func moveOccurrences(tx *sql.Tx, fromID, toID int, splitAt time.Time) error {
+	_, err := tx.Exec(`UPDATE events SET series_id = $1 WHERE series_id = $2 AND recurrence_id >= $3`, toID, fromID, splitAt)
+	if err != nil {
+		log.Printf("Error moving occurrences: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec(`
+	UPDATE participants SET series_id = $1
+	WHERE series_id = $2 AND event_id IN (SELECT id FROM events WHERE series_id = $1)
+	`, toID, fromID)
+	if err != nil {
+		log.Printf("Error moving series participants: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// removeOccurrence deletes an occurrence of a series unless somebody joined it
+func removeOccurrence(tx *sql.Tx, seriesID, eventID int) error {
+	var lockedID int
+	err := tx.QueryRow(`SELECT id FROM events WHERE id = $1 AND series_id = $2 FOR UPDATE`, eventID, seriesID).Scan(&lockedID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrEventNotFound
+		}
+		log.Printf("Error locking occurrence: %v", err)
+		return err
+	}
+
+	var count int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM participants WHERE event_id = $1`, eventID).Scan(&count)
+	if err != nil {
+		log.Printf("Error checking participants: %v", err)
+		return err
+	}
+	if count > 0 {
+		return ErrOccurrenceHasParticipants
+	}
+
+	_, err = tx.Exec(`DELETE FROM events WHERE id = $1`, eventID)
+	if err != nil {
+		log.Printf("Error deleting occurrence: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// seriesMembers returns the users who joined a series as a whole, in the order they joined
+func seriesMembers(tx *sql.Tx, seriesID int) ([]int, error) {
+	rows, err := tx.Query(`
+	SELECT user_id FROM participants
+	WHERE series_id = $1
+	GROUP BY user_id
+	ORDER BY MIN(id) ASC
+	`, seriesID)
+	if err != nil {
+		log.Printf("Error getting series members: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []int{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			log.Printf("Error scanning series member: %v", err)
+			return nil, err
+		}
+		members = append(members, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating series members: %v", err)
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// Delete deletes a series with all its occurrences if none of them has participants
+func (r *SeriesRepository) Delete(id int, organizerID int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting delete transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	series, err := lockSeries(tx, id)
+	if err != nil {
+		return err
+	}
+	if series.OrganizerID != organizerID {
+		return ErrNotSeriesOwner
+	}
+
+	var count int
+	participantsQuery := `
+	SELECT COUNT(*) FROM participants p
+	JOIN events e ON p.event_id = e.id
+	WHERE e.series_id = $1
+	`
+	err = tx.QueryRow(participantsQuery, id).Scan(&count)
+	if err != nil {
+		log.Printf("Error checking participants: %v", err)
+		return err
+	}
+	if count > 0 {
+		return ErrEventHasParticipants
+	}
+
+	_, err = tx.Exec(`DELETE FROM events WHERE series_id = $1`, id)
+	if err != nil {
+		log.Printf("Error deleting occurrences: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM event_series WHERE id = $1`, id)
+	if err != nil {
+		log.Printf("Error deleting series: %v", err)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing delete transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// JoinSeries joins a user to every open occurrence of a series that hasn't
+// started yet. Each occurrence gives a seat or a waitlist spot on its own,
+// and occurrences the user already joined one by one are left as they are.
+// The whole series counts as one event towards the active events limit.
+func (r *SeriesRepository) JoinSeries(userID, seriesID int) ([]models.Participant, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting join transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the series, then its occurrences, then the user, the same order
+	// JoinEvent locks an event and the user
+	if _, err = lockSeries(tx, seriesID); err != nil {
+		return nil, err
+	}
+
+	// Only occurrences open for registration right now, see CheckRegistration.
+	// Private occurrences need an invite each, the ones that require approval
+	// a review each and the ones with ticket types a choice of ticket each, so
+	// they are joined one by one.
+	occurrencesQuery := `
+	SELECT id, capacity FROM events
+	WHERE series_id = $1 AND status = $2 AND start_time > $3 AND visibility <> 'private' AND NOT requires_approval
+	  AND NOT EXISTS (SELECT 1 FROM ticket_types t WHERE t.event_id = events.id)
+	  AND (publish_at IS NULL OR publish_at <= $3)
+	  AND (registration_opens_at IS NULL OR registration_opens_at <= $3)
+	  AND (registration_closes_at IS NULL OR registration_closes_at > $3)
+	ORDER BY start_time ASC, id ASC
+	FOR UPDATE
+	`
+	rows, err := tx.Query(occurrencesQuery, seriesID, models.EventStatusPublished, time.Now())
+	if err != nil {
+		log.Printf("Error locking occurrences: %v", err)
+		return nil, err
+	}
+	type occurrence struct{ id, capacity int }
+	occurrences := []occurrence{}
+	for rows.Next() {
+		var o occurrence
+		if err := rows.Scan(&o.id, &o.capacity); err != nil {
+			rows.Close()
+			log.Printf("Error scanning occurrence: %v", err)
+			return nil, err
+		}
+		occurrences = append(occurrences, o)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating occurrences: %v", err)
+		return nil, err
+	}
+
+	if len(occurrences) == 0 {
+		return nil, ErrEventNotOpen
+	}
+
+	var lockedUserID int
+	err = tx.QueryRow(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&lockedUserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		log.Printf("Error locking user: %v", err)
+		return nil, err
+	}
+
+	var memberID int
+	err = tx.QueryRow(`SELECT id FROM participants WHERE user_id = $1 AND series_id = $2 LIMIT 1`, userID, seriesID).Scan(&memberID)
+	if err == nil {
+		return nil, ErrAlreadyParticipant
+	} else if err != sql.ErrNoRows {
+		log.Printf("Error checking existing participant: %v", err)
+		return nil, err
+	}
+
+	err = checkActiveEventLimit(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	participants := []models.Participant{}
+	for _, o := range occurrences {
+		var existingID int
+		err = tx.QueryRow(`SELECT id FROM participants WHERE user_id = $1 AND event_id = $2`, userID, o.id).Scan(&existingID)
+		if err == nil {
+			continue
+		} else if err != sql.ErrNoRows {
+			log.Printf("Error checking existing participant: %v", err)
+			return nil, err
+		}
+
+		participant, err := addParticipant(tx, userID, o.id, o.capacity, &seriesID, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		participants = append(participants, *participant)
+	}
+
+	if len(participants) == 0 {
+		return nil, ErrAlreadyParticipant
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing join transaction: %v", err)
+		return nil, err
+	}
+
+	return participants, nil
+}
+
+// LeaveSeries takes a user out of every upcoming occurrence they joined
+// through the series and hands the freed seats to the waitlists. It returns
+// the participants who moved up.
+func (r *SeriesRepository) LeaveSeries(userID, seriesID int) ([]models.Participant, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting leave transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err = lockSeries(tx, seriesID); err != nil {
+		return nil, err
+	}
+
+	occurrencesQuery := `
+	SELECT e.id, e.capacity FROM events e
+	JOIN participants p ON p.event_id = e.id
+	WHERE e.series_id = $1 AND e.start_time > $2 AND p.user_id = $3 AND p.series_id = $1
+	ORDER BY e.start_time ASC, e.id ASC
+	FOR UPDATE
+	`
+	rows, err := tx.Query(occurrencesQuery, seriesID, time.Now(), userID)
+	if err != nil {
+		log.Printf("Error locking occurrences: %v", err)
+		return nil, err
+	}
+	capacities := map[int]int{}
+	ids := []int{}
+	for rows.Next() {
+		var id, capacity int
+		if err := rows.Scan(&id, &capacity); err != nil {
+			rows.Close()
+			log.Printf("Error scanning occurrence: %v", err)
+			return nil, err
+		}
+		capacities[id] = capacity
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating occurrences: %v", err)
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, ErrNotParticipant
+	}
+
+	promoted := []models.Participant{}
+	for _, id := range ids {
+		_, err = tx.Exec(`DELETE FROM participants WHERE user_id = $1 AND event_id = $2`, userID, id)
+		if err != nil {
+			log.Printf("Error removing participant: %v", err)
+			return nil, err
+		}
+		moved, err := promoteWaitlisted(tx, id, capacities[id])
+		if err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, moved...)
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing leave transaction: %v", err)
+		return nil, err
+	}
+
+	return promoted, nil
+}
+
+// lockSeriesOf locks the series an event belongs to, if any, before the
+// event itself gets locked. It returns the series ID and the recurrence ID
+// of the event, both invalid for stand-alone events.
+func lockSeriesOf(tx *sql.Tx, eventID int) (sql.NullInt64, sql.NullTime, error) {
+	var seriesID sql.NullInt64
+	var recurrenceID sql.NullTime
+	err := tx.QueryRow(`SELECT series_id, recurrence_id FROM events WHERE id = $1`, eventID).Scan(&seriesID, &recurrenceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return seriesID, recurrenceID, nil
+		}
+		log.Printf("Error getting series of event: %v", err)
+		return seriesID, recurrenceID, err
+	}
+
+	if seriesID.Valid {
+		if _, err = lockSeries(tx, int(seriesID.Int64)); err != nil {
+			return seriesID, recurrenceID, err
+		}
+	}
+
+	return seriesID, recurrenceID, nil
+}
+
+// excludeOccurrence records a deleted occurrence as an exception date of its
+// series, so editing the series later doesn't bring it back
+func excludeOccurrence(tx *sql.Tx, seriesID int64, recurrenceID time.Time) error {
+	series, err := lockSeries(tx, int(seriesID))
+	if err != nil {
+		return err
+	}
+
+	series.ExDates = append(series.ExDates, recurrenceID)
+	return updateSeries(tx, series)
+}
@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// createTestSeries stores a weekly series with the given number of occurrences
+func createTestSeries(t *testing.T, db *sql.DB, organizerID, capacity, count int) (*models.EventSeries, []models.Event) {
+	t.Helper()
+
+	start := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	series := &models.EventSeries{
+		Name:        "Series test",
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		Capacity:    capacity,
+		OrganizerID: organizerID,
+		TimeZone:    "UTC",
+		RRule:       "FREQ=WEEKLY",
+		ExDates:     []time.Time{},
+	}
+
+	occurrences := make([]models.Event, count)
+	for i := range occurrences {
+		recurrenceID := start.AddDate(0, 0, 7*i)
+		occurrences[i] = models.Event{
+			Name:         series.Name,
+			StartTime:    recurrenceID,
+			EndTime:      recurrenceID.Add(time.Hour),
+			Capacity:     capacity,
+			OrganizerID:  organizerID,
+			RecurrenceID: &recurrenceID,
+		}
+	}
+
+	if err := NewSeriesRepository(db).Create(series, occurrences); err != nil {
+		t.Fatalf("create series: %v", err)
+	}
+	return series, occurrences
+}
+
+func TestSeriesJoinAndSplit(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 3)
+	organizer, member, waiting := users[0], users[1], users[2]
+	series, occurrences := createTestSeries(t, db, organizer.ID, 1, 3)
+
+	repo := NewSeriesRepository(db)
+	stored, err := repo.GetOccurrences(series.ID)
+	if err != nil {
+		t.Fatalf("get occurrences: %v", err)
+	}
+	if len(stored) != 3 || stored[0].SeriesID == nil || *stored[0].SeriesID != series.ID || !stored[2].RecurrenceID.Equal(*occurrences[2].RecurrenceID) {
+		t.Fatalf("unexpected occurrences: %+v", stored)
+	}
+
+	joined, err := repo.JoinSeries(member.ID, series.ID)
+	if err != nil {
+		t.Fatalf("join series: %v", err)
+	}
+	if len(joined) != 3 || joined[0].Status != models.ParticipantStatusConfirmed {
+		t.Fatalf("unexpected participations: %+v", joined)
+	}
+	if _, err := repo.JoinSeries(member.ID, series.ID); !errors.Is(err, ErrAlreadyParticipant) {
+		t.Errorf("expected ErrAlreadyParticipant, got %v", err)
+	}
+	if _, err := repo.JoinSeries(waiting.ID, series.ID); err != nil {
+		t.Fatalf("join series: %v", err)
+	}
+
+	// Removing an occurrence somebody joined rolls the whole change back
+	series.Name = "Renamed"
+	_, err = repo.Save(&models.SeriesChange{Series: series, Remove: []int{occurrences[0].ID}})
+	if !errors.Is(err, ErrOccurrenceHasParticipants) {
+		t.Fatalf("expected ErrOccurrenceHasParticipants, got %v", err)
+	}
+	if unchanged, _ := repo.GetByID(series.ID); unchanged.Name != "Series test" {
+		t.Errorf("expected the series to be left alone, got %q", unchanged.Name)
+	}
+	series.Name = "Series test"
+
+	// Split off the last occurrence and add one more after it
+	splitAt := *occurrences[2].RecurrenceID
+	split := *series
+	split.RRule = "FREQ=WEEKLY;UNTIL=" + splitAt.Add(-time.Second).Format("20060102T150405Z")
+	following := *series
+	following.ID = 0
+	following.StartTime = splitAt
+	following.EndTime = splitAt.Add(time.Hour)
+	next := splitAt.AddDate(0, 0, 7)
+	_, err = repo.Save(&models.SeriesChange{
+		Series:  &following,
+		Split:   &split,
+		SplitAt: splitAt,
+		Create: []models.Event{{
+			Name:         following.Name,
+			StartTime:    next,
+			EndTime:      next.Add(time.Hour),
+			Capacity:     1,
+			OrganizerID:  organizer.ID,
+			RecurrenceID: &next,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("save split: %v", err)
+	}
+
+	moved, err := repo.GetOccurrences(following.ID)
+	if err != nil {
+		t.Fatalf("get occurrences: %v", err)
+	}
+	if len(moved) != 2 || moved[0].ID != occurrences[2].ID {
+		t.Fatalf("expected the last occurrence and a new one, got %+v", moved)
+	}
+
+	// Both members followed in their original order
+	participants := NewParticipantRepository(db)
+	if ok, _ := participants.IsParticipant(member.ID, moved[1].ID); !ok {
+		t.Error("expected the first member to get a seat in the new occurrence")
+	}
+	if position, err := participants.GetWaitlistPosition(waiting.ID, moved[1].ID); err != nil || position != 1 {
+		t.Errorf("expected the second member first on the waitlist, got %d, %v", position, err)
+	}
+
+	promoted, err := repo.LeaveSeries(member.ID, following.ID)
+	if err != nil {
+		t.Fatalf("leave new series: %v", err)
+	}
+	if len(promoted) == 0 || promoted[0].UserID != waiting.ID || promoted[0].EventID != moved[0].ID {
+		t.Errorf("expected the waitlisted member to be reported as promoted, got %+v", promoted)
+	}
+	if ok, _ := participants.IsParticipant(member.ID, occurrences[0].ID); !ok {
+		t.Error("expected the member to stay in the old series")
+	}
+	if ok, _ := participants.IsParticipant(waiting.ID, moved[0].ID); !ok {
+		t.Error("expected the waitlist to be promoted")
+	}
+}
+
+func TestDeleteOccurrenceExcludesIt(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 1)
+	series, occurrences := createTestSeries(t, db, users[0].ID, 5, 3)
+
+	if err := NewEventRepository(db).Delete(occurrences[1].ID, users[0].ID); err != nil {
+		t.Fatalf("delete occurrence: %v", err)
+	}
+
+	repo := NewSeriesRepository(db)
+	stored, err := repo.GetByID(series.ID)
+	if err != nil {
+		t.Fatalf("get series: %v", err)
+	}
+	if len(stored.ExDates) != 1 || !stored.ExDates[0].Equal(*occurrences[1].RecurrenceID) {
+		t.Errorf("expected the occurrence as exception date, got %v", stored.ExDates)
+	}
+
+	if err := repo.Delete(series.ID, users[0].ID+1); !errors.Is(err, ErrNotSeriesOwner) {
+		t.Errorf("expected ErrNotSeriesOwner, got %v", err)
+	}
+	if err := repo.Delete(series.ID, users[0].ID); err != nil {
+		t.Fatalf("delete series: %v", err)
+	}
+	if _, err := NewEventRepository(db).GetByID(occurrences[0].ID); !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("expected the occurrences to be deleted, got %v", err)
+	}
+}
+
+func TestAddOccurrences(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 2)
+	organizer, member := users[0], users[1]
+	created, occurrences := createTestSeries(t, db, organizer.ID, 5, 2)
+
+	repo := NewSeriesRepository(db)
+	if _, err := repo.JoinSeries(member.ID, created.ID); err != nil {
+		t.Fatalf("join series: %v", err)
+	}
+	ids, err := repo.GetIDs()
+	if err != nil {
+		t.Fatalf("get ids: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != created.ID {
+		t.Errorf("expected the series ID, got %v", ids)
+	}
+
+	series, err := repo.GetByID(created.ID)
+	if err != nil {
+		t.Fatalf("get series: %v", err)
+	}
+	recurrenceID := occurrences[1].RecurrenceID.AddDate(0, 0, 7)
+	next := models.Event{
+		Name:         series.Name,
+		StartTime:    recurrenceID,
+		EndTime:      recurrenceID.Add(time.Hour),
+		Capacity:     series.Capacity,
+		OrganizerID:  organizer.ID,
+		RecurrenceID: &recurrenceID,
+	}
+
+	stale := *series
+	stale.UpdatedAt = series.UpdatedAt.Add(-time.Minute)
+	if err := repo.AddOccurrences(&stale, []models.Event{next}); !errors.Is(err, ErrSeriesChanged) {
+		t.Errorf("expected ErrSeriesChanged, got %v", err)
+	}
+
+	if err := repo.AddOccurrences(series, []models.Event{next}); err != nil {
+		t.Fatalf("add occurrences: %v", err)
+	}
+	stored, err := repo.GetOccurrences(series.ID)
+	if err != nil {
+		t.Fatalf("get occurrences: %v", err)
+	}
+	if len(stored) != 3 || !stored[2].RecurrenceID.Equal(recurrenceID) {
+		t.Fatalf("expected the new occurrence last, got %+v", stored)
+	}
+
+	// Members of the series join the new occurrence
+	userIDs, err := NewParticipantRepository(db).GetParticipantUserIDs(stored[2].ID)
+	if err != nil {
+		t.Fatalf("participants: %v", err)
+	}
+	if len(userIDs) != 1 || userIDs[0] != member.ID {
+		t.Errorf("expected the member on the new occurrence, got %v", userIDs)
+	}
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// StaffRepository handles database operations related to event staff
+type StaffRepository struct {
+	DB *sql.DB
+}
+
+// NewStaffRepository creates a new staff repository instance
+func NewStaffRepository(db *sql.DB) *StaffRepository {
+	return &StaffRepository{DB: db}
+}
+
+// AddStaff grants a user a staff role on an event, replacing any role they already had
+func (r *StaffRepository) AddStaff(staff *models.EventStaff) error {
+	query := `
+	INSERT INTO event_staff (event_id, user_id, role, created_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (event_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	RETURNING created_at
+	`
+
+	err := r.DB.QueryRow(query, staff.EventID, staff.UserID, staff.Role, time.Now()).Scan(&staff.CreatedAt)
+	if err != nil {
+		log.Printf("Error adding event staff: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RemoveStaff takes away the staff role of a user on an event
+func (r *StaffRepository) RemoveStaff(eventID, userID int) error {
+	result, err := r.DB.Exec(`DELETE FROM event_staff WHERE event_id = $1 AND user_id = $2`, eventID, userID)
+	if err != nil {
+		log.Printf("Error removing event staff: %v", err)
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotStaff
+	}
+
+	return nil
+}
+
+// GetStaffRole returns the staff role of a user on an event, or an empty string if they have none
+func (r *StaffRepository) GetStaffRole(eventID, userID int) (string, error) {
+	query := `
+	SELECT role FROM event_staff WHERE event_id = $1 AND user_id = $2
+	`
+
+	var role string
+	err := r.DB.QueryRow(query, eventID, userID).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		log.Printf("Error getting staff role: %v", err)
+		return "", err
+	}
+
+	return role, nil
+}
+
+// GetStaff retrieves the staff of an event
+func (r *StaffRepository) GetStaff(eventID int) ([]models.StaffResponse, error) {
+	query := `
+	SELECT u.id, u.username, u.email, u.created_at, s.role, s.created_at
+	FROM event_staff s
+	JOIN users u ON s.user_id = u.id
+	WHERE s.event_id = $1
+	ORDER BY s.created_at, u.id
+	`
+
+	rows, err := r.DB.Query(query, eventID)
+	if err != nil {
+		log.Printf("Error getting event staff: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	staff := []models.StaffResponse{}
+	for rows.Next() {
+		var member models.StaffResponse
+		err := rows.Scan(
+			&member.User.ID,
+			&member.User.Username,
+			&member.User.Email,
+			&member.User.CreatedAt,
+			&member.Role,
+			&member.CreatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning event staff: %v", err)
+			return nil, err
+		}
+		staff = append(staff, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating event staff: %v", err)
+		return nil, err
+	}
+
+	return staff, nil
+}
+
+// TransferOwnership hands an event over to another user. The previous owner
+// stays on as co-organizer and the new owner loses any staff role they had.
+func (r *StaffRepository) TransferOwnership(eventID, ownerID, newOwnerID int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting transfer transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the event so the owner can't change underneath us
+	eventQuery := `
+	SELECT organizer_id FROM events WHERE id = $1 FOR UPDATE
+	`
+	var currentOwnerID int
+	err = tx.QueryRow(eventQuery, eventID).Scan(&currentOwnerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrEventNotFound
+		}
+		log.Printf("Error locking event: %v", err)
+		return err
+	}
+	if currentOwnerID != ownerID {
+		return ErrNotEventOwner
+	}
+
+	_, err = tx.Exec(`UPDATE events SET organizer_id = $1, updated_at = $2 WHERE id = $3`, newOwnerID, time.Now(), eventID)
+	if err != nil {
+		log.Printf("Error transferring event: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM event_staff WHERE event_id = $1 AND user_id = $2`, eventID, newOwnerID)
+	if err != nil {
+		log.Printf("Error removing new owner from staff: %v", err)
+		return err
+	}
+
+	staffQuery := `
+	INSERT INTO event_staff (event_id, user_id, role, created_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (event_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`
+	_, err = tx.Exec(staffQuery, eventID, ownerID, models.StaffRoleCoOrganizer, time.Now())
+	if err != nil {
+		log.Printf("Error adding previous owner to staff: %v", err)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing transfer transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
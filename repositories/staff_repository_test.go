@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/event-system/models"
+)
+
+func TestStaffRoles(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 2)
+	owner, helper := users[0], users[1]
+	event := createTestEvent(t, db, owner.ID, 5)
+
+	repo := NewStaffRepository(db)
+
+	role, err := repo.GetStaffRole(event.ID, helper.ID)
+	if err != nil {
+		t.Fatalf("get staff role: %v", err)
+	}
+	if role != "" {
+		t.Errorf("expected no staff role, got %q", role)
+	}
+
+	if err := repo.AddStaff(&models.EventStaff{EventID: event.ID, UserID: helper.ID, Role: models.StaffRoleViewer}); err != nil {
+		t.Fatalf("add staff: %v", err)
+	}
+	// Adding again changes the role instead of failing
+	if err := repo.AddStaff(&models.EventStaff{EventID: event.ID, UserID: helper.ID, Role: models.StaffRoleCheckIn}); err != nil {
+		t.Fatalf("change staff role: %v", err)
+	}
+
+	staff, err := repo.GetStaff(event.ID)
+	if err != nil {
+		t.Fatalf("get staff: %v", err)
+	}
+	if len(staff) != 1 || staff[0].User.ID != helper.ID || staff[0].Role != models.StaffRoleCheckIn {
+		t.Errorf("unexpected staff: %+v", staff)
+	}
+
+	if err := repo.RemoveStaff(event.ID, helper.ID); err != nil {
+		t.Fatalf("remove staff: %v", err)
+	}
+	if err := repo.RemoveStaff(event.ID, helper.ID); err == nil {
+		t.Errorf("expected removing a non-member to fail")
+	}
+}
+
+func TestTransferOwnership(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 3)
+	owner, coOrganizer, stranger := users[0], users[1], users[2]
+	event := createTestEvent(t, db, owner.ID, 5)
+
+	repo := NewStaffRepository(db)
+	if err := repo.AddStaff(&models.EventStaff{EventID: event.ID, UserID: coOrganizer.ID, Role: models.StaffRoleCoOrganizer}); err != nil {
+		t.Fatalf("add staff: %v", err)
+	}
+
+	if err := repo.TransferOwnership(event.ID, stranger.ID, coOrganizer.ID); err == nil {
+		t.Fatalf("expected transfer by a non-owner to fail")
+	}
+
+	if err := repo.TransferOwnership(event.ID, owner.ID, coOrganizer.ID); err != nil {
+		t.Fatalf("transfer ownership: %v", err)
+	}
+
+	updated, err := NewEventRepository(db).GetByID(event.ID)
+	if err != nil {
+		t.Fatalf("get event: %v", err)
+	}
+	if updated.OrganizerID != coOrganizer.ID {
+		t.Errorf("expected organizer %d, got %d", coOrganizer.ID, updated.OrganizerID)
+	}
+
+	role, err := repo.GetStaffRole(event.ID, owner.ID)
+	if err != nil {
+		t.Fatalf("get staff role: %v", err)
+	}
+	if role != models.StaffRoleCoOrganizer {
+		t.Errorf("expected previous owner to become co-organizer, got %q", role)
+	}
+
+	role, err = repo.GetStaffRole(event.ID, coOrganizer.ID)
+	if err != nil {
+		t.Fatalf("get staff role: %v", err)
+	}
+	if role != "" {
+		t.Errorf("expected new owner to lose their staff role, got %q", role)
+	}
+}
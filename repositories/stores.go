@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// The services depend on these interfaces instead of the PostgreSQL
+// repositories, so the business rules can also run against MemoryStore.
+
+// UserStore stores users
+type UserStore interface {
+	Create(user *models.User) error
+	GetByID(id int) (*models.User, error)
+	GetByEmail(email string) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	List(limit, offset int) ([]models.User, int, error)
+	SetRole(id int, role string) error
+	SetSuspended(id int, suspended bool) error
+}
+
+// EventStore stores events
+type EventStore interface {
+	Create(event *models.Event) error
+	CreateMany(events []*models.Event) error
+	GetByID(id int) (*models.Event, error)
+	Update(event *models.Event) ([]models.Participant, error)
+	UpdateStatus(event *models.Event, status string) error
+	GetDue(now time.Time, limit int) ([]models.Event, error)
+	Delete(id int, organizerID int) error
+	ForceDelete(id int) error
+	GetAllPublic(q models.EventListQuery) (*models.EventPage, error)
+	GetByOrganizer(organizerID int, q models.EventListQuery) (*models.EventPage, error)
+	GetParticipantsByEventID(eventID int) ([]models.UserResponse, error)
+	GetEventsByParticipant(userID int, q models.EventListQuery) (*models.EventPage, error)
+	Search(q models.EventSearchQuery) ([]models.EventSearchHit, int, error)
+}
+
+// ParticipantStore stores participants, waitlists and pending registrations of events
+type ParticipantStore interface {
+	JoinEvent(userID, eventID int, opts models.JoinOptions) (*models.Participant, error)
+	LeaveEvent(userID, eventID int) ([]models.Participant, error)
+	IsParticipant(userID, eventID int) (bool, error)
+	GetRegistration(userID, eventID int) (*models.Participant, error)
+	GetParticipantUserIDs(eventID int) ([]int, error)
+	GetParticipantCount(eventID int) (*models.ParticipantCountResponse, error)
+	GetWaitlistPosition(userID, eventID int) (int, error)
+	GetWaitlist(eventID int) ([]models.WaitlistEntryResponse, error)
+	ReorderWaitlist(eventID int, userIDs []int) error
+	GetPendingRegistrations(eventID int) ([]models.PendingRegistrationResponse, error)
+	ReviewRegistrations(eventID int, userIDs []int, approve bool, message string) ([]models.Participant, error)
+	CheckIn(eventID, participantID int, at time.Time) (*models.CheckInResponse, error)
+	GetAttendance(eventID int) (*models.AttendanceResponse, error)
+	GetConfirmedParticipants(eventID int) ([]models.Participant, error)
+	SyncCheckIns(eventID int, checkIns []models.OfflineCheckIn) ([]models.CheckInSyncResult, error)
+}
+
+// StaffStore stores the staff of events
+type StaffStore interface {
+	AddStaff(staff *models.EventStaff) error
+	RemoveStaff(eventID, userID int) error
+	GetStaffRole(eventID, userID int) (string, error)
+	GetStaff(eventID int) ([]models.StaffResponse, error)
+	TransferOwnership(eventID, ownerID, newOwnerID int) error
+}
+
+// InviteStore stores the invites to private events
+type InviteStore interface {
+	CreateMany(invites []*models.EventInvite) error
+	GetByID(id int) (*models.EventInvite, error)
+	GetByEvent(eventID int) ([]models.EventInvite, error)
+	Delete(eventID, id int) error
+	IsInvited(eventID, userID int, now time.Time) (bool, error)
+}
+
+// TicketTypeStore stores the ticket types of events
+type TicketTypeStore interface {
+	Create(ticketType *models.TicketType) error
+	GetByID(id int) (*models.TicketType, error)
+	GetByEvent(eventID int) ([]models.TicketType, error)
+	Update(ticketType *models.TicketType) ([]models.Participant, error)
+	Delete(eventID, id int) error
+}
+
+// PromoCodeStore stores the promo codes of events and reports their redemptions
+type PromoCodeStore interface {
+	Create(code *models.PromoCode) error
+	GetByID(id int) (*models.PromoCode, error)
+	GetByEvent(eventID int) ([]models.PromoCode, error)
+	Update(code *models.PromoCode) ([]models.Participant, error)
+	Delete(eventID, id int) ([]models.Participant, error)
+	GetRedemptions(codeID int) ([]models.PromoRedemptionResponse, error)
+}
+
+// OrderStore stores the orders of priced tickets and settles their payments
+type OrderStore interface {
+	GetByID(id int) (*models.Order, error)
+	GetByProviderRef(provider, ref string) (*models.Order, error)
+	GetByUser(eventID, userID int) ([]models.Order, error)
+	GetByEvent(eventID int) ([]models.Order, error)
+	GetRefunding(limit int) ([]models.Order, error)
+	SetCheckout(id int, provider string, session *models.PaymentSession) error
+	ConfirmPayment(id int, now time.Time) (*models.Order, error)
+	FailPayment(id int, now time.Time) (*models.Order, []models.Participant, error)
+	ReleaseExpired(now time.Time) (int, []models.Participant, error)
+	MarkRefunded(id int, now time.Time) error
+	MarkRefundFailed(id int, now time.Time) error
+}
+
+// SeriesStore stores recurring event series and their occurrences
+type SeriesStore interface {
+	Create(series *models.EventSeries, occurrences []models.Event) error
+	GetByID(id int) (*models.EventSeries, error)
+	GetOccurrences(seriesID int) ([]models.Event, error)
+	Save(change *models.SeriesChange) ([]models.Participant, error)
+	GetIDs() ([]int, error)
+	AddOccurrences(series *models.EventSeries, occurrences []models.Event) error
+	Delete(id int, organizerID int) error
+	JoinSeries(userID, seriesID int) ([]models.Participant, error)
+	LeaveSeries(userID, seriesID int) ([]models.Participant, error)
+}
+
+// TokenStore stores refresh tokens, revoked access tokens and calendar feed tokens
+type TokenStore interface {
+	CreateRefreshToken(token *models.RefreshToken) error
+	RotateRefreshToken(hash string, next *models.RefreshToken, accessExpiresAt time.Time) error
+	RevokeFamilyByToken(userID int, hash string, accessExpiresAt time.Time) error
+	RevokeAllForUser(userID int, accessExpiresAt time.Time) error
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+	DeleteExpired() error
+	SetCalendarToken(userID int, hash string) error
+	GetCalendarTokenUser(hash string) (int, error)
+	DeleteCalendarToken(userID int) error
+}
+
+// Make sure the PostgreSQL repositories keep implementing the stores
+var (
+	_ UserStore        = (*UserRepository)(nil)
+	_ EventStore       = (*EventRepository)(nil)
+	_ ParticipantStore = (*ParticipantRepository)(nil)
+	_ StaffStore       = (*StaffRepository)(nil)
+	_ InviteStore      = (*InviteRepository)(nil)
+	_ TicketTypeStore  = (*TicketTypeRepository)(nil)
+	_ PromoCodeStore   = (*PromoCodeRepository)(nil)
+	_ OrderStore       = (*OrderRepository)(nil)
+	_ SeriesStore      = (*SeriesRepository)(nil)
+	_ TokenStore       = (*TokenRepository)(nil)
+)
@@ -0,0 +1,369 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/database"
+	"github.com/event-system/models"
+)
+
+// TicketTypeRepository handles database operations related to the ticket types of events
+type TicketTypeRepository struct {
+	DB *sql.DB
+}
+
+// NewTicketTypeRepository creates a new ticket type repository instance
+func NewTicketTypeRepository(db *sql.DB) *TicketTypeRepository {
+	return &TicketTypeRepository{DB: db}
+}
+
+// ticketTypeColumns selects a ticket type with the number of seats taken by
+// its confirmed and reserved participants. Queries using it pass those two
+// statuses as $1 and $2.
+const ticketTypeColumns = `t.id, t.event_id, t.name, t.quota, t.sales_starts_at, t.sales_ends_at, t.visibility, t.price, t.currency,
+	t.created_at, t.updated_at, (SELECT COUNT(*) FROM participants p WHERE p.ticket_type_id = t.id AND p.status IN ($1, $2))`
+
+// ticketTypeFields returns the scan destinations for ticketTypeColumns
+func ticketTypeFields(ticketType *models.TicketType) []interface{} {
+	return []interface{}{
+		&ticketType.ID,
+		&ticketType.EventID,
+		&ticketType.Name,
+		&ticketType.Quota,
+		&ticketType.SalesStartsAt,
+		&ticketType.SalesEndsAt,
+		&ticketType.Visibility,
+		&ticketType.Price,
+		&ticketType.Currency,
+		&ticketType.CreatedAt,
+		&ticketType.UpdatedAt,
+		&ticketType.Sold,
+	}
+}
+
+// CheckTicketSales returns the reason a ticket type can't be bought at now, or
+// nil while it is on sale. The registration of its event is checked separately.
+func CheckTicketSales(ticketType *models.TicketType, now time.Time) error {
+	switch {
+	case ticketType.SalesStartsAt != nil && now.Before(*ticketType.SalesStartsAt):
+		return apperrors.Conflict(ErrTicketSalesNotOpen.Code, fmt.Sprintf("%s: starts at %s",
+			ErrTicketSalesNotOpen.Message, ticketType.SalesStartsAt.UTC().Format(time.RFC3339)))
+	case ticketType.SalesEndsAt != nil && !now.Before(*ticketType.SalesEndsAt):
+		return ErrTicketSalesEnded
+	}
+	return nil
+}
+
+// Create stores a new ticket type of an event
+func (r *TicketTypeRepository) Create(ticketType *models.TicketType) error {
+	query := `
+	INSERT INTO ticket_types (event_id, name, quota, sales_starts_at, sales_ends_at, visibility, price, currency, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	RETURNING id
+	`
+
+	now := time.Now()
+	err := r.DB.QueryRow(query, ticketType.EventID, ticketType.Name, ticketType.Quota, ticketType.SalesStartsAt,
+		ticketType.SalesEndsAt, ticketType.Visibility, ticketType.Price, ticketType.Currency, now, now).Scan(&ticketType.ID)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return ErrTicketTypeExists
+		}
+		log.Printf("Error creating ticket type: %v", err)
+		return err
+	}
+	ticketType.CreatedAt = now
+	ticketType.UpdatedAt = now
+	ticketType.Sold = 0
+
+	return nil
+}
+
+// GetByID retrieves a ticket type by ID
+func (r *TicketTypeRepository) GetByID(id int) (*models.TicketType, error) {
+	ticketType := &models.TicketType{}
+	err := r.DB.QueryRow(`SELECT `+ticketTypeColumns+` FROM ticket_types t WHERE t.id = $3`,
+		models.ParticipantStatusConfirmed, models.ParticipantStatusReserved, id).Scan(ticketTypeFields(ticketType)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTicketTypeNotFound
+		}
+		log.Printf("Error getting ticket type: %v", err)
+		return nil, err
+	}
+
+	return ticketType, nil
+}
+
+// GetByEvent retrieves all ticket types of an event, oldest first
+func (r *TicketTypeRepository) GetByEvent(eventID int) ([]models.TicketType, error) {
+	rows, err := r.DB.Query(`SELECT `+ticketTypeColumns+` FROM ticket_types t WHERE t.event_id = $3 ORDER BY t.id ASC`,
+		models.ParticipantStatusConfirmed, models.ParticipantStatusReserved, eventID)
+	if err != nil {
+		log.Printf("Error getting ticket types: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	ticketTypes := []models.TicketType{}
+	for rows.Next() {
+		var ticketType models.TicketType
+		if err := rows.Scan(ticketTypeFields(&ticketType)...); err != nil {
+			log.Printf("Error scanning ticket type: %v", err)
+			return nil, err
+		}
+		ticketTypes = append(ticketTypes, ticketType)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating ticket types: %v", err)
+		return nil, err
+	}
+
+	return ticketTypes, nil
+}
+
+// Update changes a ticket type of an event. It locks the event row like
+// JoinEvent, so the quota can't drop below the tickets sold in the meantime,
+// and a larger quota hands its new seats to the waitlist right away; it
+// returns the participants who moved up. A new price only applies to orders
+// created after the change.
+func (r *TicketTypeRepository) Update(ticketType *models.TicketType) ([]models.Participant, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting ticket type transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var capacity int
+	err = tx.QueryRow(`SELECT capacity FROM events WHERE id = $1 FOR UPDATE`, ticketType.EventID).Scan(&capacity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEventNotFound
+		}
+		log.Printf("Error locking event: %v", err)
+		return nil, err
+	}
+
+	sold, err := ticketTypeSold(tx, ticketType.ID)
+	if err != nil {
+		return nil, err
+	}
+	if ticketType.Quota < sold {
+		return nil, apperrors.Conflict(ErrQuotaBelowSold.Code, fmt.Sprintf("%s: %d sold", ErrQuotaBelowSold.Message, sold))
+	}
+
+	updateQuery := `
+	UPDATE ticket_types
+	SET name = $1, quota = $2, sales_starts_at = $3, sales_ends_at = $4, visibility = $5, price = $6, currency = $7, updated_at = $8
+	WHERE id = $9 AND event_id = $10
+	RETURNING created_at
+	`
+	now := time.Now()
+	err = tx.QueryRow(updateQuery, ticketType.Name, ticketType.Quota, ticketType.SalesStartsAt, ticketType.SalesEndsAt,
+		ticketType.Visibility, ticketType.Price, ticketType.Currency, now, ticketType.ID, ticketType.EventID).Scan(&ticketType.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTicketTypeNotFound
+		}
+		if database.IsUniqueViolation(err) {
+			return nil, ErrTicketTypeExists
+		}
+		log.Printf("Error updating ticket type: %v", err)
+		return nil, err
+	}
+
+	// A larger quota may free seats for users waiting for this ticket type
+	promoted, err := promoteWaitlisted(tx, ticketType.EventID, capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing ticket type transaction: %v", err)
+		return nil, err
+	}
+	ticketType.UpdatedAt = now
+
+	// Promoted users count as sold now
+	ticketType.Sold, err = ticketTypeSold(r.DB, ticketType.ID)
+	return promoted, err
+}
+
+// Delete removes a ticket type of an event that nobody registered with and
+// no promo code is tied to
+func (r *TicketTypeRepository) Delete(eventID, id int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting ticket type transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the event row so nobody registers with the ticket type meanwhile
+	var lockedID int
+	err = tx.QueryRow(`SELECT id FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(&lockedID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrEventNotFound
+		}
+		log.Printf("Error locking event: %v", err)
+		return err
+	}
+
+	var used bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM participants WHERE ticket_type_id = $1)`, id).Scan(&used)
+	if err != nil {
+		log.Printf("Error checking ticket type registrations: %v", err)
+		return err
+	}
+	if used {
+		return ErrTicketTypeInUse
+	}
+
+	// Codes for the ticket type would change meaning without it
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM promo_codes WHERE ticket_type_id = $1)`, id).Scan(&used)
+	if err != nil {
+		log.Printf("Error checking ticket type promo codes: %v", err)
+		return err
+	}
+	if used {
+		return ErrTicketTypeHasPromoCodes
+	}
+
+	result, err := tx.Exec(`DELETE FROM ticket_types WHERE id = $1 AND event_id = $2`, id, eventID)
+	if err != nil {
+		log.Printf("Error deleting ticket type: %v", err)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTicketTypeNotFound
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing ticket type transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// chooseTicketType checks the ticket type a user picked when joining an event
+// inside the join transaction and returns it, or nil for events without
+// ticket types. Hidden ticket types look like they don't exist unless the
+// promo code of the user unlocks them; unlocked is the ticket type of that
+// code, which is then the only one the user can pick.
+func chooseTicketType(tx *sql.Tx, eventID, ticketTypeID int, unlocked *int, now time.Time) (*int, error) {
+	if unlocked != nil {
+		if ticketTypeID != 0 && ticketTypeID != *unlocked {
+			return nil, ErrPromoCodeNotApplicable
+		}
+		ticketTypeID = *unlocked
+	}
+	if ticketTypeID == 0 {
+		var tiered bool
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM ticket_types WHERE event_id = $1)`, eventID).Scan(&tiered)
+		if err != nil {
+			log.Printf("Error checking ticket types: %v", err)
+			return nil, err
+		}
+		if tiered {
+			return nil, ErrTicketTypeRequired
+		}
+		return nil, nil
+	}
+
+	ticketType := models.TicketType{ID: ticketTypeID, EventID: eventID}
+	err := tx.QueryRow(`SELECT visibility, sales_starts_at, sales_ends_at FROM ticket_types WHERE id = $1 AND event_id = $2`,
+		ticketTypeID, eventID).Scan(&ticketType.Visibility, &ticketType.SalesStartsAt, &ticketType.SalesEndsAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTicketTypeNotFound
+		}
+		log.Printf("Error getting ticket type: %v", err)
+		return nil, err
+	}
+	if ticketType.Visibility == models.TicketTypeHidden && unlocked == nil {
+		return nil, ErrTicketTypeNotFound
+	}
+	if err = CheckTicketSales(&ticketType, now); err != nil {
+		return nil, err
+	}
+
+	return &ticketTypeID, nil
+}
+
+// ticketTypeSold counts the seats of a ticket type taken by confirmed
+// participants and by reservations waiting for payment
+func ticketTypeSold(q rowQuerier, ticketTypeID int) (int, error) {
+	query := `
+	SELECT COUNT(*) FROM participants WHERE ticket_type_id = $1 AND status IN ($2, $3)
+	`
+
+	var sold int
+	err := q.QueryRow(query, ticketTypeID, models.ParticipantStatusConfirmed, models.ParticipantStatusReserved).Scan(&sold)
+	if err != nil {
+		log.Printf("Error counting sold tickets: %v", err)
+		return 0, err
+	}
+
+	return sold, nil
+}
+
+// ticketTypeFull reports whether every ticket of a ticket type is taken by a
+// confirmed participant or a reservation
+func ticketTypeFull(q rowQuerier, ticketTypeID int) (bool, error) {
+	query := `
+	SELECT quota <= (SELECT COUNT(*) FROM participants WHERE ticket_type_id = $1 AND status IN ($2, $3))
+	FROM ticket_types WHERE id = $1
+	`
+
+	var full bool
+	err := q.QueryRow(query, ticketTypeID, models.ParticipantStatusConfirmed, models.ParticipantStatusReserved).Scan(&full)
+	if err != nil {
+		log.Printf("Error checking ticket type quota: %v", err)
+		return false, err
+	}
+
+	return full, nil
+}
+
+// ticketTypeRoom returns how many tickets of each ticket type of an event are
+// left. Events without ticket types get an empty map.
+func ticketTypeRoom(tx *sql.Tx, eventID int) (map[int]int, error) {
+	query := `
+	SELECT t.id, t.quota - COUNT(p.id)
+	FROM ticket_types t
+	LEFT JOIN participants p ON p.ticket_type_id = t.id AND p.status IN ($2, $3)
+	WHERE t.event_id = $1
+	GROUP BY t.id, t.quota
+	`
+
+	rows, err := tx.Query(query, eventID, models.ParticipantStatusConfirmed, models.ParticipantStatusReserved)
+	if err != nil {
+		log.Printf("Error getting ticket type room: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	room := map[int]int{}
+	for rows.Next() {
+		var id, left int
+		if err := rows.Scan(&id, &left); err != nil {
+			log.Printf("Error scanning ticket type room: %v", err)
+			return nil, err
+		}
+		room[id] = left
+	}
+
+	return room, rows.Err()
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+func TestJoinTicketTypeConcurrentNeverExceedsQuota(t *testing.T) {
+	db := openTestDB(t)
+
+	const joiners = 50
+	const quota = 3
+
+	users := createTestUsers(t, db, joiners+1)
+	organizer, joining := users[0], users[1:]
+	event := createTestEvent(t, db, organizer.ID, 100)
+
+	ticketTypes := NewTicketTypeRepository(db)
+	vip := &models.TicketType{EventID: event.ID, Name: "VIP", Quota: quota, Visibility: models.TicketTypePublic}
+	if err := ticketTypes.Create(vip); err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+
+	repo := NewParticipantRepository(db)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for _, user := range joining {
+		wg.Add(1)
+		go func(userID int) {
+			defer wg.Done()
+			<-start
+			if _, err := repo.JoinEvent(userID, event.ID, models.JoinOptions{TicketTypeID: vip.ID}); err != nil {
+				t.Errorf("unexpected join error: %v", err)
+			}
+		}(user.ID)
+	}
+	close(start)
+	wg.Wait()
+
+	stored, err := ticketTypes.GetByID(vip.ID)
+	if err != nil {
+		t.Fatalf("get ticket type: %v", err)
+	}
+	if stored.Sold != quota {
+		t.Errorf("expected %d tickets sold, got %d", quota, stored.Sold)
+	}
+	waitlist, err := repo.GetWaitlist(event.ID)
+	if err != nil {
+		t.Fatalf("get waitlist: %v", err)
+	}
+	if len(waitlist) != joiners-quota {
+		t.Errorf("expected %d waitlisted users, got %d", joiners-quota, len(waitlist))
+	}
+}
+
+func TestTicketTypeQuotasAndWaitlist(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 6)
+	organizer, early, late, first, second, undecided := users[0], users[1], users[2], users[3], users[4], users[5]
+	// The event caps all ticket types at 3 seats together
+	event := createTestEvent(t, db, organizer.ID, 3)
+
+	ticketTypes := NewTicketTypeRepository(db)
+	earlyBird := &models.TicketType{EventID: event.ID, Name: "Early bird", Quota: 1, Visibility: models.TicketTypePublic}
+	standard := &models.TicketType{EventID: event.ID, Name: "Standard", Quota: 5, Visibility: models.TicketTypePublic}
+	ended := time.Now().Add(-time.Hour)
+	closed := &models.TicketType{EventID: event.ID, Name: "Last year", Quota: 5, Visibility: models.TicketTypePublic, SalesEndsAt: &ended}
+	comp := &models.TicketType{EventID: event.ID, Name: "Speaker", Quota: 5, Visibility: models.TicketTypeHidden}
+	for _, ticketType := range []*models.TicketType{earlyBird, standard, closed, comp} {
+		if err := ticketTypes.Create(ticketType); err != nil {
+			t.Fatalf("create ticket type: %v", err)
+		}
+	}
+	if err := ticketTypes.Create(&models.TicketType{EventID: event.ID, Name: "Standard", Quota: 1, Visibility: models.TicketTypePublic}); !errors.Is(err, ErrTicketTypeExists) {
+		t.Errorf("duplicate name: got %v, want ErrTicketTypeExists", err)
+	}
+
+	repo := NewParticipantRepository(db)
+	for _, tc := range []struct {
+		name         string
+		ticketTypeID int
+		want         error
+	}{
+		{"no ticket type", 0, ErrTicketTypeRequired},
+		{"hidden", comp.ID, ErrTicketTypeNotFound},
+		{"sales ended", closed.ID, ErrTicketSalesEnded},
+	} {
+		if _, err := repo.JoinEvent(undecided.ID, event.ID, models.JoinOptions{TicketTypeID: tc.ticketTypeID}); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// The early bird tier sells out before the event does
+	joins := []struct {
+		user         *models.User
+		ticketTypeID int
+		want         string
+	}{
+		{early, earlyBird.ID, models.ParticipantStatusConfirmed},
+		{late, earlyBird.ID, models.ParticipantStatusWaitlisted},
+		{first, standard.ID, models.ParticipantStatusConfirmed},
+		{second, standard.ID, models.ParticipantStatusConfirmed},
+		{undecided, standard.ID, models.ParticipantStatusWaitlisted},
+	}
+	for _, join := range joins {
+		participant, err := repo.JoinEvent(join.user.ID, event.ID, models.JoinOptions{TicketTypeID: join.ticketTypeID})
+		if err != nil {
+			t.Fatalf("join: %v", err)
+		}
+		if participant.Status != join.want {
+			t.Errorf("user %d: got status %q, want %q", join.user.ID, participant.Status, join.want)
+		}
+	}
+
+	count, err := repo.GetParticipantCount(event.ID)
+	if err != nil {
+		t.Fatalf("get participant count: %v", err)
+	}
+	if count.Count != 3 || len(count.TicketTypes) != 4 || count.TicketTypes[0].Count != 1 || count.TicketTypes[1].Count != 2 {
+		t.Errorf("unexpected participant count %+v", count)
+	}
+
+	// A standard seat frees up: the early bird user at the head of the
+	// waitlist can't take it, the next standard user can
+	if _, err := repo.LeaveEvent(second.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	for user, want := range map[*models.User]string{late: models.ParticipantStatusWaitlisted, undecided: models.ParticipantStatusConfirmed} {
+		registration, err := repo.GetRegistration(user.ID, event.ID)
+		if err != nil || registration.Status != want {
+			t.Errorf("user %d: got %+v (%v), want status %q", user.ID, registration, err, want)
+		}
+	}
+
+	// Quotas can't drop below what is sold, and tiers in use can't go away
+	earlyBird.Quota = 2
+	if _, err := ticketTypes.Update(earlyBird); err != nil {
+		t.Fatalf("raise quota: %v", err)
+	}
+	if registration, _ := repo.GetRegistration(late.ID, event.ID); registration.Status != models.ParticipantStatusWaitlisted {
+		t.Errorf("a full event must keep the early bird user waiting, got %q", registration.Status)
+	}
+	standard.Quota = 1
+	if _, err := ticketTypes.Update(standard); !errors.Is(err, ErrQuotaBelowSold) {
+		t.Errorf("lower quota: got %v, want ErrQuotaBelowSold", err)
+	}
+	if err := ticketTypes.Delete(event.ID, standard.ID); !errors.Is(err, ErrTicketTypeInUse) {
+		t.Errorf("delete used ticket type: got %v, want ErrTicketTypeInUse", err)
+	}
+	if err := ticketTypes.Delete(event.ID, closed.ID); err != nil {
+		t.Errorf("delete unused ticket type: %v", err)
+	}
+
+	// Raising the cap of the event hands the seat to the early bird user now
+	event.Capacity = 4
+	if _, err := NewEventRepository(db).Update(event); err != nil {
+		t.Fatalf("update event: %v", err)
+	}
+	if registration, _ := repo.GetRegistration(late.ID, event.ID); registration.Status != models.ParticipantStatusConfirmed || *registration.TicketTypeID != earlyBird.ID {
+		t.Errorf("expected the early bird user to be promoted, got %+v", registration)
+	}
+}
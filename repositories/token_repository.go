@@ -0,0 +1,348 @@
+package repositories
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown or expired refresh tokens
+	ErrInvalidRefreshToken = apperrors.Unauthorized("invalid_refresh_token", "invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again
+	ErrRefreshTokenReused = apperrors.Unauthorized("refresh_token_reused", "refresh token reuse detected, please log in again")
+	// ErrInvalidCalendarToken is returned for unknown calendar feed tokens
+	ErrInvalidCalendarToken = apperrors.Unauthorized("invalid_calendar_token", "invalid calendar feed token")
+)
+
+// TokenRepository handles database operations related to refresh tokens, revoked access tokens
+// and calendar feed tokens
+type TokenRepository struct {
+	DB *sql.DB
+}
+
+// NewTokenRepository creates a new token repository instance
+func NewTokenRepository(db *sql.DB) *TokenRepository {
+	return &TokenRepository{DB: db}
+}
+
+// CreateRefreshToken stores a new refresh token
+func (r *TokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	query := `
+	INSERT INTO refresh_tokens (user_id, token_hash, family_id, access_token_id, expires_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id
+	`
+
+	token.CreatedAt = time.Now()
+
+	err := r.DB.QueryRow(
+		query,
+		token.UserID,
+		token.TokenHash,
+		token.FamilyID,
+		token.AccessTokenID,
+		token.ExpiresAt,
+		token.CreatedAt,
+	).Scan(&token.ID)
+
+	if err != nil {
+		log.Printf("Error creating refresh token: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RotateRefreshToken exchanges the refresh token with the given hash for next.
+// next inherits the user and family of the old token. Presenting a token that
+// was already rotated or revoked revokes the whole family and returns
+// ErrRefreshTokenReused; access tokens issued to the family are revoked until accessExpiresAt.
+func (r *TokenRepository) RotateRefreshToken(hash string, next *models.RefreshToken, accessExpiresAt time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting refresh transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the old token so two parallel refreshes can't both succeed
+	query := `
+	SELECT id, user_id, family_id, expires_at, revoked_at
+	FROM refresh_tokens
+	WHERE token_hash = $1
+	FOR UPDATE
+	`
+
+	var oldID int
+	var expiresAt time.Time
+	var revokedAt sql.NullTime
+	err = tx.QueryRow(query, hash).Scan(&oldID, &next.UserID, &next.FamilyID, &expiresAt, &revokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInvalidRefreshToken
+		}
+		log.Printf("Error getting refresh token: %v", err)
+		return err
+	}
+
+	if revokedAt.Valid {
+		// Someone is replaying a token that was already used, so nothing in
+		// this family can be trusted anymore
+		if err := revokeFamily(tx, next.FamilyID, accessExpiresAt); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			log.Printf("Error committing family revocation: %v", err)
+			return err
+		}
+		return ErrRefreshTokenReused
+	}
+
+	if time.Now().After(expiresAt) {
+		return ErrInvalidRefreshToken
+	}
+
+	insertQuery := `
+	INSERT INTO refresh_tokens (user_id, token_hash, family_id, access_token_id, expires_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id
+	`
+
+	next.CreatedAt = time.Now()
+	err = tx.QueryRow(
+		insertQuery,
+		next.UserID,
+		next.TokenHash,
+		next.FamilyID,
+		next.AccessTokenID,
+		next.ExpiresAt,
+		next.CreatedAt,
+	).Scan(&next.ID)
+	if err != nil {
+		log.Printf("Error creating refresh token: %v", err)
+		return err
+	}
+
+	revokeQuery := `
+	UPDATE refresh_tokens SET revoked_at = $1, replaced_by = $2 WHERE id = $3
+	`
+	_, err = tx.Exec(revokeQuery, next.CreatedAt, next.ID, oldID)
+	if err != nil {
+		log.Printf("Error revoking rotated refresh token: %v", err)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing refresh transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RevokeFamilyByToken revokes every refresh token in the family of the given
+// token, as long as it belongs to userID
+func (r *TokenRepository) RevokeFamilyByToken(userID int, hash string, accessExpiresAt time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting revoke transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	SELECT family_id FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2
+	`
+
+	var familyID string
+	err = tx.QueryRow(query, hash, userID).Scan(&familyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInvalidRefreshToken
+		}
+		log.Printf("Error getting refresh token: %v", err)
+		return err
+	}
+
+	if err := revokeFamily(tx, familyID, accessExpiresAt); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing revoke transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAllForUser revokes every refresh token of a user and the access tokens issued with them
+func (r *TokenRepository) RevokeAllForUser(userID int, accessExpiresAt time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		log.Printf("Error starting revoke transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	accessQuery := `
+	INSERT INTO revoked_tokens (jti, expires_at)
+	SELECT access_token_id, $2 FROM refresh_tokens
+	WHERE user_id = $1 AND access_token_id IS NOT NULL
+	ON CONFLICT (jti) DO NOTHING
+	`
+	_, err = tx.Exec(accessQuery, userID, accessExpiresAt)
+	if err != nil {
+		log.Printf("Error revoking user access tokens: %v", err)
+		return err
+	}
+
+	refreshQuery := `
+	UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL
+	`
+	_, err = tx.Exec(refreshQuery, time.Now(), userID)
+	if err != nil {
+		log.Printf("Error revoking user refresh tokens: %v", err)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing revoke transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAccessToken puts an access token on the revocation list until it expires
+func (r *TokenRepository) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	query := `
+	INSERT INTO revoked_tokens (jti, expires_at)
+	VALUES ($1, $2)
+	ON CONFLICT (jti) DO NOTHING
+	`
+
+	_, err := r.DB.Exec(query, jti, expiresAt)
+	if err != nil {
+		log.Printf("Error revoking access token: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked checks the revocation list for an access token
+func (r *TokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	query := `
+	SELECT jti FROM revoked_tokens WHERE jti = $1
+	`
+
+	var found string
+	err := r.DB.QueryRow(query, jti).Scan(&found)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		log.Printf("Error checking revoked token: %v", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DeleteExpired removes refresh tokens and revocation entries that can no longer be used
+func (r *TokenRepository) DeleteExpired() error {
+	now := time.Now()
+
+	_, err := r.DB.Exec(`DELETE FROM revoked_tokens WHERE expires_at < $1`, now)
+	if err != nil {
+		log.Printf("Error deleting expired revoked tokens: %v", err)
+		return err
+	}
+
+	_, err = r.DB.Exec(`DELETE FROM refresh_tokens WHERE expires_at < $1`, now)
+	if err != nil {
+		log.Printf("Error deleting expired refresh tokens: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// revokeFamily revokes all refresh tokens of a family and the access tokens issued with them
+func revokeFamily(tx *sql.Tx, familyID string, accessExpiresAt time.Time) error {
+	accessQuery := `
+	INSERT INTO revoked_tokens (jti, expires_at)
+	SELECT access_token_id, $2 FROM refresh_tokens
+	WHERE family_id = $1 AND access_token_id IS NOT NULL
+	ON CONFLICT (jti) DO NOTHING
+	`
+	_, err := tx.Exec(accessQuery, familyID, accessExpiresAt)
+	if err != nil {
+		log.Printf("Error revoking family access tokens: %v", err)
+		return err
+	}
+
+	refreshQuery := `
+	UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL
+	`
+	_, err = tx.Exec(refreshQuery, time.Now(), familyID)
+	if err != nil {
+		log.Printf("Error revoking refresh token family: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// SetCalendarToken stores the hash of the calendar feed token of a user,
+// replacing the previous one
+func (r *TokenRepository) SetCalendarToken(userID int, hash string) error {
+	query := `
+	INSERT INTO calendar_tokens (user_id, token_hash, created_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = EXCLUDED.created_at
+	`
+
+	_, err := r.DB.Exec(query, userID, hash, time.Now())
+	if err != nil {
+		log.Printf("Error setting calendar token: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetCalendarTokenUser returns the user a calendar feed token belongs to
+func (r *TokenRepository) GetCalendarTokenUser(hash string) (int, error) {
+	query := `
+	SELECT user_id FROM calendar_tokens WHERE token_hash = $1
+	`
+
+	var userID int
+	err := r.DB.QueryRow(query, hash).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrInvalidCalendarToken
+		}
+		log.Printf("Error getting calendar token: %v", err)
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// DeleteCalendarToken removes the calendar feed token of a user, if there is one
+func (r *TokenRepository) DeleteCalendarToken(userID int) error {
+	_, err := r.DB.Exec(`DELETE FROM calendar_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		log.Printf("Error deleting calendar token: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 1)
+	repo := NewTokenRepository(db)
+	suffix := time.Now().UnixNano()
+	hash := func(n int) string { return fmt.Sprintf("%048x%016x", suffix, n) }
+	accessExpiresAt := time.Now().Add(15 * time.Minute)
+
+	first := &models.RefreshToken{
+		UserID:        users[0].ID,
+		TokenHash:     hash(1),
+		FamilyID:      fmt.Sprintf("family-%d", suffix),
+		AccessTokenID: fmt.Sprintf("jti-1-%d", suffix),
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+	if err := repo.CreateRefreshToken(first); err != nil {
+		t.Fatalf("create refresh token: %v", err)
+	}
+
+	second := &models.RefreshToken{
+		TokenHash:     hash(2),
+		AccessTokenID: fmt.Sprintf("jti-2-%d", suffix),
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+	if err := repo.RotateRefreshToken(first.TokenHash, second, accessExpiresAt); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if second.UserID != first.UserID || second.FamilyID != first.FamilyID {
+		t.Fatalf("rotated token should inherit user and family")
+	}
+
+	// Replaying the first token must fail and take the second one down with it
+	third := &models.RefreshToken{TokenHash: hash(3), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.RotateRefreshToken(first.TokenHash, third, accessExpiresAt); err != ErrRefreshTokenReused {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+
+	fourth := &models.RefreshToken{TokenHash: hash(4), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.RotateRefreshToken(second.TokenHash, fourth, accessExpiresAt); err != ErrRefreshTokenReused {
+		t.Fatalf("expected the whole family to be revoked, got %v", err)
+	}
+
+	for _, jti := range []string{first.AccessTokenID, second.AccessTokenID} {
+		revoked, err := repo.IsAccessTokenRevoked(jti)
+		if err != nil {
+			t.Fatalf("is revoked: %v", err)
+		}
+		if !revoked {
+			t.Errorf("expected access token %s to be revoked", jti)
+		}
+	}
+}
+
+func TestRotateUnknownRefreshToken(t *testing.T) {
+	db := openTestDB(t)
+
+	repo := NewTokenRepository(db)
+	next := &models.RefreshToken{TokenHash: fmt.Sprintf("%064x", time.Now().UnixNano()), ExpiresAt: time.Now().Add(time.Hour)}
+
+	if err := repo.RotateRefreshToken("does-not-exist", next, time.Now()); err != ErrInvalidRefreshToken {
+		t.Fatalf("expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
+
+func TestCalendarToken(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 1)
+	repo := NewTokenRepository(db)
+	suffix := time.Now().UnixNano()
+	hash := func(n int) string { return fmt.Sprintf("%048x%016x", suffix, n) }
+
+	if err := repo.SetCalendarToken(users[0].ID, hash(1)); err != nil {
+		t.Fatalf("set calendar token: %v", err)
+	}
+	// Setting a new token replaces the old one
+	if err := repo.SetCalendarToken(users[0].ID, hash(2)); err != nil {
+		t.Fatalf("replace calendar token: %v", err)
+	}
+
+	if _, err := repo.GetCalendarTokenUser(hash(1)); err != ErrInvalidCalendarToken {
+		t.Errorf("expected the old token to stop working, got %v", err)
+	}
+	userID, err := repo.GetCalendarTokenUser(hash(2))
+	if err != nil || userID != users[0].ID {
+		t.Errorf("expected user %d, got %d, %v", users[0].ID, userID, err)
+	}
+
+	if err := repo.DeleteCalendarToken(users[0].ID); err != nil {
+		t.Fatalf("delete calendar token: %v", err)
+	}
+	if _, err := repo.GetCalendarTokenUser(hash(2)); err != ErrInvalidCalendarToken {
+		t.Errorf("expected the token to be gone, got %v", err)
+	}
+}
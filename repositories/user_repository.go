@@ -2,10 +2,10 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
 	"log"
 	"time"
 
+	"github.com/event-system/database"
 	"github.com/event-system/models"
 )
 
@@ -22,25 +22,33 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 // Create inserts a new user into the database
 func (r *UserRepository) Create(user *models.User) error {
 	query := `
-	INSERT INTO users (username, email, password, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5)
+	INSERT INTO users (username, email, password, role, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
 	RETURNING id
 	`
 
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
 
 	err := r.DB.QueryRow(
 		query,
 		user.Username,
 		user.Email,
 		user.Password,
+		user.Role,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID)
 
 	if err != nil {
+		// Two registrations with the same name can race past the service checks
+		if database.IsUniqueViolation(err) {
+			return ErrUserExists
+		}
 		log.Printf("Error creating user: %v", err)
 		return err
 	}
@@ -51,7 +59,7 @@ func (r *UserRepository) Create(user *models.User) error {
 // GetByID retrieves a user by ID
 func (r *UserRepository) GetByID(id int) (*models.User, error) {
 	query := `
-	SELECT id, username, email, password, created_at, updated_at
+	SELECT id, username, email, password, role, suspended_at, created_at, updated_at
 	FROM users
 	WHERE id = $1
 	`
@@ -62,13 +70,15 @@ func (r *UserRepository) GetByID(id int) (*models.User, error) {
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
+		&user.SuspendedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		log.Printf("Error getting user by ID: %v", err)
 		return nil, err
@@ -80,7 +90,7 @@ func (r *UserRepository) GetByID(id int) (*models.User, error) {
 // GetByEmail retrieves a user by email
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	query := `
-	SELECT id, username, email, password, created_at, updated_at
+	SELECT id, username, email, password, role, suspended_at, created_at, updated_at
 	FROM users
 	WHERE email = $1
 	`
@@ -91,13 +101,15 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
+		&user.SuspendedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		log.Printf("Error getting user by email: %v", err)
 		return nil, err
@@ -109,7 +121,7 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 // GetByUsername retrieves a user by username
 func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 	query := `
-	SELECT id, username, email, password, created_at, updated_at
+	SELECT id, username, email, password, role, suspended_at, created_at, updated_at
 	FROM users
 	WHERE username = $1
 	`
@@ -120,13 +132,15 @@ func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
+		&user.SuspendedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		log.Printf("Error getting user by username: %v", err)
 		return nil, err
@@ -134,3 +148,100 @@ func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 
 	return user, nil
 }
+
+// List retrieves users ordered by ID, together with the total number of users
+func (r *UserRepository) List(limit, offset int) ([]models.User, int, error) {
+	var total int
+	err := r.DB.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&total)
+	if err != nil {
+		log.Printf("Error counting users: %v", err)
+		return nil, 0, err
+	}
+
+	query := `
+	SELECT id, username, email, password, role, suspended_at, created_at, updated_at
+	FROM users
+	ORDER BY id ASC
+	LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.DB.Query(query, limit, offset)
+	if err != nil {
+		log.Printf("Error listing users: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user := models.User{}
+		err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.Password,
+			&user.Role,
+			&user.SuspendedAt,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning user: %v", err)
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating users: %v", err)
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// SetRole changes the role of a user
+func (r *UserRepository) SetRole(id int, role string) error {
+	query := `
+	UPDATE users SET role = $1, updated_at = $2 WHERE id = $3
+	RETURNING id
+	`
+
+	var updatedID int
+	err := r.DB.QueryRow(query, role, time.Now(), id).Scan(&updatedID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		log.Printf("Error setting user role: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// SetSuspended suspends or reinstates a user
+func (r *UserRepository) SetSuspended(id int, suspended bool) error {
+	query := `
+	UPDATE users SET suspended_at = $1, updated_at = $2 WHERE id = $3
+	RETURNING id
+	`
+
+	now := time.Now()
+	var suspendedAt *time.Time
+	if suspended {
+		suspendedAt = &now
+	}
+
+	var updatedID int
+	err := r.DB.QueryRow(query, suspendedAt, now, id).Scan(&updatedID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		log.Printf("Error suspending user: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/event-system/models"
+)
+
+func TestUserRoleAndSuspension(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 1)
+	repo := NewUserRepository(db)
+
+	user, err := repo.GetByID(users[0].ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if user.Role != models.RoleUser {
+		t.Errorf("expected new users to have role %q, got %q", models.RoleUser, user.Role)
+	}
+
+	if err := repo.SetRole(user.ID, models.RoleOrganizer); err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+	if err := repo.SetSuspended(user.ID, true); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+
+	user, err = repo.GetByID(user.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if user.Role != models.RoleOrganizer {
+		t.Errorf("expected role %q, got %q", models.RoleOrganizer, user.Role)
+	}
+	if user.SuspendedAt == nil {
+		t.Errorf("expected user to be suspended")
+	}
+
+	if err := repo.SetSuspended(user.ID, false); err != nil {
+		t.Fatalf("unsuspend: %v", err)
+	}
+	user, err = repo.GetByID(user.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if user.SuspendedAt != nil {
+		t.Errorf("expected suspension to be lifted")
+	}
+
+	if err := repo.SetRole(0, models.RoleAdmin); err == nil {
+		t.Errorf("expected an error for an unknown user")
+	}
+}
+
+func TestForceDeleteRemovesParticipants(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 2)
+	organizer, user := users[0], users[1]
+	event := createTestEvent(t, db, organizer.ID, 5)
+
+	if _, err := NewParticipantRepository(db).JoinEvent(user.ID, event.ID, models.JoinOptions{}); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	eventRepo := NewEventRepository(db)
+	if err := eventRepo.ForceDelete(event.ID); err != nil {
+		t.Fatalf("force delete: %v", err)
+	}
+	if _, err := eventRepo.GetByID(event.ID); err == nil {
+		t.Errorf("expected event to be gone")
+	}
+}
+
+func TestCreateDuplicateUser(t *testing.T) {
+	db := openTestDB(t)
+
+	users := createTestUsers(t, db, 1)
+	repo := NewUserRepository(db)
+
+	err := repo.Create(&models.User{
+		Username: users[0].Username,
+		Email:    "other-" + users[0].Email,
+		Password: "hashed",
+	})
+	if !errors.Is(err, ErrUserExists) {
+		t.Errorf("expected ErrUserExists, got %v", err)
+	}
+}
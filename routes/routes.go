@@ -2,35 +2,117 @@ package routes
 
 import (
 	"database/sql"
+	"log"
+	"os"
+	"time"
 
 	"github.com/event-system/controllers"
 	"github.com/event-system/middleware"
+	"github.com/event-system/models"
 	"github.com/event-system/repositories"
+	"github.com/event-system/scheduler"
 	"github.com/event-system/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	fiberSwagger "github.com/swaggo/fiber-swagger"
 )
 
-// SetupRoutes configures all the routes for the application
-func SetupRoutes(app *fiber.App, db *sql.DB) {
+// SetupRoutes configures all the routes for the application and registers
+// the background jobs on the scheduler
+func SetupRoutes(app *fiber.App, db *sql.DB, jobs *scheduler.Scheduler) {
 	// Create repositories
 	userRepo := repositories.NewUserRepository(db)
 	eventRepo := repositories.NewEventRepository(db)
 	participantRepo := repositories.NewParticipantRepository(db)
+	tokenRepo := repositories.NewTokenRepository(db)
+	staffRepo := repositories.NewStaffRepository(db)
+	seriesRepo := repositories.NewSeriesRepository(db)
+	inviteRepo := repositories.NewInviteRepository(db)
+	ticketTypeRepo := repositories.NewTicketTypeRepository(db)
+	orderRepo := repositories.NewOrderRepository(db)
+	promoCodeRepo := repositories.NewPromoCodeRepository(db)
+
+	// Payments need a gateway chosen with PAYMENT_PROVIDER. "fake" turns on the
+	// fake gateway for development; without a provider priced tickets can't be
+	// sold and checkout is refused.
+	var paymentProvider services.PaymentProvider
+	var fakePayments *services.FakePaymentProvider
+	switch name := os.Getenv("PAYMENT_PROVIDER"); name {
+	case "":
+	case "fake":
+		fakePayments = services.NewFakePaymentProvider()
+		paymentProvider = fakePayments
+	default:
+		log.Printf("Warning: unknown PAYMENT_PROVIDER %q, payments are disabled", name)
+	}
 
 	// Create services
-	authService := services.NewAuthService(userRepo)
-	eventService := services.NewEventService(eventRepo, participantRepo)
-	participantService := services.NewParticipantService(participantRepo)
+	authService := services.NewAuthService(userRepo, tokenRepo)
+	paymentService := services.NewPaymentService(orderRepo, eventRepo, paymentProvider, services.LogNotifier{})
+	eventService := services.NewEventService(eventRepo, participantRepo, staffRepo, inviteRepo, paymentService, services.LogNotifier{})
+	participantService := services.NewParticipantService(participantRepo, eventRepo, staffRepo, inviteRepo, paymentService, services.LogNotifier{})
+	staffService := services.NewStaffService(eventRepo, staffRepo, userRepo)
+	adminService := services.NewAdminService(userRepo, eventRepo, tokenRepo, paymentService)
+	seriesService := services.NewSeriesService(seriesRepo, eventRepo, paymentService)
+	calendarService := services.NewCalendarService(eventRepo, staffRepo, inviteRepo, userRepo, tokenRepo)
+	inviteService := services.NewInviteService(eventRepo, staffRepo, inviteRepo, userRepo, services.LogNotifier{})
+	ticketService := services.NewTicketService(participantRepo, eventRepo, staffRepo)
+	ticketTypeService := services.NewTicketTypeService(ticketTypeRepo, eventRepo, staffRepo, inviteRepo, paymentService)
+	promoCodeService := services.NewPromoCodeService(promoCodeRepo, ticketTypeRepo, eventRepo, staffRepo, paymentService)
+	if fakePayments != nil {
+		fakePayments.OnWebhook(paymentService.HandleWebhook)
+	}
 
 	// Create controllers
 	authController := controllers.NewAuthController(authService)
 	eventController := controllers.NewEventController(eventService)
 	participantController := controllers.NewParticipantController(participantService)
+	staffController := controllers.NewStaffController(staffService)
+	adminController := controllers.NewAdminController(adminService)
+	seriesController := controllers.NewSeriesController(seriesService)
+	calendarController := controllers.NewCalendarController(calendarService)
+	inviteController := controllers.NewInviteController(inviteService)
+	ticketController := controllers.NewTicketController(ticketService)
+	ticketTypeController := controllers.NewTicketTypeController(ticketTypeService)
+	promoCodeController := controllers.NewPromoCodeController(promoCodeService)
+	paymentController := controllers.NewPaymentController(paymentService, fakePayments)
+	jobController := controllers.NewJobController(jobs)
+
+	// Background jobs
+	jobs.Add(scheduler.Job{
+		Name:     "event_transitions",
+		Interval: time.Minute,
+		Run: func() (int, error) {
+			return eventService.AdvanceEvents(time.Now())
+		},
+	})
+	jobs.Add(scheduler.Job{
+		Name:     "series_window",
+		Interval: time.Hour,
+		Run:      seriesService.ExtendSeries,
+	})
+	jobs.Add(scheduler.Job{
+		Name:     "token_cleanup",
+		Interval: time.Hour,
+		Run: func() (int, error) {
+			return 0, tokenRepo.DeleteExpired()
+		},
+	})
+	jobs.Add(scheduler.Job{
+		Name:     "order_expiry",
+		Interval: time.Minute,
+		Run: func() (int, error) {
+			return paymentService.ProcessOrders(time.Now())
+		},
+	})
 
 	// Protected middleware
 	protectedMiddleware := middleware.Protected(authService)
+	// Optional auth middleware for public routes that show more to signed in users
+	optionalAuth := middleware.OptionalAuth(authService)
+	// Role middleware
+	organizerOnly := middleware.RequireRole(models.RoleOrganizer, models.RoleAdmin)
+	adminOnly := middleware.RequireRole(models.RoleAdmin)
 	// API routes
 	api := app.Group("/api")
 
@@ -41,19 +123,26 @@ func SetupRoutes(app *fiber.App, db *sql.DB) {
 	auth := api.Group("/auth")
 	auth.Post("/register", authController.Register)
 	auth.Post("/login", authController.Login)
+	auth.Post("/refresh", authController.Refresh)
+	auth.Post("/logout", protectedMiddleware, authController.Logout)
 	auth.Get("/profile", protectedMiddleware, authController.GetProfile)
 
 	// Public event routes
 	events := api.Group("/events")
 	events.Get("/public", eventController.GetAllPublicEvents)
-	events.Get("/:id<int>", eventController.GetEvent)
-	events.Get("/:id<int>/participant-count", participantController.GetParticipantCount)
+	events.Get("/search", eventController.SearchEvents)
+	events.Get("/:id<int>", optionalAuth, eventController.GetEvent)
+	events.Get("/:id<int>.ics", optionalAuth, calendarController.ExportEvent)
+	events.Get("/:id<int>/participant-count", optionalAuth, participantController.GetParticipantCount)
 
 	// Protected event routes
-	events.Post("/", protectedMiddleware, eventController.CreateEvent)
+	events.Post("/", protectedMiddleware, organizerOnly, eventController.CreateEvent)
+	events.Post("/import", protectedMiddleware, organizerOnly, eventController.ImportEvents)
 	events.Put("/:id<int>", protectedMiddleware, eventController.UpdateEvent)
 	events.Post("/:id/close", protectedMiddleware, eventController.CloseEvent)
 	events.Post("/:id/open", protectedMiddleware, eventController.OpenEvent)
+	events.Get("/:id<int>/transitions", protectedMiddleware, eventController.GetTransitions)
+	events.Post("/:id<int>/transitions", protectedMiddleware, eventController.TransitionEvent)
 	events.Delete("/:id<int>", protectedMiddleware, eventController.DeleteEvent)
 	events.Get("/my/", protectedMiddleware, eventController.GetMyEvents)
 	events.Get("/participating", protectedMiddleware, eventController.GetMyParticipatingEvents)
@@ -64,6 +153,87 @@ func SetupRoutes(app *fiber.App, db *sql.DB) {
 	events.Post("/:id<int>/leave", protectedMiddleware, participantController.LeaveEvent)
 	events.Get("/:id<int>/is-participant", protectedMiddleware, participantController.IsParticipant)
 
+	// Waitlist routes
+	events.Get("/:id<int>/waitlist/position", protectedMiddleware, participantController.GetWaitlistPosition)
+	events.Get("/:id<int>/waitlist", protectedMiddleware, participantController.GetWaitlist)
+	events.Put("/:id<int>/waitlist", protectedMiddleware, participantController.ReorderWaitlist)
+
+	// Registration review routes
+	events.Get("/:id<int>/registrations/pending", protectedMiddleware, participantController.GetPendingRegistrations)
+	events.Post("/:id<int>/registrations/approve", protectedMiddleware, participantController.ApproveRegistrations)
+	events.Post("/:id<int>/registrations/reject", protectedMiddleware, participantController.RejectRegistrations)
+
+	// Ticket type routes
+	events.Get("/:id<int>/ticket-types", optionalAuth, ticketTypeController.ListTicketTypes)
+	events.Post("/:id<int>/ticket-types", protectedMiddleware, ticketTypeController.CreateTicketType)
+	events.Put("/:id<int>/ticket-types/:ticketTypeId<int>", protectedMiddleware, ticketTypeController.UpdateTicketType)
+	events.Delete("/:id<int>/ticket-types/:ticketTypeId<int>", protectedMiddleware, ticketTypeController.DeleteTicketType)
+
+	// Promo code routes
+	events.Get("/:id<int>/promo-codes", protectedMiddleware, promoCodeController.ListPromoCodes)
+	events.Post("/:id<int>/promo-codes", protectedMiddleware, promoCodeController.CreatePromoCode)
+	events.Put("/:id<int>/promo-codes/:codeId<int>", protectedMiddleware, promoCodeController.UpdatePromoCode)
+	events.Delete("/:id<int>/promo-codes/:codeId<int>", protectedMiddleware, promoCodeController.DeletePromoCode)
+	events.Get("/:id<int>/promo-codes/:codeId<int>/redemptions", protectedMiddleware, promoCodeController.GetRedemptionReport)
+
+	// Order routes
+	events.Post("/:id<int>/checkout", protectedMiddleware, paymentController.Checkout)
+	events.Get("/:id<int>/orders", protectedMiddleware, paymentController.GetOrders)
+
+	// Ticket and check-in routes
+	events.Get("/:id<int>/ticket", protectedMiddleware, ticketController.GetTicket)
+	events.Post("/:id<int>/checkins", protectedMiddleware, ticketController.CheckIn)
+	events.Get("/:id<int>/checkins/manifest", protectedMiddleware, ticketController.GetCheckInManifest)
+	events.Post("/:id<int>/checkins/sync", protectedMiddleware, ticketController.SyncCheckIns)
+	events.Get("/:id<int>/attendance", protectedMiddleware, ticketController.GetAttendance)
+
+	// Staff routes
+	events.Get("/:id<int>/staff", protectedMiddleware, staffController.GetStaff)
+	events.Post("/:id<int>/staff", protectedMiddleware, staffController.AddStaff)
+	events.Delete("/:id<int>/staff/:userId<int>", protectedMiddleware, staffController.RemoveStaff)
+	events.Post("/:id<int>/transfer-ownership", protectedMiddleware, staffController.TransferOwnership)
+
+	// Invite routes
+	events.Post("/:id<int>/invites/link", protectedMiddleware, inviteController.CreateInviteLink)
+	events.Post("/:id<int>/invites", protectedMiddleware, inviteController.InviteUsers)
+	events.Get("/:id<int>/invites", protectedMiddleware, inviteController.ListInvites)
+	events.Delete("/:id<int>/invites/:inviteId<int>", protectedMiddleware, inviteController.RevokeInvite)
+
+	// Recurring event series routes
+	series := api.Group("/series")
+	series.Get("/:id<int>", seriesController.GetSeries)
+	series.Post("/", protectedMiddleware, organizerOnly, seriesController.CreateSeries)
+	series.Put("/:id<int>/occurrences/:eventId<int>", protectedMiddleware, seriesController.UpdateOccurrence)
+	series.Delete("/:id<int>", protectedMiddleware, seriesController.DeleteSeries)
+	series.Post("/:id<int>/join", protectedMiddleware, seriesController.JoinSeries)
+	series.Post("/:id<int>/leave", protectedMiddleware, seriesController.LeaveSeries)
+
+	// Payment routes
+	payments := api.Group("/payments")
+	payments.Post("/webhook", paymentController.Webhook)
+	if fakePayments != nil {
+		// The checkout page of the fake gateway settles payments without
+		// any check, so it only exists in development
+		payments.Post("/fake/:ref", paymentController.SettleFakePayment)
+	}
+
+	// Calendar feed routes
+	calendar := api.Group("/calendar")
+	calendar.Post("/feed", protectedMiddleware, calendarController.CreateFeed)
+	calendar.Delete("/feed", protectedMiddleware, calendarController.RevokeFeed)
+	calendar.Get("/feed/:token.ics", calendarController.Feed)
+
+	// Admin routes
+	admin := api.Group("/admin", protectedMiddleware, adminOnly)
+	admin.Get("/users", adminController.ListUsers)
+	admin.Post("/users/:id<int>/suspend", adminController.SuspendUser)
+	admin.Post("/users/:id<int>/unsuspend", adminController.UnsuspendUser)
+	admin.Put("/users/:id<int>/role", adminController.SetUserRole)
+	admin.Put("/events/:id<int>", adminController.UpdateEvent)
+	admin.Post("/events/:id<int>/close", adminController.CloseEvent)
+	admin.Delete("/events/:id<int>", adminController.DeleteEvent)
+	admin.Get("/jobs", jobController.ListJobs)
+
 	// Add request logger middleware for API routes
 	api.Use(logger.New(logger.Config{
 		Format: "[${time}] ${status} - ${latency} ${method} ${path}\n",
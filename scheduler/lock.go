@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"log"
+	"sync"
+
+	"github.com/event-system/database"
+)
+
+// Locker makes sure a job runs on one instance at a time
+type Locker interface {
+	// TryLock takes the lock of a job without waiting. ok is false when
+	// someone else holds it; otherwise unlock must be called after the run.
+	TryLock(name string) (unlock func(), ok bool, err error)
+}
+
+// NewLocker returns the locker that fits the database: PostgreSQL advisory
+// locks, so several instances can share the database, or a process-local
+// lock for SQLite, whose database file belongs to a single instance.
+func NewLocker(db *sql.DB) Locker {
+	if database.Dialect(db) == database.DriverSQLite {
+		return NewLocalLocker()
+	}
+	return &PostgresLocker{DB: db}
+}
+
+// PostgresLocker takes a session advisory lock per job
+type PostgresLocker struct {
+	DB *sql.DB
+}
+
+// TryLock holds a dedicated connection for as long as the lock is taken,
+// because advisory locks belong to a session
+func (l *PostgresLocker) TryLock(name string) (func(), bool, error) {
+	ctx := context.Background()
+	conn, err := l.DB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := lockKey(name)
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked)
+	if err != nil || !locked {
+		conn.Close()
+		return nil, false, err
+	}
+
+	unlock := func() {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			log.Printf("Error releasing lock of job %s: %v", name, err)
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
+
+// lockKey derives the advisory lock key of a job from its name
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("event-system/job/" + name))
+	return int64(h.Sum64())
+}
+
+// LocalLocker only keeps jobs from overlapping inside this process
+type LocalLocker struct {
+	mu     sync.Mutex
+	locked map[string]bool
+}
+
+// NewLocalLocker creates a process-local locker
+func NewLocalLocker() *LocalLocker {
+	return &LocalLocker{locked: map[string]bool{}}
+}
+
+// TryLock takes the lock of a job if it is free
+func (l *LocalLocker) TryLock(name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locked[name] {
+		return nil, false, nil
+	}
+	l.locked[name] = true
+
+	unlock := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.locked, name)
+	}
+	return unlock, true, nil
+}
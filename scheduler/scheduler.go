@@ -0,0 +1,198 @@
+// Package scheduler runs periodic background jobs inside the server process.
+// Every run takes the lock of its job first, so when several instances share
+// a database each run happens on only one of them.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// Job is a piece of work that runs once per interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Run does the work and returns how many items it changed
+	Run func() (int, error)
+}
+
+// Scheduler runs jobs in the background and keeps track of how they did
+type Scheduler struct {
+	Locker Locker
+
+	mu      sync.Mutex
+	jobs    []*jobState
+	started bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// jobState is a job together with its status, guarded by Scheduler.mu
+type jobState struct {
+	job    Job
+	status models.JobStatus
+}
+
+// New creates a scheduler that takes job locks from locker
+func New(locker Locker) *Scheduler {
+	return &Scheduler{
+		Locker: locker,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Add registers a job. Jobs added after Start never run.
+func (s *Scheduler) Add(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = append(s.jobs, &jobState{
+		job:    job,
+		status: models.JobStatus{Name: job.Name, Interval: job.Interval.String()},
+	})
+}
+
+// Start runs every job right away and then once per interval
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	now := time.Now()
+	for _, state := range s.jobs {
+		state.status.NextRunAt = &now
+		s.wg.Add(1)
+		go s.loop(state)
+	}
+}
+
+// Stop stops scheduling runs and waits until the running ones finish, or
+// until ctx is done
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for running jobs: %w", ctx.Err())
+	}
+}
+
+// Status returns the status of every job in the order they were added
+func (s *Scheduler) Status() []models.JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]models.JobStatus, len(s.jobs))
+	for i, state := range s.jobs {
+		statuses[i] = state.status
+	}
+	return statuses
+}
+
+// loop runs a job until the scheduler stops
+func (s *Scheduler) loop(state *jobState) {
+	defer s.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-timer.C:
+		}
+		// Both may be ready at once; stopping wins
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		s.run(state)
+
+		next := time.Now().Add(state.job.Interval)
+		s.mu.Lock()
+		state.status.NextRunAt = &next
+		s.mu.Unlock()
+		timer.Reset(state.job.Interval)
+	}
+}
+
+// run runs a job once if its lock is free and records the outcome
+func (s *Scheduler) run(state *jobState) {
+	name := state.job.Name
+
+	unlock, ok, err := s.Locker.TryLock(name)
+	if err != nil {
+		log.Printf("Error taking lock of job %s: %v", name, err)
+		s.mu.Lock()
+		state.status.Failures++
+		state.status.LastError = "take lock: " + err.Error()
+		s.mu.Unlock()
+		return
+	}
+	if !ok {
+		s.mu.Lock()
+		state.status.Skipped++
+		s.mu.Unlock()
+		return
+	}
+	defer unlock()
+
+	started := time.Now()
+	s.mu.Lock()
+	state.status.Running = true
+	state.status.LastStartedAt = &started
+	s.mu.Unlock()
+
+	processed, err := runJob(state.job)
+
+	finished := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state.status.Running = false
+	state.status.Runs++
+	state.status.LastFinishedAt = &finished
+	state.status.LastDuration = finished.Sub(started).String()
+	state.status.LastProcessed = processed
+	state.status.LastError = ""
+	if err != nil {
+		log.Printf("Error running job %s: %v", name, err)
+		state.status.Failures++
+		state.status.LastError = err.Error()
+	}
+}
+
+// runJob calls the job, turning a panic into an error so one broken run
+// doesn't take the server down
+func runJob(job Job) (processed int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run()
+}
@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls until cond holds or a second passed
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerRunsJobsAndReportsStatus(t *testing.T) {
+	s := New(NewLocalLocker())
+	var runs int32
+	s.Add(Job{Name: "count", Interval: 5 * time.Millisecond, Run: func() (int, error) {
+		return int(atomic.AddInt32(&runs, 1)), nil
+	}})
+	s.Add(Job{Name: "fail", Interval: time.Hour, Run: func() (int, error) {
+		return 0, errors.New("database is down")
+	}})
+	s.Add(Job{Name: "panic", Interval: time.Hour, Run: func() (int, error) {
+		panic("boom")
+	}})
+
+	s.Start()
+	waitFor(t, func() bool {
+		statuses := s.Status()
+		return statuses[0].Runs >= 3 && statuses[1].Runs == 1 && statuses[2].Runs == 1
+	})
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	statuses := s.Status()
+	count := statuses[0]
+	if count.Name != "count" || count.Interval != "5ms" || count.Failures != 0 || count.LastError != "" {
+		t.Errorf("unexpected status %+v", count)
+	}
+	if count.LastStartedAt == nil || count.LastFinishedAt == nil || count.NextRunAt == nil || count.LastProcessed != count.Runs {
+		t.Errorf("expected the last run to be recorded, got %+v", count)
+	}
+
+	if statuses[1].Failures != 1 || statuses[1].LastError != "database is down" {
+		t.Errorf("expected the failure to be recorded, got %+v", statuses[1])
+	}
+	if statuses[2].Failures != 1 || statuses[2].LastError != "panic: boom" {
+		t.Errorf("expected the panic to be recorded, got %+v", statuses[2])
+	}
+
+	// Nothing runs after Stop
+	stopped := atomic.LoadInt32(&runs)
+	time.Sleep(20 * time.Millisecond)
+	if atomic.LoadInt32(&runs) != stopped {
+		t.Error("job ran after Stop")
+	}
+}
+
+func TestSchedulerSkipsLockedJobs(t *testing.T) {
+	locker := NewLocalLocker()
+	unlock, ok, _ := locker.TryLock("cleanup")
+	if !ok {
+		t.Fatal("expected to get the lock")
+	}
+	defer unlock()
+	if _, ok, _ := locker.TryLock("cleanup"); ok {
+		t.Fatal("expected the lock to be taken")
+	}
+
+	// Another instance holds the lock, so this one leaves the job alone
+	s := New(locker)
+	ran := false
+	s.Add(Job{Name: "cleanup", Interval: 5 * time.Millisecond, Run: func() (int, error) {
+		ran = true
+		return 0, nil
+	}})
+	s.Start()
+	waitFor(t, func() bool { return s.Status()[0].Skipped >= 2 })
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	if ran || s.Status()[0].Runs != 0 {
+		t.Errorf("expected the job to be skipped, got %+v", s.Status()[0])
+	}
+}
+
+func TestStopWaitsForRunningJobs(t *testing.T) {
+	s := New(NewLocalLocker())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var finished int32
+	s.Add(Job{Name: "slow", Interval: time.Hour, Run: func() (int, error) {
+		close(started)
+		<-release
+		atomic.StoreInt32(&finished, 1)
+		return 1, nil
+	}})
+	s.Start()
+	<-started
+
+	if !s.Status()[0].Running {
+		t.Error("expected the job to be reported as running")
+	}
+
+	// Stop gives up when its context ends first
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to pass, got %v", err)
+	}
+
+	close(release)
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if atomic.LoadInt32(&finished) != 1 || s.Status()[0].Running {
+		t.Error("expected Stop to wait for the running job")
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// AdminService handles administration of users and events across the whole system
+type AdminService struct {
+	UserRepo  repositories.UserStore
+	EventRepo repositories.EventStore
+	TokenRepo repositories.TokenStore
+	Payments  *PaymentService
+}
+
+// NewAdminService creates a new admin service instance
+func NewAdminService(userRepo repositories.UserStore, eventRepo repositories.EventStore, tokenRepo repositories.TokenStore, payments *PaymentService) *AdminService {
+	return &AdminService{
+		UserRepo:  userRepo,
+		EventRepo: eventRepo,
+		TokenRepo: tokenRepo,
+		Payments:  payments,
+	}
+}
+
+// ListUsers retrieves one page of users
+func (s *AdminService) ListUsers(limit, offset int) (*models.AdminUserListResponse, error) {
+	if limit <= 0 {
+		limit = models.DefaultPageSize
+	}
+	if limit > models.MaxPageSize {
+		limit = models.MaxPageSize
+	}
+
+	users, total, err := s.UserRepo.List(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.AdminUserListResponse{
+		Users:  make([]models.AdminUserResponse, len(users)),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	for i, user := range users {
+		response.Users[i] = newAdminUserResponse(&user)
+	}
+
+	return response, nil
+}
+
+// SuspendUser suspends a user and revokes all of their tokens
+func (s *AdminService) SuspendUser(adminID, userID int) (*models.AdminUserResponse, error) {
+	if adminID == userID {
+		return nil, ErrCannotSuspendSelf
+	}
+
+	err := s.UserRepo.SetSuspended(userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sign the user out everywhere
+	err = s.TokenRepo.RevokeAllForUser(userID, time.Now().Add(accessTokenTTL))
+	if err != nil {
+		log.Printf("Error revoking tokens of suspended user: %v", err)
+		return nil, apperrors.Internal(err)
+	}
+
+	return s.getUser(userID)
+}
+
+// UnsuspendUser lifts the suspension of a user
+func (s *AdminService) UnsuspendUser(userID int) (*models.AdminUserResponse, error) {
+	err := s.UserRepo.SetSuspended(userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getUser(userID)
+}
+
+// SetUserRole changes the role of a user. Their tokens are revoked so the new
+// role is picked up on the next login instead of lingering in old tokens.
+func (s *AdminService) SetUserRole(adminID, userID int, role string) (*models.AdminUserResponse, error) {
+	if role != models.RoleUser && role != models.RoleOrganizer && role != models.RoleAdmin {
+		return nil, ErrInvalidRole
+	}
+	if adminID == userID {
+		return nil, ErrCannotChangeOwnRole
+	}
+
+	err := s.UserRepo.SetRole(userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.TokenRepo.RevokeAllForUser(userID, time.Now().Add(accessTokenTTL))
+	if err != nil {
+		log.Printf("Error revoking tokens after role change: %v", err)
+		return nil, apperrors.Internal(err)
+	}
+
+	return s.getUser(userID)
+}
+
+// UpdateEvent updates any event, no matter who organizes it
+func (s *AdminService) UpdateEvent(id int, req models.EventRequest) (*models.EventResponse, error) {
+	// Get existing event
+	event, err := s.EventRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkEditable(event); err != nil {
+		return nil, err
+	}
+	if err := checkStartTime(event, req); err != nil {
+		return nil, err
+	}
+	if err := checkRegistrationWindow(req); err != nil {
+		return nil, err
+	}
+
+	// Update event fields
+	event.Name = req.Name
+	event.Description = req.Description
+	event.Location = req.Location
+	event.StartTime = req.StartTime
+	event.EndTime = req.EndTime
+	event.Capacity = req.Capacity
+	event.PublishAt = req.PublishAt
+	event.RegistrationOpensAt = req.RegistrationOpensAt
+	event.RegistrationClosesAt = req.RegistrationClosesAt
+	event.RequiresApproval = req.RequiresApproval
+	if req.Visibility != "" {
+		event.Visibility = req.Visibility
+	}
+
+	// Save updated event
+	promoted, err := s.EventRepo.Update(event)
+	if err != nil {
+		log.Printf("Error updating event: %v", err)
+		return nil, apperrors.Internal(err)
+	}
+	s.Payments.NotifyPromoted(promoted)
+
+	return newEventResponse(event), nil
+}
+
+// CloseEvent closes registration for any event
+func (s *AdminService) CloseEvent(id int) (*models.EventResponse, error) {
+	// Get existing event
+	event, err := s.EventRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.Status == models.EventStatusRegistrationClosed {
+		return nil, ErrEventAlreadyClosed
+	}
+	if err := changeEventStatus(s.EventRepo, event, models.EventStatusRegistrationClosed); err != nil {
+		return nil, err
+	}
+
+	return newEventResponse(event), nil
+}
+
+// DeleteEvent deletes any event along with its participants. An event that
+// holds payments has to be cancelled and refunded first.
+func (s *AdminService) DeleteEvent(id int) error {
+	return s.EventRepo.ForceDelete(id)
+}
+
+// getUser loads a user in admin response format
+func (s *AdminService) getUser(id int) (*models.AdminUserResponse, error) {
+	user, err := s.UserRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := newAdminUserResponse(user)
+	return &response, nil
+}
+
+// newAdminUserResponse converts a user to admin response format
+func newAdminUserResponse(user *models.User) models.AdminUserResponse {
+	return models.AdminUserResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		Role:        user.Role,
+		SuspendedAt: user.SuspendedAt,
+		CreatedAt:   user.CreatedAt,
+	}
+}
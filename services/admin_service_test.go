@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/event-system/models"
+)
+
+func TestSuspendUser(t *testing.T) {
+	ts := newTestServices()
+	admin := ts.createUser(t, models.RoleAdmin)
+	tokens := register(t, ts, "alice")
+
+	if _, err := ts.admin.SuspendUser(admin.ID, admin.ID); !errors.Is(err, ErrCannotSuspendSelf) {
+		t.Errorf("suspend self: got %v, want ErrCannotSuspendSelf", err)
+	}
+
+	suspended, err := ts.admin.SuspendUser(admin.ID, tokens.User.ID)
+	if err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+	if suspended.SuspendedAt == nil {
+		t.Error("suspended_at is not set")
+	}
+	if _, err := ts.auth.ValidateToken(tokens.Token); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("access token after suspension: got %v, want ErrTokenRevoked", err)
+	}
+
+	if _, err := ts.admin.UnsuspendUser(tokens.User.ID); err != nil {
+		t.Fatalf("unsuspend: %v", err)
+	}
+	if _, err := ts.auth.Login(models.LoginRequest{Email: "alice@example.com", Password: "secret123"}); err != nil {
+		t.Errorf("login after unsuspend: %v", err)
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	ts := newTestServices()
+	admin := ts.createUser(t, models.RoleAdmin)
+	user := ts.createUser(t, models.RoleUser)
+
+	if _, err := ts.admin.SetUserRole(admin.ID, admin.ID, models.RoleUser); !errors.Is(err, ErrCannotChangeOwnRole) {
+		t.Errorf("change own role: got %v, want ErrCannotChangeOwnRole", err)
+	}
+	if _, err := ts.admin.SetUserRole(admin.ID, user.ID, "superuser"); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("unknown role: got %v, want ErrInvalidRole", err)
+	}
+
+	updated, err := ts.admin.SetUserRole(admin.ID, user.ID, models.RoleOrganizer)
+	if err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+	if updated.Role != models.RoleOrganizer {
+		t.Errorf("role = %s, want %s", updated.Role, models.RoleOrganizer)
+	}
+}
+
+func TestAdminDeleteEventRemovesParticipants(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	user := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if err := ts.admin.DeleteEvent(event.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	// The user's slot is freed, so the active events count no longer includes it
+	page, err := ts.events.GetEventsByParticipant(user.ID, models.EventListQuery{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if page.Total != 0 {
+		t.Errorf("events of participant = %d, want 0", page.Total)
+	}
+}
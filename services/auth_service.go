@@ -1,25 +1,48 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"log"
 	"os"
 	"time"
 
+	"github.com/event-system/apperrors"
 	"github.com/event-system/models"
 	"github.com/event-system/repositories"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// عمر توکن دسترسی کوتاهه و با توکن رفرش تمدید میشه
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
 // سرویس احراز هویت که منطق کسب و کار مربوط به احراز هویت رو مدیریت میکنه
 type AuthService struct {
-	UserRepo *repositories.UserRepository
+	UserRepo  repositories.UserStore
+	TokenRepo repositories.TokenStore
+}
+
+// اطلاعاتی که از یه توکن دسترسی معتبر استخراج میشه
+type TokenClaims struct {
+	UserID    int
+	Role      string
+	TokenID   string // jti توکن
+	ExpiresAt time.Time
 }
 
 // یه نمونه جدید از سرویس احراز هویت میسازه
-func NewAuthService(userRepo *repositories.UserRepository) *AuthService {
-	return &AuthService{UserRepo: userRepo}
+func NewAuthService(userRepo repositories.UserStore, tokenRepo repositories.TokenStore) *AuthService {
+	return &AuthService{
+		UserRepo:  userRepo,
+		TokenRepo: tokenRepo,
+	}
 }
 
 // ثبت نام کاربر رو انجام میده
@@ -27,20 +50,26 @@ func (s *AuthService) Register(req models.RegisterRequest) (*models.TokenRespons
 	// چک میکنه ببینه نام کاربری قبلا وجود داره یا نه
 	_, err := s.UserRepo.GetByUsername(req.Username)
 	if err == nil {
-		return nil, errors.New("username already exists")
+		return nil, ErrUsernameTaken
+	}
+	if !errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, apperrors.Internal(err)
 	}
 
 	// چک میکنه ببینه ایمیل قبلا وجود داره یا نه
 	_, err = s.UserRepo.GetByEmail(req.Email)
 	if err == nil {
-		return nil, errors.New("email already exists")
+		return nil, ErrEmailTaken
+	}
+	if !errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, apperrors.Internal(err)
 	}
 
 	// رمز عبور رو هش میکنه
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		log.Printf("Error hashing password: %v", err)
-		return nil, errors.New("error processing registration")
+		return nil, apperrors.Internal(err)
 	}
 
 	// کاربر رو میسازه
@@ -53,26 +82,17 @@ func (s *AuthService) Register(req models.RegisterRequest) (*models.TokenRespons
 	err = s.UserRepo.Create(user)
 	if err != nil {
 		log.Printf("Error creating user: %v", err)
-		return nil, errors.New("error processing registration")
+		return nil, apperrors.Internal(err)
 	}
 
-	// توکن رو میسازه
-	token, expiresAt, err := s.generateToken(user)
+	// توکن‌ها رو میسازه
+	response, err := s.issueTokens(user)
 	if err != nil {
-		log.Printf("Error generating token: %v", err)
-		return nil, errors.New("error processing registration")
+		log.Printf("Error generating tokens: %v", err)
+		return nil, apperrors.Internal(err)
 	}
 
-	return &models.TokenResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
-		User: models.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		},
-	}, nil
+	return response, nil
 }
 
 // ورود کاربر رو مدیریت میکنه
@@ -80,34 +100,110 @@ func (s *AuthService) Login(req models.LoginRequest) (*models.TokenResponse, err
 	// کاربر رو با ایمیل پیدا میکنه
 	user, err := s.UserRepo.GetByEmail(req.Email)
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, apperrors.Internal(err)
 	}
 
 	// رمز عبور رو چک میکنه
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		return nil, ErrInvalidCredentials
 	}
 
-	// توکن رو میسازه
-	token, expiresAt, err := s.generateToken(user)
+	// کاربر تعلیق‌شده نمیتونه وارد بشه
+	if user.SuspendedAt != nil {
+		return nil, ErrAccountSuspended
+	}
+
+	// توکن‌ها رو میسازه
+	response, err := s.issueTokens(user)
+	if err != nil {
+		log.Printf("Error generating tokens: %v", err)
+		return nil, apperrors.Internal(err)
+	}
+
+	return response, nil
+}
+
+// با یه توکن رفرش معتبر، توکن دسترسی و توکن رفرش جدید میده و توکن قبلی رو باطل میکنه
+func (s *AuthService) Refresh(req models.RefreshRequest) (*models.TokenResponse, error) {
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		log.Printf("Error generating refresh token: %v", err)
+		return nil, apperrors.Internal(err)
+	}
+	tokenID, err := newOpaqueToken()
+	if err != nil {
+		log.Printf("Error generating token ID: %v", err)
+		return nil, apperrors.Internal(err)
+	}
+
+	now := time.Now()
+	next := &models.RefreshToken{
+		TokenHash:     hashToken(refreshToken),
+		AccessTokenID: tokenID,
+		ExpiresAt:     now.Add(refreshTokenTTL),
+	}
+
+	// توکن قدیمی رو با جدید عوض میکنه؛ اگه توکن قبلا استفاده شده باشه کل خانواده‌ش باطل میشه
+	err = s.TokenRepo.RotateRefreshToken(hashToken(req.RefreshToken), next, now.Add(accessTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.UserRepo.GetByID(next.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.SuspendedAt != nil {
+		return nil, ErrAccountSuspended
+	}
+
+	token, expiresAt, err := s.generateToken(user, tokenID)
 	if err != nil {
 		log.Printf("Error generating token: %v", err)
-		return nil, errors.New("error processing login")
+		return nil, apperrors.Internal(err)
 	}
 
 	return &models.TokenResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
+		Token:                 token,
+		ExpiresAt:             expiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: next.ExpiresAt,
 		User: models.UserResponse{
 			ID:        user.ID,
 			Username:  user.Username,
 			Email:     user.Email,
+			Role:      user.Role,
 			CreatedAt: user.CreatedAt,
 		},
 	}, nil
 }
 
+// خروج از سیستم: توکن دسترسی فعلی و (اگه داده شده باشه) کل خانواده توکن رفرش رو باطل میکنه
+func (s *AuthService) Logout(claims *TokenClaims, req models.LogoutRequest) error {
+	err := s.TokenRepo.RevokeAccessToken(claims.TokenID, claims.ExpiresAt)
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if req.RefreshToken == "" {
+		return nil
+	}
+
+	err = s.TokenRepo.RevokeFamilyByToken(claims.UserID, hashToken(req.RefreshToken), time.Now().Add(accessTokenTTL))
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidRefreshToken) {
+			return err
+		}
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
 // کاربر رو با آیدی پیدا میکنه
 func (s *AuthService) GetUserByID(id int) (*models.UserResponse, error) {
 	user, err := s.UserRepo.GetByID(id)
@@ -119,26 +215,77 @@ func (s *AuthService) GetUserByID(id int) (*models.UserResponse, error) {
 		ID:        user.ID,
 		Username:  user.Username,
 		Email:     user.Email,
+		Role:      user.Role,
 		CreatedAt: user.CreatedAt,
 	}, nil
 }
 
-// یه توکن JWT برای کاربر میسازه
-func (s *AuthService) generateToken(user *models.User) (string, time.Time, error) {
+// توکن دسترسی و یه خانواده جدید از توکن رفرش برای کاربر میسازه
+func (s *AuthService) issueTokens(user *models.User) (*models.TokenResponse, error) {
+	tokenID, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	familyID, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := s.generateToken(user, tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	// فقط هش توکن رفرش ذخیره میشه
+	stored := &models.RefreshToken{
+		UserID:        user.ID,
+		TokenHash:     hashToken(refreshToken),
+		FamilyID:      familyID,
+		AccessTokenID: tokenID,
+		ExpiresAt:     time.Now().Add(refreshTokenTTL),
+	}
+	err = s.TokenRepo.CreateRefreshToken(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		Token:                 token,
+		ExpiresAt:             expiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: stored.ExpiresAt,
+		User: models.UserResponse{
+			ID:        user.ID,
+			Username:  user.Username,
+			Email:     user.Email,
+			Role:      user.Role,
+			CreatedAt: user.CreatedAt,
+		},
+	}, nil
+}
+
+// یه توکن JWT با شناسه tokenID برای کاربر میسازه
+func (s *AuthService) generateToken(user *models.User, tokenID string) (string, time.Time, error) {
 	// کلید رمزنگاری JWT رو از متغیرهای محیطی میگیره یا از مقدار پیشفرض استفاده میکنه
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
 		jwtSecret = "your-secret-key" // کلید رمزنگاری پیشفرض (حتما تو محیط پروداکشن عوضش کن)
 	}
 
-	// زمان انقضای توکن رو تنظیم میکنه (24 ساعت)
-	expiresAt := time.Now().Add(24 * time.Hour)
+	// زمان انقضای توکن رو تنظیم میکنه (15 دقیقه)
+	expiresAt := time.Now().Add(accessTokenTTL)
 
 	// اطلاعات توکن رو میسازه
 	claims := jwt.MapClaims{
 		"id":       user.ID,
 		"username": user.Username,
 		"email":    user.Email,
+		"role":     user.Role,
+		"jti":      tokenID,
 		"exp":      expiresAt.Unix(),
 	}
 
@@ -154,8 +301,8 @@ func (s *AuthService) generateToken(user *models.User) (string, time.Time, error
 	return tokenString, expiresAt, nil
 }
 
-// توکن JWT رو اعتبارسنجی میکنه و آیدی کاربر رو برمیگردونه
-func (s *AuthService) ValidateToken(tokenString string) (int, error) {
+// توکن JWT رو اعتبارسنجی میکنه، لیست توکن‌های باطل‌شده رو چک میکنه و اطلاعات توکن رو برمیگردونه
+func (s *AuthService) ValidateToken(tokenString string) (*TokenClaims, error) {
 	// کلید رمزنگاری JWT رو از متغیرهای محیطی میگیره یا از مقدار پیشفرض استفاده میکنه
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
@@ -172,20 +319,63 @@ func (s *AuthService) ValidateToken(tokenString string) (int, error) {
 	})
 
 	if err != nil {
-		return 0, err
+		return nil, ErrInvalidToken.Wrap(err)
 	}
 
 	// توکن رو اعتبارسنجی میکنه
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// تاریخ انقضا رو چک میکنه
-		if float64(time.Now().Unix()) > claims["exp"].(float64) {
-			return 0, errors.New("token expired")
-		}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	// تاریخ انقضا رو چک میکنه
+	exp, ok := claims["exp"].(float64)
+	if !ok || float64(time.Now().Unix()) > exp {
+		return nil, ErrInvalidToken
+	}
+
+	// آیدی کاربر و شناسه توکن رو میگیره
+	id, ok := claims["id"].(float64)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	tokenID, ok := claims["jti"].(string)
+	if !ok || tokenID == "" {
+		return nil, ErrInvalidToken
+	}
+	role, ok := claims["role"].(string)
+	if !ok {
+		role = models.RoleUser
+	}
+
+	// چک میکنه توکن باطل نشده باشه (مثلا با خروج از سیستم)
+	revoked, err := s.TokenRepo.IsAccessTokenRevoked(tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return &TokenClaims{
+		UserID:    int(id),
+		Role:      role,
+		TokenID:   tokenID,
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}, nil
+}
 
-		// آیدی کاربر رو میگیره
-		userID := int(claims["id"].(float64))
-		return userID, nil
+// یه رشته تصادفی و غیرقابل حدس برای توکن رفرش یا شناسه توکن میسازه
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
 
-	return 0, errors.New("invalid token")
+// هش SHA-256 توکن رفرش که تو دیتابیس ذخیره میشه
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+func register(t *testing.T, ts *testServices, username string) *models.TokenResponse {
+	t.Helper()
+
+	tokens, err := ts.auth.Register(models.RegisterRequest{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "secret123",
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	return tokens
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	ts := newTestServices()
+	register(t, ts, "alice")
+
+	_, err := ts.auth.Register(models.RegisterRequest{Username: "alice", Email: "other@example.com", Password: "secret123"})
+	if !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("same username: got %v, want ErrUsernameTaken", err)
+	}
+
+	_, err = ts.auth.Register(models.RegisterRequest{Username: "bob", Email: "alice@example.com", Password: "secret123"})
+	if !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("same email: got %v, want ErrEmailTaken", err)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	ts := newTestServices()
+	tokens := register(t, ts, "alice")
+
+	_, err := ts.auth.Login(models.LoginRequest{Email: "alice@example.com", Password: "wrong"})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password: got %v, want ErrInvalidCredentials", err)
+	}
+
+	if _, err := ts.auth.Login(models.LoginRequest{Email: "alice@example.com", Password: "secret123"}); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	if err := ts.store.Users().SetSuspended(tokens.User.ID, true); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+	_, err = ts.auth.Login(models.LoginRequest{Email: "alice@example.com", Password: "secret123"})
+	if !errors.Is(err, ErrAccountSuspended) {
+		t.Errorf("suspended login: got %v, want ErrAccountSuspended", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ts := newTestServices()
+	tokens := register(t, ts, "alice")
+
+	rotated, err := ts.auth.Refresh(models.RefreshRequest{RefreshToken: tokens.RefreshToken})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	// Replaying the first token must fail and take the rotated one down with it
+	_, err = ts.auth.Refresh(models.RefreshRequest{RefreshToken: tokens.RefreshToken})
+	if !errors.Is(err, repositories.ErrRefreshTokenReused) {
+		t.Fatalf("replay: got %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := ts.auth.Refresh(models.RefreshRequest{RefreshToken: rotated.RefreshToken}); err == nil {
+		t.Error("rotated token still works after reuse was detected")
+	}
+	if _, err := ts.auth.ValidateToken(rotated.Token); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("access token after reuse: got %v, want ErrTokenRevoked", err)
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/ical"
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// calendarUIDDomain makes the UIDs of exported events globally unique. It
+// must never change, or calendar clients would see every event as new.
+const calendarUIDDomain = "event-system"
+
+// CalendarService handles iCalendar export of events and per-user calendar feeds
+type CalendarService struct {
+	EventRepo  repositories.EventStore
+	StaffRepo  repositories.StaffStore
+	InviteRepo repositories.InviteStore
+	UserRepo   repositories.UserStore
+	TokenRepo  repositories.TokenStore
+}
+
+// NewCalendarService creates a new calendar service instance
+func NewCalendarService(eventRepo repositories.EventStore, staffRepo repositories.StaffStore, inviteRepo repositories.InviteStore, userRepo repositories.UserStore, tokenRepo repositories.TokenStore) *CalendarService {
+	return &CalendarService{
+		EventRepo:  eventRepo,
+		StaffRepo:  staffRepo,
+		InviteRepo: inviteRepo,
+		UserRepo:   userRepo,
+		TokenRepo:  tokenRepo,
+	}
+}
+
+// EventCalendar exports a single event the viewer may see as an iCalendar file
+func (s *CalendarService) EventCalendar(eventID int, viewer Viewer) ([]byte, error) {
+	event, err := viewEvent(s.EventRepo, s.StaffRepo, s.InviteRepo, eventID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &ical.Calendar{Events: []ical.Event{newCalendarEvent(event)}}
+	return calendar.Bytes(), nil
+}
+
+// CreateFeedToken creates a new secret token for the calendar feed of a user.
+// A previous token stops working.
+func (s *CalendarService) CreateFeedToken(userID int) (string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		log.Printf("Error generating calendar token: %v", err)
+		return "", apperrors.Internal(err)
+	}
+
+	if err := s.TokenRepo.SetCalendarToken(userID, hashToken(token)); err != nil {
+		return "", apperrors.Internal(err)
+	}
+
+	return token, nil
+}
+
+// RevokeFeedToken stops the calendar feed of a user from working
+func (s *CalendarService) RevokeFeedToken(userID int) error {
+	if err := s.TokenRepo.DeleteCalendarToken(userID); err != nil {
+		return apperrors.Internal(err)
+	}
+	return nil
+}
+
+// FeedCalendar exports every event the owner of a feed token organizes or
+// takes part in
+func (s *CalendarService) FeedCalendar(token string) ([]byte, error) {
+	userID, err := s.TokenRepo.GetCalendarTokenUser(hashToken(token))
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	// The feed of a suspended user stops working until the suspension is lifted
+	user, err := s.UserRepo.GetByID(userID)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	if user.SuspendedAt != nil {
+		return nil, repositories.ErrInvalidCalendarToken
+	}
+
+	organized, err := allEvents(func(q models.EventListQuery) (*models.EventPage, error) {
+		return s.EventRepo.GetByOrganizer(userID, q)
+	})
+	if err != nil {
+		return nil, err
+	}
+	joined, err := allEvents(func(q models.EventListQuery) (*models.EventPage, error) {
+		return s.EventRepo.GetEventsByParticipant(userID, q)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// An organizer may also have joined their own event
+	seen := map[int]bool{}
+	events := []models.Event{}
+	for _, event := range append(organized, joined...) {
+		if !seen[event.ID] {
+			seen[event.ID] = true
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartTime.Before(events[j].StartTime) })
+
+	calendar := &ical.Calendar{Name: "Event System - " + user.Username}
+	for i := range events {
+		calendar.Events = append(calendar.Events, newCalendarEvent(&events[i]))
+	}
+	return calendar.Bytes(), nil
+}
+
+// allEvents pages through a listing until its end
+func allEvents(list func(q models.EventListQuery) (*models.EventPage, error)) ([]models.Event, error) {
+	events := []models.Event{}
+	q := models.EventListQuery{Limit: models.MaxPageSize}
+	for {
+		page, err := list(q)
+		if err != nil {
+			log.Printf("Error listing calendar events: %v", err)
+			return nil, apperrors.Internal(err)
+		}
+		events = append(events, page.Events...)
+		if page.NextCursor == "" {
+			return events, nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// newCalendarEvent converts an event into a VEVENT. The UID only depends on
+// the event ID, so clients update the event they already have on every export.
+func newCalendarEvent(event *models.Event) ical.Event {
+	return ical.Event{
+		UID:          fmt.Sprintf("event-%d@%s", event.ID, calendarUIDDomain),
+		Summary:      event.Name,
+		Description:  event.Description,
+		Location:     event.Location,
+		Start:        event.StartTime,
+		End:          event.EndTime,
+		Status:       calendarStatus(event.Status),
+		Created:      event.CreatedAt,
+		LastModified: event.UpdatedAt,
+		// Lets the file be imported again without losing the capacity
+		Extra: map[string]string{capacityProperty: strconv.Itoa(event.Capacity)},
+	}
+}
+
+// calendarStatus maps the status of an event to the STATUS of its VEVENT
+func calendarStatus(status string) string {
+	switch status {
+	case models.EventStatusCancelled:
+		return ical.StatusCancelled
+	case models.EventStatusDraft:
+		return ical.StatusTentative
+	default:
+		return ical.StatusConfirmed
+	}
+}
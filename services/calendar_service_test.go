@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/event-system/ical"
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+func eventUID(id int) string {
+	return fmt.Sprintf("UID:event-%d@%s\r\n", id, calendarUIDDomain)
+}
+
+func TestEventCalendar(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	event := ts.createEvent(t, organizer.ID, 10)
+
+	data, err := ts.calendar.EventCalendar(event.ID, Viewer{})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	calendar := string(data)
+
+	for _, line := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		eventUID(event.ID),
+		"DTSTART:" + ical.FormatTime(event.StartTime) + "\r\n",
+		"DTEND:" + ical.FormatTime(event.EndTime) + "\r\n",
+		"SUMMARY:Go Meetup\r\n",
+		"LOCATION:Tehran\r\n",
+	} {
+		if !strings.Contains(calendar, line) {
+			t.Errorf("expected %q in\n%s", line, calendar)
+		}
+	}
+
+	// The UID stays the same on every export
+	again, _ := ts.calendar.EventCalendar(event.ID, Viewer{})
+	if !strings.Contains(string(again), eventUID(event.ID)) {
+		t.Error("expected the same UID on the second export")
+	}
+
+	if !strings.Contains(calendar, "STATUS:CONFIRMED\r\n") {
+		t.Errorf("expected a confirmed event in\n%s", calendar)
+	}
+
+	// Calendar applications strike through cancelled events
+	if _, err := ts.events.TransitionEvent(organizer.ID, event.ID, models.EventTransitionRequest{Status: models.EventStatusCancelled}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	cancelled, _ := ts.calendar.EventCalendar(event.ID, Viewer{})
+	if !strings.Contains(string(cancelled), "STATUS:CANCELLED\r\n") {
+		t.Errorf("expected a cancelled event in\n%s", cancelled)
+	}
+
+	if _, err := ts.calendar.EventCalendar(event.ID+100, Viewer{}); !errors.Is(err, repositories.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound, got %v", err)
+	}
+}
+
+func TestCalendarFeed(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	user := ts.createUser(t, models.RoleUser)
+	joined := ts.createEvent(t, organizer.ID, 10)
+	other := ts.createEvent(t, organizer.ID, 10)
+
+	for _, userID := range []int{user.ID, organizer.ID} {
+		if _, err := ts.participants.JoinEvent(userID, joined.ID, models.JoinEventRequest{}); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+
+	token, err := ts.calendar.CreateFeedToken(user.ID)
+	if err != nil {
+		t.Fatalf("create feed token: %v", err)
+	}
+	data, err := ts.calendar.FeedCalendar(token)
+	if err != nil {
+		t.Fatalf("feed: %v", err)
+	}
+	if !strings.Contains(string(data), eventUID(joined.ID)) || strings.Contains(string(data), eventUID(other.ID)) {
+		t.Errorf("expected only the joined event in the feed:\n%s", data)
+	}
+
+	// Organized events are in the feed once, even when joined as well
+	organizerToken, err := ts.calendar.CreateFeedToken(organizer.ID)
+	if err != nil {
+		t.Fatalf("create feed token: %v", err)
+	}
+	data, err = ts.calendar.FeedCalendar(organizerToken)
+	if err != nil {
+		t.Fatalf("feed: %v", err)
+	}
+	if strings.Count(string(data), eventUID(joined.ID)) != 1 || strings.Count(string(data), eventUID(other.ID)) != 1 {
+		t.Errorf("expected both events once in the organizer's feed:\n%s", data)
+	}
+
+	// A new token replaces the old one
+	newToken, err := ts.calendar.CreateFeedToken(user.ID)
+	if err != nil {
+		t.Fatalf("create feed token: %v", err)
+	}
+	if _, err := ts.calendar.FeedCalendar(token); !errors.Is(err, repositories.ErrInvalidCalendarToken) {
+		t.Errorf("expected the old token to stop working, got %v", err)
+	}
+
+	if err := ts.calendar.RevokeFeedToken(user.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := ts.calendar.FeedCalendar(newToken); !errors.Is(err, repositories.ErrInvalidCalendarToken) {
+		t.Errorf("expected the revoked token to stop working, got %v", err)
+	}
+}
+
+func TestCalendarFeedOfSuspendedUser(t *testing.T) {
+	ts := newTestServices()
+	admin := ts.createUser(t, models.RoleAdmin)
+	user := ts.createUser(t, models.RoleUser)
+
+	token, err := ts.calendar.CreateFeedToken(user.ID)
+	if err != nil {
+		t.Fatalf("create feed token: %v", err)
+	}
+	if _, err := ts.admin.SuspendUser(admin.ID, user.ID); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+
+	if _, err := ts.calendar.FeedCalendar(token); !errors.Is(err, repositories.ErrInvalidCalendarToken) {
+		t.Errorf("expected ErrInvalidCalendarToken, got %v", err)
+	}
+}
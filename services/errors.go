@@ -0,0 +1,49 @@
+package services
+
+import "github.com/event-system/apperrors"
+
+// Errors returned by the services on top of the ones from the repositories
+var (
+	ErrUsernameTaken       = apperrors.Conflict("username_taken", "username already exists")
+	ErrEmailTaken          = apperrors.Conflict("email_taken", "email already exists")
+	ErrInvalidCredentials  = apperrors.Unauthorized("invalid_credentials", "invalid email or password")
+	ErrAccountSuspended    = apperrors.Forbidden("account_suspended", "account is suspended")
+	ErrInvalidToken        = apperrors.Unauthorized("invalid_token", "invalid or expired token")
+	ErrTokenRevoked        = apperrors.Unauthorized("token_revoked", "token has been revoked")
+	ErrNotOrganizer        = apperrors.Forbidden("not_event_organizer", "you are not the organizer of this event")
+	ErrStaffRoleForbidden  = apperrors.Forbidden("staff_role_forbidden", "your staff role does not allow this action")
+	ErrEventAlreadyClosed  = apperrors.Conflict("event_already_closed", "event is already closed")
+	ErrEventAlreadyOpen    = apperrors.Conflict("event_already_open", "event is already open")
+	ErrInvalidEventStatus  = apperrors.Validation("invalid_event_status", "unknown event status")
+	ErrInvalidTransition   = apperrors.Conflict("invalid_transition", "the event can't move to this status now")
+	ErrEventNotEditable    = apperrors.Conflict("event_not_editable", "completed and cancelled events can't be edited")
+	ErrInvalidRole         = apperrors.Validation("invalid_role", "invalid role")
+	ErrCannotSuspendSelf   = apperrors.Forbidden("cannot_suspend_self", "you cannot suspend yourself")
+	ErrCannotChangeOwnRole = apperrors.Forbidden("cannot_change_own_role", "you cannot change your own role")
+	ErrInvalidStaffRole    = apperrors.Validation("invalid_staff_role", "invalid staff role")
+	ErrOwnerAsStaff        = apperrors.Conflict("owner_as_staff", "the owner of an event can't be added as staff")
+	ErrAlreadyOwner        = apperrors.Conflict("already_owner", "user already owns this event")
+	ErrOwnerNotOrganizer   = apperrors.Validation("owner_not_organizer", "new owner must have the organizer role")
+	ErrOwnerSuspended      = apperrors.Conflict("owner_suspended", "new owner is suspended")
+	ErrInvalidRRule        = apperrors.Validation("invalid_rrule", "invalid recurrence rule")
+	ErrInvalidTimeZone     = apperrors.Validation("invalid_timezone", "unknown time zone")
+	ErrEmptySeries         = apperrors.Validation("empty_series", "the recurrence rule has no occurrences in the coming year")
+	ErrOccurrenceNotFound  = apperrors.NotFound("occurrence_not_found", "event is not an occurrence of this series")
+	ErrRuleChangeScope     = apperrors.Validation("rule_change_scope", "rrule and exdates can only be changed for following occurrences or all of them")
+	ErrInvalidCalendar     = apperrors.Validation("invalid_calendar", "invalid iCalendar file")
+	ErrEmptyImport         = apperrors.Validation("empty_import", "the calendar has no events")
+	ErrImportTooLarge      = apperrors.Validation("import_too_large", "the calendar has too many events")
+	ErrNoInvitees          = apperrors.Validation("no_invitees", "invite at least one user or email")
+	ErrInvalidInvite       = apperrors.Forbidden("invalid_invite", "invalid or expired invite")
+	ErrNoTicket            = apperrors.Conflict("no_ticket", "only confirmed participants have a ticket")
+	ErrInvalidTicket       = apperrors.Validation("invalid_ticket", "invalid ticket code")
+	ErrInvalidScanTime     = apperrors.Validation("invalid_scan_time", "the scan time is in the future or before check-in opened")
+	ErrInvalidSalesWindow  = apperrors.Validation("invalid_sales_window", "ticket sales must end after they start")
+	ErrNoPendingOrder      = apperrors.NotFound("no_pending_order", "you have no order waiting for payment in this event")
+	ErrInvalidWebhook      = apperrors.Unauthorized("invalid_webhook", "the webhook could not be verified")
+	ErrPaymentNotFound     = apperrors.NotFound("payment_not_found", "payment not found")
+	ErrPaymentSettled      = apperrors.Conflict("payment_already_settled", "the payment already has a different outcome")
+	ErrPaymentsDisabled    = apperrors.Conflict("payments_disabled", "no payment provider is configured")
+	ErrInvalidDiscount     = apperrors.Validation("invalid_discount", "a percent discount must be between 1 and 100 and a fixed one above 0")
+	ErrPromoSeatsAboveUses = apperrors.Validation("reserved_seats_above_max_uses", "a promo code can't reserve more seats than it can be used")
+)
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/ical"
+	"github.com/event-system/models"
+	"github.com/event-system/validation"
+)
+
+// maxImportEvents is the most events one calendar file may create
+const maxImportEvents = 500
+
+// capacityProperty is the custom property that carries the capacity of an
+// event in iCalendar files
+const capacityProperty = "X-EVENT-CAPACITY"
+
+// ImportEvents creates the events of an iCalendar file for an organizer.
+// Every event is built and validated exactly like a CreateEvent request, and
+// they are stored in a single transaction: if any of them is invalid nothing
+// is created and the error lists the invalid fields of every event. A dry run
+// only reports which events would be created and which would fail.
+func (s *EventService) ImportEvents(r io.Reader, options models.ImportOptions, organizerID int) (*models.ImportResponse, error) {
+	loc, err := loadTimeZone(options.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := ical.Parse(r, loc)
+	if err != nil {
+		return nil, apperrors.Validation(ErrInvalidCalendar.Code, ErrInvalidCalendar.Message+": "+err.Error())
+	}
+	if len(parsed) == 0 {
+		return nil, ErrEmptyImport
+	}
+	if len(parsed) > maxImportEvents {
+		return nil, apperrors.Validation(ErrImportTooLarge.Code, fmt.Sprintf("%s: at most %d can be imported at once", ErrImportTooLarge.Message, maxImportEvents))
+	}
+
+	response := &models.ImportResponse{
+		DryRun: options.DryRun,
+		Total:  len(parsed),
+		Events: []models.EventResponse{},
+	}
+	events := []*models.Event{}
+	for i, entry := range parsed {
+		req, problem := importRequest(entry, options.DefaultCapacity)
+		if problem != nil {
+			problem.Index = i
+			response.Errors = append(response.Errors, *problem)
+			continue
+		}
+		events = append(events, newEvent(req, organizerID))
+	}
+	response.Valid = len(events)
+
+	if len(response.Errors) > 0 && !options.DryRun {
+		return nil, apperrors.InvalidFields(importFieldErrors(response.Errors))
+	}
+
+	if !options.DryRun {
+		if err := s.EventRepo.CreateMany(events); err != nil {
+			log.Printf("Error importing events: %v", err)
+			return nil, apperrors.Internal(err)
+		}
+	}
+
+	for _, event := range events {
+		response.Events = append(response.Events, *newEventResponse(event))
+	}
+	return response, nil
+}
+
+// importRequest turns a VEVENT into an event creation request and validates
+// it. It returns the problems of the VEVENT instead when there are any.
+func importRequest(entry ical.ParsedEvent, defaultCapacity int) (models.EventRequest, *models.ImportError) {
+	problem := &models.ImportError{UID: entry.UID, Summary: entry.Summary}
+	if entry.Err != nil {
+		problem.Message = entry.Err.Error()
+		return models.EventRequest{}, problem
+	}
+
+	req := models.EventRequest{
+		Name:        strings.TrimSpace(entry.Summary),
+		Description: entry.Description,
+		Location:    entry.Location,
+		StartTime:   entry.Start.UTC(),
+		EndTime:     entry.End.UTC(),
+		Capacity:    defaultCapacity,
+	}
+
+	if entry.RRule != "" {
+		problem.Fields = append(problem.Fields, apperrors.FieldError{
+			Field:   "rrule",
+			Rule:    "unsupported",
+			Message: "recurring events can't be imported, create a series instead",
+		})
+	}
+	badCapacity := false
+	if value, ok := entry.Extra[capacityProperty]; ok {
+		capacity, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			badCapacity = true
+			problem.Fields = append(problem.Fields, apperrors.FieldError{
+				Field:   "capacity",
+				Rule:    "integer",
+				Message: capacityProperty + " must be a whole number",
+			})
+		}
+		req.Capacity = capacity
+	}
+
+	if err := validation.Struct(req); err != nil {
+		var appErr *apperrors.Error
+		if !errors.As(err, &appErr) {
+			problem.Message = err.Error()
+			return req, problem
+		}
+		for _, field := range appErr.Fields {
+			// An unreadable capacity is reported once
+			if badCapacity && field.Field == "capacity" {
+				continue
+			}
+			problem.Fields = append(problem.Fields, field)
+		}
+	}
+
+	if len(problem.Fields) > 0 {
+		return req, problem
+	}
+	return req, nil
+}
+
+// importFieldErrors flattens the problems of every VEVENT into field errors
+// with paths such as events[3].start_time
+func importFieldErrors(problems []models.ImportError) []apperrors.FieldError {
+	fields := []apperrors.FieldError{}
+	for _, problem := range problems {
+		path := fmt.Sprintf("events[%d]", problem.Index)
+		if problem.Message != "" {
+			fields = append(fields, apperrors.FieldError{Field: path, Rule: "ical", Message: problem.Message})
+		}
+		for _, field := range problem.Fields {
+			field.Field = path + "." + field.Field
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+)
+
+// calendarFile builds an iCalendar file from VEVENT bodies
+func calendarFile(events ...string) *strings.Reader {
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0"}
+	for _, event := range events {
+		lines = append(lines, "BEGIN:VEVENT", event, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR", "")
+	return strings.NewReader(strings.Join(lines, "\r\n"))
+}
+
+// futureLocal formats a time a month from now without a zone, at the given hour
+func futureLocal(hour int) string {
+	day := time.Now().AddDate(0, 1, 0)
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, time.UTC).Format("20060102T150405")
+}
+
+func TestImportEvents(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+
+	file := calendarFile(
+		"UID:one@example.com\r\nSUMMARY:Go Meetup\r\nLOCATION:Berlin\r\nDTSTART;TZID=Europe/Berlin:"+futureLocal(18)+"\r\nDTEND;TZID=Europe/Berlin:"+futureLocal(20),
+		"UID:two@example.com\r\nSUMMARY:Workshop\r\nDTSTART:"+futureLocal(9)+"Z\r\nDURATION:PT3H\r\nX-EVENT-CAPACITY:12",
+	)
+
+	response, err := ts.events.ImportEvents(file, models.ImportOptions{DefaultCapacity: 30}, organizer.ID)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if response.DryRun || response.Total != 2 || response.Valid != 2 || len(response.Events) != 2 || len(response.Errors) != 0 {
+		t.Fatalf("unexpected response %+v", response)
+	}
+
+	first, err := ts.events.GetEventByID(response.Events[0].ID, Viewer{})
+	if err != nil {
+		t.Fatalf("get imported event: %v", err)
+	}
+	start, _ := time.ParseInLocation("20060102T150405", futureLocal(18), berlin)
+	if !first.StartTime.Equal(start) || first.EndTime.Sub(first.StartTime) != 2*time.Hour {
+		t.Errorf("expected the TZID to be honoured, got %v - %v", first.StartTime, first.EndTime)
+	}
+	if first.Name != "Go Meetup" || first.Location != "Berlin" || first.Capacity != 30 || first.OrganizerID != organizer.ID {
+		t.Errorf("unexpected event %+v", first)
+	}
+
+	second := response.Events[1]
+	if second.Capacity != 12 || second.EndTime.Sub(second.StartTime) != 3*time.Hour {
+		t.Errorf("unexpected event %+v", second)
+	}
+}
+
+func TestImportEventsIsAllOrNothing(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+
+	events := []string{
+		"UID:good\r\nSUMMARY:Fine\r\nDTSTART:" + futureLocal(10) + "Z\r\nDTEND:" + futureLocal(11) + "Z",
+		"UID:past\r\nSUMMARY:Long gone\r\nDTSTART:20200101T100000Z\r\nDTEND:20200101T110000Z",
+		"UID:broken\r\nSUMMARY:\r\nDTSTART:" + futureLocal(10) + "Z\r\nDTEND:" + futureLocal(11) + "Z\r\nX-EVENT-CAPACITY:lots",
+		"UID:zone\r\nSUMMARY:Unknown zone\r\nDTSTART;TZID=Mars/Base:" + futureLocal(10),
+	}
+
+	// A dry run reports the problems of every event
+	response, err := ts.events.ImportEvents(calendarFile(events...), models.ImportOptions{DryRun: true, DefaultCapacity: 10}, organizer.ID)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !response.DryRun || response.Total != 4 || response.Valid != 1 || len(response.Events) != 1 || response.Events[0].ID != 0 {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	if len(response.Errors) != 3 {
+		t.Fatalf("expected 3 errors, got %+v", response.Errors)
+	}
+
+	past := response.Errors[0]
+	if past.Index != 1 || past.UID != "past" || len(past.Fields) != 1 || past.Fields[0].Field != "start_time" || past.Fields[0].Rule != "future" {
+		t.Errorf("unexpected error for the past event %+v", past)
+	}
+	rules := map[string]string{}
+	for _, field := range response.Errors[1].Fields {
+		rules[field.Field] = field.Rule
+	}
+	if len(rules) != 2 || rules["name"] != "required" || rules["capacity"] != "integer" {
+		t.Errorf("unexpected error for the broken event %+v", response.Errors[1])
+	}
+	if !strings.Contains(response.Errors[2].Message, "unknown time zone") {
+		t.Errorf("unexpected error for the unknown zone %+v", response.Errors[2])
+	}
+
+	// The real import creates nothing and points at the invalid fields
+	_, err = ts.events.ImportEvents(calendarFile(events...), models.ImportOptions{DefaultCapacity: 10}, organizer.ID)
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Code != "validation_failed" {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	paths := []string{}
+	for _, field := range appErr.Fields {
+		paths = append(paths, field.Field)
+	}
+	if strings.Join(paths, ",") != "events[1].start_time,events[2].capacity,events[2].name,events[3]" {
+		t.Errorf("unexpected fields %v", paths)
+	}
+
+	page, err := ts.events.GetEventsByOrganizer(organizer.ID, models.EventListQuery{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if page.Total != 0 {
+		t.Errorf("expected nothing to be created, got %d events", page.Total)
+	}
+}
+
+func TestImportEventsRejectsBadFiles(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	options := models.ImportOptions{DefaultCapacity: 10}
+
+	if _, err := ts.events.ImportEvents(strings.NewReader("not a calendar"), options, organizer.ID); !errors.Is(err, ErrInvalidCalendar) {
+		t.Errorf("expected ErrInvalidCalendar, got %v", err)
+	}
+	if _, err := ts.events.ImportEvents(calendarFile(), options, organizer.ID); !errors.Is(err, ErrEmptyImport) {
+		t.Errorf("expected ErrEmptyImport, got %v", err)
+	}
+
+	options.TimeZone = "Mars/Base"
+	if _, err := ts.events.ImportEvents(calendarFile("SUMMARY:x"), options, organizer.ID); !errors.Is(err, ErrInvalidTimeZone) {
+		t.Errorf("expected ErrInvalidTimeZone, got %v", err)
+	}
+}
+
+func TestExportedEventsCanBeImported(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	event := ts.createEvent(t, organizer.ID, 42)
+
+	data, err := ts.calendar.EventCalendar(event.ID, Viewer{})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	response, err := ts.events.ImportEvents(strings.NewReader(string(data)), models.ImportOptions{}, organizer.ID)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	imported := response.Events[0]
+	if imported.Name != event.Name || imported.Capacity != 42 || !imported.StartTime.Equal(event.StartTime.Truncate(time.Second)) {
+		t.Errorf("expected a copy of the event, got %+v", imported)
+	}
+}
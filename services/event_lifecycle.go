@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// dueBatchSize is the most events AdvanceEvents looks at in one run
+const dueBatchSize = 500
+
+// eventTransitions lists the statuses an event may move to from each status.
+// Completed and cancelled events are final.
+var eventTransitions = map[string][]string{
+	models.EventStatusDraft:              {models.EventStatusPublished, models.EventStatusCancelled},
+	models.EventStatusPublished:          {models.EventStatusRegistrationClosed, models.EventStatusOngoing, models.EventStatusCancelled},
+	models.EventStatusRegistrationClosed: {models.EventStatusPublished, models.EventStatusOngoing, models.EventStatusCancelled},
+	models.EventStatusOngoing:            {models.EventStatusCompleted, models.EventStatusCancelled},
+}
+
+// validEventStatus reports whether status is part of the lifecycle
+func validEventStatus(status string) bool {
+	for _, known := range models.EventStatuses {
+		if status == known {
+			return true
+		}
+	}
+	return false
+}
+
+// canTransition reports whether an event may move to status at the given
+// time. Besides the transition table, registration can only be opened
+// before the event starts and an event can't be ongoing before it starts.
+func canTransition(event *models.Event, status string, now time.Time) bool {
+	allowed := false
+	for _, next := range eventTransitions[event.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return false
+	}
+
+	switch status {
+	case models.EventStatusPublished:
+		return now.Before(event.StartTime)
+	case models.EventStatusOngoing:
+		return !now.Before(event.StartTime)
+	}
+	return true
+}
+
+// allowedTransitions returns the statuses an event may move to at the given time
+func allowedTransitions(event *models.Event, now time.Time) []string {
+	transitions := []string{}
+	for _, status := range models.EventStatuses {
+		if canTransition(event, status, now) {
+			transitions = append(transitions, status)
+		}
+	}
+	return transitions
+}
+
+// changeEventStatus moves an event to another status and saves it. It fails
+// with ErrStatusChanged when the status changed since the event was loaded.
+func changeEventStatus(eventRepo repositories.EventStore, event *models.Event, status string) error {
+	if !validEventStatus(status) {
+		return ErrInvalidEventStatus
+	}
+	if !canTransition(event, status, time.Now()) {
+		return apperrors.Conflict(ErrInvalidTransition.Code, fmt.Sprintf("%s: from %s to %s", ErrInvalidTransition.Message, event.Status, status))
+	}
+
+	if err := eventRepo.UpdateStatus(event, status); err != nil {
+		return apperrors.Internal(err)
+	}
+	return nil
+}
+
+// checkEditable rejects changes to events that are over
+func checkEditable(event *models.Event) error {
+	if event.Status == models.EventStatusCompleted || event.Status == models.EventStatusCancelled {
+		return ErrEventNotEditable
+	}
+	return nil
+}
+
+// GetTransitions returns the status of an event and the statuses it may move to right now
+func (s *EventService) GetTransitions(userID, eventID int) (*models.EventTransitionsResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.EventTransitionsResponse{
+		Status:      event.Status,
+		Transitions: allowedTransitions(event, time.Now()),
+	}, nil
+}
+
+// TransitionEvent moves an event to another status of its lifecycle.
+// Cancelling an event tells every participant, including the waitlist,
+// along with the reason given in the request, and refunds the paid orders,
+// which the repository marked for a refund together with the status change.
+func (s *EventService) TransitionEvent(userID, eventID int, req models.EventTransitionRequest) (*models.EventResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := changeEventStatus(s.EventRepo, event, req.Status); err != nil {
+		return nil, err
+	}
+	if event.Status == models.EventStatusCancelled {
+		s.Payments.RefundEventOrders(event.ID)
+		s.notifyCancelled(event, req.Reason)
+	}
+
+	return newEventResponse(event), nil
+}
+
+// notifyCancelled tells the participants of an event that it was cancelled.
+// The cancellation already happened, so failures are only logged.
+func (s *EventService) notifyCancelled(event *models.Event, reason string) {
+	userIDs, err := s.ParticipantRepo.GetParticipantUserIDs(event.ID)
+	if err != nil {
+		log.Printf("Error loading participants to notify: %v", err)
+		return
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+
+	err = s.Notifier.Notify(userIDs, models.Notification{
+		Type:    models.NotificationEventCancelled,
+		EventID: event.ID,
+		Subject: fmt.Sprintf("%s has been cancelled", event.Name),
+		Message: reason,
+	})
+	if err != nil {
+		log.Printf("Error notifying participants of event %d: %v", event.ID, err)
+	}
+}
+
+// AdvanceEvents moves events along their lifecycle as time passes: events
+// that started become ongoing, which closes their registration, and ongoing
+// events that ended are completed. An event that started and ended since the
+// last run takes both steps at once. Events whose status someone else changed
+// in the meantime are left alone. It returns the number of status changes.
+func (s *EventService) AdvanceEvents(now time.Time) (int, error) {
+	events, err := s.EventRepo.GetDue(now, dueBatchSize)
+	if err != nil {
+		return 0, apperrors.Internal(err)
+	}
+
+	changes := 0
+	for i := range events {
+		event := &events[i]
+
+		steps := []string{}
+		if event.Status != models.EventStatusOngoing {
+			steps = append(steps, models.EventStatusOngoing)
+		}
+		if !event.EndTime.After(now) {
+			steps = append(steps, models.EventStatusCompleted)
+		}
+
+		for _, status := range steps {
+			err := s.EventRepo.UpdateStatus(event, status)
+			if errors.Is(err, repositories.ErrStatusChanged) || errors.Is(err, repositories.ErrEventNotFound) {
+				break
+			}
+			if err != nil {
+				return changes, apperrors.Internal(err)
+			}
+			changes++
+		}
+	}
+
+	return changes, nil
+}
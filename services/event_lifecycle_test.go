@@ -0,0 +1,295 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// transition moves an event to a status and fails the test if that isn't allowed
+func (ts *testServices) transition(t *testing.T, userID, eventID int, status string) *models.EventResponse {
+	t.Helper()
+
+	event, err := ts.events.TransitionEvent(userID, eventID, models.EventTransitionRequest{Status: status})
+	if err != nil {
+		t.Fatalf("transition to %s: %v", status, err)
+	}
+	return event
+}
+
+func TestEventLifecycle(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+
+	start := time.Now().Add(24 * time.Hour)
+	draft, err := ts.events.CreateEvent(models.EventRequest{
+		Name:      "Draft",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		Capacity:  10,
+		Draft:     true,
+	}, owner.ID)
+	if err != nil {
+		t.Fatalf("create draft: %v", err)
+	}
+	if draft.Status != models.EventStatusDraft {
+		t.Fatalf("status = %q, want draft", draft.Status)
+	}
+
+	transitions, err := ts.events.GetTransitions(owner.ID, draft.ID)
+	if err != nil {
+		t.Fatalf("transitions: %v", err)
+	}
+	want := []string{models.EventStatusPublished, models.EventStatusCancelled}
+	if !reflect.DeepEqual(transitions.Transitions, want) {
+		t.Errorf("draft transitions = %v, want %v", transitions.Transitions, want)
+	}
+
+	// A draft can't skip straight to completed
+	_, err = ts.events.TransitionEvent(owner.ID, draft.ID, models.EventTransitionRequest{Status: models.EventStatusCompleted})
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("draft to completed: got %v, want ErrInvalidTransition", err)
+	}
+	_, err = ts.events.TransitionEvent(owner.ID, draft.ID, models.EventTransitionRequest{Status: "open"})
+	if !errors.Is(err, ErrInvalidEventStatus) {
+		t.Errorf("unknown status: got %v, want ErrInvalidEventStatus", err)
+	}
+
+	ts.transition(t, owner.ID, draft.ID, models.EventStatusPublished)
+	ts.transition(t, owner.ID, draft.ID, models.EventStatusRegistrationClosed)
+
+	// The event can't be ongoing before it starts
+	transitions, err = ts.events.GetTransitions(owner.ID, draft.ID)
+	if err != nil {
+		t.Fatalf("transitions: %v", err)
+	}
+	want = []string{models.EventStatusPublished, models.EventStatusCancelled}
+	if !reflect.DeepEqual(transitions.Transitions, want) {
+		t.Errorf("transitions before the start = %v, want %v", transitions.Transitions, want)
+	}
+
+	// Once it started it can't be reopened, only run and be completed
+	event, err := ts.store.Events().GetByID(draft.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	event.StartTime = time.Now().Add(-time.Minute)
+	if _, err := ts.store.Events().Update(event); err != nil {
+		t.Fatalf("move start: %v", err)
+	}
+	transitions, err = ts.events.GetTransitions(owner.ID, draft.ID)
+	if err != nil {
+		t.Fatalf("transitions: %v", err)
+	}
+	want = []string{models.EventStatusOngoing, models.EventStatusCancelled}
+	if !reflect.DeepEqual(transitions.Transitions, want) {
+		t.Errorf("transitions after the start = %v, want %v", transitions.Transitions, want)
+	}
+
+	ts.transition(t, owner.ID, draft.ID, models.EventStatusOngoing)
+	completed := ts.transition(t, owner.ID, draft.ID, models.EventStatusCompleted)
+	if completed.Status != models.EventStatusCompleted {
+		t.Errorf("status = %q, want completed", completed.Status)
+	}
+
+	transitions, err = ts.events.GetTransitions(owner.ID, draft.ID)
+	if err != nil {
+		t.Fatalf("transitions: %v", err)
+	}
+	if len(transitions.Transitions) != 0 {
+		t.Errorf("completed events should be final, got %v", transitions.Transitions)
+	}
+	if _, err := ts.events.UpdateEvent(draft.ID, updateRequest(completed), owner.ID); !errors.Is(err, ErrEventNotEditable) {
+		t.Errorf("update completed event: got %v, want ErrEventNotEditable", err)
+	}
+}
+
+func TestDraftsAreHidden(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	user := ts.createUser(t, models.RoleUser)
+
+	published := ts.createEvent(t, owner.ID, 10)
+	start := time.Now().Add(24 * time.Hour)
+	draft, err := ts.events.CreateEvent(models.EventRequest{
+		Name:      "Go Meetup",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		Capacity:  10,
+		Draft:     true,
+	}, owner.ID)
+	if err != nil {
+		t.Fatalf("create draft: %v", err)
+	}
+
+	page, err := ts.events.GetAllPublicEvents(models.EventListQuery{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if page.Total != 1 || page.Events[0].ID != published.ID {
+		t.Errorf("expected only the published event, got %+v", page.Events)
+	}
+
+	if _, err := ts.events.SearchEvents(models.EventSearchQuery{Text: "go", Status: models.EventStatusDraft}); !errors.Is(err, ErrInvalidEventStatus) {
+		t.Errorf("search drafts: got %v, want ErrInvalidEventStatus", err)
+	}
+	if _, err := ts.participants.JoinEvent(user.ID, draft.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrEventNotOpen) {
+		t.Errorf("join draft: got %v, want ErrEventNotOpen", err)
+	}
+
+	// The organizer still sees it among their events
+	mine, err := ts.events.GetEventsByOrganizer(owner.ID, models.EventListQuery{})
+	if err != nil {
+		t.Fatalf("list own events: %v", err)
+	}
+	if mine.Total != 2 {
+		t.Errorf("expected both events for the organizer, got %d", mine.Total)
+	}
+}
+
+func TestCancelEventNotifiesParticipants(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	seated := ts.createUser(t, models.RoleUser)
+	waiting := ts.createUser(t, models.RoleUser)
+	late := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 1)
+
+	for _, user := range []*models.User{seated, waiting} {
+		if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+
+	cancelled, err := ts.events.TransitionEvent(owner.ID, event.ID, models.EventTransitionRequest{
+		Status: models.EventStatusCancelled,
+		Reason: "The venue is flooded",
+	})
+	if err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if cancelled.Status != models.EventStatusCancelled {
+		t.Errorf("status = %q, want cancelled", cancelled.Status)
+	}
+
+	if len(ts.notifier.sent) != 1 {
+		t.Fatalf("expected one notification, got %d", len(ts.notifier.sent))
+	}
+	sent := ts.notifier.sent[0]
+	if !reflect.DeepEqual(sent.userIDs, []int{seated.ID, waiting.ID}) {
+		t.Errorf("notified %v, want the seated and the waitlisted user", sent.userIDs)
+	}
+	if sent.notification.Type != models.NotificationEventCancelled || sent.notification.EventID != event.ID || sent.notification.Message != "The venue is flooded" {
+		t.Errorf("unexpected notification %+v", sent.notification)
+	}
+
+	if _, err := ts.participants.JoinEvent(late.ID, event.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrEventCancelled) {
+		t.Errorf("join cancelled event: got %v, want ErrEventCancelled", err)
+	}
+	if _, err := ts.events.OpenEvent(owner.ID, event.ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("reopen cancelled event: got %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestTransitionsNeedManagePermission(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	checkIn := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	if _, err := ts.staff.AddStaff(event.ID, owner.ID, models.AddStaffRequest{UserID: checkIn.ID, Role: models.StaffRoleCheckIn}); err != nil {
+		t.Fatalf("add staff: %v", err)
+	}
+
+	if _, err := ts.events.GetTransitions(checkIn.ID, event.ID); !errors.Is(err, ErrStaffRoleForbidden) {
+		t.Errorf("transitions: got %v, want ErrStaffRoleForbidden", err)
+	}
+	_, err := ts.events.TransitionEvent(checkIn.ID, event.ID, models.EventTransitionRequest{Status: models.EventStatusCancelled})
+	if !errors.Is(err, ErrStaffRoleForbidden) {
+		t.Errorf("cancel: got %v, want ErrStaffRoleForbidden", err)
+	}
+	if len(ts.notifier.sent) != 0 {
+		t.Errorf("expected no notifications, got %d", len(ts.notifier.sent))
+	}
+}
+
+func TestAdvanceEvents(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+
+	// All of them start in a day and end two hours later
+	published := ts.createEvent(t, owner.ID, 10)
+	closed := ts.createEvent(t, owner.ID, 10)
+	ts.transition(t, owner.ID, closed.ID, models.EventStatusRegistrationClosed)
+	cancelled := ts.createEvent(t, owner.ID, 10)
+	ts.transition(t, owner.ID, cancelled.ID, models.EventStatusCancelled)
+	start := time.Now().Add(24 * time.Hour)
+	draft, err := ts.events.CreateEvent(models.EventRequest{
+		Name:      "Draft",
+		StartTime: start,
+		EndTime:   start.Add(2 * time.Hour),
+		Capacity:  10,
+		Draft:     true,
+	}, owner.ID)
+	if err != nil {
+		t.Fatalf("create draft: %v", err)
+	}
+
+	status := func(id int) string {
+		t.Helper()
+		event, err := ts.events.GetEventByID(id, Viewer{UserID: owner.ID})
+		if err != nil {
+			t.Fatalf("get event: %v", err)
+		}
+		return event.Status
+	}
+
+	// Nothing is due yet
+	changes, err := ts.events.AdvanceEvents(time.Now())
+	if err != nil || changes != 0 {
+		t.Fatalf("advance before start: %d changes, %v", changes, err)
+	}
+
+	// Once they started, registration closes
+	changes, err = ts.events.AdvanceEvents(start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+	if changes != 2 {
+		t.Errorf("changes = %d, want 2", changes)
+	}
+	if status(published.ID) != models.EventStatusOngoing || status(closed.ID) != models.EventStatusOngoing {
+		t.Errorf("expected both events to be ongoing")
+	}
+
+	late := ts.createEvent(t, owner.ID, 10)
+
+	// After the end they are completed. The late event was never seen
+	// ongoing, so it takes both steps in one run.
+	changes, err = ts.events.AdvanceEvents(start.Add(3 * time.Hour))
+	if err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+	if changes != 4 {
+		t.Errorf("changes = %d, want 4", changes)
+	}
+	for _, id := range []int{published.ID, closed.ID, late.ID} {
+		if got := status(id); got != models.EventStatusCompleted {
+			t.Errorf("event %d status = %q, want completed", id, got)
+		}
+	}
+
+	// Drafts and cancelled events are left alone
+	if status(draft.ID) != models.EventStatusDraft || status(cancelled.ID) != models.EventStatusCancelled {
+		t.Errorf("expected the draft and the cancelled event to keep their status")
+	}
+
+	changes, err = ts.events.AdvanceEvents(start.Add(3 * time.Hour))
+	if err != nil || changes != 0 {
+		t.Errorf("advance again: %d changes, %v", changes, err)
+	}
+}
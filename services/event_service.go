@@ -3,106 +3,105 @@ package services
 import (
 	"errors"
 	"log"
+	"time"
 
+	"github.com/event-system/apperrors"
 	"github.com/event-system/models"
 	"github.com/event-system/repositories"
 )
 
 // EventService handles event related business logic
 type EventService struct {
-	EventRepo       *repositories.EventRepository
-	ParticipantRepo *repositories.ParticipantRepository
+	EventRepo       repositories.EventStore
+	ParticipantRepo repositories.ParticipantStore
+	StaffRepo       repositories.StaffStore
+	InviteRepo      repositories.InviteStore
+	Payments        *PaymentService
+	Notifier        Notifier
 }
 
 // NewEventService creates a new event service instance
-func NewEventService(eventRepo *repositories.EventRepository, participantRepo *repositories.ParticipantRepository) *EventService {
+func NewEventService(eventRepo repositories.EventStore, participantRepo repositories.ParticipantStore, staffRepo repositories.StaffStore, inviteRepo repositories.InviteStore, payments *PaymentService, notifier Notifier) *EventService {
 	return &EventService{
 		EventRepo:       eventRepo,
 		ParticipantRepo: participantRepo,
+		StaffRepo:       staffRepo,
+		InviteRepo:      inviteRepo,
+		Payments:        payments,
+		Notifier:        notifier,
 	}
 }
+
+// CloseEvent closes registration of a published event
 func (s *EventService) CloseEvent(organizerID int, eventID int) (*models.EventResponse, error) {
-	// Get existing event
-	existingEvent, err := s.EventRepo.GetByID(eventID)
+	// Get existing event and check that the user may manage it
+	existingEvent, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, organizerID, permissionManageEvent)
 	if err != nil {
 		return nil, err
 	}
-	// Check if user is the organizer
-	if existingEvent.OrganizerID != organizerID {
-		return nil, errors.New("you are not the organizer of this event")
-	}
 	// check if event is already closed
-	if existingEvent.Status == "closed" {
-		return nil, errors.New("event is already closed")
+	if existingEvent.Status == models.EventStatusRegistrationClosed {
+		return nil, ErrEventAlreadyClosed
 	}
 	// Close the event
-	existingEvent.Status = "closed"
-	// Save updated event
-	err = s.EventRepo.Update(existingEvent)
-	if err != nil {
-		log.Printf("Error closing event: %v", err)
-		return nil, errors.New("error closing event")
+	if err := changeEventStatus(s.EventRepo, existingEvent, models.EventStatusRegistrationClosed); err != nil {
+		return nil, err
 	}
 	// Return updated event
-	return &models.EventResponse{
-		ID:          existingEvent.ID,
-		Name:        existingEvent.Name,
-		Description: existingEvent.Description,
-		Location:    existingEvent.Location,
-		StartTime:   existingEvent.StartTime,
-		EndTime:     existingEvent.EndTime,
-		Capacity:    existingEvent.Capacity,
-		OrganizerID: existingEvent.OrganizerID,
-		Status:      existingEvent.Status,
-		CreatedAt:   existingEvent.CreatedAt,
-		UpdatedAt:   existingEvent.UpdatedAt,
-	}, nil
-
+	return newEventResponse(existingEvent), nil
 }
 
+// OpenEvent publishes a draft or reopens registration of an event
 func (s *EventService) OpenEvent(organizerID int, eventID int) (*models.EventResponse, error) {
-	// Get existing event
-	existingEvent, err := s.EventRepo.GetByID(eventID)
+	// Get existing event and check that the user may manage it
+	existingEvent, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, organizerID, permissionManageEvent)
 	if err != nil {
 		return nil, err
 	}
-	// Check if user is the organizer
-	if existingEvent.OrganizerID != organizerID {
-		return nil, errors.New("you are not the organizer of this event")
-	}
-	// check if event is already closed
-	if existingEvent.Status == "open" {
-		return nil, errors.New("event is already open")
+	// check if event is already open
+	if existingEvent.Status == models.EventStatusPublished {
+		return nil, ErrEventAlreadyOpen
 	}
-	// Close the event
-	existingEvent.Status = "open"
-	// Save updated event
-	err = s.EventRepo.Update(existingEvent)
-	if err != nil {
-		log.Printf("Error opening event: %v", err)
-		return nil, errors.New("error opening event")
+	// Open the event
+	if err := changeEventStatus(s.EventRepo, existingEvent, models.EventStatusPublished); err != nil {
+		return nil, err
 	}
 	// Return updated event
-	return &models.EventResponse{
-		ID:          existingEvent.ID,
-		Name:        existingEvent.Name,
-		Description: existingEvent.Description,
-		Location:    existingEvent.Location,
-		StartTime:   existingEvent.StartTime,
-		EndTime:     existingEvent.EndTime,
-		Capacity:    existingEvent.Capacity,
-		OrganizerID: existingEvent.OrganizerID,
-		Status:      existingEvent.Status,
-		CreatedAt:   existingEvent.CreatedAt,
-		UpdatedAt:   existingEvent.UpdatedAt,
-	}, nil
-
+	return newEventResponse(existingEvent), nil
 }
 
 // CreateEvent creates a new event
 func (s *EventService) CreateEvent(req models.EventRequest, organizerID int) (*models.EventResponse, error) {
+	if err := checkRegistrationWindow(req); err != nil {
+		return nil, err
+	}
+
 	// Create event object
-	event := &models.Event{
+	event := newEvent(req, organizerID)
+
+	// Save event to database
+	err := s.EventRepo.Create(event)
+	if err != nil {
+		log.Printf("Error creating event: %v", err)
+		return nil, apperrors.Internal(err)
+	}
+
+	// اطلاعات رویداد رو برمیگردونه
+	return newEventResponse(event), nil
+}
+
+// newEvent builds a new published or draft event from a creation request
+func newEvent(req models.EventRequest, organizerID int) *models.Event {
+	status := models.EventStatusPublished
+	if req.Draft {
+		status = models.EventStatusDraft
+	}
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityPublic
+	}
+
+	return &models.Event{
 		Name:        req.Name,
 		Description: req.Description,
 		Location:    req.Location,
@@ -110,67 +109,45 @@ func (s *EventService) CreateEvent(req models.EventRequest, organizerID int) (*m
 		EndTime:     req.EndTime,
 		Capacity:    req.Capacity,
 		OrganizerID: organizerID,
-		Status:      "open",
-	}
+		Status:      status,
+		Visibility:  visibility,
 
-	// Save event to database
-	err := s.EventRepo.Create(event)
-	if err != nil {
-		log.Printf("Error creating event: %v", err)
-		return nil, errors.New("error creating event")
-	}
+		RequiresApproval: req.RequiresApproval,
 
-	// اطلاعات رویداد رو برمیگردونه
-	return &models.EventResponse{
-		ID:          event.ID,
-		Name:        event.Name,
-		Description: event.Description,
-		Location:    event.Location,
-		StartTime:   event.StartTime,
-		EndTime:     event.EndTime,
-		Capacity:    event.Capacity,
-		OrganizerID: event.OrganizerID,
-		Status:      event.Status,
-		CreatedAt:   event.CreatedAt,
-		UpdatedAt:   event.UpdatedAt,
-	}, nil
+		PublishAt:            req.PublishAt,
+		RegistrationOpensAt:  req.RegistrationOpensAt,
+		RegistrationClosesAt: req.RegistrationClosesAt,
+	}
 }
 
-// GetEventByID retrieves an event by ID
-func (s *EventService) GetEventByID(id int) (*models.EventResponse, error) {
+// GetEventByID retrieves an event by ID if the viewer may see it
+func (s *EventService) GetEventByID(id int, viewer Viewer) (*models.EventResponse, error) {
 	// Get event from database
-	event, err := s.EventRepo.GetByID(id)
+	event, err := viewEvent(s.EventRepo, s.StaffRepo, s.InviteRepo, id, viewer)
 	if err != nil {
 		return nil, err
 	}
 
 	// اطلاعات رویداد رو برمیگردونه
-	return &models.EventResponse{
-		ID:          event.ID,
-		Name:        event.Name,
-		Description: event.Description,
-		Location:    event.Location,
-		StartTime:   event.StartTime,
-		EndTime:     event.EndTime,
-		Capacity:    event.Capacity,
-		OrganizerID: event.OrganizerID,
-		Status:      event.Status,
-		CreatedAt:   event.CreatedAt,
-		UpdatedAt:   event.UpdatedAt,
-	}, nil
+	return newEventResponse(event), nil
 }
 
 // UpdateEvent updates an existing event
 func (s *EventService) UpdateEvent(id int, req models.EventRequest, organizerID int) (*models.EventResponse, error) {
-	// Get existing event
-	existingEvent, err := s.EventRepo.GetByID(id)
+	// Get existing event and check that the user may manage it
+	existingEvent, err := authorizeEvent(s.EventRepo, s.StaffRepo, id, organizerID, permissionManageEvent)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if user is the organizer
-	if existingEvent.OrganizerID != organizerID {
-		return nil, errors.New("you are not the organizer of this event")
+	if err := checkEditable(existingEvent); err != nil {
+		return nil, err
+	}
+	if err := checkStartTime(existingEvent, req); err != nil {
+		return nil, err
+	}
+	if err := checkRegistrationWindow(req); err != nil {
+		return nil, err
 	}
 
 	// Update event fields
@@ -180,107 +157,101 @@ func (s *EventService) UpdateEvent(id int, req models.EventRequest, organizerID
 	existingEvent.StartTime = req.StartTime
 	existingEvent.EndTime = req.EndTime
 	existingEvent.Capacity = req.Capacity
+	existingEvent.PublishAt = req.PublishAt
+	existingEvent.RegistrationOpensAt = req.RegistrationOpensAt
+	existingEvent.RegistrationClosesAt = req.RegistrationClosesAt
+	existingEvent.RequiresApproval = req.RequiresApproval
+	if req.Visibility != "" {
+		existingEvent.Visibility = req.Visibility
+	}
 
 	// Save updated event
-	err = s.EventRepo.Update(existingEvent)
+	promoted, err := s.EventRepo.Update(existingEvent)
 	if err != nil {
 		log.Printf("Error updating event: %v", err)
-		return nil, errors.New("error updating event")
+		return nil, apperrors.Internal(err)
 	}
+	s.Payments.NotifyPromoted(promoted)
 
 	// Return updated event
-	return &models.EventResponse{
-		ID:          existingEvent.ID,
-		Name:        existingEvent.Name,
-		Description: existingEvent.Description,
-		Location:    existingEvent.Location,
-		StartTime:   existingEvent.StartTime,
-		EndTime:     existingEvent.EndTime,
-		Capacity:    existingEvent.Capacity,
-		OrganizerID: existingEvent.OrganizerID,
-		Status:      existingEvent.Status,
-		CreatedAt:   existingEvent.CreatedAt,
-		UpdatedAt:   existingEvent.UpdatedAt,
-	}, nil
+	return newEventResponse(existingEvent), nil
 }
 
 // DeleteEvent deletes an event
 func (s *EventService) DeleteEvent(id int, organizerID int) error {
+	// Only the owner may delete an event
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, id, organizerID, permissionDeleteEvent)
+	if err != nil {
+		return err
+	}
+
 	// Delete event from database
-	return s.EventRepo.Delete(id, organizerID)
+	return s.EventRepo.Delete(id, event.OrganizerID)
 }
 
-// GetAllPublicEvents retrieves all public events
-func (s *EventService) GetAllPublicEvents() ([]models.EventResponse, error) {
+// GetAllPublicEvents retrieves one page of public events
+func (s *EventService) GetAllPublicEvents(query models.EventListQuery) (*models.EventPageResponse, error) {
 	// Get events from database
-	events, err := s.EventRepo.GetAllPublic()
+	page, err := s.EventRepo.GetAllPublic(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return newEventPageResponse(page), nil
+}
+
+// SearchEvents runs a full-text search over events
+func (s *EventService) SearchEvents(query models.EventSearchQuery) (*models.EventSearchResponse, error) {
+	// Apply the same defaults the repository uses so the response reports them
+	if query.Limit <= 0 {
+		query.Limit = models.DefaultPageSize
+	}
+	// Drafts are only visible to their organizers
+	if query.Status != "" && (!validEventStatus(query.Status) || query.Status == models.EventStatusDraft) {
+		return nil, ErrInvalidEventStatus
+	}
+
+	hits, total, err := s.EventRepo.Search(query)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert to response format
-	response := make([]models.EventResponse, len(events))
-	for i, event := range events {
-		response[i] = models.EventResponse{
-			ID:          event.ID,
-			Name:        event.Name,
-			Description: event.Description,
-			Location:    event.Location,
-			StartTime:   event.StartTime,
-			EndTime:     event.EndTime,
-			Capacity:    event.Capacity,
-			OrganizerID: event.OrganizerID,
-			Status:      event.Status,
-			CreatedAt:   event.CreatedAt,
-			UpdatedAt:   event.UpdatedAt,
+	response := &models.EventSearchResponse{
+		Results: make([]models.EventSearchResult, len(hits)),
+		Total:   total,
+		Limit:   query.Limit,
+		Offset:  query.Offset,
+	}
+	for i, hit := range hits {
+		response.Results[i] = models.EventSearchResult{
+			Event:      *newEventResponse(&hit.Event),
+			Rank:       hit.Rank,
+			Highlights: hit.Highlights,
 		}
 	}
 
 	return response, nil
 }
 
-// GetEventsByOrganizer retrieves all events created by a specific organizer
-func (s *EventService) GetEventsByOrganizer(organizerID int) ([]models.EventResponse, error) {
+// GetEventsByOrganizer retrieves one page of events created by a specific organizer
+func (s *EventService) GetEventsByOrganizer(organizerID int, query models.EventListQuery) (*models.EventPageResponse, error) {
 	// Get events from database
-	events, err := s.EventRepo.GetByOrganizer(organizerID)
+	page, err := s.EventRepo.GetByOrganizer(organizerID, query)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert to response format
-	response := make([]models.EventResponse, len(events))
-	for i, event := range events {
-		response[i] = models.EventResponse{
-			ID:          event.ID,
-			Name:        event.Name,
-			Description: event.Description,
-			Location:    event.Location,
-			StartTime:   event.StartTime,
-			EndTime:     event.EndTime,
-			Capacity:    event.Capacity,
-			OrganizerID: event.OrganizerID,
-			Status:      event.Status,
-			CreatedAt:   event.CreatedAt,
-			UpdatedAt:   event.UpdatedAt,
-		}
-	}
-
-	return response, nil
+	return newEventPageResponse(page), nil
 }
 
 // GetEventWithParticipants retrieves an event with its participants
 func (s *EventService) GetEventWithParticipants(id int, organizerID int) (*models.EventWithParticipantsResponse, error) {
-	// Get event from database
-	event, err := s.EventRepo.GetByID(id)
+	// Get event from database and check that the user may see its participants
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, id, organizerID, permissionViewParticipants)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if user is the organizer
-	if event.OrganizerID != organizerID {
-		return nil, errors.New("you are not the organizer of this event")
-	}
-
 	// Get participants
 	participants, err := s.EventRepo.GetParticipantsByEventID(id)
 	if err != nil {
@@ -289,50 +260,99 @@ func (s *EventService) GetEventWithParticipants(id int, organizerID int) (*model
 
 	// Create response
 	response := &models.EventWithParticipantsResponse{
-		Event: models.EventResponse{
-			ID:          event.ID,
-			Name:        event.Name,
-			Description: event.Description,
-			Location:    event.Location,
-			StartTime:   event.StartTime,
-			EndTime:     event.EndTime,
-			Capacity:    event.Capacity,
-			OrganizerID: event.OrganizerID,
-			Status:      event.Status,
-			CreatedAt:   event.CreatedAt,
-			UpdatedAt:   event.UpdatedAt,
-		},
+		Event:        *newEventResponse(event),
 		Participants: participants,
 	}
 
 	return response, nil
 }
 
-// GetEventsByParticipant retrieves all events a user is participating in
-func (s *EventService) GetEventsByParticipant(userID int) ([]models.EventResponse, error) {
+// GetEventsByParticipant retrieves one page of events a user is participating in
+func (s *EventService) GetEventsByParticipant(userID int, query models.EventListQuery) (*models.EventPageResponse, error) {
 	// Get events from database
-	events, err := s.EventRepo.GetEventsByParticipant(userID)
+	page, err := s.EventRepo.GetEventsByParticipant(userID, query)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert to response format
-	response := make([]models.EventResponse, len(events))
-	for i, event := range events {
-		response[i] = models.EventResponse{
-			ID:          event.ID,
-			Name:        event.Name,
-			Description: event.Description,
-			Location:    event.Location,
-			StartTime:   event.StartTime,
-			EndTime:     event.EndTime,
-			Capacity:    event.Capacity,
-			OrganizerID: event.OrganizerID,
-			Status:      event.Status,
-			CreatedAt:   event.CreatedAt,
-			UpdatedAt:   event.UpdatedAt,
-		}
+	return newEventPageResponse(page), nil
+}
+
+// checkStartTime rejects moving an event to a start time in the past.
+// Events that already started may keep their original start time.
+func checkStartTime(event *models.Event, req models.EventRequest) error {
+	if req.StartTime.Equal(event.StartTime) || req.StartTime.After(time.Now()) {
+		return nil
 	}
 
-	return response, nil
+	return apperrors.InvalidFields([]apperrors.FieldError{{
+		Field:   "start_time",
+		Rule:    "future",
+		Message: "must be in the future",
+	}})
+}
+
+// checkRegistrationWindow rejects a registration that would close before it
+// opens. That both lie before the start is checked by the validate tags.
+func checkRegistrationWindow(req models.EventRequest) error {
+	if req.RegistrationOpensAt == nil || req.RegistrationClosesAt == nil || req.RegistrationClosesAt.After(*req.RegistrationOpensAt) {
+		return nil
+	}
+
+	return apperrors.InvalidFields([]apperrors.FieldError{{
+		Field:   "registration_closes_at",
+		Rule:    "gtfield",
+		Message: "must be after registration_opens_at",
+	}})
+}
+
+// newEventResponse converts an event to response format, including whether
+// anyone can join it right now
+func newEventResponse(event *models.Event) *models.EventResponse {
+	response := &models.EventResponse{
+		ID:          event.ID,
+		Name:        event.Name,
+		Description: event.Description,
+		Location:    event.Location,
+		StartTime:   event.StartTime,
+		EndTime:     event.EndTime,
+		Capacity:    event.Capacity,
+		OrganizerID: event.OrganizerID,
+		Status:      event.Status,
+		Visibility:  event.Visibility,
+		CreatedAt:   event.CreatedAt,
+		UpdatedAt:   event.UpdatedAt,
+
+		RequiresApproval: event.RequiresApproval,
+
+		PublishAt:            event.PublishAt,
+		RegistrationOpensAt:  event.RegistrationOpensAt,
+		RegistrationClosesAt: event.RegistrationClosesAt,
+
+		SeriesID:     event.SeriesID,
+		RecurrenceID: event.RecurrenceID,
+	}
+
+	var closed *apperrors.Error
+	if err := repositories.CheckRegistration(event, time.Now()); errors.As(err, &closed) {
+		response.RegistrationClosedReason = closed.Code
+	} else {
+		response.RegistrationOpen = true
+	}
+
+	return response
+}
+
+// newEventPageResponse converts a page of events to response format
+func newEventPageResponse(page *models.EventPage) *models.EventPageResponse {
+	response := &models.EventPageResponse{
+		Events:     make([]models.EventResponse, len(page.Events)),
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}
+	for i := range page.Events {
+		response.Events[i] = *newEventResponse(&page.Events[i])
+	}
+
+	return response
 }
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+func updateRequest(event *models.EventResponse) models.EventRequest {
+	return models.EventRequest{
+		Name:        event.Name + " (updated)",
+		Description: event.Description,
+		Location:    event.Location,
+		StartTime:   event.StartTime,
+		EndTime:     event.EndTime,
+		Capacity:    event.Capacity,
+
+		PublishAt:            event.PublishAt,
+		RegistrationOpensAt:  event.RegistrationOpensAt,
+		RegistrationClosesAt: event.RegistrationClosesAt,
+		RequiresApproval:     event.RequiresApproval,
+	}
+}
+
+func TestUpdateEventPermissions(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	stranger := ts.createUser(t, models.RoleOrganizer)
+	coOrganizer := ts.createUser(t, models.RoleUser)
+	viewer := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	for _, member := range []struct {
+		userID int
+		role   string
+	}{{coOrganizer.ID, models.StaffRoleCoOrganizer}, {viewer.ID, models.StaffRoleViewer}} {
+		_, err := ts.staff.AddStaff(event.ID, owner.ID, models.AddStaffRequest{UserID: member.userID, Role: member.role})
+		if err != nil {
+			t.Fatalf("add staff: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		userID int
+		want   error
+	}{
+		{"owner", owner.ID, nil},
+		{"co-organizer", coOrganizer.ID, nil},
+		{"viewer", viewer.ID, ErrStaffRoleForbidden},
+		{"stranger", stranger.ID, ErrNotOrganizer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := ts.events.UpdateEvent(event.ID, updateRequest(event), tt.userID)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if err == nil && updated.OrganizerID != owner.ID {
+				t.Errorf("organizer = %d, want %d", updated.OrganizerID, owner.ID)
+			}
+		})
+	}
+}
+
+func TestUpdateEventRaisingCapacityPromotesWaitlist(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	event := ts.createEvent(t, owner.ID, 1)
+
+	var waiting *models.User
+	for i := 0; i < 2; i++ {
+		waiting = ts.createUser(t, models.RoleUser)
+		if _, err := ts.participants.JoinEvent(waiting.ID, event.ID, models.JoinEventRequest{}); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+
+	req := updateRequest(event)
+	req.Capacity = 2
+	if _, err := ts.events.UpdateEvent(event.ID, req, owner.ID); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	status, err := ts.participants.IsParticipant(waiting.ID, event.ID)
+	if err != nil {
+		t.Fatalf("is participant: %v", err)
+	}
+	if !status.IsParticipant {
+		t.Error("waitlisted user was not promoted after the capacity went up")
+	}
+}
+
+func TestUpdateEventKeepsCapacityForTakenSeats(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	event := ts.createEvent(t, owner.ID, 5)
+
+	for i := 0; i < 2; i++ {
+		user := ts.createUser(t, models.RoleUser)
+		if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+	if _, err := ts.promoCodes.CreatePromoCode(event.ID, owner.ID, models.PromoCodeRequest{Code: "PARTNER", ReservedSeats: 1}); err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+
+	req := updateRequest(event)
+	req.Capacity = 2
+	if _, err := ts.events.UpdateEvent(event.ID, req, owner.ID); !errors.Is(err, repositories.ErrCapacityBelowTaken) {
+		t.Errorf("capacity below taken and held seats: got %v, want ErrCapacityBelowTaken", err)
+	}
+	req.Capacity = 3
+	if _, err := ts.events.UpdateEvent(event.ID, req, owner.ID); err != nil {
+		t.Errorf("capacity that fits every seat: %v", err)
+	}
+}
+
+func TestDeleteEvent(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	coOrganizer := ts.createUser(t, models.RoleUser)
+	user := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	_, err := ts.staff.AddStaff(event.ID, owner.ID, models.AddStaffRequest{UserID: coOrganizer.ID, Role: models.StaffRoleCoOrganizer})
+	if err != nil {
+		t.Fatalf("add staff: %v", err)
+	}
+	if err := ts.events.DeleteEvent(event.ID, coOrganizer.ID); !errors.Is(err, ErrStaffRoleForbidden) {
+		t.Errorf("delete by co-organizer: got %v, want ErrStaffRoleForbidden", err)
+	}
+
+	if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if err := ts.events.DeleteEvent(event.ID, owner.ID); !errors.Is(err, repositories.ErrEventHasParticipants) {
+		t.Errorf("delete with participants: got %v, want ErrEventHasParticipants", err)
+	}
+
+	if err := ts.participants.LeaveEvent(user.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if err := ts.events.DeleteEvent(event.ID, owner.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := ts.events.GetEventByID(event.ID, Viewer{}); !errors.Is(err, repositories.ErrEventNotFound) {
+		t.Errorf("get deleted event: got %v, want ErrEventNotFound", err)
+	}
+}
+
+func TestUpdateEventRejectsPastStartTime(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	req := updateRequest(event)
+	req.StartTime = time.Now().Add(-time.Hour)
+	if _, err := ts.events.UpdateEvent(event.ID, req, owner.ID); err == nil {
+		t.Fatal("moving the start time into the past succeeded")
+	}
+}
+
+func TestGetAllPublicEventsPaging(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	for i := 0; i < 5; i++ {
+		ts.createEvent(t, owner.ID, 10)
+	}
+	closed := ts.createEvent(t, owner.ID, 10)
+	if _, err := ts.events.CloseEvent(owner.ID, closed.ID); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	seen := map[int]bool{}
+	query := models.EventListQuery{Limit: 2}
+	for {
+		page, err := ts.events.GetAllPublicEvents(query)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if page.Total != 5 {
+			t.Fatalf("total = %d, want 5", page.Total)
+		}
+		for _, event := range page.Events {
+			if seen[event.ID] {
+				t.Fatalf("event %d returned twice", event.ID)
+			}
+			seen[event.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	if len(seen) != 5 || seen[closed.ID] {
+		t.Errorf("listed %d events (closed included: %v), want the 5 open ones", len(seen), seen[closed.ID])
+	}
+}
+
+func TestRegistrationWindow(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	user := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	if !event.RegistrationOpen || event.RegistrationClosedReason != "" {
+		t.Errorf("expected registration of a new event to be open, got %+v", event)
+	}
+
+	// Announce it now, open sign-ups in an hour and close them two hours
+	// before the start
+	now := time.Now()
+	opens := now.Add(time.Hour)
+	closes := event.StartTime.Add(-2 * time.Hour)
+	req := updateRequest(event)
+	req.RegistrationOpensAt = &opens
+	req.RegistrationClosesAt = &closes
+	updated, err := ts.events.UpdateEvent(event.ID, req, owner.ID)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.RegistrationOpen || updated.RegistrationClosedReason != repositories.ErrRegistrationNotOpen.Code {
+		t.Errorf("expected registration to open later, got open=%v reason=%q", updated.RegistrationOpen, updated.RegistrationClosedReason)
+	}
+	if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrRegistrationNotOpen) {
+		t.Errorf("join: got %v, want ErrRegistrationNotOpen", err)
+	}
+
+	// It is announced, so it is listed
+	page, err := ts.events.GetAllPublicEvents(models.EventListQuery{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if page.Total != 1 || page.Events[0].RegistrationClosedReason != repositories.ErrRegistrationNotOpen.Code {
+		t.Errorf("expected the event in the listing with its registration state, got %+v", page.Events)
+	}
+
+	// Closing registration before it opens is rejected
+	req.RegistrationClosesAt = &now
+	_, err = ts.events.UpdateEvent(event.ID, req, owner.ID)
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || len(appErr.Fields) != 1 || appErr.Fields[0].Field != "registration_closes_at" {
+		t.Errorf("close before open: got %v, want a validation error on registration_closes_at", err)
+	}
+}
+
+func TestScheduledPublishing(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	user := ts.createUser(t, models.RoleUser)
+
+	start := time.Now().Add(24 * time.Hour)
+	publishAt := time.Now().Add(time.Hour)
+	event, err := ts.events.CreateEvent(models.EventRequest{
+		Name:      "Go Meetup",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		Capacity:  10,
+		PublishAt: &publishAt,
+	}, owner.ID)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if event.Status != models.EventStatusPublished || event.RegistrationClosedReason != repositories.ErrEventNotPublished.Code {
+		t.Errorf("expected a published event that isn't public yet, got %+v", event)
+	}
+
+	page, err := ts.events.GetAllPublicEvents(models.EventListQuery{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if page.Total != 0 {
+		t.Errorf("expected no public events before the publish time, got %d", page.Total)
+	}
+	search, err := ts.events.SearchEvents(models.EventSearchQuery{Text: "go"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if search.Total != 0 {
+		t.Errorf("expected no search results before the publish time, got %d", search.Total)
+	}
+	if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrEventNotPublished) {
+		t.Errorf("join: got %v, want ErrEventNotPublished", err)
+	}
+
+	// Publishing now instead
+	req := updateRequest(event)
+	req.PublishAt = nil
+	if _, err := ts.events.UpdateEvent(event.ID, req, owner.ID); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+		t.Errorf("join after publishing: %v", err)
+	}
+}
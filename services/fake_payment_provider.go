@@ -0,0 +1,180 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// fakeSignatureHeader carries the signature of the webhooks of FakePaymentProvider
+const fakeSignatureHeader = "X-Fake-Signature"
+
+// FakePaymentProvider is a payment gateway that runs in the process, for
+// development and tests. Nobody pays anything: the outcome of a payment is
+// chosen with Settle, which sends a signed webhook to the handler given to
+// OnWebhook right away or after a delay, the way a real gateway would call
+// back later. Its payments only live in memory: after a restart, or on
+// another instance, their refs are unknown, so they can't be settled and
+// their refunds fail for good.
+type FakePaymentProvider struct {
+	secret []byte
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment // by provider ref
+	webhook  func(body []byte, header http.Header) error
+}
+
+type fakePayment struct {
+	orderID  int
+	outcome  string // empty until Settle
+	refunded bool
+}
+
+// fakeWebhook is the body of the webhooks of FakePaymentProvider
+type fakeWebhook struct {
+	PaymentID string `json:"payment_id"`
+	Outcome   string `json:"outcome"`
+}
+
+// NewFakePaymentProvider creates a fake gateway that signs its webhooks with
+// the same secret as tokens
+func NewFakePaymentProvider() *FakePaymentProvider {
+	return &FakePaymentProvider{
+		secret:   signingSecret(),
+		payments: map[string]*fakePayment{},
+	}
+}
+
+// OnWebhook sets where the webhooks of the gateway are delivered
+func (p *FakePaymentProvider) OnWebhook(handler func(body []byte, header http.Header) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.webhook = handler
+}
+
+// Name identifies the fake gateway in stored orders
+func (p *FakePaymentProvider) Name() string {
+	return "fake"
+}
+
+// CreatePayment starts a payment that waits for Settle
+func (p *FakePaymentProvider) CreatePayment(order *models.Order) (*models.PaymentSession, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	ref := "fake_" + hex.EncodeToString(buf)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.payments[ref] = &fakePayment{orderID: order.ID}
+
+	return &models.PaymentSession{
+		ProviderRef: ref,
+		CheckoutURL: "/api/payments/fake/" + ref,
+	}, nil
+}
+
+// Settle decides the outcome of a payment and sends its webhook after delay.
+// Without a delay the webhook is handled before Settle returns, with its
+// error; a delayed one only logs its error. Settling a payment again with the
+// same outcome sends the webhook once more, like a gateway retrying it.
+func (p *FakePaymentProvider) Settle(ref, outcome string, delay time.Duration) error {
+	if outcome != models.PaymentSucceeded && outcome != models.PaymentDeclined {
+		return fmt.Errorf("unknown payment outcome %q", outcome)
+	}
+
+	p.mu.Lock()
+	payment, ok := p.payments[ref]
+	if ok && payment.outcome == "" {
+		payment.outcome = outcome
+	}
+	webhook := p.webhook
+	p.mu.Unlock()
+
+	switch {
+	case !ok:
+		return ErrPaymentNotFound
+	case payment.outcome != outcome:
+		return ErrPaymentSettled
+	case webhook == nil:
+		return errors.New("fake payment provider has no webhook handler")
+	}
+
+	body, err := json.Marshal(fakeWebhook{PaymentID: ref, Outcome: outcome})
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set(fakeSignatureHeader, p.sign(body))
+
+	if delay <= 0 {
+		return webhook(body, header)
+	}
+	time.AfterFunc(delay, func() {
+		if err := webhook(body, header); err != nil {
+			log.Printf("Error delivering fake webhook for payment %s: %v", ref, err)
+		}
+	})
+	return nil
+}
+
+// ParseWebhook checks the signature of a webhook of the fake gateway
+func (p *FakePaymentProvider) ParseWebhook(body []byte, header http.Header) (*models.PaymentEvent, error) {
+	signature := header.Get(fakeSignatureHeader)
+	if !hmac.Equal([]byte(signature), []byte(p.sign(body))) {
+		return nil, errors.New("bad webhook signature")
+	}
+
+	var webhook fakeWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, err
+	}
+	if webhook.Outcome != models.PaymentSucceeded && webhook.Outcome != models.PaymentDeclined {
+		return nil, fmt.Errorf("unknown payment outcome %q", webhook.Outcome)
+	}
+
+	return &models.PaymentEvent{ProviderRef: webhook.PaymentID, Outcome: webhook.Outcome}, nil
+}
+
+// Refund marks a succeeded payment as refunded
+func (p *FakePaymentProvider) Refund(order *models.Order) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[order.ProviderRef]
+	if !ok || payment.orderID != order.ID {
+		return fmt.Errorf("fake payment %q of order %d: %w", order.ProviderRef, order.ID, ErrPaymentNotFound)
+	}
+	if payment.outcome != models.PaymentSucceeded {
+		return fmt.Errorf("fake payment %q of order %d was not paid", order.ProviderRef, order.ID)
+	}
+	payment.refunded = true
+	return nil
+}
+
+// Refunded reports whether the payment with the given ref was refunded
+func (p *FakePaymentProvider) Refunded(ref string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[ref]
+	return ok && payment.refunded
+}
+
+// sign returns the hex HMAC-SHA256 of a webhook body
+func (p *FakePaymentProvider) sign(body []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
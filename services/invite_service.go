@@ -0,0 +1,316 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// inviteTokenType sets invite tokens apart from access tokens signed with the same secret
+const inviteTokenType = "event_invite"
+
+// InviteService handles invites to private events
+type InviteService struct {
+	EventRepo  repositories.EventStore
+	StaffRepo  repositories.StaffStore
+	InviteRepo repositories.InviteStore
+	UserRepo   repositories.UserStore
+	Notifier   Notifier
+}
+
+// NewInviteService creates a new invite service instance
+func NewInviteService(eventRepo repositories.EventStore, staffRepo repositories.StaffStore, inviteRepo repositories.InviteStore, userRepo repositories.UserStore, notifier Notifier) *InviteService {
+	return &InviteService{
+		EventRepo:  eventRepo,
+		StaffRepo:  staffRepo,
+		InviteRepo: inviteRepo,
+		UserRepo:   userRepo,
+		Notifier:   notifier,
+	}
+}
+
+// CreateInviteLink creates an invite that anyone holding its token can join with
+func (s *InviteService) CreateInviteLink(eventID, userID int, req models.InviteLinkRequest) (*models.InviteResponse, error) {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent); err != nil {
+		return nil, err
+	}
+
+	invite := &models.EventInvite{
+		EventID:   eventID,
+		CreatedBy: userID,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if req.MaxUses > 0 {
+		invite.MaxUses = &req.MaxUses
+	}
+
+	if err := s.InviteRepo.CreateMany([]*models.EventInvite{invite}); err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return newInviteResponse(invite)
+}
+
+// InviteUsers invites users and email addresses to an event. Users who were
+// already invited are skipped, and invited users get a notification. These
+// invites only let their own user in, so they have no use limit and a user
+// who leaves can join again.
+func (s *InviteService) InviteUsers(eventID, userID int, req models.InviteUsersRequest) ([]models.InviteResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.UserIDs) == 0 && len(req.Emails) == 0 {
+		return nil, ErrNoInvitees
+	}
+
+	existing, err := s.InviteRepo.GetByEvent(eventID)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	invitedUsers := map[int]bool{}
+	invitedEmails := map[string]bool{}
+	for _, invite := range existing {
+		if invite.UserID != nil {
+			invitedUsers[*invite.UserID] = true
+		}
+		if invite.Email != "" {
+			invitedEmails[invite.Email] = true
+		}
+	}
+
+	invites := []*models.EventInvite{}
+	notify := []int{}
+	for _, id := range req.UserIDs {
+		if invitedUsers[id] {
+			continue
+		}
+		if _, err := s.UserRepo.GetByID(id); err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				return nil, apperrors.NotFound(repositories.ErrUserNotFound.Code, fmt.Sprintf("%s: %d", repositories.ErrUserNotFound.Message, id))
+			}
+			return nil, apperrors.Internal(err)
+		}
+		invitedUsers[id] = true
+
+		invitee := id
+		invites = append(invites, &models.EventInvite{EventID: eventID, CreatedBy: userID, UserID: &invitee, ExpiresAt: req.ExpiresAt})
+		notify = append(notify, id)
+	}
+	for _, email := range req.Emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if invitedEmails[email] {
+			continue
+		}
+		invitedEmails[email] = true
+
+		invites = append(invites, &models.EventInvite{EventID: eventID, CreatedBy: userID, Email: email, ExpiresAt: req.ExpiresAt})
+	}
+
+	if len(invites) > 0 {
+		if err := s.InviteRepo.CreateMany(invites); err != nil {
+			return nil, apperrors.Internal(err)
+		}
+	}
+
+	if len(notify) > 0 {
+		err = s.Notifier.Notify(notify, models.Notification{
+			Type:    models.NotificationEventInvite,
+			EventID: event.ID,
+			Subject: fmt.Sprintf("You are invited to %s", event.Name),
+			Message: fmt.Sprintf("You are invited to %s on %s.", event.Name, event.StartTime.Format(time.RFC1123)),
+		})
+		if err != nil {
+			log.Printf("Error sending invite notifications for event %d: %v", event.ID, err)
+		}
+	}
+
+	responses := make([]models.InviteResponse, len(invites))
+	for i, invite := range invites {
+		response, err := newInviteResponse(invite)
+		if err != nil {
+			return nil, err
+		}
+		responses[i] = *response
+	}
+	return responses, nil
+}
+
+// ListInvites returns every invite of an event together with the tokens of its links
+func (s *InviteService) ListInvites(eventID, userID int) ([]models.InviteResponse, error) {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent); err != nil {
+		return nil, err
+	}
+
+	invites, err := s.InviteRepo.GetByEvent(eventID)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	responses := make([]models.InviteResponse, len(invites))
+	for i := range invites {
+		response, err := newInviteResponse(&invites[i])
+		if err != nil {
+			return nil, err
+		}
+		responses[i] = *response
+	}
+	return responses, nil
+}
+
+// RevokeInvite deletes an invite. Users who already joined with it stay.
+func (s *InviteService) RevokeInvite(eventID, userID, inviteID int) error {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent); err != nil {
+		return err
+	}
+
+	return s.InviteRepo.Delete(eventID, inviteID)
+}
+
+// newInviteResponse converts an invite to response format. Link invites get
+// their token, which is signed again on every call and so never stored.
+func newInviteResponse(invite *models.EventInvite) (*models.InviteResponse, error) {
+	response := &models.InviteResponse{
+		ID:        invite.ID,
+		EventID:   invite.EventID,
+		CreatedBy: invite.CreatedBy,
+		UserID:    invite.UserID,
+		Email:     invite.Email,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		ExpiresAt: invite.ExpiresAt,
+		CreatedAt: invite.CreatedAt,
+	}
+
+	if invite.UserID == nil && invite.Email == "" {
+		token, err := signInviteToken(invite)
+		if err != nil {
+			log.Printf("Error signing invite token: %v", err)
+			return nil, apperrors.Internal(err)
+		}
+		response.Token = token
+	}
+
+	return response, nil
+}
+
+// signingSecret returns the key invite tokens and tickets are signed with, the same one as access tokens
+func signingSecret() []byte {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = "your-secret-key"
+	}
+	return []byte(secret)
+}
+
+// signInviteToken signs the token of a link invite. It carries the invite
+// and event IDs and expires with the invite.
+func signInviteToken(invite *models.EventInvite) (string, error) {
+	claims := jwt.MapClaims{
+		"typ": inviteTokenType,
+		"inv": invite.ID,
+		"evt": invite.EventID,
+	}
+	if invite.ExpiresAt != nil {
+		claims["exp"] = invite.ExpiresAt.Unix()
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signingSecret())
+}
+
+// parseInviteToken checks the signature of an invite token for an event and
+// returns the ID of its invite. It doesn't look at the invite itself, which
+// may have been revoked or used up since.
+func parseInviteToken(tokenString string, eventID int) (int, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return signingSecret(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return 0, ErrInvalidInvite.Wrap(err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != inviteTokenType {
+		return 0, ErrInvalidInvite
+	}
+	inviteID, ok := claims["inv"].(float64)
+	if !ok {
+		return 0, ErrInvalidInvite
+	}
+	if evt, ok := claims["evt"].(float64); !ok || int(evt) != eventID {
+		return 0, ErrInvalidInvite
+	}
+
+	return int(inviteID), nil
+}
+
+// Viewer is whoever asks to see an event: a signed in user or nobody, maybe
+// holding the token of an invite link
+type Viewer struct {
+	UserID      int
+	Role        string
+	InviteToken string
+}
+
+// viewEvent loads an event the viewer may see. Admins, the owner and the staff
+// see every event. Others don't see drafts and events that aren't published
+// yet, and private events only with an invite, so they get the same error as
+// for an event that doesn't exist.
+func viewEvent(eventRepo repositories.EventStore, staffRepo repositories.StaffStore, inviteRepo repositories.InviteStore, eventID int, viewer Viewer) (*models.Event, error) {
+	event, err := eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if viewer.Role == models.RoleAdmin || (viewer.UserID != 0 && event.OrganizerID == viewer.UserID) {
+		return event, nil
+	}
+	if viewer.UserID != 0 {
+		role, err := staffRepo.GetStaffRole(eventID, viewer.UserID)
+		if err != nil {
+			return nil, apperrors.Internal(err)
+		}
+		if role != "" {
+			return event, nil
+		}
+	}
+
+	now := time.Now()
+	if event.Status == models.EventStatusDraft || (event.PublishAt != nil && event.PublishAt.After(now)) {
+		return nil, repositories.ErrEventNotFound
+	}
+	if event.Visibility != models.VisibilityPrivate {
+		return event, nil
+	}
+
+	if viewer.InviteToken != "" {
+		if inviteID, err := parseInviteToken(viewer.InviteToken, eventID); err == nil {
+			invite, err := inviteRepo.GetByID(inviteID)
+			if err != nil && !errors.Is(err, repositories.ErrInviteNotFound) {
+				return nil, apperrors.Internal(err)
+			}
+			if invite != nil && invite.EventID == eventID && (invite.ExpiresAt == nil || invite.ExpiresAt.After(now)) {
+				return event, nil
+			}
+		}
+	}
+	if viewer.UserID != 0 {
+		invited, err := inviteRepo.IsInvited(eventID, viewer.UserID, now)
+		if err != nil {
+			return nil, apperrors.Internal(err)
+		}
+		if invited {
+			return event, nil
+		}
+	}
+
+	return nil, repositories.ErrEventNotFound
+}
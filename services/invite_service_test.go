@@ -0,0 +1,240 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// createPrivateEvent creates an event and makes it private
+func (ts *testServices) createPrivateEvent(t *testing.T, organizerID, capacity int) *models.EventResponse {
+	t.Helper()
+
+	event := ts.createEvent(t, organizerID, capacity)
+	req := updateRequest(event)
+	req.Visibility = models.VisibilityPrivate
+	private, err := ts.events.UpdateEvent(event.ID, req, organizerID)
+	if err != nil {
+		t.Fatalf("make event private: %v", err)
+	}
+	return private
+}
+
+func TestPrivateEventIsHidden(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	staff := ts.createUser(t, models.RoleUser)
+	admin := ts.createUser(t, models.RoleAdmin)
+	guest := ts.createUser(t, models.RoleUser)
+	stranger := ts.createUser(t, models.RoleUser)
+	event := ts.createPrivateEvent(t, owner.ID, 10)
+
+	if event.Visibility != models.VisibilityPrivate {
+		t.Fatalf("visibility = %q, want private", event.Visibility)
+	}
+	// Leaving the visibility out of an update keeps it
+	kept, err := ts.events.UpdateEvent(event.ID, updateRequest(event), owner.ID)
+	if err != nil || kept.Visibility != models.VisibilityPrivate {
+		t.Fatalf("update without visibility: %+v, %v", kept, err)
+	}
+
+	if _, err := ts.staff.AddStaff(event.ID, owner.ID, models.AddStaffRequest{UserID: staff.ID, Role: models.StaffRoleViewer}); err != nil {
+		t.Fatalf("add staff: %v", err)
+	}
+	if _, err := ts.invites.InviteUsers(event.ID, owner.ID, models.InviteUsersRequest{UserIDs: []int{guest.ID}}); err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	link, err := ts.invites.CreateInviteLink(event.ID, owner.ID, models.InviteLinkRequest{})
+	if err != nil {
+		t.Fatalf("create link: %v", err)
+	}
+
+	for name, viewer := range map[string]Viewer{
+		"owner": {UserID: owner.ID},
+		"staff": {UserID: staff.ID},
+		"admin": {UserID: admin.ID, Role: models.RoleAdmin},
+		"guest": {UserID: guest.ID},
+		"link":  {InviteToken: link.Token},
+	} {
+		if _, err := ts.events.GetEventByID(event.ID, viewer); err != nil {
+			t.Errorf("%s: expected to see the event, got %v", name, err)
+		}
+		if _, err := ts.participants.GetParticipantCount(event.ID, viewer); err != nil {
+			t.Errorf("%s: expected to see the participant count, got %v", name, err)
+		}
+	}
+	for name, viewer := range map[string]Viewer{
+		"anonymous": {},
+		"stranger":  {UserID: stranger.ID},
+		"bad token": {UserID: stranger.ID, InviteToken: link.Token + "x"},
+	} {
+		if _, err := ts.events.GetEventByID(event.ID, viewer); !errors.Is(err, repositories.ErrEventNotFound) {
+			t.Errorf("%s: got %v, want ErrEventNotFound", name, err)
+		}
+		if _, err := ts.calendar.EventCalendar(event.ID, viewer); !errors.Is(err, repositories.ErrEventNotFound) {
+			t.Errorf("%s: export got %v, want ErrEventNotFound", name, err)
+		}
+		if _, err := ts.participants.GetParticipantCount(event.ID, viewer); !errors.Is(err, repositories.ErrEventNotFound) {
+			t.Errorf("%s: participant count got %v, want ErrEventNotFound", name, err)
+		}
+	}
+
+	// Private events stay out of the listings
+	page, err := ts.events.GetAllPublicEvents(models.EventListQuery{})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(page.Events) != 0 {
+		t.Errorf("expected no public events, got %d", len(page.Events))
+	}
+
+	// A revoked link stops showing the event
+	if err := ts.invites.RevokeInvite(event.ID, owner.ID, link.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := ts.events.GetEventByID(event.ID, Viewer{InviteToken: link.Token}); !errors.Is(err, repositories.ErrEventNotFound) {
+		t.Errorf("revoked link: got %v, want ErrEventNotFound", err)
+	}
+}
+
+func TestUnpublishedEventIsHidden(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	start := time.Now().Add(24 * time.Hour)
+	publishAt := time.Now().Add(time.Hour)
+
+	for name, req := range map[string]models.EventRequest{
+		"draft":     {Name: "Draft", StartTime: start, EndTime: start.Add(time.Hour), Capacity: 5, Draft: true},
+		"scheduled": {Name: "Scheduled", StartTime: start, EndTime: start.Add(time.Hour), Capacity: 5, PublishAt: &publishAt},
+	} {
+		event, err := ts.events.CreateEvent(req, owner.ID)
+		if err != nil {
+			t.Fatalf("%s: create: %v", name, err)
+		}
+		if _, err := ts.events.GetEventByID(event.ID, Viewer{}); !errors.Is(err, repositories.ErrEventNotFound) {
+			t.Errorf("%s: got %v, want ErrEventNotFound", name, err)
+		}
+		if _, err := ts.participants.GetParticipantCount(event.ID, Viewer{}); !errors.Is(err, repositories.ErrEventNotFound) {
+			t.Errorf("%s: participant count got %v, want ErrEventNotFound", name, err)
+		}
+		if _, err := ts.events.GetEventByID(event.ID, Viewer{UserID: owner.ID}); err != nil {
+			t.Errorf("%s: owner: %v", name, err)
+		}
+	}
+}
+
+func TestInviteLink(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	first := ts.createUser(t, models.RoleUser)
+	second := ts.createUser(t, models.RoleUser)
+	event := ts.createPrivateEvent(t, owner.ID, 10)
+	other := ts.createPrivateEvent(t, owner.ID, 10)
+
+	if _, err := ts.invites.CreateInviteLink(event.ID, first.ID, models.InviteLinkRequest{}); !errors.Is(err, ErrNotOrganizer) {
+		t.Errorf("link by a stranger: got %v, want ErrNotOrganizer", err)
+	}
+
+	link, err := ts.invites.CreateInviteLink(event.ID, owner.ID, models.InviteLinkRequest{MaxUses: 1})
+	if err != nil {
+		t.Fatalf("create link: %v", err)
+	}
+	if link.Token == "" || link.MaxUses == nil || *link.MaxUses != 1 {
+		t.Fatalf("unexpected link %+v", link)
+	}
+
+	if _, err := ts.participants.JoinEvent(first.ID, event.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrInviteRequired) {
+		t.Errorf("join without invite: got %v, want ErrInviteRequired", err)
+	}
+	if _, err := ts.participants.JoinEvent(first.ID, other.ID, models.JoinEventRequest{InviteToken: link.Token}); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("join another event: got %v, want ErrInvalidInvite", err)
+	}
+	if _, err := ts.participants.JoinEvent(first.ID, event.ID, models.JoinEventRequest{InviteToken: link.Token}); err != nil {
+		t.Fatalf("join with link: %v", err)
+	}
+	if _, err := ts.participants.JoinEvent(second.ID, event.ID, models.JoinEventRequest{InviteToken: link.Token}); !errors.Is(err, repositories.ErrInviteRequired) {
+		t.Errorf("join with used up link: got %v, want ErrInviteRequired", err)
+	}
+
+	invites, err := ts.invites.ListInvites(event.ID, owner.ID)
+	if err != nil {
+		t.Fatalf("list invites: %v", err)
+	}
+	if len(invites) != 1 || invites[0].Uses != 1 || invites[0].Token != link.Token {
+		t.Errorf("unexpected invites %+v", invites)
+	}
+
+	// The token expires with the invite
+	past := time.Now().Add(-time.Minute)
+	expired, err := signInviteToken(&models.EventInvite{ID: link.ID, EventID: event.ID, ExpiresAt: &past})
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	if _, err := ts.participants.JoinEvent(second.ID, event.ID, models.JoinEventRequest{InviteToken: expired}); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("join with expired token: got %v, want ErrInvalidInvite", err)
+	}
+}
+
+func TestInviteUsers(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	guest := ts.createUser(t, models.RoleUser)
+	byEmail := ts.createUser(t, models.RoleUser)
+	stranger := ts.createUser(t, models.RoleUser)
+	event := ts.createPrivateEvent(t, owner.ID, 10)
+
+	if _, err := ts.invites.InviteUsers(event.ID, owner.ID, models.InviteUsersRequest{}); !errors.Is(err, ErrNoInvitees) {
+		t.Errorf("empty invite: got %v, want ErrNoInvitees", err)
+	}
+	if _, err := ts.invites.InviteUsers(event.ID, owner.ID, models.InviteUsersRequest{UserIDs: []int{999}}); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Errorf("unknown user: got %v, want ErrUserNotFound", err)
+	}
+
+	req := models.InviteUsersRequest{
+		UserIDs: []int{guest.ID, guest.ID},
+		Emails:  []string{strings.ToUpper(byEmail.Email), "someone@example.com"},
+	}
+	invites, err := ts.invites.InviteUsers(event.ID, owner.ID, req)
+	if err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	if len(invites) != 3 {
+		t.Fatalf("expected 3 invites, got %+v", invites)
+	}
+	for _, invite := range invites {
+		if invite.Token != "" {
+			t.Errorf("expected no token for invite %+v", invite)
+		}
+	}
+	if len(ts.notifier.sent) != 1 || len(ts.notifier.sent[0].userIDs) != 1 || ts.notifier.sent[0].userIDs[0] != guest.ID ||
+		ts.notifier.sent[0].notification.Type != models.NotificationEventInvite {
+		t.Errorf("expected the invited user to be notified, got %+v", ts.notifier.sent)
+	}
+
+	// Inviting again skips everyone who already has an invite
+	again, err := ts.invites.InviteUsers(event.ID, owner.ID, req)
+	if err != nil || len(again) != 0 {
+		t.Errorf("invite again: %+v, %v", again, err)
+	}
+
+	for _, user := range []*models.User{guest, byEmail} {
+		if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+			t.Errorf("user %d: join: %v", user.ID, err)
+		}
+	}
+	if _, err := ts.participants.JoinEvent(stranger.ID, event.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrInviteRequired) {
+		t.Errorf("stranger: got %v, want ErrInviteRequired", err)
+	}
+
+	// An invited user who leaves can come back with the same invite
+	if err := ts.participants.LeaveEvent(guest.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if _, err := ts.participants.JoinEvent(guest.ID, event.ID, models.JoinEventRequest{}); err != nil {
+		t.Errorf("rejoin after leaving: %v", err)
+	}
+}
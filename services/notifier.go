@@ -0,0 +1,23 @@
+package services
+
+import (
+	"log"
+
+	"github.com/event-system/models"
+)
+
+// Notifier delivers notifications to users. The services only decide who
+// hears about what; how the message reaches them is up to the implementation.
+type Notifier interface {
+	Notify(userIDs []int, notification models.Notification) error
+}
+
+// LogNotifier writes notifications to the log. It is used until a real
+// delivery channel such as email is configured.
+type LogNotifier struct{}
+
+// Notify logs one line per notification
+func (LogNotifier) Notify(userIDs []int, notification models.Notification) error {
+	log.Printf("Notification %s for event %d to %d users: %s", notification.Type, notification.EventID, len(userIDs), notification.Subject)
+	return nil
+}
@@ -1,35 +1,251 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/event-system/models"
 	"github.com/event-system/repositories"
 )
 
 // ParticipantService handles participant related business logic
 type ParticipantService struct {
-	ParticipantRepo *repositories.ParticipantRepository
+	ParticipantRepo repositories.ParticipantStore
+	EventRepo       repositories.EventStore
+	StaffRepo       repositories.StaffStore
+	InviteRepo      repositories.InviteStore
+	Payments        *PaymentService
+	Notifier        Notifier
 }
 
 // NewParticipantService creates a new participant service instance
-func NewParticipantService(participantRepo *repositories.ParticipantRepository) *ParticipantService {
-	return &ParticipantService{ParticipantRepo: participantRepo}
+func NewParticipantService(participantRepo repositories.ParticipantStore, eventRepo repositories.EventStore, staffRepo repositories.StaffStore, inviteRepo repositories.InviteStore, payments *PaymentService, notifier Notifier) *ParticipantService {
+	return &ParticipantService{
+		ParticipantRepo: participantRepo,
+		EventRepo:       eventRepo,
+		StaffRepo:       staffRepo,
+		InviteRepo:      inviteRepo,
+		Payments:        payments,
+		Notifier:        notifier,
+	}
+}
+
+// JoinEvent adds a user as a participant to an event, or to its waitlist when it is full.
+// Events that require approval only take the registration as pending. Private events need the token of an invite link unless the user was invited directly.
+// Events with ticket types need one of their public ticket types that is on sale.
+// A seat of a priced ticket type is only reserved; the response carries its order with the link to pay it.
+// A promo code is redeemed together with the registration and counts against its limits right away.
+func (s *ParticipantService) JoinEvent(userID, eventID int, req models.JoinEventRequest) (*models.ParticipantResponse, error) {
+	opts := models.JoinOptions{TicketTypeID: req.TicketTypeID, PromoCode: normalizePromoCode(req.PromoCode)}
+	if req.InviteToken != "" {
+		inviteID, err := parseInviteToken(req.InviteToken, eventID)
+		if err != nil {
+			return nil, err
+		}
+		opts.InviteID = inviteID
+	}
+
+	participant, err := s.ParticipantRepo.JoinEvent(userID, eventID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	response := newParticipantResponse(participant)
+	if participant.Status == models.ParticipantStatusReserved {
+		// The seat stays reserved even if the payment can't start now; the
+		// user can start it again with the checkout endpoint
+		response.Order, err = s.Payments.Checkout(userID, eventID)
+		if err != nil {
+			log.Printf("Error starting payment of user %d in event %d: %v", userID, eventID, err)
+		}
+	}
+
+	return response, nil
 }
 
-// JoinEvent adds a user as a participant to an event
-func (s *ParticipantService) JoinEvent(userID, eventID int) error {
-	return s.ParticipantRepo.JoinEvent(userID, eventID)
+func newParticipantResponse(participant *models.Participant) *models.ParticipantResponse {
+	return &models.ParticipantResponse{
+		UserID:           participant.UserID,
+		EventID:          participant.EventID,
+		Status:           participant.Status,
+		WaitlistPosition: participant.WaitlistPosition,
+		JoinedAt:         participant.JoinedAt,
+		TicketTypeID:     participant.TicketTypeID,
+		ReservedUntil:    participant.ReservedUntil,
+	}
 }
 
-// LeaveEvent removes a user as a participant from an event
+// LeaveEvent removes a user as a participant from an event. A paid ticket is
+// refunded; a refund that fails now is retried by the order job. Whoever
+// moves up from the waitlist into the seat is notified.
 func (s *ParticipantService) LeaveEvent(userID, eventID int) error {
-	return s.ParticipantRepo.LeaveEvent(userID, eventID)
+	promoted, err := s.ParticipantRepo.LeaveEvent(userID, eventID)
+	if err != nil {
+		return err
+	}
+
+	s.Payments.RefundOrders(userID, eventID)
+	s.Payments.NotifyPromoted(promoted)
+	return nil
+}
+
+// IsParticipant checks if a user is a confirmed participant of an event and
+// reports the state of their registration otherwise, including the outcome
+// of a review by the organizer
+func (s *ParticipantService) IsParticipant(userID, eventID int) (*models.ParticipantStatusResponse, error) {
+	registration, err := s.ParticipantRepo.GetRegistration(userID, eventID)
+	if errors.Is(err, repositories.ErrNotParticipant) {
+		return &models.ParticipantStatusResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ParticipantStatusResponse{
+		IsParticipant:    registration.Status == models.ParticipantStatusConfirmed,
+		Status:           registration.Status,
+		WaitlistPosition: registration.WaitlistPosition,
+		ReviewedAt:       registration.ReviewedAt,
+		ReviewMessage:    registration.ReviewMessage,
+		CheckedInAt:      registration.CheckedInAt,
+		TicketTypeID:     registration.TicketTypeID,
+		ReservedUntil:    registration.ReservedUntil,
+	}, nil
+}
+
+// GetParticipantCount returns the number of participants for an event the
+// viewer may see and how many of them hold each public ticket type
+func (s *ParticipantService) GetParticipantCount(eventID int, viewer Viewer) (*models.ParticipantCountResponse, error) {
+	if _, err := viewEvent(s.EventRepo, s.StaffRepo, s.InviteRepo, eventID, viewer); err != nil {
+		return nil, err
+	}
+
+	count, err := s.ParticipantRepo.GetParticipantCount(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	var public []models.TicketTypeCount
+	for _, ticketType := range count.TicketTypes {
+		if ticketType.Visibility != models.TicketTypeHidden {
+			public = append(public, ticketType)
+		}
+	}
+	count.TicketTypes = public
+
+	return count, nil
+}
+
+// GetWaitlistPosition returns where a user currently stands on the waitlist of an event
+func (s *ParticipantService) GetWaitlistPosition(userID, eventID int) (*models.WaitlistPositionResponse, error) {
+	position, err := s.ParticipantRepo.GetWaitlistPosition(userID, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WaitlistPositionResponse{
+		EventID:  eventID,
+		Position: position,
+	}, nil
+}
+
+// GetWaitlist returns the waitlist of an event to its organizer and staff
+func (s *ParticipantService) GetWaitlist(eventID, organizerID int) ([]models.WaitlistEntryResponse, error) {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, organizerID, permissionViewParticipants); err != nil {
+		return nil, err
+	}
+
+	return s.ParticipantRepo.GetWaitlist(eventID)
+}
+
+// ReorderWaitlist lets the organizer or a co-organizer put the waitlist of an event in a new order
+func (s *ParticipantService) ReorderWaitlist(eventID, organizerID int, userIDs []int) ([]models.WaitlistEntryResponse, error) {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, organizerID, permissionManageEvent); err != nil {
+		return nil, err
+	}
+
+	err := s.ParticipantRepo.ReorderWaitlist(eventID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ParticipantRepo.GetWaitlist(eventID)
 }
 
-// IsParticipant checks if a user is a participant of an event
-func (s *ParticipantService) IsParticipant(userID, eventID int) (bool, error) {
-	return s.ParticipantRepo.IsParticipant(userID, eventID)
+// GetPendingRegistrations returns the applicants of an event that wait for approval
+func (s *ParticipantService) GetPendingRegistrations(eventID, organizerID int) ([]models.PendingRegistrationResponse, error) {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, organizerID, permissionViewParticipants); err != nil {
+		return nil, err
+	}
+
+	return s.ParticipantRepo.GetPendingRegistrations(eventID)
 }
 
-// GetParticipantCount returns the number of participants for an event
-func (s *ParticipantService) GetParticipantCount(eventID int) (int, error) {
-	return s.ParticipantRepo.GetParticipantCount(eventID)
+// ApproveRegistrations confirms pending applicants of an event. They take a
+// seat while there is one and go to the waitlist otherwise.
+func (s *ParticipantService) ApproveRegistrations(eventID, organizerID int, req models.ReviewRegistrationsRequest) ([]models.ParticipantResponse, error) {
+	return s.reviewRegistrations(eventID, organizerID, req, true)
+}
+
+// RejectRegistrations turns pending applicants of an event down
+func (s *ParticipantService) RejectRegistrations(eventID, organizerID int, req models.ReviewRegistrationsRequest) ([]models.ParticipantResponse, error) {
+	return s.reviewRegistrations(eventID, organizerID, req, false)
+}
+
+func (s *ParticipantService) reviewRegistrations(eventID, organizerID int, req models.ReviewRegistrationsRequest, approve bool) ([]models.ParticipantResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, organizerID, permissionManageEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewed, err := s.ParticipantRepo.ReviewRegistrations(eventID, req.UserIDs, approve, req.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.ParticipantResponse, 0, len(reviewed))
+	for i := range reviewed {
+		responses = append(responses, *newParticipantResponse(&reviewed[i]))
+	}
+	s.notifyReviewed(event, reviewed, req.Message)
+
+	return responses, nil
+}
+
+// notifyReviewed tells the applicants how their registration was decided.
+// The review already happened, so failures are only logged.
+func (s *ParticipantService) notifyReviewed(event *models.Event, reviewed []models.Participant, message string) {
+	byStatus := map[string][]int{}
+	for _, participant := range reviewed {
+		byStatus[participant.Status] = append(byStatus[participant.Status], participant.UserID)
+	}
+
+	for _, status := range []string{models.ParticipantStatusConfirmed, models.ParticipantStatusReserved, models.ParticipantStatusWaitlisted, models.ParticipantStatusRejected} {
+		userIDs := byStatus[status]
+		if len(userIDs) == 0 {
+			continue
+		}
+
+		notification := models.Notification{
+			Type:    models.NotificationRegistrationApproved,
+			EventID: event.ID,
+			Message: message,
+		}
+		switch status {
+		case models.ParticipantStatusConfirmed:
+			notification.Subject = fmt.Sprintf("Your registration for %s has been approved", event.Name)
+		case models.ParticipantStatusReserved:
+			notification.Subject = fmt.Sprintf("Your registration for %s has been approved, pay for your ticket to keep your seat", event.Name)
+		case models.ParticipantStatusWaitlisted:
+			notification.Subject = fmt.Sprintf("Your registration for %s has been approved, you are on the waitlist", event.Name)
+		default:
+			notification.Type = models.NotificationRegistrationRejected
+			notification.Subject = fmt.Sprintf("Your registration for %s has been declined", event.Name)
+		}
+
+		if err := s.Notifier.Notify(userIDs, notification); err != nil {
+			log.Printf("Error notifying applicants of event %d: %v", event.ID, err)
+		}
+	}
 }
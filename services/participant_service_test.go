@@ -0,0 +1,283 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+func TestJoinEventWaitlistsWhenFull(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	event := ts.createEvent(t, organizer.ID, 2)
+
+	var statuses []string
+	for i := 0; i < 4; i++ {
+		user := ts.createUser(t, models.RoleUser)
+		joined, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{})
+		if err != nil {
+			t.Fatalf("join %d: %v", i, err)
+		}
+		statuses = append(statuses, joined.Status)
+		if joined.Status == models.ParticipantStatusWaitlisted && joined.WaitlistPosition != i-1 {
+			t.Errorf("join %d: waitlist position = %d, want %d", i, joined.WaitlistPosition, i-1)
+		}
+	}
+
+	want := []string{
+		models.ParticipantStatusConfirmed,
+		models.ParticipantStatusConfirmed,
+		models.ParticipantStatusWaitlisted,
+		models.ParticipantStatusWaitlisted,
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Errorf("join %d: status = %s, want %s", i, statuses[i], want[i])
+		}
+	}
+
+	count, err := ts.participants.GetParticipantCount(event.ID, Viewer{})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count.Count != 2 {
+		t.Errorf("confirmed count = %d, want 2", count.Count)
+	}
+}
+
+func TestJoinEventRules(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	user := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, organizer.ID, 10)
+
+	if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrAlreadyParticipant) {
+		t.Errorf("second join: got %v, want ErrAlreadyParticipant", err)
+	}
+	if _, err := ts.participants.JoinEvent(user.ID, 9999, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrEventNotFound) {
+		t.Errorf("join missing event: got %v, want ErrEventNotFound", err)
+	}
+
+	closed := ts.createEvent(t, organizer.ID, 10)
+	if _, err := ts.events.CloseEvent(organizer.ID, closed.ID); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if _, err := ts.participants.JoinEvent(user.ID, closed.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrEventNotOpen) {
+		t.Errorf("join closed event: got %v, want ErrEventNotOpen", err)
+	}
+
+	// The user already holds one active event, four more reach the limit of five
+	for i := 0; i < 4; i++ {
+		other := ts.createEvent(t, organizer.ID, 10)
+		if _, err := ts.participants.JoinEvent(user.ID, other.ID, models.JoinEventRequest{}); err != nil {
+			t.Fatalf("join event %d: %v", i, err)
+		}
+	}
+	sixth := ts.createEvent(t, organizer.ID, 10)
+	if _, err := ts.participants.JoinEvent(user.ID, sixth.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrActiveEventLimit) {
+		t.Errorf("sixth join: got %v, want ErrActiveEventLimit", err)
+	}
+}
+
+func TestLeaveEventPromotesWaitlist(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	first := ts.createUser(t, models.RoleUser)
+	second := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, organizer.ID, 1)
+
+	if _, err := ts.participants.JoinEvent(first.ID, event.ID, models.JoinEventRequest{}); err != nil {
+		t.Fatalf("join first: %v", err)
+	}
+	if _, err := ts.participants.JoinEvent(second.ID, event.ID, models.JoinEventRequest{}); err != nil {
+		t.Fatalf("join second: %v", err)
+	}
+
+	if err := ts.participants.LeaveEvent(first.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+
+	status, err := ts.participants.IsParticipant(second.ID, event.ID)
+	if err != nil {
+		t.Fatalf("is participant: %v", err)
+	}
+	if !status.IsParticipant {
+		t.Error("waitlisted user was not promoted")
+	}
+	if len(ts.notifier.sent) != 1 || len(ts.notifier.sent[0].userIDs) != 1 || ts.notifier.sent[0].userIDs[0] != second.ID ||
+		ts.notifier.sent[0].notification.Type != models.NotificationWaitlistPromoted {
+		t.Errorf("expected the promoted user to be notified, got %+v", ts.notifier.sent)
+	}
+	if err := ts.participants.LeaveEvent(first.ID, event.ID); !errors.Is(err, repositories.ErrNotParticipant) {
+		t.Errorf("second leave: got %v, want ErrNotParticipant", err)
+	}
+}
+
+func TestConcurrentJoinsRespectCapacity(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	event := ts.createEvent(t, organizer.ID, 5)
+
+	users := make([]*models.User, 50)
+	for i := range users {
+		users[i] = ts.createUser(t, models.RoleUser)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	confirmed := 0
+	for _, user := range users {
+		wg.Add(1)
+		go func(userID int) {
+			defer wg.Done()
+			joined, err := ts.participants.JoinEvent(userID, event.ID, models.JoinEventRequest{})
+			if err != nil {
+				t.Errorf("join: %v", err)
+				return
+			}
+			if joined.Status == models.ParticipantStatusConfirmed {
+				mu.Lock()
+				confirmed++
+				mu.Unlock()
+			}
+		}(user.ID)
+	}
+	wg.Wait()
+
+	if confirmed != 5 {
+		t.Errorf("confirmed joins = %d, want 5", confirmed)
+	}
+	waitlist, err := ts.participants.GetWaitlist(event.ID, organizer.ID)
+	if err != nil {
+		t.Fatalf("waitlist: %v", err)
+	}
+	if len(waitlist) != 45 {
+		t.Errorf("waitlist length = %d, want 45", len(waitlist))
+	}
+}
+
+func TestReorderWaitlist(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	event := ts.createEvent(t, organizer.ID, 1)
+
+	var ids []int
+	for i := 0; i < 3; i++ {
+		user := ts.createUser(t, models.RoleUser)
+		if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+
+	// ids[0] holds the only seat, the other two are waiting
+	_, err := ts.participants.ReorderWaitlist(event.ID, organizer.ID, []int{ids[1]})
+	if !errors.Is(err, repositories.ErrInvalidWaitlistOrder) {
+		t.Errorf("partial order: got %v, want ErrInvalidWaitlistOrder", err)
+	}
+
+	waitlist, err := ts.participants.ReorderWaitlist(event.ID, organizer.ID, []int{ids[2], ids[1]})
+	if err != nil {
+		t.Fatalf("reorder: %v", err)
+	}
+	if waitlist[0].User.ID != ids[2] || waitlist[1].User.ID != ids[1] {
+		t.Errorf("waitlist order = [%d %d], want [%d %d]", waitlist[0].User.ID, waitlist[1].User.ID, ids[2], ids[1])
+	}
+
+	position, err := ts.participants.GetWaitlistPosition(ids[1], event.ID)
+	if err != nil {
+		t.Fatalf("position: %v", err)
+	}
+	if position.Position != 2 {
+		t.Errorf("position = %d, want 2", position.Position)
+	}
+}
+
+func TestApprovalRegistrationFlow(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	first := ts.createUser(t, models.RoleUser)
+	second := ts.createUser(t, models.RoleUser)
+	declined := ts.createUser(t, models.RoleUser)
+
+	event := ts.createEvent(t, owner.ID, 1)
+	req := updateRequest(event)
+	req.RequiresApproval = true
+	event, err := ts.events.UpdateEvent(event.ID, req, owner.ID)
+	if err != nil || !event.RequiresApproval {
+		t.Fatalf("require approval: %+v, %v", event, err)
+	}
+
+	for _, user := range []*models.User{first, second, declined} {
+		joined, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{})
+		if err != nil || joined.Status != models.ParticipantStatusPending {
+			t.Fatalf("join: %+v, %v", joined, err)
+		}
+	}
+	status, err := ts.participants.IsParticipant(first.ID, event.ID)
+	if err != nil || status.IsParticipant || status.Status != models.ParticipantStatusPending {
+		t.Errorf("pending status: %+v, %v", status, err)
+	}
+
+	if _, err := ts.participants.GetPendingRegistrations(event.ID, first.ID); !errors.Is(err, ErrNotOrganizer) {
+		t.Errorf("pending list by an applicant: got %v, want ErrNotOrganizer", err)
+	}
+	pending, err := ts.participants.GetPendingRegistrations(event.ID, owner.ID)
+	if err != nil || len(pending) != 3 {
+		t.Fatalf("pending list: %+v, %v", pending, err)
+	}
+
+	review := models.ReviewRegistrationsRequest{UserIDs: []int{first.ID, second.ID}, Message: "See you there"}
+	approved, err := ts.participants.ApproveRegistrations(event.ID, owner.ID, review)
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if len(approved) != 2 || approved[0].Status != models.ParticipantStatusConfirmed || approved[1].Status != models.ParticipantStatusWaitlisted {
+		t.Errorf("unexpected approvals %+v", approved)
+	}
+	review = models.ReviewRegistrationsRequest{UserIDs: []int{declined.ID}, Message: "The workshop is full"}
+	if _, err := ts.participants.RejectRegistrations(event.ID, owner.ID, review); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+
+	// Confirmed, waitlisted and rejected users each get their own notification
+	if len(ts.notifier.sent) != 3 {
+		t.Fatalf("expected 3 notifications, got %+v", ts.notifier.sent)
+	}
+	for i, want := range []struct {
+		userID int
+		kind   string
+	}{
+		{first.ID, models.NotificationRegistrationApproved},
+		{second.ID, models.NotificationRegistrationApproved},
+		{declined.ID, models.NotificationRegistrationRejected},
+	} {
+		sent := ts.notifier.sent[i]
+		if len(sent.userIDs) != 1 || sent.userIDs[0] != want.userID || sent.notification.Type != want.kind {
+			t.Errorf("notification %d = %+v", i, sent)
+		}
+	}
+
+	status, err = ts.participants.IsParticipant(first.ID, event.ID)
+	if err != nil || !status.IsParticipant || status.ReviewMessage != "See you there" {
+		t.Errorf("approved status: %+v, %v", status, err)
+	}
+	status, err = ts.participants.IsParticipant(second.ID, event.ID)
+	if err != nil || status.IsParticipant || status.Status != models.ParticipantStatusWaitlisted || status.WaitlistPosition != 1 {
+		t.Errorf("waitlisted status: %+v, %v", status, err)
+	}
+	status, err = ts.participants.IsParticipant(declined.ID, event.ID)
+	if err != nil || status.IsParticipant || status.Status != models.ParticipantStatusRejected ||
+		status.ReviewMessage != "The workshop is full" || status.ReviewedAt == nil {
+		t.Errorf("rejected status: %+v, %v", status, err)
+	}
+	if _, err := ts.participants.JoinEvent(declined.ID, event.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrRegistrationRejected) {
+		t.Errorf("join after rejection: got %v, want ErrRegistrationRejected", err)
+	}
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"net/http"
+
+	"github.com/event-system/models"
+)
+
+// PaymentProvider is a payment gateway. The services start a payment for an
+// order, learn its outcome from the webhooks of the gateway and ask it to
+// refund paid orders; how the money moves is up to the implementation.
+type PaymentProvider interface {
+	// Name identifies the provider in stored orders
+	Name() string
+	// CreatePayment starts the payment of a pending order and returns where the user pays it
+	CreatePayment(order *models.Order) (*models.PaymentSession, error)
+	// ParseWebhook checks that a webhook call really comes from the provider
+	// and returns the outcome of the payment it reports
+	ParseWebhook(body []byte, header http.Header) (*models.PaymentEvent, error)
+	// Refund pays the amount of a paid order back. Refunding the same order
+	// again must not pay it twice. An error wrapping ErrPaymentNotFound means
+	// the provider doesn't know the payment at all; the refund is then given
+	// up on instead of retried.
+	Refund(order *models.Order) error
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// refundBatchSize is how many refunds a run of ProcessOrders retries at most
+const refundBatchSize = 100
+
+// PaymentService handles the orders of priced tickets and their payments.
+// Without a provider payments are disabled: checkout and webhooks are refused
+// and refunds wait until a provider is configured.
+type PaymentService struct {
+	OrderRepo repositories.OrderStore
+	EventRepo repositories.EventStore
+	Provider  PaymentProvider
+	Notifier  Notifier
+}
+
+// NewPaymentService creates a new payment service instance
+func NewPaymentService(orderRepo repositories.OrderStore, eventRepo repositories.EventStore, provider PaymentProvider, notifier Notifier) *PaymentService {
+	return &PaymentService{
+		OrderRepo: orderRepo,
+		EventRepo: eventRepo,
+		Provider:  provider,
+		Notifier:  notifier,
+	}
+}
+
+// Enabled reports whether a payment provider is configured
+func (s *PaymentService) Enabled() bool {
+	return s.Provider != nil
+}
+
+// Checkout starts the payment of the pending order of a user in an event, or
+// returns the one already started. Users get a pending order when they join
+// with a priced ticket type, when they are approved or when they move up from
+// the waitlist.
+func (s *PaymentService) Checkout(userID, eventID int) (*models.OrderResponse, error) {
+	if !s.Enabled() {
+		return nil, ErrPaymentsDisabled
+	}
+
+	orders, err := s.OrderRepo.GetByUser(eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range orders {
+		order := &orders[i]
+		if order.Status != models.OrderStatusPending {
+			continue
+		}
+		if order.ProviderRef == "" {
+			session, err := s.Provider.CreatePayment(order)
+			if err != nil {
+				return nil, apperrors.Internal(err)
+			}
+			if err := s.OrderRepo.SetCheckout(order.ID, s.Provider.Name(), session); err != nil {
+				return nil, err
+			}
+			order.Provider = s.Provider.Name()
+			order.ProviderRef = session.ProviderRef
+			order.CheckoutURL = session.CheckoutURL
+		}
+		return newOrderResponse(order), nil
+	}
+
+	return nil, ErrNoPendingOrder
+}
+
+// GetOrders returns the orders of a user in an event, newest first
+func (s *PaymentService) GetOrders(userID, eventID int) ([]models.OrderResponse, error) {
+	orders, err := s.OrderRepo.GetByUser(eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.OrderResponse, 0, len(orders))
+	for i := range orders {
+		responses = append(responses, *newOrderResponse(&orders[i]))
+	}
+	return responses, nil
+}
+
+// HandleWebhook applies a webhook of the payment provider. A succeeded
+// payment confirms the reserved seat of its order, a declined one releases
+// it. A payment that arrives after the seat is gone is refunded right away.
+// Webhooks may come more than once; repeats change nothing.
+func (s *PaymentService) HandleWebhook(body []byte, header http.Header) error {
+	if !s.Enabled() {
+		return ErrPaymentsDisabled
+	}
+
+	event, err := s.Provider.ParseWebhook(body, header)
+	if err != nil {
+		return ErrInvalidWebhook.Wrap(err)
+	}
+
+	order, err := s.OrderRepo.GetByProviderRef(s.Provider.Name(), event.ProviderRef)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if event.Outcome == models.PaymentDeclined {
+		_, promoted, err := s.OrderRepo.FailPayment(order.ID, now)
+		if err != nil {
+			return err
+		}
+		s.NotifyPromoted(promoted)
+		return nil
+	}
+
+	order, err = s.OrderRepo.ConfirmPayment(order.ID, now)
+	if err != nil {
+		return err
+	}
+	if order.Status == models.OrderStatusRefunding {
+		s.refund(order)
+	}
+	return nil
+}
+
+// RefundOrders refunds the orders of a user in an event that wait for a
+// refund, which is the case after a paid participant left. Failed refunds
+// are only logged; ProcessOrders tries them again.
+func (s *PaymentService) RefundOrders(userID, eventID int) {
+	orders, err := s.OrderRepo.GetByUser(eventID, userID)
+	if err != nil {
+		log.Printf("Error getting orders to refund of user %d in event %d: %v", userID, eventID, err)
+		return
+	}
+	s.refundWaiting(orders)
+}
+
+// RefundEventOrders refunds the orders of an event that wait for a refund,
+// which is every paid order once the event is cancelled. Failed refunds are
+// only logged; ProcessOrders tries them again.
+func (s *PaymentService) RefundEventOrders(eventID int) {
+	orders, err := s.OrderRepo.GetByEvent(eventID)
+	if err != nil {
+		log.Printf("Error getting orders to refund in event %d: %v", eventID, err)
+		return
+	}
+	s.refundWaiting(orders)
+}
+
+// refundWaiting refunds the orders of a list that wait for a refund
+func (s *PaymentService) refundWaiting(orders []models.Order) {
+	for i := range orders {
+		if orders[i].Status == models.OrderStatusRefunding {
+			s.refund(&orders[i])
+		}
+	}
+}
+
+// ProcessOrders releases the reserved seats whose orders expired and retries
+// refunds that failed before. It returns the number of orders it handled.
+// The scheduler runs it every minute.
+func (s *PaymentService) ProcessOrders(now time.Time) (int, error) {
+	released, promoted, err := s.OrderRepo.ReleaseExpired(now)
+	s.NotifyPromoted(promoted)
+	if err != nil {
+		return released, err
+	}
+
+	orders, err := s.OrderRepo.GetRefunding(refundBatchSize)
+	if err != nil {
+		return released, err
+	}
+	refunded := 0
+	for i := range orders {
+		if s.refund(&orders[i]) {
+			refunded++
+		}
+	}
+
+	return released + refunded, nil
+}
+
+// NotifyPromoted tells participants who moved up from the waitlist that they
+// have a seat. A reserved seat only stays theirs until its order expires, so
+// their payment is started and the notification carries the link to it. The
+// seats are already given, so failures are only logged.
+func (s *PaymentService) NotifyPromoted(promoted []models.Participant) {
+	byEvent := map[int][]models.Participant{}
+	eventIDs := []int{}
+	for _, participant := range promoted {
+		if _, ok := byEvent[participant.EventID]; !ok {
+			eventIDs = append(eventIDs, participant.EventID)
+		}
+		byEvent[participant.EventID] = append(byEvent[participant.EventID], participant)
+	}
+
+	for _, eventID := range eventIDs {
+		event, err := s.EventRepo.GetByID(eventID)
+		if err != nil {
+			log.Printf("Error getting event %d to notify promoted participants: %v", eventID, err)
+			continue
+		}
+
+		confirmed := []int{}
+		for _, participant := range byEvent[eventID] {
+			if participant.Status != models.ParticipantStatusReserved {
+				confirmed = append(confirmed, participant.UserID)
+				continue
+			}
+			s.notifyReserved(event, &participant)
+		}
+		if len(confirmed) == 0 {
+			continue
+		}
+
+		notification := models.Notification{
+			Type:    models.NotificationWaitlistPromoted,
+			EventID: event.ID,
+			Subject: fmt.Sprintf("A seat opened up for you in %s", event.Name),
+		}
+		if err := s.Notifier.Notify(confirmed, notification); err != nil {
+			log.Printf("Error notifying promoted participants of event %d: %v", event.ID, err)
+		}
+	}
+}
+
+// notifyReserved starts the payment of a participant who was promoted into a
+// reserved seat and sends them the link. Without it they can still start the
+// payment with the checkout endpoint.
+func (s *PaymentService) notifyReserved(event *models.Event, participant *models.Participant) {
+	notification := models.Notification{
+		Type:    models.NotificationWaitlistPromoted,
+		EventID: event.ID,
+		Subject: fmt.Sprintf("A seat opened up for you in %s, pay for your ticket to keep it", event.Name),
+	}
+	if participant.ReservedUntil != nil {
+		notification.Subject = fmt.Sprintf("A seat opened up for you in %s, pay for your ticket by %s to keep it",
+			event.Name, participant.ReservedUntil.UTC().Format(time.RFC1123))
+	}
+
+	order, err := s.Checkout(participant.UserID, event.ID)
+	if err != nil {
+		log.Printf("Error starting payment of promoted user %d in event %d: %v", participant.UserID, event.ID, err)
+		notification.Message = "Start the payment from the checkout of the event."
+	} else {
+		notification.Message = fmt.Sprintf("Pay order %d here: %s", order.ID, order.CheckoutURL)
+	}
+
+	if err := s.Notifier.Notify([]int{participant.UserID}, notification); err != nil {
+		log.Printf("Error notifying promoted user %d of event %d: %v", participant.UserID, event.ID, err)
+	}
+}
+
+// refund asks the provider to pay an order back and records it. It reports
+// whether the refund went through; failures are logged. A payment the
+// provider doesn't know can never be refunded by it, so its order is marked
+// refund_failed and left for a manual refund.
+func (s *PaymentService) refund(order *models.Order) bool {
+	if !s.Enabled() {
+		log.Printf("Error refunding order %d: no payment provider is configured", order.ID)
+		return false
+	}
+	if err := s.Provider.Refund(order); err != nil {
+		log.Printf("Error refunding order %d: %v", order.ID, err)
+		if errors.Is(err, ErrPaymentNotFound) {
+			if err := s.OrderRepo.MarkRefundFailed(order.ID, time.Now()); err != nil {
+				log.Printf("Error marking refund of order %d failed: %v", order.ID, err)
+			}
+		}
+		return false
+	}
+	if err := s.OrderRepo.MarkRefunded(order.ID, time.Now()); err != nil {
+		log.Printf("Error marking order %d refunded: %v", order.ID, err)
+		return false
+	}
+	return true
+}
+
+// newOrderResponse converts an order to response format
+func newOrderResponse(order *models.Order) *models.OrderResponse {
+	response := &models.OrderResponse{
+		ID:           order.ID,
+		EventID:      order.EventID,
+		TicketTypeID: order.TicketTypeID,
+		Amount:       order.Amount,
+		Discount:     order.Discount,
+		Currency:     order.Currency,
+		Status:       order.Status,
+		ExpiresAt:    order.ExpiresAt,
+		PaidAt:       order.PaidAt,
+		RefundedAt:   order.RefundedAt,
+		CreatedAt:    order.CreatedAt,
+	}
+	if order.Status == models.OrderStatusPending {
+		response.CheckoutURL = order.CheckoutURL
+	}
+	return response
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+)
+
+// fakeRef returns the fake gateway's ref of a payment from its checkout URL
+func fakeRef(t *testing.T, order *models.OrderResponse) string {
+	t.Helper()
+
+	if order == nil || order.CheckoutURL == "" {
+		t.Fatalf("expected an order with a checkout URL, got %+v", order)
+	}
+	return strings.TrimPrefix(order.CheckoutURL, "/api/payments/fake/")
+}
+
+func TestPaidRegistration(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	payer := ts.createUser(t, models.RoleUser)
+	decliner := ts.createUser(t, models.RoleUser)
+	waiting := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 2)
+
+	standard, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{Name: "Standard", Quota: 10, Price: 1500})
+	if err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+	if standard.Price != 1500 || standard.Currency != models.DefaultCurrency {
+		t.Errorf("unexpected price of ticket type %+v", standard)
+	}
+
+	joined, err := ts.participants.JoinEvent(payer.ID, event.ID, models.JoinEventRequest{TicketTypeID: standard.ID})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if joined.Status != models.ParticipantStatusReserved || joined.ReservedUntil == nil {
+		t.Errorf("expected a reserved seat, got %+v", joined)
+	}
+	paid := fakeRef(t, joined.Order)
+	if joined.Order.Amount != 1500 || joined.Order.Status != models.OrderStatusPending {
+		t.Errorf("unexpected order %+v", joined.Order)
+	}
+	if again, err := ts.payments.Checkout(payer.ID, event.ID); err != nil || again.CheckoutURL != joined.Order.CheckoutURL {
+		t.Errorf("checkout again: got %+v (%v), want the same payment", again, err)
+	}
+
+	// A forged webhook is refused
+	if err := ts.payments.HandleWebhook([]byte(`{"payment_id":"`+paid+`","outcome":"succeeded"}`), http.Header{}); !errors.Is(err, ErrInvalidWebhook) {
+		t.Errorf("unsigned webhook: got %v, want ErrInvalidWebhook", err)
+	}
+
+	if err := ts.fakePayments.Settle(paid, models.PaymentSucceeded, 0); err != nil {
+		t.Fatalf("settle: %v", err)
+	}
+	if err := ts.fakePayments.Settle(paid, models.PaymentDeclined, 0); !errors.Is(err, ErrPaymentSettled) {
+		t.Errorf("settling twice: got %v, want ErrPaymentSettled", err)
+	}
+	if err := ts.fakePayments.Settle("fake_missing", models.PaymentSucceeded, 0); !errors.Is(err, ErrPaymentNotFound) {
+		t.Errorf("unknown payment: got %v, want ErrPaymentNotFound", err)
+	}
+	status, err := ts.participants.IsParticipant(payer.ID, event.ID)
+	if err != nil {
+		t.Fatalf("is participant: %v", err)
+	}
+	if status.Status != models.ParticipantStatusConfirmed {
+		t.Errorf("expected a paid seat to be confirmed, got %+v", status)
+	}
+	if _, err := ts.payments.Checkout(payer.ID, event.ID); !errors.Is(err, ErrNoPendingOrder) {
+		t.Errorf("checkout after paying: got %v, want ErrNoPendingOrder", err)
+	}
+
+	// A declined payment releases the seat to the waitlist
+	joined, err = ts.participants.JoinEvent(decliner.ID, event.ID, models.JoinEventRequest{TicketTypeID: standard.ID})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	declined := fakeRef(t, joined.Order)
+	joined, err = ts.participants.JoinEvent(waiting.ID, event.ID, models.JoinEventRequest{TicketTypeID: standard.ID})
+	if err != nil {
+		t.Fatalf("join full event: %v", err)
+	}
+	if joined.Status != models.ParticipantStatusWaitlisted || joined.Order != nil {
+		t.Errorf("expected a waitlisted user without an order, got %+v", joined)
+	}
+	if err := ts.fakePayments.Settle(declined, models.PaymentDeclined, 0); err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+	if status, err := ts.participants.IsParticipant(decliner.ID, event.ID); err != nil || status.Status != "" {
+		t.Errorf("expected a declined payment to release the seat, got %+v (%v)", status, err)
+	}
+	status, err = ts.participants.IsParticipant(waiting.ID, event.ID)
+	if err != nil {
+		t.Fatalf("is participant: %v", err)
+	}
+	if status.Status != models.ParticipantStatusReserved || status.ReservedUntil == nil || status.ReservedUntil.Before(time.Now().Add(time.Hour)) {
+		t.Errorf("expected the waitlisted user to move up into a reservation held for longer than a checkout, got %+v", status)
+	}
+
+	// The promoted user hears about the seat with the link to pay for it
+	order, err := ts.payments.Checkout(waiting.ID, event.ID)
+	if err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+	last := ts.notifier.sent[len(ts.notifier.sent)-1]
+	if last.notification.Type != models.NotificationWaitlistPromoted || len(last.userIDs) != 1 || last.userIDs[0] != waiting.ID ||
+		!strings.Contains(last.notification.Message, order.CheckoutURL) {
+		t.Errorf("expected the promoted user to get the checkout link, got %+v", last)
+	}
+
+	// The promoted user pays too late: the gateway calls back after the
+	// reservation expired, and the payment is refunded
+	late := fakeRef(t, order)
+	processed, err := ts.payments.ProcessOrders(time.Now().Add(48 * time.Hour))
+	if err != nil {
+		t.Fatalf("process orders: %v", err)
+	}
+	if processed != 1 {
+		t.Errorf("expected 1 expired order, got %d", processed)
+	}
+	delivered := make(chan error, 1)
+	ts.fakePayments.OnWebhook(func(body []byte, header http.Header) error {
+		err := ts.payments.HandleWebhook(body, header)
+		delivered <- err
+		return err
+	})
+	if err := ts.fakePayments.Settle(late, models.PaymentSucceeded, 10*time.Millisecond); err != nil {
+		t.Fatalf("settle late: %v", err)
+	}
+	select {
+	case err := <-delivered:
+		if err != nil {
+			t.Fatalf("late webhook: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the delayed webhook never came")
+	}
+	if !ts.fakePayments.Refunded(late) {
+		t.Error("expected a payment for an expired reservation to be refunded")
+	}
+	if status, err := ts.participants.IsParticipant(waiting.ID, event.ID); err != nil || status.Status != "" {
+		t.Errorf("expected the expired reservation to be released, got %+v (%v)", status, err)
+	}
+
+	// Leaving after paying refunds the ticket
+	if err := ts.participants.LeaveEvent(payer.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if !ts.fakePayments.Refunded(paid) {
+		t.Error("expected the ticket of a leaving user to be refunded")
+	}
+	orders, err := ts.payments.GetOrders(payer.ID, event.ID)
+	if err != nil {
+		t.Fatalf("get orders: %v", err)
+	}
+	if len(orders) != 1 || orders[0].Status != models.OrderStatusRefunded || orders[0].RefundedAt == nil {
+		t.Errorf("expected a refunded order, got %+v", orders)
+	}
+}
+
+func TestPaymentsDisabledWithoutProvider(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	payer := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	standard, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{Name: "Standard", Quota: 10, Price: 1500})
+	if err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+
+	// The provider goes away, e.g. after a restart without PAYMENT_PROVIDER
+	ts.payments.Provider = nil
+
+	if _, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{Name: "VIP", Quota: 5, Price: 5000}); !errors.Is(err, ErrPaymentsDisabled) {
+		t.Errorf("priced ticket type: got %v, want ErrPaymentsDisabled", err)
+	}
+	if _, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{Name: "Free", Quota: 5}); err != nil {
+		t.Errorf("free ticket type: %v", err)
+	}
+
+	joined, err := ts.participants.JoinEvent(payer.ID, event.ID, models.JoinEventRequest{TicketTypeID: standard.ID})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if joined.Status != models.ParticipantStatusReserved || joined.Order != nil {
+		t.Errorf("expected a reserved seat without a checkout, got %+v", joined)
+	}
+	if _, err := ts.payments.Checkout(payer.ID, event.ID); !errors.Is(err, ErrPaymentsDisabled) {
+		t.Errorf("checkout: got %v, want ErrPaymentsDisabled", err)
+	}
+	if err := ts.payments.HandleWebhook([]byte(`{}`), http.Header{}); !errors.Is(err, ErrPaymentsDisabled) {
+		t.Errorf("webhook: got %v, want ErrPaymentsDisabled", err)
+	}
+}
+
+func TestRefundOfUnknownPaymentIsNotRetried(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	payer := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	standard, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{Name: "Standard", Quota: 10, Price: 1500})
+	if err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+	joined, err := ts.participants.JoinEvent(payer.ID, event.ID, models.JoinEventRequest{TicketTypeID: standard.ID})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if err := ts.fakePayments.Settle(fakeRef(t, joined.Order), models.PaymentSucceeded, 0); err != nil {
+		t.Fatalf("settle: %v", err)
+	}
+
+	// After a restart the fake gateway has forgotten the payment
+	ts.payments.Provider = NewFakePaymentProvider()
+
+	if err := ts.participants.LeaveEvent(payer.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	processed, err := ts.payments.ProcessOrders(time.Now())
+	if err != nil {
+		t.Fatalf("process orders: %v", err)
+	}
+	if processed != 0 {
+		t.Errorf("expected nothing to retry, got %d", processed)
+	}
+	orders, err := ts.payments.GetOrders(payer.ID, event.ID)
+	if err != nil {
+		t.Fatalf("get orders: %v", err)
+	}
+	if len(orders) != 1 || orders[0].Status != models.OrderStatusRefundFailed {
+		t.Errorf("expected the refund to be given up on, got %+v", orders)
+	}
+}
+
+func TestCancellingEventRefundsPaidOrders(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	payer := ts.createUser(t, models.RoleUser)
+	slow := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	standard, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{Name: "Standard", Quota: 10, Price: 1500})
+	if err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+	refs := map[int]string{}
+	for _, user := range []*models.User{payer, slow} {
+		joined, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{TicketTypeID: standard.ID})
+		if err != nil {
+			t.Fatalf("join: %v", err)
+		}
+		refs[user.ID] = fakeRef(t, joined.Order)
+	}
+	if err := ts.fakePayments.Settle(refs[payer.ID], models.PaymentSucceeded, 0); err != nil {
+		t.Fatalf("settle: %v", err)
+	}
+
+	if _, err := ts.events.TransitionEvent(owner.ID, event.ID, models.EventTransitionRequest{Status: models.EventStatusCancelled}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if !ts.fakePayments.Refunded(refs[payer.ID]) {
+		t.Error("expected the paid order to be refunded")
+	}
+	orders, err := ts.payments.GetOrders(slow.ID, event.ID)
+	if err != nil {
+		t.Fatalf("get orders: %v", err)
+	}
+	if len(orders) != 1 || orders[0].Status != models.OrderStatusCancelled {
+		t.Errorf("expected the unpaid order to be cancelled, got %+v", orders)
+	}
+
+	// A payment that still comes in for the cancelled event is refunded
+	if err := ts.fakePayments.Settle(refs[slow.ID], models.PaymentSucceeded, 0); err != nil {
+		t.Fatalf("settle late: %v", err)
+	}
+	if !ts.fakePayments.Refunded(refs[slow.ID]) {
+		t.Error("expected a payment after the cancellation to be refunded")
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// eventPermission is something a user may be allowed to do with an event
+type eventPermission int
+
+const (
+	// permissionViewParticipants allows seeing the participants and the waitlist
+	permissionViewParticipants eventPermission = iota
+	// permissionCheckIn allows checking participants in at the door
+	permissionCheckIn
+	// permissionManageEvent allows editing, opening and closing the event and reordering the waitlist
+	permissionManageEvent
+	// permissionManageStaff allows adding and removing staff and transferring ownership
+	permissionManageStaff
+	// permissionDeleteEvent allows deleting the event
+	permissionDeleteEvent
+)
+
+// staffPermissions lists what each staff role may do. The owner may do everything.
+var staffPermissions = map[string][]eventPermission{
+	models.StaffRoleCoOrganizer: {permissionViewParticipants, permissionCheckIn, permissionManageEvent},
+	models.StaffRoleCheckIn:     {permissionViewParticipants, permissionCheckIn},
+	models.StaffRoleViewer:      {permissionViewParticipants},
+}
+
+// authorizeEvent loads an event and makes sure the user holds the given permission on it
+func authorizeEvent(eventRepo repositories.EventStore, staffRepo repositories.StaffStore, eventID, userID int, permission eventPermission) (*models.Event, error) {
+	event, err := eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.OrganizerID == userID {
+		return event, nil
+	}
+
+	role, err := staffRepo.GetStaffRole(eventID, userID)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	for _, granted := range staffPermissions[role] {
+		if granted == permission {
+			return event, nil
+		}
+	}
+
+	if role == "" {
+		return nil, ErrNotOrganizer
+	}
+	return nil, ErrStaffRoleForbidden
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// PromoCodeService handles the promo codes of events
+type PromoCodeService struct {
+	PromoCodeRepo  repositories.PromoCodeStore
+	TicketTypeRepo repositories.TicketTypeStore
+	EventRepo      repositories.EventStore
+	StaffRepo      repositories.StaffStore
+	Payments       *PaymentService
+}
+
+// NewPromoCodeService creates a new promo code service instance
+func NewPromoCodeService(promoCodeRepo repositories.PromoCodeStore, ticketTypeRepo repositories.TicketTypeStore, eventRepo repositories.EventStore, staffRepo repositories.StaffStore, payments *PaymentService) *PromoCodeService {
+	return &PromoCodeService{
+		PromoCodeRepo:  promoCodeRepo,
+		TicketTypeRepo: ticketTypeRepo,
+		EventRepo:      eventRepo,
+		StaffRepo:      staffRepo,
+		Payments:       payments,
+	}
+}
+
+// CreatePromoCode adds a promo code to an event. Codes are case-insensitive
+// and unique within the event.
+func (s *PromoCodeService) CreatePromoCode(eventID, userID int, req models.PromoCodeRequest) (*models.PromoCodeResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEditable(event); err != nil {
+		return nil, err
+	}
+	if err := s.checkPromoCodeRequest(eventID, req); err != nil {
+		return nil, err
+	}
+
+	code := &models.PromoCode{EventID: eventID}
+	applyPromoCodeRequest(code, req)
+	if err := s.PromoCodeRepo.Create(code); err != nil {
+		return nil, err
+	}
+
+	return newPromoCodeResponse(code, time.Now()), nil
+}
+
+// ListPromoCodes returns the promo codes of an event with their usage. Only
+// the owner, the staff and admins see them.
+func (s *PromoCodeService) ListPromoCodes(eventID, userID int) ([]models.PromoCodeResponse, error) {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionViewParticipants); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.PromoCodeRepo.GetByEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	responses := make([]models.PromoCodeResponse, len(codes))
+	for i := range codes {
+		responses[i] = *newPromoCodeResponse(&codes[i], now)
+	}
+	return responses, nil
+}
+
+// UpdatePromoCode changes a promo code of an event. Lowering its limits
+// doesn't undo redemptions already made.
+func (s *PromoCodeService) UpdatePromoCode(eventID, userID, codeID int, req models.PromoCodeRequest) (*models.PromoCodeResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEditable(event); err != nil {
+		return nil, err
+	}
+	if err := s.checkPromoCodeRequest(eventID, req); err != nil {
+		return nil, err
+	}
+
+	code, err := s.getPromoCode(eventID, codeID)
+	if err != nil {
+		return nil, err
+	}
+
+	applyPromoCodeRequest(code, req)
+	promoted, err := s.PromoCodeRepo.Update(code)
+	if err != nil {
+		return nil, err
+	}
+	s.Payments.NotifyPromoted(promoted)
+
+	return newPromoCodeResponse(code, time.Now()), nil
+}
+
+// DeletePromoCode removes a promo code that was never redeemed. A redeemed
+// code can be expired instead.
+func (s *PromoCodeService) DeletePromoCode(eventID, userID, codeID int) error {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent); err != nil {
+		return err
+	}
+
+	promoted, err := s.PromoCodeRepo.Delete(eventID, codeID)
+	if err != nil {
+		return err
+	}
+	s.Payments.NotifyPromoted(promoted)
+	return nil
+}
+
+// GetRedemptionReport returns every use of a promo code with the discount it
+// gave. The total leaves out released uses, whose orders were cancelled or
+// refunded.
+func (s *PromoCodeService) GetRedemptionReport(eventID, userID, codeID int) (*models.PromoCodeReportResponse, error) {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionViewParticipants); err != nil {
+		return nil, err
+	}
+
+	code, err := s.getPromoCode(eventID, codeID)
+	if err != nil {
+		return nil, err
+	}
+	redemptions, err := s.PromoCodeRepo.GetRedemptions(codeID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.PromoCodeReportResponse{
+		PromoCode:   *newPromoCodeResponse(code, time.Now()),
+		Redemptions: redemptions,
+	}
+	for _, redemption := range redemptions {
+		if redemption.ReleasedAt == nil {
+			report.TotalDiscount += redemption.Discount
+		}
+	}
+	return report, nil
+}
+
+// getPromoCode returns a promo code that belongs to the event
+func (s *PromoCodeService) getPromoCode(eventID, codeID int) (*models.PromoCode, error) {
+	code, err := s.PromoCodeRepo.GetByID(codeID)
+	if err != nil {
+		return nil, err
+	}
+	if code.EventID != eventID {
+		return nil, repositories.ErrPromoCodeNotFound
+	}
+	return code, nil
+}
+
+// checkPromoCodeRequest validates what the request tags can't: the discount,
+// the seat block against the usage limit and the ticket type of the code
+func (s *PromoCodeService) checkPromoCodeRequest(eventID int, req models.PromoCodeRequest) error {
+	switch req.DiscountType {
+	case models.PromoDiscountPercent:
+		if req.DiscountValue < 1 || req.DiscountValue > 100 {
+			return ErrInvalidDiscount
+		}
+	case models.PromoDiscountFixed:
+		if req.DiscountValue < 1 {
+			return ErrInvalidDiscount
+		}
+	default:
+		if req.DiscountValue != 0 {
+			return ErrInvalidDiscount
+		}
+	}
+
+	if req.MaxUses > 0 && req.ReservedSeats > req.MaxUses {
+		return ErrPromoSeatsAboveUses
+	}
+
+	if req.TicketTypeID != nil {
+		ticketType, err := s.TicketTypeRepo.GetByID(*req.TicketTypeID)
+		if err != nil {
+			return err
+		}
+		if ticketType.EventID != eventID {
+			return repositories.ErrTicketTypeNotFound
+		}
+	}
+	return nil
+}
+
+// applyPromoCodeRequest copies the fields of a request to a promo code
+func applyPromoCodeRequest(code *models.PromoCode, req models.PromoCodeRequest) {
+	code.Code = normalizePromoCode(req.Code)
+	code.DiscountType = req.DiscountType
+	code.DiscountValue = req.DiscountValue
+	code.TicketTypeID = req.TicketTypeID
+	code.ReservedSeats = req.ReservedSeats
+	code.MaxUses = req.MaxUses
+	code.PerUserLimit = req.PerUserLimit
+	code.ExpiresAt = req.ExpiresAt
+}
+
+// normalizePromoCode returns a code the way it is stored: trimmed and upper case
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// newPromoCodeResponse converts a promo code to response format
+func newPromoCodeResponse(code *models.PromoCode, now time.Time) *models.PromoCodeResponse {
+	return &models.PromoCodeResponse{
+		ID:            code.ID,
+		EventID:       code.EventID,
+		Code:          code.Code,
+		DiscountType:  code.DiscountType,
+		DiscountValue: code.DiscountValue,
+		TicketTypeID:  code.TicketTypeID,
+		ReservedSeats: code.ReservedSeats,
+		SeatsTaken:    code.SeatsTaken,
+		MaxUses:       code.MaxUses,
+		PerUserLimit:  code.PerUserLimit,
+		Uses:          code.Uses,
+		ExpiresAt:     code.ExpiresAt,
+		Expired:       code.ExpiresAt != nil && !code.ExpiresAt.After(now),
+		CreatedAt:     code.CreatedAt,
+		UpdatedAt:     code.UpdatedAt,
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+func TestPromoCodeValidation(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	stranger := ts.createUser(t, models.RoleOrganizer)
+	event := ts.createEvent(t, owner.ID, 10)
+	other := ts.createEvent(t, stranger.ID, 10)
+
+	otherTier, err := ts.ticketTypes.CreateTicketType(other.ID, stranger.ID, models.TicketTypeRequest{Name: "Standard", Quota: 10})
+	if err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  models.PromoCodeRequest
+		want error
+	}{
+		{"percent above 100", models.PromoCodeRequest{Code: "HALF", DiscountType: models.PromoDiscountPercent, DiscountValue: 150}, ErrInvalidDiscount},
+		{"fixed without amount", models.PromoCodeRequest{Code: "HALF", DiscountType: models.PromoDiscountFixed}, ErrInvalidDiscount},
+		{"amount without type", models.PromoCodeRequest{Code: "HALF", DiscountValue: 10}, ErrInvalidDiscount},
+		{"block above uses", models.PromoCodeRequest{Code: "HALF", ReservedSeats: 5, MaxUses: 2}, ErrPromoSeatsAboveUses},
+		{"block above capacity", models.PromoCodeRequest{Code: "HALF", ReservedSeats: 11}, repositories.ErrPromoSeatsAboveCapacity},
+		{"ticket type of another event", models.PromoCodeRequest{Code: "HALF", TicketTypeID: &otherTier.ID}, repositories.ErrTicketTypeNotFound},
+	} {
+		if _, err := ts.promoCodes.CreatePromoCode(event.ID, owner.ID, tc.req); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	if _, err := ts.promoCodes.CreatePromoCode(event.ID, stranger.ID, models.PromoCodeRequest{Code: "MINE"}); !errors.Is(err, ErrNotOrganizer) {
+		t.Errorf("create by stranger: got %v, want ErrNotOrganizer", err)
+	}
+	if _, err := ts.promoCodes.ListPromoCodes(event.ID, stranger.ID); !errors.Is(err, ErrNotOrganizer) {
+		t.Errorf("list by stranger: got %v, want ErrNotOrganizer", err)
+	}
+
+	// Codes are stored in upper case, so they clash regardless of case
+	code, err := ts.promoCodes.CreatePromoCode(event.ID, owner.ID, models.PromoCodeRequest{Code: " spring ", DiscountType: models.PromoDiscountPercent, DiscountValue: 10})
+	if err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+	if code.Code != "SPRING" {
+		t.Errorf("expected the code to be normalized, got %q", code.Code)
+	}
+	if _, err := ts.promoCodes.CreatePromoCode(event.ID, owner.ID, models.PromoCodeRequest{Code: "Spring"}); !errors.Is(err, repositories.ErrPromoCodeExists) {
+		t.Errorf("duplicate code: got %v, want ErrPromoCodeExists", err)
+	}
+	if _, err := ts.promoCodes.UpdatePromoCode(other.ID, stranger.ID, code.ID, models.PromoCodeRequest{Code: "SPRING"}); !errors.Is(err, repositories.ErrPromoCodeNotFound) {
+		t.Errorf("update through another event: got %v, want ErrPromoCodeNotFound", err)
+	}
+
+	// A ticket type can't be deleted while a code is tied to it
+	tier, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{Name: "Partner", Quota: 5})
+	if err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+	if _, err := ts.promoCodes.CreatePromoCode(event.ID, owner.ID, models.PromoCodeRequest{Code: "PARTNER", TicketTypeID: &tier.ID}); err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+	if err := ts.ticketTypes.DeleteTicketType(event.ID, owner.ID, tier.ID); !errors.Is(err, repositories.ErrTicketTypeHasPromoCodes) {
+		t.Errorf("delete the tier of a code: got %v, want ErrTicketTypeHasPromoCodes", err)
+	}
+}
+
+func TestPromoCodeDiscountsAndReport(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	buyer := ts.createUser(t, models.RoleUser)
+	friend := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	standard, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{Name: "Standard", Quota: 10, Price: 2000})
+	if err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+	spring, err := ts.promoCodes.CreatePromoCode(event.ID, owner.ID, models.PromoCodeRequest{
+		Code: "SPRING25", DiscountType: models.PromoDiscountPercent, DiscountValue: 25, PerUserLimit: 1,
+	})
+	if err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+	friends, err := ts.promoCodes.CreatePromoCode(event.ID, owner.ID, models.PromoCodeRequest{
+		Code: "FRIENDS", DiscountType: models.PromoDiscountFixed, DiscountValue: 5000,
+	})
+	if err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+
+	// A percent discount lowers the order; codes are case-insensitive
+	joined, err := ts.participants.JoinEvent(buyer.ID, event.ID, models.JoinEventRequest{TicketTypeID: standard.ID, PromoCode: "spring25"})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if joined.Order == nil || joined.Order.Amount != 1500 || joined.Order.Discount != 500 {
+		t.Errorf("expected an order of 1500 after a discount of 500, got %+v", joined.Order)
+	}
+
+	// A discount that covers the whole price confirms the seat without an order
+	joined, err = ts.participants.JoinEvent(friend.ID, event.ID, models.JoinEventRequest{TicketTypeID: standard.ID, PromoCode: "FRIENDS"})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if joined.Status != models.ParticipantStatusConfirmed || joined.Order != nil {
+		t.Errorf("expected a free confirmed seat, got %+v", joined)
+	}
+
+	// Leaving cancels the order; the code can't be used by the same user again
+	if err := ts.participants.LeaveEvent(buyer.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if _, err := ts.participants.JoinEvent(buyer.ID, event.ID, models.JoinEventRequest{TicketTypeID: standard.ID, PromoCode: "SPRING25"}); !errors.Is(err, repositories.ErrPromoCodeUserLimit) {
+		t.Errorf("rejoin: got %v, want ErrPromoCodeUserLimit", err)
+	}
+
+	report, err := ts.promoCodes.GetRedemptionReport(event.ID, owner.ID, spring.ID)
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if len(report.Redemptions) != 1 || report.Redemptions[0].ReleasedAt == nil || report.TotalDiscount != 0 || report.PromoCode.Uses != 0 {
+		t.Errorf("expected one released redemption, got %+v", report)
+	}
+	report, err = ts.promoCodes.GetRedemptionReport(event.ID, owner.ID, friends.ID)
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if len(report.Redemptions) != 1 || report.Redemptions[0].Status != models.ParticipantStatusConfirmed || report.TotalDiscount != 2000 {
+		t.Errorf("expected one redemption worth the whole price, got %+v", report)
+	}
+
+	if err := ts.promoCodes.DeletePromoCode(event.ID, owner.ID, friends.ID); !errors.Is(err, repositories.ErrPromoCodeInUse) {
+		t.Errorf("delete redeemed code: got %v, want ErrPromoCodeInUse", err)
+	}
+	unused, err := ts.promoCodes.CreatePromoCode(event.ID, owner.ID, models.PromoCodeRequest{Code: "UNUSED"})
+	if err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+	if err := ts.promoCodes.DeletePromoCode(event.ID, owner.ID, unused.ID); err != nil {
+		t.Errorf("delete unused code: %v", err)
+	}
+}
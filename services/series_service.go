@@ -0,0 +1,560 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+	"github.com/event-system/recurrence"
+	"github.com/event-system/repositories"
+)
+
+// Occurrences of a series are created as events up to seriesWindow ahead,
+// and never more than maxSeriesOccurrences upcoming ones at a time
+const (
+	seriesWindow         = 365 * 24 * time.Hour
+	maxSeriesOccurrences = 100
+)
+
+// SeriesService handles recurring event series
+type SeriesService struct {
+	SeriesRepo repositories.SeriesStore
+	EventRepo  repositories.EventStore
+	Payments   *PaymentService
+}
+
+// NewSeriesService creates a new series service instance
+func NewSeriesService(seriesRepo repositories.SeriesStore, eventRepo repositories.EventStore, payments *PaymentService) *SeriesService {
+	return &SeriesService{
+		SeriesRepo: seriesRepo,
+		EventRepo:  eventRepo,
+		Payments:   payments,
+	}
+}
+
+// CreateSeries creates a recurring series and its occurrences for the coming year
+func (s *SeriesService) CreateSeries(req models.SeriesRequest, organizerID int) (*models.SeriesResponse, error) {
+	loc, err := loadTimeZone(req.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := parseRule(req.RRule, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &models.EventSeries{
+		Name:        req.Name,
+		Description: req.Description,
+		Location:    req.Location,
+		StartTime:   req.StartTime.UTC(),
+		EndTime:     req.EndTime.UTC(),
+		Capacity:    req.Capacity,
+		OrganizerID: organizerID,
+		TimeZone:    loc.String(),
+		RRule:       rule.String(),
+		ExDates:     utcTimes(req.ExDates),
+	}
+
+	times := expandSeries(series, rule, loc, series.StartTime)
+	if len(times) == 0 {
+		return nil, ErrEmptySeries
+	}
+
+	occurrences := make([]models.Event, len(times))
+	for i, t := range times {
+		occurrences[i] = newOccurrence(series, t)
+	}
+
+	err = s.SeriesRepo.Create(series, occurrences)
+	if err != nil {
+		log.Printf("Error creating series: %v", err)
+		return nil, apperrors.Internal(err)
+	}
+
+	return newSeriesResponse(series, occurrences), nil
+}
+
+// GetSeries retrieves a series with all its occurrences
+func (s *SeriesService) GetSeries(id int) (*models.SeriesResponse, error) {
+	series, err := s.SeriesRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences, err := s.SeriesRepo.GetOccurrences(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSeriesResponse(series, occurrences), nil
+}
+
+// UpdateOccurrence edits one occurrence of a series, that occurrence and all
+// following ones, or the whole series. Only the organizer of the series may
+// do this. Occurrences that already started are never changed.
+func (s *SeriesService) UpdateOccurrence(seriesID, eventID, userID int, req models.SeriesUpdateRequest) (*models.SeriesResponse, error) {
+	series, err := s.SeriesRepo.GetByID(seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if series.OrganizerID != userID {
+		return nil, repositories.ErrNotSeriesOwner
+	}
+
+	event, err := s.EventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event.SeriesID == nil || *event.SeriesID != seriesID || event.RecurrenceID == nil {
+		return nil, ErrOccurrenceNotFound
+	}
+
+	err = checkStartTime(event, models.EventRequest{StartTime: req.StartTime})
+	if err != nil {
+		return nil, err
+	}
+
+	var change *models.SeriesChange
+	switch req.Scope {
+	case models.SeriesScopeThis:
+		if req.RRule != "" || req.ExDates != nil {
+			return nil, ErrRuleChangeScope
+		}
+
+		event.Name = req.Name
+		event.Description = req.Description
+		event.Location = req.Location
+		event.StartTime = req.StartTime
+		event.EndTime = req.EndTime
+		event.Capacity = req.Capacity
+
+		promoted, err := s.EventRepo.Update(event)
+		if err != nil {
+			log.Printf("Error updating occurrence: %v", err)
+			return nil, apperrors.Internal(err)
+		}
+		s.Payments.NotifyPromoted(promoted)
+
+		return s.GetSeries(seriesID)
+
+	case models.SeriesScopeFollowing:
+		change, err = s.planFollowing(series, event, req)
+	default:
+		change, err = s.planAll(series, event, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	promoted, err := s.SeriesRepo.Save(change)
+	if err != nil {
+		log.Printf("Error saving series: %v", err)
+		return nil, apperrors.Internal(err)
+	}
+	s.Payments.NotifyPromoted(promoted)
+
+	return s.GetSeries(change.Series.ID)
+}
+
+// planAll applies an edit of one occurrence to the whole series. Every
+// occurrence moves by as much as the edited one did.
+func (s *SeriesService) planAll(series *models.EventSeries, event *models.Event, req models.SeriesUpdateRequest) (*models.SeriesChange, error) {
+	loc, err := loadTimeZone(series.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleText := series.RRule
+	if req.RRule != "" {
+		ruleText = req.RRule
+	}
+	rule, err := parseRule(ruleText, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := req.StartTime.Sub(*event.RecurrenceID)
+	updated := editedSeries(series, req, series.StartTime.Add(delta), rule)
+	updated.ID = series.ID
+	updated.CreatedAt = series.CreatedAt
+	updated.ExDates = shiftTimes(series.ExDates, delta)
+	if req.ExDates != nil {
+		updated.ExDates = utcTimes(req.ExDates)
+	}
+
+	occurrences, err := s.SeriesRepo.GetOccurrences(series.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &models.SeriesChange{Series: updated}
+	reconcile(change, rule, loc, occurrences, delta)
+	return change, nil
+}
+
+// planFollowing ends the series right before the edited occurrence and
+// starts a new series with the edit from that occurrence on. Editing the
+// first occurrence this way is the same as editing all of them.
+func (s *SeriesService) planFollowing(series *models.EventSeries, event *models.Event, req models.SeriesUpdateRequest) (*models.SeriesChange, error) {
+	splitAt := *event.RecurrenceID
+	if !splitAt.After(series.StartTime) {
+		return s.planAll(series, event, req)
+	}
+
+	loc, err := loadTimeZone(series.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	oldRule, err := parseRule(series.RRule, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	// The old series keeps the occurrences before the split and its exception
+	// dates among them; UNTIL replaces a COUNT so it can't grow back
+	dtstart := series.StartTime.In(loc)
+	before := len(oldRule.Between(dtstart, dtstart, splitAt, 0))
+	ended := *oldRule
+	ended.Count = 0
+	ended.Until = splitAt.Add(-time.Second)
+
+	old := *series
+	old.RRule = ended.String()
+	old.ExDates = []time.Time{}
+	following := []time.Time{}
+	for _, date := range series.ExDates {
+		if date.Before(splitAt) {
+			old.ExDates = append(old.ExDates, date)
+		} else {
+			following = append(following, date)
+		}
+	}
+
+	rule := oldRule
+	if req.RRule != "" {
+		rule, err = parseRule(req.RRule, loc)
+		if err != nil {
+			return nil, err
+		}
+	} else if oldRule.Count > 0 {
+		rest := *oldRule
+		rest.Count = oldRule.Count - before
+		rule = &rest
+	}
+
+	delta := req.StartTime.Sub(splitAt)
+	updated := editedSeries(series, req, req.StartTime.UTC(), rule)
+	updated.ExDates = shiftTimes(following, delta)
+	if req.ExDates != nil {
+		updated.ExDates = utcTimes(req.ExDates)
+	}
+
+	occurrences, err := s.SeriesRepo.GetOccurrences(series.ID)
+	if err != nil {
+		return nil, err
+	}
+	moved := []models.Event{}
+	for _, occurrence := range occurrences {
+		if occurrence.RecurrenceID != nil && !occurrence.RecurrenceID.Before(splitAt) {
+			moved = append(moved, occurrence)
+		}
+	}
+
+	change := &models.SeriesChange{Series: updated, Split: &old, SplitAt: splitAt}
+	reconcile(change, rule, loc, moved, delta)
+	return change, nil
+}
+
+// DeleteSeries deletes a series with all its occurrences. Like single events
+// it can't be deleted once somebody joined one of the occurrences.
+func (s *SeriesService) DeleteSeries(id, userID int) error {
+	return s.SeriesRepo.Delete(id, userID)
+}
+
+// JoinSeries joins a user to every upcoming occurrence of a series
+func (s *SeriesService) JoinSeries(userID, seriesID int) (*models.SeriesJoinResponse, error) {
+	participants, err := s.SeriesRepo.JoinSeries(userID, seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.SeriesJoinResponse{
+		SeriesID:    seriesID,
+		Occurrences: make([]models.ParticipantResponse, len(participants)),
+	}
+	for i, participant := range participants {
+		response.Occurrences[i] = models.ParticipantResponse{
+			UserID:           participant.UserID,
+			EventID:          participant.EventID,
+			Status:           participant.Status,
+			WaitlistPosition: participant.WaitlistPosition,
+			JoinedAt:         participant.JoinedAt,
+			SeriesID:         participant.SeriesID,
+		}
+	}
+
+	return response, nil
+}
+
+// LeaveSeries takes a user out of the upcoming occurrences they joined through
+// the series. Whoever moves up from the waitlists is notified.
+func (s *SeriesService) LeaveSeries(userID, seriesID int) error {
+	promoted, err := s.SeriesRepo.LeaveSeries(userID, seriesID)
+	if err != nil {
+		return err
+	}
+	s.Payments.NotifyPromoted(promoted)
+	return nil
+}
+
+// ExtendSeries keeps every series seriesWindow ahead by creating the
+// occurrences that came into the window since the series was created or last
+// extended. Series edited while they are extended are picked up on the next
+// run. It returns the number of occurrences created.
+func (s *SeriesService) ExtendSeries() (int, error) {
+	ids, err := s.SeriesRepo.GetIDs()
+	if err != nil {
+		return 0, apperrors.Internal(err)
+	}
+
+	created := 0
+	for _, id := range ids {
+		n, err := s.extendSeries(id)
+		if errors.Is(err, repositories.ErrSeriesChanged) || errors.Is(err, repositories.ErrSeriesNotFound) {
+			continue
+		}
+		if err != nil {
+			return created, err
+		}
+		created += n
+	}
+
+	return created, nil
+}
+
+// extendSeries creates the missing occurrences of one series after the last
+// one it already has
+func (s *SeriesService) extendSeries(id int) (int, error) {
+	series, err := s.SeriesRepo.GetByID(id)
+	if err != nil {
+		return 0, err
+	}
+	existing, err := s.SeriesRepo.GetOccurrences(id)
+	if err != nil {
+		return 0, apperrors.Internal(err)
+	}
+	loc, err := loadTimeZone(series.TimeZone)
+	if err != nil {
+		return 0, err
+	}
+	rule, err := parseRule(series.RRule, loc)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	last := series.StartTime
+	upcoming := 0
+	for _, event := range existing {
+		if event.RecurrenceID != nil && event.RecurrenceID.After(last) {
+			last = *event.RecurrenceID
+		}
+		if event.StartTime.After(now) {
+			upcoming++
+		}
+	}
+
+	occurrences := []models.Event{}
+	for _, t := range expandSeries(series, rule, loc, now) {
+		if upcoming+len(occurrences) >= maxSeriesOccurrences {
+			break
+		}
+		if t.After(last) {
+			occurrences = append(occurrences, newOccurrence(series, t))
+		}
+	}
+	if len(occurrences) == 0 {
+		return 0, nil
+	}
+
+	if err := s.SeriesRepo.AddOccurrences(series, occurrences); err != nil {
+		return 0, apperrors.Internal(err)
+	}
+	return len(occurrences), nil
+}
+
+// reconcile fills change with what it takes to bring the upcoming occurrences
+// in line with the rule. An existing occurrence is kept when its recurrence ID
+// moved by delta is still generated by the rule, otherwise it is removed.
+func reconcile(change *models.SeriesChange, rule *recurrence.Rule, loc *time.Location, existing []models.Event, delta time.Duration) {
+	series := change.Series
+	now := time.Now()
+	duration := series.EndTime.Sub(series.StartTime)
+
+	wanted := expandSeries(series, rule, loc, now)
+	pending := map[int64]bool{}
+	for _, t := range wanted {
+		pending[t.Unix()] = true
+	}
+
+	for _, event := range existing {
+		// Occurrences that started already stay as they are
+		if !event.StartTime.After(now) {
+			continue
+		}
+
+		start := event.RecurrenceID.Add(delta).UTC()
+		if !pending[start.Unix()] {
+			change.Remove = append(change.Remove, event.ID)
+			continue
+		}
+		delete(pending, start.Unix())
+
+		event.Name = series.Name
+		event.Description = series.Description
+		event.Location = series.Location
+		event.StartTime = start
+		event.EndTime = start.Add(duration)
+		event.Capacity = series.Capacity
+		event.RecurrenceID = &start
+		change.Update = append(change.Update, event)
+	}
+
+	for _, t := range wanted {
+		if pending[t.Unix()] {
+			change.Create = append(change.Create, newOccurrence(series, t))
+		}
+	}
+}
+
+// expandSeries returns the start times of the occurrences of a series from
+// from on, up to seriesWindow ahead, without its exception dates
+func expandSeries(series *models.EventSeries, rule *recurrence.Rule, loc *time.Location, from time.Time) []time.Time {
+	dtstart := series.StartTime.In(loc)
+	to := time.Now().Add(seriesWindow)
+
+	times := []time.Time{}
+	for _, t := range rule.Between(dtstart, from, to, 0) {
+		if containsTime(series.ExDates, t) {
+			continue
+		}
+		times = append(times, t.UTC())
+		if len(times) == maxSeriesOccurrences {
+			break
+		}
+	}
+
+	return times
+}
+
+// editedSeries returns a series with the fields of an update request
+func editedSeries(series *models.EventSeries, req models.SeriesUpdateRequest, start time.Time, rule *recurrence.Rule) *models.EventSeries {
+	return &models.EventSeries{
+		Name:        req.Name,
+		Description: req.Description,
+		Location:    req.Location,
+		StartTime:   start,
+		EndTime:     start.Add(req.EndTime.Sub(req.StartTime)),
+		Capacity:    req.Capacity,
+		OrganizerID: series.OrganizerID,
+		TimeZone:    series.TimeZone,
+		RRule:       rule.String(),
+	}
+}
+
+// newOccurrence builds the event of a series that starts at t
+func newOccurrence(series *models.EventSeries, t time.Time) models.Event {
+	recurrenceID := t
+	return models.Event{
+		Name:         series.Name,
+		Description:  series.Description,
+		Location:     series.Location,
+		StartTime:    t,
+		EndTime:      t.Add(series.EndTime.Sub(series.StartTime)),
+		Capacity:     series.Capacity,
+		OrganizerID:  series.OrganizerID,
+		Status:       models.EventStatusPublished,
+		RecurrenceID: &recurrenceID,
+	}
+}
+
+// loadTimeZone resolves an IANA time zone name; empty means UTC
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if name == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+	return loc, nil
+}
+
+// parseRule parses an RRULE and reports what is wrong with it to the client
+func parseRule(text string, loc *time.Location) (*recurrence.Rule, error) {
+	rule, err := recurrence.Parse(text, loc)
+	if err != nil {
+		return nil, apperrors.Validation(ErrInvalidRRule.Code, ErrInvalidRRule.Message+": "+err.Error())
+	}
+	return rule, nil
+}
+
+func containsTime(times []time.Time, t time.Time) bool {
+	for _, candidate := range times {
+		if candidate.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func utcTimes(times []time.Time) []time.Time {
+	converted := make([]time.Time, len(times))
+	for i, t := range times {
+		converted[i] = t.UTC()
+	}
+	return converted
+}
+
+func shiftTimes(times []time.Time, delta time.Duration) []time.Time {
+	shifted := make([]time.Time, len(times))
+	for i, t := range times {
+		shifted[i] = t.Add(delta).UTC()
+	}
+	return shifted
+}
+
+// newSeriesResponse converts a series and its occurrences to response format
+func newSeriesResponse(series *models.EventSeries, occurrences []models.Event) *models.SeriesResponse {
+	response := &models.SeriesResponse{
+		ID:          series.ID,
+		Name:        series.Name,
+		Description: series.Description,
+		Location:    series.Location,
+		StartTime:   series.StartTime,
+		EndTime:     series.EndTime,
+		Capacity:    series.Capacity,
+		OrganizerID: series.OrganizerID,
+		TimeZone:    series.TimeZone,
+		RRule:       series.RRule,
+		ExDates:     series.ExDates,
+		CreatedAt:   series.CreatedAt,
+		UpdatedAt:   series.UpdatedAt,
+		Occurrences: make([]models.EventResponse, len(occurrences)),
+	}
+	if response.ExDates == nil {
+		response.ExDates = []time.Time{}
+	}
+	for i := range occurrences {
+		response.Occurrences[i] = *newEventResponse(&occurrences[i])
+	}
+
+	return response
+}
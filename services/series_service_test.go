@@ -0,0 +1,512 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// seriesStart is a future start time on a whole minute
+func seriesStart() time.Time {
+	return time.Now().Add(48 * time.Hour).UTC().Truncate(time.Minute)
+}
+
+// createSeries creates a two hour series with the given rule, starting at seriesStart
+func (ts *testServices) createSeries(t *testing.T, organizerID, capacity int, rrule string) *models.SeriesResponse {
+	t.Helper()
+
+	start := seriesStart()
+	series, err := ts.series.CreateSeries(models.SeriesRequest{
+		Name:      "Weekly Go Meetup",
+		Location:  "Tehran",
+		StartTime: start,
+		EndTime:   start.Add(2 * time.Hour),
+		Capacity:  capacity,
+		RRule:     rrule,
+	}, organizerID)
+	if err != nil {
+		t.Fatalf("create series: %v", err)
+	}
+	return series
+}
+
+// occurrenceUpdate edits an occurrence keeping its times unless changed by the caller
+func occurrenceUpdate(scope string, occurrence models.EventResponse) models.SeriesUpdateRequest {
+	return models.SeriesUpdateRequest{
+		Scope:     scope,
+		Name:      occurrence.Name,
+		Location:  occurrence.Location,
+		StartTime: occurrence.StartTime,
+		EndTime:   occurrence.EndTime,
+		Capacity:  occurrence.Capacity,
+	}
+}
+
+func TestCreateSeries(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	start := seriesStart()
+
+	series, err := ts.series.CreateSeries(models.SeriesRequest{
+		Name:      "Weekly Go Meetup",
+		StartTime: start,
+		EndTime:   start.Add(90 * time.Minute),
+		Capacity:  20,
+		RRule:     "RRULE:FREQ=WEEKLY;COUNT=4",
+		ExDates:   []time.Time{start.AddDate(0, 0, 7)},
+	}, organizer.ID)
+	if err != nil {
+		t.Fatalf("create series: %v", err)
+	}
+
+	if series.RRule != "FREQ=WEEKLY;COUNT=4" || series.TimeZone != "UTC" {
+		t.Errorf("rrule = %q, timezone = %q", series.RRule, series.TimeZone)
+	}
+
+	want := []time.Time{start, start.AddDate(0, 0, 14), start.AddDate(0, 0, 21)}
+	if len(series.Occurrences) != len(want) {
+		t.Fatalf("expected %d occurrences, got %d", len(want), len(series.Occurrences))
+	}
+	for i, occurrence := range series.Occurrences {
+		if !occurrence.StartTime.Equal(want[i]) || occurrence.EndTime.Sub(occurrence.StartTime) != 90*time.Minute {
+			t.Errorf("occurrence %d runs %v - %v", i, occurrence.StartTime, occurrence.EndTime)
+		}
+		if occurrence.SeriesID == nil || *occurrence.SeriesID != series.ID || !occurrence.RecurrenceID.Equal(want[i]) {
+			t.Errorf("occurrence %d is not linked to the series", i)
+		}
+	}
+
+	// Occurrences are ordinary events
+	event, err := ts.events.GetEventByID(series.Occurrences[0].ID, Viewer{})
+	if err != nil || event.SeriesID == nil {
+		t.Fatalf("get occurrence: %v", err)
+	}
+}
+
+func TestCreateSeriesRejectsBadInput(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	start := seriesStart()
+	req := models.SeriesRequest{
+		Name:      "Weekly Go Meetup",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		Capacity:  20,
+		RRule:     "FREQ=WEEKLY",
+	}
+
+	bad := req
+	bad.RRule = "FREQ=YEARLY"
+	if _, err := ts.series.CreateSeries(bad, organizer.ID); !errors.Is(err, ErrInvalidRRule) {
+		t.Errorf("expected ErrInvalidRRule, got %v", err)
+	} else if !strings.Contains(err.Error(), "FREQ") {
+		t.Errorf("expected the error to explain the problem, got %v", err)
+	}
+
+	bad = req
+	bad.TimeZone = "Mars/Olympus_Mons"
+	if _, err := ts.series.CreateSeries(bad, organizer.ID); !errors.Is(err, ErrInvalidTimeZone) {
+		t.Errorf("expected ErrInvalidTimeZone, got %v", err)
+	}
+
+	bad = req
+	bad.RRule = "FREQ=DAILY;COUNT=1"
+	bad.ExDates = []time.Time{start}
+	if _, err := ts.series.CreateSeries(bad, organizer.ID); !errors.Is(err, ErrEmptySeries) {
+		t.Errorf("expected ErrEmptySeries, got %v", err)
+	}
+}
+
+func TestCreateSeriesIsBounded(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+
+	// A daily rule without an end stops at maxSeriesOccurrences
+	series := ts.createSeries(t, organizer.ID, 10, "FREQ=DAILY")
+	if len(series.Occurrences) != maxSeriesOccurrences {
+		t.Errorf("expected %d occurrences, got %d", maxSeriesOccurrences, len(series.Occurrences))
+	}
+
+	// A weekly one stops a year ahead
+	series = ts.createSeries(t, organizer.ID, 10, "FREQ=WEEKLY")
+	last := series.Occurrences[len(series.Occurrences)-1].StartTime
+	if len(series.Occurrences) < 51 || last.After(time.Now().Add(seriesWindow)) {
+		t.Errorf("expected a year of weekly occurrences, got %d ending %v", len(series.Occurrences), last)
+	}
+}
+
+func TestUpdateOccurrenceThis(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	series := ts.createSeries(t, organizer.ID, 10, "FREQ=WEEKLY;COUNT=3")
+
+	req := occurrenceUpdate(models.SeriesScopeThis, series.Occurrences[1])
+	req.Name = "Special edition"
+	req.StartTime = req.StartTime.Add(time.Hour)
+	req.EndTime = req.EndTime.Add(3 * time.Hour)
+
+	updated, err := ts.series.UpdateOccurrence(series.ID, series.Occurrences[1].ID, organizer.ID, req)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	names := []string{}
+	for _, occurrence := range updated.Occurrences {
+		names = append(names, occurrence.Name)
+	}
+	if strings.Join(names, ",") != "Weekly Go Meetup,Special edition,Weekly Go Meetup" {
+		t.Errorf("unexpected names %v", names)
+	}
+	moved := updated.Occurrences[1]
+	if !moved.StartTime.Equal(series.Occurrences[1].StartTime.Add(time.Hour)) || !moved.RecurrenceID.Equal(*series.Occurrences[1].RecurrenceID) {
+		t.Errorf("expected the occurrence to move but keep its recurrence ID, got %+v", moved)
+	}
+
+	req.RRule = "FREQ=DAILY"
+	if _, err := ts.series.UpdateOccurrence(series.ID, series.Occurrences[1].ID, organizer.ID, req); !errors.Is(err, ErrRuleChangeScope) {
+		t.Errorf("expected ErrRuleChangeScope, got %v", err)
+	}
+
+	other := ts.createUser(t, models.RoleOrganizer)
+	req.RRule = ""
+	if _, err := ts.series.UpdateOccurrence(series.ID, series.Occurrences[1].ID, other.ID, req); !errors.Is(err, repositories.ErrNotSeriesOwner) {
+		t.Errorf("expected ErrNotSeriesOwner, got %v", err)
+	}
+
+	single := ts.createEvent(t, organizer.ID, 10)
+	if _, err := ts.series.UpdateOccurrence(series.ID, single.ID, organizer.ID, req); !errors.Is(err, ErrOccurrenceNotFound) {
+		t.Errorf("expected ErrOccurrenceNotFound, got %v", err)
+	}
+}
+
+func TestUpdateOccurrenceAll(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	user := ts.createUser(t, models.RoleUser)
+	series := ts.createSeries(t, organizer.ID, 10, "FREQ=WEEKLY;COUNT=3")
+
+	if _, err := ts.participants.JoinEvent(user.ID, series.Occurrences[2].ID, models.JoinEventRequest{}); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	// Move the second occurrence an hour later and rename it; everything follows
+	req := occurrenceUpdate(models.SeriesScopeAll, series.Occurrences[1])
+	req.Name = "Go Meetup"
+	req.StartTime = req.StartTime.Add(time.Hour)
+	req.EndTime = req.EndTime.Add(time.Hour)
+
+	updated, err := ts.series.UpdateOccurrence(series.ID, series.Occurrences[1].ID, organizer.ID, req)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	if updated.ID != series.ID || !updated.StartTime.Equal(series.StartTime.Add(time.Hour)) {
+		t.Errorf("expected the same series starting an hour later, got %+v", updated)
+	}
+	if len(updated.Occurrences) != 3 {
+		t.Fatalf("expected 3 occurrences, got %d", len(updated.Occurrences))
+	}
+	for i, occurrence := range updated.Occurrences {
+		if occurrence.ID != series.Occurrences[i].ID {
+			t.Errorf("occurrence %d was replaced instead of updated", i)
+		}
+		if occurrence.Name != "Go Meetup" || !occurrence.StartTime.Equal(series.Occurrences[i].StartTime.Add(time.Hour)) {
+			t.Errorf("occurrence %d = %s at %v", i, occurrence.Name, occurrence.StartTime)
+		}
+	}
+
+	joined, err := ts.participants.IsParticipant(user.ID, series.Occurrences[2].ID)
+	if err != nil || !joined.IsParticipant {
+		t.Errorf("expected the participant to keep their seat, got %+v, %v", joined, err)
+	}
+
+	// Shortening the rule would drop the occurrence somebody joined
+	req = occurrenceUpdate(models.SeriesScopeAll, updated.Occurrences[0])
+	req.RRule = "FREQ=WEEKLY;COUNT=2"
+	if _, err := ts.series.UpdateOccurrence(series.ID, series.Occurrences[0].ID, organizer.ID, req); !errors.Is(err, repositories.ErrOccurrenceHasParticipants) {
+		t.Fatalf("expected ErrOccurrenceHasParticipants, got %v", err)
+	}
+
+	if err := ts.participants.LeaveEvent(user.ID, series.Occurrences[2].ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	updated, err = ts.series.UpdateOccurrence(series.ID, series.Occurrences[0].ID, organizer.ID, req)
+	if err != nil {
+		t.Fatalf("update rule: %v", err)
+	}
+	if len(updated.Occurrences) != 2 || updated.RRule != "FREQ=WEEKLY;COUNT=2" {
+		t.Errorf("expected 2 occurrences with the new rule, got %d with %s", len(updated.Occurrences), updated.RRule)
+	}
+}
+
+func TestUpdateOccurrenceFollowing(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	member := ts.createUser(t, models.RoleUser)
+	series := ts.createSeries(t, organizer.ID, 10, "FREQ=WEEKLY;COUNT=4")
+
+	if _, err := ts.series.JoinSeries(member.ID, series.ID); err != nil {
+		t.Fatalf("join series: %v", err)
+	}
+
+	req := occurrenceUpdate(models.SeriesScopeFollowing, series.Occurrences[2])
+	req.Location = "Isfahan"
+
+	split, err := ts.series.UpdateOccurrence(series.ID, series.Occurrences[2].ID, organizer.ID, req)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	if split.ID == series.ID {
+		t.Fatal("expected a new series for the following occurrences")
+	}
+	if split.RRule != "FREQ=WEEKLY;COUNT=2" || len(split.Occurrences) != 2 {
+		t.Errorf("new series has rule %s and %d occurrences", split.RRule, len(split.Occurrences))
+	}
+	for i, occurrence := range split.Occurrences {
+		if occurrence.ID != series.Occurrences[i+2].ID || occurrence.Location != "Isfahan" {
+			t.Errorf("occurrence %d was not moved to the new series: %+v", i, occurrence)
+		}
+	}
+
+	old, err := ts.series.GetSeries(series.ID)
+	if err != nil {
+		t.Fatalf("get old series: %v", err)
+	}
+	if len(old.Occurrences) != 2 || !strings.Contains(old.RRule, "UNTIL=") || strings.Contains(old.RRule, "COUNT") {
+		t.Errorf("old series has rule %s and %d occurrences", old.RRule, len(old.Occurrences))
+	}
+	for _, occurrence := range old.Occurrences {
+		if occurrence.Location != "Tehran" {
+			t.Errorf("old occurrence changed: %+v", occurrence)
+		}
+	}
+
+	// The member follows the occurrences into the new series
+	if err := ts.series.LeaveSeries(member.ID, split.ID); err != nil {
+		t.Errorf("expected the member to belong to the new series: %v", err)
+	}
+	joined, err := ts.participants.IsParticipant(member.ID, old.Occurrences[0].ID)
+	if err != nil || !joined.IsParticipant {
+		t.Errorf("expected the member to stay in the old occurrences, got %+v, %v", joined, err)
+	}
+}
+
+func TestJoinSeries(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	first := ts.createUser(t, models.RoleUser)
+	second := ts.createUser(t, models.RoleUser)
+	series := ts.createSeries(t, organizer.ID, 1, "FREQ=WEEKLY;COUNT=3")
+
+	joined, err := ts.series.JoinSeries(first.ID, series.ID)
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if len(joined.Occurrences) != 3 {
+		t.Fatalf("expected 3 occurrences, got %d", len(joined.Occurrences))
+	}
+	for _, occurrence := range joined.Occurrences {
+		if occurrence.Status != models.ParticipantStatusConfirmed || occurrence.SeriesID == nil {
+			t.Errorf("unexpected participation %+v", occurrence)
+		}
+	}
+	if _, err := ts.series.JoinSeries(first.ID, series.ID); !errors.Is(err, repositories.ErrAlreadyParticipant) {
+		t.Errorf("expected ErrAlreadyParticipant, got %v", err)
+	}
+
+	joined, err = ts.series.JoinSeries(second.ID, series.ID)
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	for _, occurrence := range joined.Occurrences {
+		if occurrence.Status != models.ParticipantStatusWaitlisted || occurrence.WaitlistPosition != 1 {
+			t.Errorf("expected the second user on the waitlist, got %+v", occurrence)
+		}
+	}
+
+	// Members get a seat in occurrences added later
+	req := occurrenceUpdate(models.SeriesScopeAll, series.Occurrences[0])
+	req.RRule = "FREQ=WEEKLY;COUNT=4"
+	updated, err := ts.series.UpdateOccurrence(series.ID, series.Occurrences[0].ID, organizer.ID, req)
+	if err != nil {
+		t.Fatalf("extend series: %v", err)
+	}
+	added := updated.Occurrences[3].ID
+	if status, err := ts.participants.IsParticipant(first.ID, added); err != nil || !status.IsParticipant {
+		t.Error("expected the first member to be confirmed in the new occurrence")
+	}
+	if position, err := ts.participants.GetWaitlistPosition(second.ID, added); err != nil || position.Position != 1 {
+		t.Errorf("expected the second member first on the waitlist, got %d, %v", position, err)
+	}
+
+	// Leaving the series frees every seat for the waitlist
+	if err := ts.series.LeaveSeries(first.ID, series.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	for _, occurrence := range updated.Occurrences {
+		if status, err := ts.participants.IsParticipant(second.ID, occurrence.ID); err != nil || !status.IsParticipant {
+			t.Errorf("expected the second member to be promoted in event %d", occurrence.ID)
+		}
+	}
+	if err := ts.series.LeaveSeries(first.ID, series.ID); !errors.Is(err, repositories.ErrNotParticipant) {
+		t.Errorf("expected ErrNotParticipant, got %v", err)
+	}
+}
+
+func TestSeriesCountsOnceTowardsActiveLimit(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	user := ts.createUser(t, models.RoleUser)
+	series := ts.createSeries(t, organizer.ID, 10, "FREQ=DAILY;COUNT=10")
+
+	if _, err := ts.series.JoinSeries(user.ID, series.ID); err != nil {
+		t.Fatalf("join series: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		event := ts.createEvent(t, organizer.ID, 10)
+		if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+			t.Fatalf("join event %d: %v", i, err)
+		}
+	}
+
+	event := ts.createEvent(t, organizer.ID, 10)
+	if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrActiveEventLimit) {
+		t.Errorf("expected ErrActiveEventLimit, got %v", err)
+	}
+}
+
+func TestDeletedOccurrenceStaysDeleted(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	series := ts.createSeries(t, organizer.ID, 10, "FREQ=WEEKLY;COUNT=3")
+
+	if err := ts.events.DeleteEvent(series.Occurrences[1].ID, organizer.ID); err != nil {
+		t.Fatalf("delete occurrence: %v", err)
+	}
+
+	req := occurrenceUpdate(models.SeriesScopeAll, series.Occurrences[0])
+	req.Name = "Renamed"
+	updated, err := ts.series.UpdateOccurrence(series.ID, series.Occurrences[0].ID, organizer.ID, req)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	if len(updated.ExDates) != 1 || !updated.ExDates[0].Equal(*series.Occurrences[1].RecurrenceID) {
+		t.Errorf("expected the deleted occurrence as exception date, got %v", updated.ExDates)
+	}
+	if len(updated.Occurrences) != 2 {
+		t.Errorf("expected the deleted occurrence to stay deleted, got %d occurrences", len(updated.Occurrences))
+	}
+}
+
+func TestDeleteSeries(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	user := ts.createUser(t, models.RoleUser)
+	series := ts.createSeries(t, organizer.ID, 10, "FREQ=WEEKLY;COUNT=3")
+
+	if _, err := ts.participants.JoinEvent(user.ID, series.Occurrences[0].ID, models.JoinEventRequest{}); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if err := ts.series.DeleteSeries(series.ID, organizer.ID); !errors.Is(err, repositories.ErrEventHasParticipants) {
+		t.Fatalf("expected ErrEventHasParticipants, got %v", err)
+	}
+
+	if err := ts.participants.LeaveEvent(user.ID, series.Occurrences[0].ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if err := ts.series.DeleteSeries(series.ID, user.ID); !errors.Is(err, repositories.ErrNotSeriesOwner) {
+		t.Errorf("expected ErrNotSeriesOwner, got %v", err)
+	}
+	if err := ts.series.DeleteSeries(series.ID, organizer.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	if _, err := ts.series.GetSeries(series.ID); !errors.Is(err, repositories.ErrSeriesNotFound) {
+		t.Errorf("expected ErrSeriesNotFound, got %v", err)
+	}
+	if _, err := ts.events.GetEventByID(series.Occurrences[0].ID, Viewer{}); !errors.Is(err, repositories.ErrEventNotFound) {
+		t.Errorf("expected the occurrences to be gone, got %v", err)
+	}
+}
+
+func TestExtendSeries(t *testing.T) {
+	ts := newTestServices()
+	organizer := ts.createUser(t, models.RoleOrganizer)
+	member := ts.createUser(t, models.RoleUser)
+
+	// A daily series that only has its first occurrences yet, as if it was
+	// created a while ago and the window moved on since
+	start := seriesStart()
+	rule, err := parseRule("FREQ=DAILY", time.UTC)
+	if err != nil {
+		t.Fatalf("parse rule: %v", err)
+	}
+	series := &models.EventSeries{
+		Name:        "Daily Standup",
+		StartTime:   start,
+		EndTime:     start.Add(15 * time.Minute),
+		Capacity:    10,
+		OrganizerID: organizer.ID,
+		TimeZone:    "UTC",
+		RRule:       rule.String(),
+		ExDates:     []time.Time{},
+	}
+	occurrences := []models.Event{}
+	for _, at := range expandSeries(series, rule, time.UTC, start)[:40] {
+		occurrences = append(occurrences, newOccurrence(series, at))
+	}
+	if err := ts.store.Series().Create(series, occurrences); err != nil {
+		t.Fatalf("create series: %v", err)
+	}
+	if _, err := ts.series.JoinSeries(member.ID, series.ID); err != nil {
+		t.Fatalf("join series: %v", err)
+	}
+
+	created, err := ts.series.ExtendSeries()
+	if err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if created != maxSeriesOccurrences-40 {
+		t.Errorf("created = %d, want %d", created, maxSeriesOccurrences-40)
+	}
+
+	extended, err := ts.series.GetSeries(series.ID)
+	if err != nil {
+		t.Fatalf("get series: %v", err)
+	}
+	if len(extended.Occurrences) != maxSeriesOccurrences {
+		t.Fatalf("expected %d occurrences, got %d", maxSeriesOccurrences, len(extended.Occurrences))
+	}
+	last := extended.Occurrences[len(extended.Occurrences)-1]
+	if want := start.AddDate(0, 0, maxSeriesOccurrences-1); !last.StartTime.Equal(want) {
+		t.Errorf("last occurrence starts at %v, want %v", last.StartTime, want)
+	}
+
+	// Members of the series join the new occurrences too
+	userIDs, err := ts.store.Participants().GetParticipantUserIDs(last.ID)
+	if err != nil {
+		t.Fatalf("participants: %v", err)
+	}
+	if len(userIDs) != 1 || userIDs[0] != member.ID {
+		t.Errorf("expected the member on the new occurrence, got %v", userIDs)
+	}
+
+	created, err = ts.series.ExtendSeries()
+	if err != nil || created != 0 {
+		t.Errorf("extend again: created %d, %v", created, err)
+	}
+
+	// A series edited since it was loaded isn't extended over the edit
+	stale := *series
+	stale.UpdatedAt = series.UpdatedAt.Add(-time.Minute)
+	err = ts.store.Series().AddOccurrences(&stale, []models.Event{newOccurrence(series, last.StartTime.Add(24*time.Hour))})
+	if !errors.Is(err, repositories.ErrSeriesChanged) {
+		t.Errorf("add to stale series: got %v, want ErrSeriesChanged", err)
+	}
+}
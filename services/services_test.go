@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// testServices wires every service to one in-memory store
+type testServices struct {
+	store        *repositories.MemoryStore
+	auth         *AuthService
+	events       *EventService
+	participants *ParticipantService
+	staff        *StaffService
+	admin        *AdminService
+	series       *SeriesService
+	calendar     *CalendarService
+	invites      *InviteService
+	tickets      *TicketService
+	ticketTypes  *TicketTypeService
+	promoCodes   *PromoCodeService
+	payments     *PaymentService
+	fakePayments *FakePaymentProvider
+	notifier     *recordingNotifier
+	userCount    int
+}
+
+// recordingNotifier keeps the notifications sent by the services
+type recordingNotifier struct {
+	sent []sentNotification
+}
+
+type sentNotification struct {
+	userIDs      []int
+	notification models.Notification
+}
+
+func (n *recordingNotifier) Notify(userIDs []int, notification models.Notification) error {
+	n.sent = append(n.sent, sentNotification{userIDs: userIDs, notification: notification})
+	return nil
+}
+
+func newTestServices() *testServices {
+	store := repositories.NewMemoryStore()
+	notifier := &recordingNotifier{}
+	fakePayments := NewFakePaymentProvider()
+	payments := NewPaymentService(store.Orders(), store.Events(), fakePayments, notifier)
+	fakePayments.OnWebhook(payments.HandleWebhook)
+	return &testServices{
+		store:        store,
+		notifier:     notifier,
+		payments:     payments,
+		fakePayments: fakePayments,
+		auth:         NewAuthService(store.Users(), store.Tokens()),
+		events:       NewEventService(store.Events(), store.Participants(), store.Staff(), store.Invites(), payments, notifier),
+		participants: NewParticipantService(store.Participants(), store.Events(), store.Staff(), store.Invites(), payments, notifier),
+		staff:        NewStaffService(store.Events(), store.Staff(), store.Users()),
+		admin:        NewAdminService(store.Users(), store.Events(), store.Tokens(), payments),
+		series:       NewSeriesService(store.Series(), store.Events(), payments),
+		calendar:     NewCalendarService(store.Events(), store.Staff(), store.Invites(), store.Users(), store.Tokens()),
+		invites:      NewInviteService(store.Events(), store.Staff(), store.Invites(), store.Users(), notifier),
+		tickets:      NewTicketService(store.Participants(), store.Events(), store.Staff()),
+		ticketTypes:  NewTicketTypeService(store.TicketTypes(), store.Events(), store.Staff(), store.Invites(), payments),
+		promoCodes:   NewPromoCodeService(store.PromoCodes(), store.TicketTypes(), store.Events(), store.Staff(), payments),
+	}
+}
+
+// createUser stores a user with the given role directly, skipping password hashing
+func (ts *testServices) createUser(t *testing.T, role string) *models.User {
+	t.Helper()
+
+	ts.userCount++
+	user := &models.User{
+		Username: fmt.Sprintf("user%d", ts.userCount),
+		Email:    fmt.Sprintf("user%d@example.com", ts.userCount),
+		Password: "hashed",
+		Role:     role,
+	}
+	if err := ts.store.Users().Create(user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// createEvent creates a published event through the service, a day from now
+func (ts *testServices) createEvent(t *testing.T, organizerID, capacity int) *models.EventResponse {
+	t.Helper()
+
+	start := time.Now().Add(24 * time.Hour)
+	event, err := ts.events.CreateEvent(models.EventRequest{
+		Name:      "Go Meetup",
+		Location:  "Tehran",
+		StartTime: start,
+		EndTime:   start.Add(2 * time.Hour),
+		Capacity:  capacity,
+	}, organizerID)
+	if err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	return event
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"log"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// StaffService handles co-organizers and other staff of events
+type StaffService struct {
+	EventRepo repositories.EventStore
+	StaffRepo repositories.StaffStore
+	UserRepo  repositories.UserStore
+}
+
+// NewStaffService creates a new staff service instance
+func NewStaffService(eventRepo repositories.EventStore, staffRepo repositories.StaffStore, userRepo repositories.UserStore) *StaffService {
+	return &StaffService{
+		EventRepo: eventRepo,
+		StaffRepo: staffRepo,
+		UserRepo:  userRepo,
+	}
+}
+
+// GetStaff lists the staff of an event to its owner and staff
+func (s *StaffService) GetStaff(eventID, userID int) ([]models.StaffResponse, error) {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionViewParticipants); err != nil {
+		return nil, err
+	}
+
+	return s.StaffRepo.GetStaff(eventID)
+}
+
+// AddStaff lets the owner of an event grant a user a staff role, or change the role they have
+func (s *StaffService) AddStaff(eventID, ownerID int, req models.AddStaffRequest) ([]models.StaffResponse, error) {
+	if req.Role != models.StaffRoleCoOrganizer && req.Role != models.StaffRoleCheckIn && req.Role != models.StaffRoleViewer {
+		return nil, ErrInvalidStaffRole
+	}
+
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, ownerID, permissionManageStaff)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserID == event.OrganizerID {
+		return nil, ErrOwnerAsStaff
+	}
+
+	// Make sure the user exists
+	if _, err := s.UserRepo.GetByID(req.UserID); err != nil {
+		return nil, err
+	}
+
+	err = s.StaffRepo.AddStaff(&models.EventStaff{
+		EventID: eventID,
+		UserID:  req.UserID,
+		Role:    req.Role,
+	})
+	if err != nil {
+		log.Printf("Error adding event staff: %v", err)
+		return nil, apperrors.Internal(err)
+	}
+
+	return s.StaffRepo.GetStaff(eventID)
+}
+
+// RemoveStaff takes a staff role away. The owner may remove anyone and staff may remove themselves.
+func (s *StaffService) RemoveStaff(eventID, userID, staffUserID int) error {
+	if userID != staffUserID {
+		if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageStaff); err != nil {
+			return err
+		}
+	}
+
+	return s.StaffRepo.RemoveStaff(eventID, staffUserID)
+}
+
+// TransferOwnership hands an event over to another organizer. The previous owner becomes a co-organizer.
+func (s *StaffService) TransferOwnership(eventID, ownerID, newOwnerID int) (*models.EventResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, ownerID, permissionManageStaff)
+	if err != nil {
+		return nil, err
+	}
+
+	if newOwnerID == event.OrganizerID {
+		return nil, ErrAlreadyOwner
+	}
+
+	// Only users who may create events may own them
+	newOwner, err := s.UserRepo.GetByID(newOwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if newOwner.Role != models.RoleOrganizer && newOwner.Role != models.RoleAdmin {
+		return nil, ErrOwnerNotOrganizer
+	}
+	if newOwner.SuspendedAt != nil {
+		return nil, ErrOwnerSuspended
+	}
+
+	err = s.StaffRepo.TransferOwnership(eventID, event.OrganizerID, newOwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err = s.EventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newEventResponse(event), nil
+}
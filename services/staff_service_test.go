@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/event-system/models"
+)
+
+func TestStaffRolePermissions(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	checkIn := ts.createUser(t, models.RoleUser)
+	other := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	_, err := ts.staff.AddStaff(event.ID, owner.ID, models.AddStaffRequest{UserID: checkIn.ID, Role: models.StaffRoleCheckIn})
+	if err != nil {
+		t.Fatalf("add staff: %v", err)
+	}
+
+	// Check-in staff can see the participants but not manage the event or its staff
+	if _, err := ts.events.GetEventWithParticipants(event.ID, checkIn.ID); err != nil {
+		t.Errorf("view participants: %v", err)
+	}
+	if _, err := ts.events.CloseEvent(checkIn.ID, event.ID); !errors.Is(err, ErrStaffRoleForbidden) {
+		t.Errorf("close: got %v, want ErrStaffRoleForbidden", err)
+	}
+	_, err = ts.staff.AddStaff(event.ID, checkIn.ID, models.AddStaffRequest{UserID: other.ID, Role: models.StaffRoleViewer})
+	if !errors.Is(err, ErrStaffRoleForbidden) {
+		t.Errorf("add staff: got %v, want ErrStaffRoleForbidden", err)
+	}
+
+	_, err = ts.staff.AddStaff(event.ID, owner.ID, models.AddStaffRequest{UserID: owner.ID, Role: models.StaffRoleViewer})
+	if !errors.Is(err, ErrOwnerAsStaff) {
+		t.Errorf("add owner as staff: got %v, want ErrOwnerAsStaff", err)
+	}
+
+	// Staff may step down on their own
+	if err := ts.staff.RemoveStaff(event.ID, checkIn.ID, checkIn.ID); err != nil {
+		t.Fatalf("remove self: %v", err)
+	}
+	if _, err := ts.events.GetEventWithParticipants(event.ID, checkIn.ID); !errors.Is(err, ErrNotOrganizer) {
+		t.Errorf("view after removal: got %v, want ErrNotOrganizer", err)
+	}
+}
+
+func TestTransferOwnership(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	newOwner := ts.createUser(t, models.RoleOrganizer)
+	plainUser := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+
+	_, err := ts.staff.AddStaff(event.ID, owner.ID, models.AddStaffRequest{UserID: newOwner.ID, Role: models.StaffRoleViewer})
+	if err != nil {
+		t.Fatalf("add staff: %v", err)
+	}
+
+	if _, err := ts.staff.TransferOwnership(event.ID, owner.ID, plainUser.ID); !errors.Is(err, ErrOwnerNotOrganizer) {
+		t.Errorf("transfer to plain user: got %v, want ErrOwnerNotOrganizer", err)
+	}
+	if _, err := ts.staff.TransferOwnership(event.ID, owner.ID, owner.ID); !errors.Is(err, ErrAlreadyOwner) {
+		t.Errorf("transfer to self: got %v, want ErrAlreadyOwner", err)
+	}
+
+	transferred, err := ts.staff.TransferOwnership(event.ID, owner.ID, newOwner.ID)
+	if err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if transferred.OrganizerID != newOwner.ID {
+		t.Errorf("organizer = %d, want %d", transferred.OrganizerID, newOwner.ID)
+	}
+
+	staff, err := ts.staff.GetStaff(event.ID, newOwner.ID)
+	if err != nil {
+		t.Fatalf("get staff: %v", err)
+	}
+	if len(staff) != 1 || staff[0].User.ID != owner.ID || staff[0].Role != models.StaffRoleCoOrganizer {
+		t.Errorf("staff after transfer = %+v, want only the previous owner as co-organizer", staff)
+	}
+
+	// The previous owner can still manage the event but no longer delete it
+	if err := ts.events.DeleteEvent(event.ID, owner.ID); !errors.Is(err, ErrStaffRoleForbidden) {
+		t.Errorf("delete by previous owner: got %v, want ErrStaffRoleForbidden", err)
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	// ticketSignatureSize is how many bytes of the HMAC a ticket code carries
+	ticketSignatureSize = 16
+	// ticketQRSize is the width and height of ticket QR codes in pixels
+	ticketQRSize = 256
+	// manifestTokenType tells check-in manifests apart from other tokens
+	manifestTokenType = "checkin_manifest"
+	// scanClockSkew is how far ahead of the server the clock of a door device may run
+	scanClockSkew = 5 * time.Minute
+	// checkInLeadTime is how long before the start of an event doors may check tickets in
+	checkInLeadTime = 24 * time.Hour
+)
+
+// TicketService handles tickets of confirmed participants and checking them in at the door
+type TicketService struct {
+	ParticipantRepo repositories.ParticipantStore
+	EventRepo       repositories.EventStore
+	StaffRepo       repositories.StaffStore
+}
+
+// NewTicketService creates a new ticket service instance
+func NewTicketService(participantRepo repositories.ParticipantStore, eventRepo repositories.EventStore, staffRepo repositories.StaffStore) *TicketService {
+	return &TicketService{
+		ParticipantRepo: participantRepo,
+		EventRepo:       eventRepo,
+		StaffRepo:       staffRepo,
+	}
+}
+
+// GetTicket returns the ticket code of a confirmed participant. The code stays
+// the same as long as the registration does.
+func (s *TicketService) GetTicket(userID, eventID int) (string, error) {
+	registration, err := s.ParticipantRepo.GetRegistration(userID, eventID)
+	if err != nil {
+		return "", err
+	}
+	if registration.Status != models.ParticipantStatusConfirmed {
+		return "", ErrNoTicket
+	}
+
+	return signTicketCode(eventID, registration.ID), nil
+}
+
+// TicketQR returns the ticket of a confirmed participant as a PNG QR code
+func (s *TicketService) TicketQR(userID, eventID int) ([]byte, error) {
+	code, err := s.GetTicket(userID, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	png, err := qrcode.Encode(code, qrcode.Medium, ticketQRSize)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	return png, nil
+}
+
+// CheckIn lets the organizer or check-in staff admit the holder of a scanned ticket
+func (s *TicketService) CheckIn(eventID, staffID int, req models.CheckInRequest) (*models.CheckInResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, staffID, permissionCheckIn)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status == models.EventStatusCancelled {
+		return nil, repositories.ErrEventCancelled
+	}
+
+	participantID, err := parseTicketCode(req.TicketCode, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ParticipantRepo.CheckIn(eventID, participantID, time.Now())
+}
+
+// GetAttendance returns how many confirmed participants have checked in so far
+func (s *TicketService) GetAttendance(eventID, userID int) (*models.AttendanceResponse, error) {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionViewParticipants); err != nil {
+		return nil, err
+	}
+
+	return s.ParticipantRepo.GetAttendance(eventID)
+}
+
+// GetCheckInManifest returns the tickets of an event signed for checking in
+// offline. Devices look scanned codes up by their SHA-256 hash and verify the
+// signature with the public key, which stays the same as long as JWT_SECRET does.
+func (s *TicketService) GetCheckInManifest(eventID, staffID int) (*models.CheckInManifestResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, staffID, permissionCheckIn)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status == models.EventStatusCancelled {
+		return nil, repositories.ErrEventCancelled
+	}
+
+	participants, err := s.ParticipantRepo.GetConfirmedParticipants(eventID)
+	if err != nil {
+		return nil, err
+	}
+	tickets := make([]models.ManifestTicket, 0, len(participants))
+	for _, participant := range participants {
+		tickets = append(tickets, models.ManifestTicket{
+			Hash:        hashToken(signTicketCode(eventID, participant.ID)),
+			CheckedInAt: participant.CheckedInAt,
+		})
+	}
+
+	now := time.Now()
+	key := manifestKey()
+	manifest, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+		"typ":     manifestTokenType,
+		"evt":     eventID,
+		"iat":     now.Unix(),
+		"tickets": tickets,
+	}).SignedString(key)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return &models.CheckInManifestResponse{
+		EventID:     eventID,
+		GeneratedAt: now,
+		TicketCount: len(tickets),
+		Manifest:    manifest,
+		PublicKey:   base64.RawURLEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}, nil
+}
+
+// SyncCheckIns records check-ins that door staff made offline with the time
+// their device scanned the ticket. Bad tickets and scans from the future or
+// from before check-in opened are reported back one by one instead of failing
+// the batch, so a device with a wrong clock can't win every conflict; see
+// ParticipantStore.SyncCheckIns for how conflicts are resolved.
+func (s *TicketService) SyncCheckIns(eventID, staffID int, req models.CheckInSyncRequest) (*models.CheckInSyncResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, staffID, permissionCheckIn)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status == models.EventStatusCancelled {
+		return nil, repositories.ErrEventCancelled
+	}
+
+	response := &models.CheckInSyncResponse{
+		EventID: eventID,
+		Results: make([]models.CheckInSyncResult, len(req.CheckIns)),
+	}
+	earliest := event.StartTime.Add(-checkInLeadTime)
+	latest := time.Now().Add(scanClockSkew)
+	var checkIns []models.OfflineCheckIn
+	var indexes []int
+	for i, scan := range req.CheckIns {
+		response.Results[i].TicketCode = scan.TicketCode
+
+		participantID, err := parseTicketCode(scan.TicketCode, eventID)
+		if err != nil {
+			response.Results[i].Status = models.CheckInInvalid
+			response.Results[i].Error = ErrInvalidTicket.Code
+			continue
+		}
+		if scan.ScannedAt.Before(earliest) || scan.ScannedAt.After(latest) {
+			response.Results[i].Status = models.CheckInInvalid
+			response.Results[i].Error = ErrInvalidScanTime.Code
+			continue
+		}
+
+		// The databases keep microseconds, compare scans at the same precision
+		checkIns = append(checkIns, models.OfflineCheckIn{
+			ParticipantID: participantID,
+			ScannedAt:     scan.ScannedAt.Truncate(time.Microsecond),
+		})
+		indexes = append(indexes, i)
+	}
+
+	if len(checkIns) > 0 {
+		results, err := s.ParticipantRepo.SyncCheckIns(eventID, checkIns)
+		if err != nil {
+			return nil, err
+		}
+		for j, result := range results {
+			result.TicketCode = response.Results[indexes[j]].TicketCode
+			response.Results[indexes[j]] = result
+		}
+	}
+
+	for _, result := range response.Results {
+		switch result.Status {
+		case models.CheckInSynced:
+			response.CheckedIn++
+		case models.CheckInDuplicate:
+			response.Duplicates++
+		default:
+			response.Invalid++
+		}
+	}
+
+	return response, nil
+}
+
+// signTicketCode builds the ticket code of a registration: its ID and an HMAC
+// of the event and registration IDs, so codes can't be guessed or moved to
+// another event
+func signTicketCode(eventID, participantID int) string {
+	return fmt.Sprintf("%d.%s", participantID, base64.RawURLEncoding.EncodeToString(ticketSignature(eventID, participantID)))
+}
+
+// parseTicketCode checks the signature of a ticket code for an event and
+// returns the ID of its registration
+func parseTicketCode(code string, eventID int) (int, error) {
+	id, signature, ok := strings.Cut(strings.TrimSpace(code), ".")
+	if !ok {
+		return 0, ErrInvalidTicket
+	}
+	participantID, err := strconv.Atoi(id)
+	if err != nil || participantID <= 0 {
+		return 0, ErrInvalidTicket
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sum, ticketSignature(eventID, participantID)) {
+		return 0, ErrInvalidTicket
+	}
+
+	return participantID, nil
+}
+
+func ticketSignature(eventID, participantID int) []byte {
+	mac := hmac.New(sha256.New, signingSecret())
+	fmt.Fprintf(mac, "ticket:%d:%d", eventID, participantID)
+	return mac.Sum(nil)[:ticketSignatureSize]
+}
+
+// manifestKey returns the Ed25519 key check-in manifests are signed with. It
+// is derived from the signing secret, so every instance of the server signs
+// with the same key.
+func manifestKey() ed25519.PrivateKey {
+	seed := sha256.Sum256(append([]byte("checkin-manifest:"), signingSecret()...))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTicketCheckIn(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	door := ts.createUser(t, models.RoleUser)
+	viewer := ts.createUser(t, models.RoleUser)
+	guest := ts.createUser(t, models.RoleUser)
+	waiting := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 1)
+	other := ts.createEvent(t, owner.ID, 1)
+
+	for _, staff := range []models.AddStaffRequest{
+		{UserID: door.ID, Role: models.StaffRoleCheckIn},
+		{UserID: viewer.ID, Role: models.StaffRoleViewer},
+	} {
+		if _, err := ts.staff.AddStaff(event.ID, owner.ID, staff); err != nil {
+			t.Fatalf("add staff: %v", err)
+		}
+	}
+	for _, user := range []*models.User{guest, waiting} {
+		if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+
+	if _, err := ts.tickets.GetTicket(waiting.ID, event.ID); !errors.Is(err, ErrNoTicket) {
+		t.Errorf("waitlisted ticket: got %v, want ErrNoTicket", err)
+	}
+	if _, err := ts.tickets.GetTicket(owner.ID, event.ID); !errors.Is(err, repositories.ErrNotParticipant) {
+		t.Errorf("ticket of a non-participant: got %v, want ErrNotParticipant", err)
+	}
+	code, err := ts.tickets.GetTicket(guest.ID, event.ID)
+	if err != nil {
+		t.Fatalf("get ticket: %v", err)
+	}
+	png, err := ts.tickets.TicketQR(guest.ID, event.ID)
+	if err != nil || !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Errorf("expected a PNG QR code, got %d bytes, %v", len(png), err)
+	}
+
+	if _, err := ts.tickets.CheckIn(event.ID, viewer.ID, models.CheckInRequest{TicketCode: code}); !errors.Is(err, ErrStaffRoleForbidden) {
+		t.Errorf("check in by a viewer: got %v, want ErrStaffRoleForbidden", err)
+	}
+	// The signature of one registration doesn't work for another one
+	_, signature, _ := strings.Cut(code, ".")
+	for name, bad := range map[string]string{
+		"garbage":     "not-a-ticket",
+		"tampered":    code + "x",
+		"other event": code,
+		"swapped id":  fmt.Sprintf("%d.%s", owner.ID, signature),
+	} {
+		eventID := event.ID
+		if name == "other event" {
+			eventID = other.ID
+		}
+		if _, err := ts.tickets.CheckIn(eventID, owner.ID, models.CheckInRequest{TicketCode: bad}); !errors.Is(err, ErrInvalidTicket) {
+			t.Errorf("%s: got %v, want ErrInvalidTicket", name, err)
+		}
+	}
+
+	checkIn, err := ts.tickets.CheckIn(event.ID, door.ID, models.CheckInRequest{TicketCode: code})
+	if err != nil {
+		t.Fatalf("check in: %v", err)
+	}
+	if checkIn.User.ID != guest.ID || checkIn.CheckedInAt.IsZero() {
+		t.Errorf("unexpected check-in %+v", checkIn)
+	}
+	if _, err := ts.tickets.CheckIn(event.ID, owner.ID, models.CheckInRequest{TicketCode: code}); !errors.Is(err, repositories.ErrAlreadyCheckedIn) {
+		t.Errorf("second check-in: got %v, want ErrAlreadyCheckedIn", err)
+	}
+
+	status, err := ts.participants.IsParticipant(guest.ID, event.ID)
+	if err != nil || status.CheckedInAt == nil {
+		t.Errorf("expected the participant to see the check-in, got %+v, %v", status, err)
+	}
+	attendance, err := ts.tickets.GetAttendance(event.ID, viewer.ID)
+	if err != nil {
+		t.Fatalf("attendance: %v", err)
+	}
+	if attendance.Registered != 1 || attendance.CheckedIn != 1 || attendance.NotCheckedIn != 0 {
+		t.Errorf("unexpected attendance %+v", attendance)
+	}
+
+	// Leaving and joining again gives a new ticket; the old one stops working
+	if err := ts.participants.LeaveEvent(guest.ID, event.ID); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if _, err := ts.tickets.CheckIn(event.ID, owner.ID, models.CheckInRequest{TicketCode: code}); !errors.Is(err, repositories.ErrTicketNotFound) {
+		t.Errorf("ticket of a left participant: got %v, want ErrTicketNotFound", err)
+	}
+}
+
+func TestCheckInManifest(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	stranger := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 5)
+
+	var codes []string
+	for i := 0; i < 2; i++ {
+		user := ts.createUser(t, models.RoleUser)
+		if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+		code, err := ts.tickets.GetTicket(user.ID, event.ID)
+		if err != nil {
+			t.Fatalf("get ticket: %v", err)
+		}
+		codes = append(codes, code)
+	}
+	if _, err := ts.tickets.CheckIn(event.ID, owner.ID, models.CheckInRequest{TicketCode: codes[0]}); err != nil {
+		t.Fatalf("check in: %v", err)
+	}
+
+	if _, err := ts.tickets.GetCheckInManifest(event.ID, stranger.ID); !errors.Is(err, ErrNotOrganizer) {
+		t.Errorf("manifest for a stranger: got %v, want ErrNotOrganizer", err)
+	}
+	manifest, err := ts.tickets.GetCheckInManifest(event.ID, owner.ID)
+	if err != nil {
+		t.Fatalf("get manifest: %v", err)
+	}
+	if manifest.TicketCount != 2 {
+		t.Errorf("ticket count = %d, want 2", manifest.TicketCount)
+	}
+
+	// A device only needs the public key to trust the manifest
+	publicKey, err := base64.RawURLEncoding.DecodeString(manifest.PublicKey)
+	if err != nil {
+		t.Fatalf("decode public key: %v", err)
+	}
+	var claims struct {
+		jwt.RegisteredClaims
+		Event   int                     `json:"evt"`
+		Tickets []models.ManifestTicket `json:"tickets"`
+	}
+	_, err = jwt.ParseWithClaims(manifest.Manifest, &claims, func(*jwt.Token) (interface{}, error) {
+		return ed25519.PublicKey(publicKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}))
+	if err != nil {
+		t.Fatalf("verify manifest: %v", err)
+	}
+	if claims.Event != event.ID || len(claims.Tickets) != 2 {
+		t.Fatalf("unexpected manifest claims %+v", claims)
+	}
+	for i, ticket := range claims.Tickets {
+		if ticket.Hash != hashToken(codes[i]) {
+			t.Errorf("ticket %d: hash doesn't match the ticket code", i)
+		}
+		if (ticket.CheckedInAt != nil) != (i == 0) {
+			t.Errorf("ticket %d: checked in at %v", i, ticket.CheckedInAt)
+		}
+	}
+
+	// Tampering breaks the signature
+	tampered := manifest.Manifest[:len(manifest.Manifest)-2] + "xx"
+	if _, err := jwt.Parse(tampered, func(*jwt.Token) (interface{}, error) {
+		return ed25519.PublicKey(publicKey), nil
+	}); err == nil {
+		t.Error("expected a tampered manifest to fail verification")
+	}
+}
+
+func TestSyncCheckInsResolvesConflicts(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	door := ts.createUser(t, models.RoleUser)
+	// Doors open a day before the start, so the scans below fall inside
+	start := time.Now().Add(2 * time.Hour)
+	event, err := ts.events.CreateEvent(models.EventRequest{Name: "Go Meetup", StartTime: start, EndTime: start.Add(time.Hour), Capacity: 5}, owner.ID)
+	if err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	if _, err := ts.staff.AddStaff(event.ID, owner.ID, models.AddStaffRequest{UserID: door.ID, Role: models.StaffRoleCheckIn}); err != nil {
+		t.Fatalf("add staff: %v", err)
+	}
+
+	guest := ts.createUser(t, models.RoleUser)
+	other := ts.createUser(t, models.RoleUser)
+	var codes []string
+	for _, user := range []*models.User{guest, other} {
+		if _, err := ts.participants.JoinEvent(user.ID, event.ID, models.JoinEventRequest{}); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+		code, err := ts.tickets.GetTicket(user.ID, event.ID)
+		if err != nil {
+			t.Fatalf("get ticket: %v", err)
+		}
+		codes = append(codes, code)
+	}
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	// The north door synced first, but the south door scanned the guest earlier
+	north := models.CheckInSyncRequest{CheckIns: []models.OfflineCheckInRequest{
+		{TicketCode: codes[0], ScannedAt: base.Add(10 * time.Minute)},
+		{TicketCode: codes[1], ScannedAt: base.Add(12 * time.Minute)},
+		{TicketCode: codes[1], ScannedAt: base.Add(11 * time.Minute)},
+		{TicketCode: "forged.ticket", ScannedAt: base},
+		{TicketCode: codes[0], ScannedAt: time.Now().Add(time.Hour)},
+		{TicketCode: codes[1], ScannedAt: start.Add(-checkInLeadTime - time.Minute)},
+	}}
+	synced, err := ts.tickets.SyncCheckIns(event.ID, door.ID, north)
+	if err != nil {
+		t.Fatalf("sync north: %v", err)
+	}
+	if synced.CheckedIn != 2 || synced.Duplicates != 1 || synced.Invalid != 3 {
+		t.Errorf("unexpected north summary %+v", synced)
+	}
+	want := []struct {
+		status string
+		error  string
+	}{
+		{models.CheckInSynced, ""},
+		{models.CheckInDuplicate, ""},
+		{models.CheckInSynced, ""},
+		{models.CheckInInvalid, ErrInvalidTicket.Code},
+		{models.CheckInInvalid, ErrInvalidScanTime.Code},
+		{models.CheckInInvalid, ErrInvalidScanTime.Code},
+	}
+	for i, result := range synced.Results {
+		if result.TicketCode != north.CheckIns[i].TicketCode || result.Status != want[i].status || result.Error != want[i].error {
+			t.Errorf("north result %d = %+v, want %s %s", i, result, want[i].status, want[i].error)
+		}
+	}
+	// Within a batch the earlier scan of the other ticket is the one kept
+	if at := synced.Results[1].CheckedInAt; at == nil || !at.Equal(base.Add(11*time.Minute)) {
+		t.Errorf("duplicate reports check-in at %v, want the earlier scan", at)
+	}
+
+	south := models.CheckInSyncRequest{CheckIns: []models.OfflineCheckInRequest{
+		{TicketCode: codes[0], ScannedAt: base.Add(5 * time.Minute)},
+		{TicketCode: codes[1], ScannedAt: base.Add(11 * time.Minute)},
+	}}
+	synced, err = ts.tickets.SyncCheckIns(event.ID, door.ID, south)
+	if err != nil {
+		t.Fatalf("sync south: %v", err)
+	}
+	first := synced.Results[0]
+	if first.Status != models.CheckInSynced || first.SupersededAt == nil || !first.SupersededAt.Equal(base.Add(10*time.Minute)) {
+		t.Errorf("expected the earlier scan to replace the recorded one, got %+v", first)
+	}
+	// A scan at the same time as the recorded one doesn't replace it
+	if synced.Results[1].Status != models.CheckInDuplicate {
+		t.Errorf("same time scan: got %+v, want a duplicate", synced.Results[1])
+	}
+
+	// Syncing the same batches again changes nothing
+	for _, batch := range []models.CheckInSyncRequest{north, south} {
+		again, err := ts.tickets.SyncCheckIns(event.ID, door.ID, batch)
+		if err != nil {
+			t.Fatalf("sync again: %v", err)
+		}
+		if again.CheckedIn != 0 {
+			t.Errorf("expected no new check-ins, got %+v", again)
+		}
+	}
+	status, err := ts.participants.IsParticipant(guest.ID, event.ID)
+	if err != nil || status.CheckedInAt == nil || !status.CheckedInAt.Equal(base.Add(5*time.Minute)) {
+		t.Errorf("expected the earliest scan to win, got %+v, %v", status, err)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"time"
+
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+// TicketTypeService handles the ticket types (tiers) of events
+type TicketTypeService struct {
+	TicketTypeRepo repositories.TicketTypeStore
+	EventRepo      repositories.EventStore
+	StaffRepo      repositories.StaffStore
+	InviteRepo     repositories.InviteStore
+	Payments       *PaymentService
+}
+
+// NewTicketTypeService creates a new ticket type service instance
+func NewTicketTypeService(ticketTypeRepo repositories.TicketTypeStore, eventRepo repositories.EventStore, staffRepo repositories.StaffStore, inviteRepo repositories.InviteStore, payments *PaymentService) *TicketTypeService {
+	return &TicketTypeService{
+		TicketTypeRepo: ticketTypeRepo,
+		EventRepo:      eventRepo,
+		StaffRepo:      staffRepo,
+		InviteRepo:     inviteRepo,
+		Payments:       payments,
+	}
+}
+
+// CreateTicketType adds a ticket type to an event. From then on everyone who
+// joins the event has to choose one of its ticket types.
+func (s *TicketTypeService) CreateTicketType(eventID, userID int, req models.TicketTypeRequest) (*models.TicketTypeResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEditable(event); err != nil {
+		return nil, err
+	}
+	if err := s.checkTicketTypeRequest(req); err != nil {
+		return nil, err
+	}
+
+	ticketType := &models.TicketType{EventID: eventID}
+	applyTicketTypeRequest(ticketType, req)
+	if err := s.TicketTypeRepo.Create(ticketType); err != nil {
+		return nil, err
+	}
+
+	return newTicketTypeResponse(ticketType, time.Now()), nil
+}
+
+// ListTicketTypes returns the ticket types of an event the viewer may see.
+// Hidden ones are only shown to admins, the owner and the staff.
+func (s *TicketTypeService) ListTicketTypes(eventID int, viewer Viewer) ([]models.TicketTypeResponse, error) {
+	if _, err := viewEvent(s.EventRepo, s.StaffRepo, s.InviteRepo, eventID, viewer); err != nil {
+		return nil, err
+	}
+
+	showHidden := viewer.Role == models.RoleAdmin
+	if !showHidden && viewer.UserID != 0 {
+		_, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, viewer.UserID, permissionViewParticipants)
+		showHidden = err == nil
+	}
+
+	ticketTypes, err := s.TicketTypeRepo.GetByEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	responses := []models.TicketTypeResponse{}
+	for i := range ticketTypes {
+		if ticketTypes[i].Visibility == models.TicketTypeHidden && !showHidden {
+			continue
+		}
+		responses = append(responses, *newTicketTypeResponse(&ticketTypes[i], now))
+	}
+	return responses, nil
+}
+
+// UpdateTicketType changes a ticket type of an event. The quota can't drop
+// below the tickets already sold; raising it promotes waitlisted users. A new
+// price doesn't change orders that already exist.
+func (s *TicketTypeService) UpdateTicketType(eventID, userID, ticketTypeID int, req models.TicketTypeRequest) (*models.TicketTypeResponse, error) {
+	event, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEditable(event); err != nil {
+		return nil, err
+	}
+	if err := s.checkTicketTypeRequest(req); err != nil {
+		return nil, err
+	}
+
+	ticketType, err := s.TicketTypeRepo.GetByID(ticketTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if ticketType.EventID != eventID {
+		return nil, repositories.ErrTicketTypeNotFound
+	}
+
+	applyTicketTypeRequest(ticketType, req)
+	promoted, err := s.TicketTypeRepo.Update(ticketType)
+	if err != nil {
+		return nil, err
+	}
+	s.Payments.NotifyPromoted(promoted)
+
+	return newTicketTypeResponse(ticketType, time.Now()), nil
+}
+
+// DeleteTicketType removes a ticket type nobody has registered with and no
+// promo code is tied to
+func (s *TicketTypeService) DeleteTicketType(eventID, userID, ticketTypeID int) error {
+	if _, err := authorizeEvent(s.EventRepo, s.StaffRepo, eventID, userID, permissionManageEvent); err != nil {
+		return err
+	}
+
+	return s.TicketTypeRepo.Delete(eventID, ticketTypeID)
+}
+
+// checkTicketTypeRequest makes sure the sales of a ticket type end after they
+// start and that a priced ticket type can be paid for
+func (s *TicketTypeService) checkTicketTypeRequest(req models.TicketTypeRequest) error {
+	if req.SalesStartsAt != nil && req.SalesEndsAt != nil && !req.SalesEndsAt.After(*req.SalesStartsAt) {
+		return ErrInvalidSalesWindow
+	}
+	if req.Price > 0 && !s.Payments.Enabled() {
+		return ErrPaymentsDisabled
+	}
+	return nil
+}
+
+// applyTicketTypeRequest copies the fields of a request to a ticket type
+func applyTicketTypeRequest(ticketType *models.TicketType, req models.TicketTypeRequest) {
+	ticketType.Name = req.Name
+	ticketType.Quota = req.Quota
+	ticketType.SalesStartsAt = req.SalesStartsAt
+	ticketType.SalesEndsAt = req.SalesEndsAt
+	ticketType.Visibility = req.Visibility
+	if ticketType.Visibility == "" {
+		ticketType.Visibility = models.TicketTypePublic
+	}
+	ticketType.Price = req.Price
+	ticketType.Currency = req.Currency
+	if ticketType.Currency == "" {
+		ticketType.Currency = models.DefaultCurrency
+	}
+}
+
+// newTicketTypeResponse converts a ticket type to response format
+func newTicketTypeResponse(ticketType *models.TicketType, now time.Time) *models.TicketTypeResponse {
+	return &models.TicketTypeResponse{
+		ID:            ticketType.ID,
+		EventID:       ticketType.EventID,
+		Name:          ticketType.Name,
+		Quota:         ticketType.Quota,
+		Sold:          ticketType.Sold,
+		Available:     max(ticketType.Quota-ticketType.Sold, 0),
+		SalesStartsAt: ticketType.SalesStartsAt,
+		SalesEndsAt:   ticketType.SalesEndsAt,
+		Visibility:    ticketType.Visibility,
+		Price:         ticketType.Price,
+		Currency:      ticketType.Currency,
+		OnSale:        repositories.CheckTicketSales(ticketType, now) == nil,
+		CreatedAt:     ticketType.CreatedAt,
+		UpdatedAt:     ticketType.UpdatedAt,
+	}
+}
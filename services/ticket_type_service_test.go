@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/event-system/models"
+	"github.com/event-system/repositories"
+)
+
+func TestTicketTypes(t *testing.T) {
+	ts := newTestServices()
+	owner := ts.createUser(t, models.RoleOrganizer)
+	viewer := ts.createUser(t, models.RoleUser)
+	student := ts.createUser(t, models.RoleUser)
+	guest := ts.createUser(t, models.RoleUser)
+	event := ts.createEvent(t, owner.ID, 10)
+	if _, err := ts.staff.AddStaff(event.ID, owner.ID, models.AddStaffRequest{UserID: viewer.ID, Role: models.StaffRoleViewer}); err != nil {
+		t.Fatalf("add staff: %v", err)
+	}
+
+	tomorrow := time.Now().Add(24 * time.Hour)
+	today := time.Now().Add(-time.Hour)
+	if _, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{
+		Name: "Broken", Quota: 1, SalesStartsAt: &tomorrow, SalesEndsAt: &today,
+	}); !errors.Is(err, ErrInvalidSalesWindow) {
+		t.Errorf("sales ending before they start: got %v, want ErrInvalidSalesWindow", err)
+	}
+	if _, err := ts.ticketTypes.CreateTicketType(event.ID, viewer.ID, models.TicketTypeRequest{Name: "Standard", Quota: 5}); !errors.Is(err, ErrStaffRoleForbidden) {
+		t.Errorf("create by a viewer: got %v, want ErrStaffRoleForbidden", err)
+	}
+
+	studentTier, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{Name: "Student", Quota: 1})
+	if err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+	if studentTier.Visibility != models.TicketTypePublic || !studentTier.OnSale || studentTier.Available != 1 {
+		t.Errorf("unexpected ticket type %+v", studentTier)
+	}
+	vip, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{Name: "VIP", Quota: 2, Visibility: models.TicketTypeHidden})
+	if err != nil {
+		t.Fatalf("create hidden ticket type: %v", err)
+	}
+	soon, err := ts.ticketTypes.CreateTicketType(event.ID, owner.ID, models.TicketTypeRequest{Name: "Standard", Quota: 5, SalesStartsAt: &tomorrow})
+	if err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+	if soon.OnSale {
+		t.Error("expected a ticket type whose sales start tomorrow not to be on sale")
+	}
+
+	// Attendees don't see hidden ticket types, the staff does
+	for _, tc := range []struct {
+		viewer Viewer
+		want   int
+	}{
+		{Viewer{}, 2},
+		{Viewer{UserID: guest.ID}, 2},
+		{Viewer{UserID: viewer.ID}, 3},
+		{Viewer{UserID: guest.ID, Role: models.RoleAdmin}, 3},
+	} {
+		list, err := ts.ticketTypes.ListTicketTypes(event.ID, tc.viewer)
+		if err != nil || len(list) != tc.want {
+			t.Errorf("viewer %+v: got %d ticket types (%v), want %d", tc.viewer, len(list), err, tc.want)
+		}
+	}
+
+	if _, err := ts.participants.JoinEvent(guest.ID, event.ID, models.JoinEventRequest{}); !errors.Is(err, repositories.ErrTicketTypeRequired) {
+		t.Errorf("join without ticket type: got %v, want ErrTicketTypeRequired", err)
+	}
+	if _, err := ts.participants.JoinEvent(guest.ID, event.ID, models.JoinEventRequest{TicketTypeID: vip.ID}); !errors.Is(err, repositories.ErrTicketTypeNotFound) {
+		t.Errorf("join with hidden ticket type: got %v, want ErrTicketTypeNotFound", err)
+	}
+	if _, err := ts.participants.JoinEvent(guest.ID, event.ID, models.JoinEventRequest{TicketTypeID: soon.ID}); !errors.Is(err, repositories.ErrTicketSalesNotOpen) {
+		t.Errorf("join before sales start: got %v, want ErrTicketSalesNotOpen", err)
+	}
+
+	joined, err := ts.participants.JoinEvent(student.ID, event.ID, models.JoinEventRequest{TicketTypeID: studentTier.ID})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if joined.Status != models.ParticipantStatusConfirmed || joined.TicketTypeID == nil || *joined.TicketTypeID != studentTier.ID {
+		t.Errorf("unexpected registration %+v", joined)
+	}
+	waiting, err := ts.participants.JoinEvent(guest.ID, event.ID, models.JoinEventRequest{TicketTypeID: studentTier.ID})
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if waiting.Status != models.ParticipantStatusWaitlisted {
+		t.Errorf("sold out ticket type: got status %q, want waitlisted", waiting.Status)
+	}
+
+	// The public count leaves hidden ticket types out
+	count, err := ts.participants.GetParticipantCount(event.ID, Viewer{})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count.Count != 1 || len(count.TicketTypes) != 2 || count.TicketTypes[0].TicketTypeID != studentTier.ID || count.TicketTypes[0].Count != 1 {
+		t.Errorf("unexpected participant count %+v", count)
+	}
+
+	// More student tickets go to the waitlist right away
+	updated, err := ts.ticketTypes.UpdateTicketType(event.ID, owner.ID, studentTier.ID, models.TicketTypeRequest{Name: "Student", Quota: 2})
+	if err != nil {
+		t.Fatalf("update ticket type: %v", err)
+	}
+	if updated.Sold != 2 || updated.Available != 0 {
+		t.Errorf("expected the waitlisted student to get the new ticket, got %+v", updated)
+	}
+	if _, err := ts.ticketTypes.UpdateTicketType(event.ID, owner.ID, studentTier.ID, models.TicketTypeRequest{Name: "Student", Quota: 1}); !errors.Is(err, repositories.ErrQuotaBelowSold) {
+		t.Errorf("quota below sold: got %v, want ErrQuotaBelowSold", err)
+	}
+	if _, err := ts.ticketTypes.UpdateTicketType(event.ID, owner.ID, vip.ID, models.TicketTypeRequest{Name: "Standard", Quota: 2}); !errors.Is(err, repositories.ErrTicketTypeExists) {
+		t.Errorf("duplicate name: got %v, want ErrTicketTypeExists", err)
+	}
+
+	other := ts.createEvent(t, owner.ID, 10)
+	if _, err := ts.ticketTypes.UpdateTicketType(other.ID, owner.ID, vip.ID, models.TicketTypeRequest{Name: "VIP", Quota: 2}); !errors.Is(err, repositories.ErrTicketTypeNotFound) {
+		t.Errorf("ticket type of another event: got %v, want ErrTicketTypeNotFound", err)
+	}
+	if err := ts.ticketTypes.DeleteTicketType(event.ID, owner.ID, studentTier.ID); !errors.Is(err, repositories.ErrTicketTypeInUse) {
+		t.Errorf("delete used ticket type: got %v, want ErrTicketTypeInUse", err)
+	}
+	if err := ts.ticketTypes.DeleteTicketType(event.ID, owner.ID, vip.ID); err != nil {
+		t.Errorf("delete ticket type: %v", err)
+	}
+}
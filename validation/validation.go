@@ -0,0 +1,144 @@
+// Package validation checks request structs against their validate struct
+// tags and reports every invalid field as an apperrors validation error.
+//
+// On top of the standard rules of go-playground/validator it knows:
+//
+//	future    the time must lie in the future (skipped when AllowPast is set on the context)
+//	capacity  the number must not exceed models.MaxEventCapacity
+package validation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+	"github.com/go-playground/validator/v10"
+)
+
+type contextKey int
+
+const allowPastKey contextKey = iota
+
+var validate = newValidator()
+
+var upperCase = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// newValidator creates a validator that reports fields by their JSON name and knows the custom rules
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	v.RegisterValidationCtx("future", func(ctx context.Context, fl validator.FieldLevel) bool {
+		if allowed, _ := ctx.Value(allowPastKey).(bool); allowed {
+			return true
+		}
+		t, ok := fl.Field().Interface().(time.Time)
+		return ok && t.After(time.Now())
+	})
+
+	v.RegisterValidation("capacity", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int() <= models.MaxEventCapacity
+	})
+
+	return v
+}
+
+// AllowPast returns a context in which the future rule accepts times in the
+// past, for example when an event that already started is edited
+func AllowPast(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowPastKey, true)
+}
+
+// Struct validates s against its validate tags
+func Struct(s interface{}) error {
+	return StructCtx(context.Background(), s)
+}
+
+// StructCtx validates s against its validate tags using ctx for the custom rules
+func StructCtx(ctx context.Context, s interface{}) error {
+	err := validate.StructCtx(ctx, s)
+	if err == nil {
+		return nil
+	}
+
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return apperrors.Internal(err)
+	}
+
+	fields := make([]apperrors.FieldError, len(validationErrors))
+	for i, fe := range validationErrors {
+		fields[i] = apperrors.FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Message: message(fe),
+		}
+	}
+
+	return apperrors.InvalidFields(fields)
+}
+
+// fieldPath returns the JSON path of a field without the name of the root struct
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// message describes a failed rule in words
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "gtfield":
+		return fmt.Sprintf("must be after %s", jsonName(fe.Param()))
+	case "ltfield":
+		return fmt.Sprintf("must be before %s", jsonName(fe.Param()))
+	case "ltefield":
+		return fmt.Sprintf("must not be after %s", jsonName(fe.Param()))
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "future":
+		return "must be in the future"
+	case "capacity":
+		return fmt.Sprintf("must be at most %d", models.MaxEventCapacity)
+	default:
+		return fmt.Sprintf("failed the %s rule", fe.Tag())
+	}
+}
+
+// jsonName turns a Go field name such as StartTime into its JSON name start_time
+func jsonName(field string) string {
+	return strings.ToLower(upperCase.ReplaceAllString(field, "${1}_${2}"))
+}
@@ -0,0 +1,108 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/event-system/apperrors"
+	"github.com/event-system/models"
+)
+
+// invalidFields validates s and returns the invalid fields with their failed rule
+func invalidFields(t *testing.T, ctx context.Context, s interface{}) map[string]string {
+	t.Helper()
+
+	err := StructCtx(ctx, s)
+	if err == nil {
+		return nil
+	}
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindValidation {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	fields := map[string]string{}
+	for _, field := range appErr.Fields {
+		fields[field.Field] = field.Rule
+	}
+	return fields
+}
+
+func TestEventRequest(t *testing.T) {
+	start := time.Now().Add(24 * time.Hour)
+	valid := models.EventRequest{
+		Name:      "Go meetup",
+		StartTime: start,
+		EndTime:   start.Add(2 * time.Hour),
+		Capacity:  50,
+	}
+
+	if fields := invalidFields(t, context.Background(), valid); fields != nil {
+		t.Fatalf("expected valid request, got %v", fields)
+	}
+
+	invalid := valid
+	invalid.Name = ""
+	invalid.StartTime = time.Now().Add(-time.Hour)
+	invalid.EndTime = invalid.StartTime.Add(-time.Minute)
+	invalid.Capacity = models.MaxEventCapacity + 1
+	afterStart := invalid.StartTime.Add(time.Minute)
+	invalid.PublishAt = &afterStart
+	invalid.RegistrationClosesAt = &afterStart
+
+	fields := invalidFields(t, context.Background(), invalid)
+	expected := map[string]string{
+		"name":                   "required",
+		"start_time":             "future",
+		"end_time":               "gtfield",
+		"capacity":               "capacity",
+		"publish_at":             "ltfield",
+		"registration_closes_at": "ltefield",
+	}
+	for field, rule := range expected {
+		if fields[field] != rule {
+			t.Errorf("expected %s to fail %q, got %q", field, rule, fields[field])
+		}
+	}
+
+	// Past start times are accepted when editing an event that already started
+	fields = invalidFields(t, AllowPast(context.Background()), invalid)
+	if _, ok := fields["start_time"]; ok {
+		t.Errorf("expected past start time to be allowed, got %v", fields)
+	}
+}
+
+func TestRegisterRequest(t *testing.T) {
+	fields := invalidFields(t, context.Background(), models.RegisterRequest{
+		Username: "ab",
+		Email:    "not-an-email",
+		Password: "12345",
+	})
+
+	expected := map[string]string{
+		"username": "min",
+		"email":    "email",
+		"password": "min",
+	}
+	for field, rule := range expected {
+		if fields[field] != rule {
+			t.Errorf("expected %s to fail %q, got %q", field, rule, fields[field])
+		}
+	}
+}
+
+func TestMessageNamesOtherField(t *testing.T) {
+	start := time.Now().Add(time.Hour)
+	err := Struct(models.EventRequest{Name: "x", StartTime: start, EndTime: start, Capacity: 1})
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || len(appErr.Fields) != 1 {
+		t.Fatalf("expected one invalid field, got %v", err)
+	}
+	if appErr.Fields[0].Message != "must be after start_time" {
+		t.Errorf("unexpected message %q", appErr.Fields[0].Message)
+	}
+}